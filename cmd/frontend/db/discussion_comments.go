@@ -56,6 +56,9 @@ func (c *discussionComments) Create(ctx context.Context, newComment *types.Discu
 	if newComment.DeletedAt != nil {
 		return nil, errors.New("newComment.DeletedAt must not be specified")
 	}
+	if (newComment.AuthorUserID == nil) == (newComment.ExternalAuthor == nil) {
+		return nil, errors.New("exactly one of newComment.AuthorUserID and newComment.ExternalAuthor must be specified")
+	}
 
 	// Create the comment.
 	newComment.CreatedAt = time.Now()
@@ -64,12 +67,14 @@ func (c *discussionComments) Create(ctx context.Context, newComment *types.Discu
 	err := dbconn.Global.QueryRowContext(ctx, `INSERT INTO discussion_comments(
 		thread_id,
 		author_user_id,
+		external_author,
 		contents,
 		created_at,
 		updated_at
-	) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+	) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
 		newComment.ThreadID,
 		newComment.AuthorUserID,
+		newComment.ExternalAuthor,
 		newComment.Contents,
 		newComment.CreatedAt,
 		newComment.UpdatedAt,
@@ -280,6 +285,7 @@ func (*discussionComments) getBySQL(ctx context.Context, query string, args ...i
 			c.id,
 			c.thread_id,
 			c.author_user_id,
+			c.external_author,
 			c.contents,
 			c.created_at,
 			c.updated_at,
@@ -297,6 +303,7 @@ func (*discussionComments) getBySQL(ctx context.Context, query string, args ...i
 			&comment.ID,
 			&comment.ThreadID,
 			&comment.AuthorUserID,
+			&comment.ExternalAuthor,
 			&comment.Contents,
 			&comment.CreatedAt,
 			&comment.UpdatedAt,
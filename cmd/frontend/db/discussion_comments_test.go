@@ -58,7 +58,7 @@ func TestDiscussionComments_Create(t *testing.T) {
 	// Create the comment.
 	comment, err := DiscussionComments.Create(ctx, &types.DiscussionComment{
 		ThreadID:     thread.ID,
-		AuthorUserID: user.ID,
+		AuthorUserID: &user.ID,
 		Contents:     "What do you think of Hello World as a Service?",
 	})
 	if err != nil {
@@ -71,6 +71,25 @@ func TestDiscussionComments_Create(t *testing.T) {
 		t.Fatal("expected to get created comment", err)
 	}
 
+	// Create a comment by an external author.
+	externalAuthor := "bob"
+	comment2, err := DiscussionComments.Create(ctx, &types.DiscussionComment{
+		ThreadID:       thread.ID,
+		ExternalAuthor: &externalAuthor,
+		Contents:       "Imported from the code host",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DiscussionComments.Get(ctx, comment2.ID); err != nil {
+		t.Fatal(err)
+	} else if got.AuthorUserID != nil || got.ExternalAuthor == nil || *got.ExternalAuthor != externalAuthor {
+		t.Errorf("got comment %+v, want external author %q", got, externalAuthor)
+	}
+	if _, err := DiscussionComments.Create(ctx, &types.DiscussionComment{ThreadID: thread.ID, Contents: "no author"}); err == nil {
+		t.Error("expected error for comment with no author")
+	}
+
 	// Test deleting the repo cascade deletes
 	err = Repos.Delete(ctx, repo.ID)
 	if err != nil {
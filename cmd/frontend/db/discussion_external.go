@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// discussionExternal provides access to the `discussion_threads_external` and
+// `discussion_comments_external` tables, which map discussion threads and
+// comments to the pull request comments on a code host that they are mirrored
+// to.
+//
+// For a detailed overview of the schema, see schema.md.
+type discussionExternal struct{}
+
+// CreateThread records that the thread is mirrored to the pull request on the
+// code host.
+func (*discussionExternal) CreateThread(ctx context.Context, t *types.DiscussionThreadExternal) error {
+	if Mocks.DiscussionExternal.CreateThread != nil {
+		return Mocks.DiscussionExternal.CreateThread(ctx, t)
+	}
+	if t.ServiceType == "" || t.ServiceID == "" || t.PullRequestID == "" || t.ExternalID == "" {
+		return errors.New("external thread must have a service, pull request, and external ID")
+	}
+	return dbconn.Global.QueryRowContext(ctx, `INSERT INTO discussion_threads_external(
+		thread_id,
+		repo_id,
+		service_type,
+		service_id,
+		pull_request_id,
+		external_id
+	) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`,
+		t.ThreadID,
+		t.RepoID,
+		t.ServiceType,
+		t.ServiceID,
+		t.PullRequestID,
+		t.ExternalID,
+	).Scan(&t.CreatedAt)
+}
+
+// ListThreads lists all threads that are mirrored to a code host, including
+// deleted threads.
+func (*discussionExternal) ListThreads(ctx context.Context) ([]*types.DiscussionThreadExternal, error) {
+	if Mocks.DiscussionExternal.ListThreads != nil {
+		return Mocks.DiscussionExternal.ListThreads(ctx)
+	}
+	rows, err := dbconn.Global.QueryContext(ctx, `
+		SELECT thread_id, repo_id, service_type, service_id, pull_request_id, external_id, created_at
+		FROM discussion_threads_external ORDER BY thread_id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	threads := []*types.DiscussionThreadExternal{}
+	for rows.Next() {
+		t := &types.DiscussionThreadExternal{}
+		if err := rows.Scan(&t.ThreadID, &t.RepoID, &t.ServiceType, &t.ServiceID, &t.PullRequestID, &t.ExternalID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		threads = append(threads, t)
+	}
+	return threads, rows.Err()
+}
+
+// DeleteThread stops mirroring the thread, and deletes the mapping of its
+// comments.
+func (*discussionExternal) DeleteThread(ctx context.Context, threadID int64) error {
+	if Mocks.DiscussionExternal.DeleteThread != nil {
+		return Mocks.DiscussionExternal.DeleteThread(ctx, threadID)
+	}
+	_, err := dbconn.Global.ExecContext(ctx, "DELETE FROM discussion_threads_external WHERE thread_id=$1", threadID)
+	return err
+}
+
+// ListComments lists the mapping of the comments in the mirrored thread,
+// including comments that were deleted in Sourcegraph.
+func (*discussionExternal) ListComments(ctx context.Context, threadID int64) ([]*types.DiscussionCommentExternal, error) {
+	if Mocks.DiscussionExternal.ListComments != nil {
+		return Mocks.DiscussionExternal.ListComments(ctx, threadID)
+	}
+	rows, err := dbconn.Global.QueryContext(ctx, `
+		SELECT comment_id, thread_id, external_id, imported, synced_at, external_updated_at
+		FROM discussion_comments_external WHERE thread_id=$1 ORDER BY comment_id ASC`, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*types.DiscussionCommentExternal{}
+	for rows.Next() {
+		c := &types.DiscussionCommentExternal{}
+		if err := rows.Scan(&c.CommentID, &c.ThreadID, &c.ExternalID, &c.Imported, &c.SyncedAt, &c.ExternalUpdatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// UpsertComment records (or updates the record) that the comment is mirrored
+// to the comment with c.ExternalID on the code host.
+func (*discussionExternal) UpsertComment(ctx context.Context, c *types.DiscussionCommentExternal) error {
+	if Mocks.DiscussionExternal.UpsertComment != nil {
+		return Mocks.DiscussionExternal.UpsertComment(ctx, c)
+	}
+	_, err := dbconn.Global.ExecContext(ctx, `
+		INSERT INTO discussion_comments_external(comment_id, thread_id, external_id, imported, synced_at, external_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (comment_id) DO UPDATE SET
+			synced_at=excluded.synced_at,
+			external_updated_at=excluded.external_updated_at`,
+		c.CommentID,
+		c.ThreadID,
+		c.ExternalID,
+		c.Imported,
+		c.SyncedAt,
+		c.ExternalUpdatedAt,
+	)
+	return err
+}
+
+// DeleteComment deletes the mapping of the comment.
+func (*discussionExternal) DeleteComment(ctx context.Context, commentID int64) error {
+	if Mocks.DiscussionExternal.DeleteComment != nil {
+		return Mocks.DiscussionExternal.DeleteComment(ctx, commentID)
+	}
+	_, err := dbconn.Global.ExecContext(ctx, "DELETE FROM discussion_comments_external WHERE comment_id=$1", commentID)
+	return err
+}
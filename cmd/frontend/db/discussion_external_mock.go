@@ -0,0 +1,16 @@
+package db
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+type MockDiscussionExternal struct {
+	CreateThread  func(ctx context.Context, t *types.DiscussionThreadExternal) error
+	ListThreads   func(ctx context.Context) ([]*types.DiscussionThreadExternal, error)
+	DeleteThread  func(ctx context.Context, threadID int64) error
+	ListComments  func(ctx context.Context, threadID int64) ([]*types.DiscussionCommentExternal, error)
+	UpsertComment func(ctx context.Context, c *types.DiscussionCommentExternal) error
+	DeleteComment func(ctx context.Context, commentID int64) error
+}
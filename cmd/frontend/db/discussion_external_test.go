@@ -0,0 +1,111 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestDiscussionExternal(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	user, err := Users.Create(ctx, NewUser{
+		Email:                 "a@a.com",
+		Username:              "u",
+		Password:              "p",
+		EmailVerificationCode: "c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: "myrepo", Description: "", Fork: false, Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Repos.GetByName(ctx, "myrepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread, err := DiscussionThreads.Create(ctx, &types.DiscussionThread{
+		AuthorUserID: user.ID,
+		Title:        "Hello world!",
+		TargetRepo: &types.DiscussionThreadTargetRepo{
+			RepoID: repo.ID,
+			Path:   strPtr("foo/bar/mux.go"),
+			Branch: strPtr("feature"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment, err := DiscussionComments.Create(ctx, &types.DiscussionComment{
+		ThreadID:     thread.ID,
+		AuthorUserID: &user.ID,
+		Contents:     "Hello world!",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mirror the thread.
+	extThread := &types.DiscussionThreadExternal{
+		ThreadID:      thread.ID,
+		RepoID:        repo.ID,
+		ServiceType:   "github",
+		ServiceID:     "https://github.com/",
+		PullRequestID: "7",
+		ExternalID:    "123",
+	}
+	if err := DiscussionExternal.CreateThread(ctx, extThread); err != nil {
+		t.Fatal(err)
+	}
+	threads, err := DiscussionExternal.ListThreads(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*types.DiscussionThreadExternal{extThread}; !reflect.DeepEqual(threads, want) {
+		t.Errorf("got threads %+v, want %+v", threads, want)
+	}
+
+	// Mirror the comment, then update the mapping.
+	syncedAt := time.Now().Round(time.Second).UTC()
+	extComment := &types.DiscussionCommentExternal{
+		CommentID:         comment.ID,
+		ThreadID:          thread.ID,
+		ExternalID:        "123",
+		SyncedAt:          syncedAt,
+		ExternalUpdatedAt: syncedAt,
+	}
+	if err := DiscussionExternal.UpsertComment(ctx, extComment); err != nil {
+		t.Fatal(err)
+	}
+	extComment.SyncedAt = syncedAt.Add(time.Minute)
+	if err := DiscussionExternal.UpsertComment(ctx, extComment); err != nil {
+		t.Fatal(err)
+	}
+	comments, err := DiscussionExternal.ListComments(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || !comments[0].SyncedAt.Equal(extComment.SyncedAt) || comments[0].ExternalID != "123" {
+		t.Errorf("got comments %+v, want [%+v]", comments, extComment)
+	}
+
+	// Deleting the thread mapping deletes the comment mappings.
+	if err := DiscussionExternal.DeleteThread(ctx, thread.ID); err != nil {
+		t.Fatal(err)
+	}
+	comments, err = DiscussionExternal.ListComments(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 0 {
+		t.Errorf("got comments %+v, want none", comments)
+	}
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// ExplicitRepoPermission grants read access to a repository, or to all repositories whose name
+// matches a pattern, to a user or to all members of an organization. Explicit permissions are
+// granted by site admins for repositories whose code host does not provide permissions.
+type ExplicitRepoPermission struct {
+	ID          int32
+	RepoName    api.RepoName // the name of the repository (empty if RepoPattern is set)
+	RepoPattern string       // regexp matching the entire repository name (empty if RepoName is set)
+	UserID      int32        // the user granted access (0 if OrgID is set)
+	OrgID       int32        // the organization whose members are granted access (0 if UserID is set)
+	CreatedAt   time.Time
+}
+
+// ErrExplicitRepoPermissionNotFound occurs when a database operation expects a specific explicit
+// repository permission to exist but it does not exist.
+var ErrExplicitRepoPermissionNotFound = errors.New("explicit repository permission not found")
+
+// CompileExplicitRepoPattern compiles the repository name pattern of an explicit repository
+// permission. The pattern must match the entire repository name, and is case-insensitive like
+// repository names.
+func CompileExplicitRepoPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)^(?:" + pattern + ")$")
+}
+
+type explicitPermissions struct{}
+
+func (p *ExplicitRepoPermission) validate() error {
+	if (p.RepoName == "") == (p.RepoPattern == "") {
+		return errors.New("exactly one of repository name and repository pattern must be set")
+	}
+	if (p.UserID == 0) == (p.OrgID == 0) {
+		return errors.New("exactly one of user and organization must be set")
+	}
+	if p.RepoPattern != "" {
+		if _, err := CompileExplicitRepoPattern(p.RepoPattern); err != nil {
+			return errors.Wrap(err, "invalid repository pattern")
+		}
+	}
+	return nil
+}
+
+// Create creates the explicit repository permissions, setting their ID and CreatedAt fields. Either
+// all or none of the permissions are created.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *explicitPermissions) Create(ctx context.Context, perms ...*ExplicitRepoPermission) error {
+	if Mocks.ExplicitPermissions.Create != nil {
+		return Mocks.ExplicitPermissions.Create(perms...)
+	}
+
+	if len(perms) == 0 {
+		return nil
+	}
+	values := make([]*sqlf.Query, len(perms))
+	for i, p := range perms {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		values[i] = sqlf.Sprintf("(NULLIF(%s, ''), NULLIF(%s, ''), NULLIF(%d, 0), NULLIF(%d, 0))", string(p.RepoName), p.RepoPattern, p.UserID, p.OrgID)
+	}
+
+	q := sqlf.Sprintf(`
+INSERT INTO explicit_repo_permissions(repo_name, repo_pattern, user_id, org_id) VALUES %s
+RETURNING id, created_at`,
+		sqlf.Join(values, ", "),
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Rows are returned in the order of the VALUES list.
+	for _, p := range perms {
+		if !rows.Next() {
+			return errors.New("explicit repository permissions were not all created")
+		}
+		if err := rows.Scan(&p.ID, &p.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetByID retrieves the explicit repository permission (if any) given its ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *explicitPermissions) GetByID(ctx context.Context, id int32) (*ExplicitRepoPermission, error) {
+	if Mocks.ExplicitPermissions.GetByID != nil {
+		return Mocks.ExplicitPermissions.GetByID(id)
+	}
+
+	results, err := s.list(ctx, []*sqlf.Query{sqlf.Sprintf("id=%d", id)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrExplicitRepoPermissionNotFound
+	}
+	return results[0], nil
+}
+
+// ExplicitPermissionsListOptions contains options for listing explicit repository permissions.
+type ExplicitPermissionsListOptions struct {
+	UserID int32 // only list permissions granted to this user (not including those granted to the user's organizations)
+	OrgID  int32 // only list permissions granted to this organization
+	*LimitOffset
+}
+
+func (o ExplicitPermissionsListOptions) sqlConditions() []*sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if o.UserID != 0 {
+		conds = append(conds, sqlf.Sprintf("user_id=%d", o.UserID))
+	}
+	if o.OrgID != 0 {
+		conds = append(conds, sqlf.Sprintf("org_id=%d", o.OrgID))
+	}
+	return conds
+}
+
+// List lists all explicit repository permissions that satisfy the options.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *explicitPermissions) List(ctx context.Context, opt ExplicitPermissionsListOptions) ([]*ExplicitRepoPermission, error) {
+	return s.list(ctx, opt.sqlConditions(), opt.LimitOffset)
+}
+
+// ListByUser lists the explicit repository permissions which apply to the user: those granted to
+// the user and those granted to the organizations the user is a member of.
+//
+// 🚨 SECURITY: The caller must ensure that the result is only used to determine the permissions
+// of the specified user.
+func (s *explicitPermissions) ListByUser(ctx context.Context, userID int32) ([]*ExplicitRepoPermission, error) {
+	if Mocks.ExplicitPermissions.ListByUser != nil {
+		return Mocks.ExplicitPermissions.ListByUser(userID)
+	}
+
+	return s.list(ctx, []*sqlf.Query{sqlf.Sprintf(`
+user_id=%d OR org_id IN (
+  SELECT org_members.org_id FROM org_members
+  JOIN orgs ON org_members.org_id=orgs.id
+  WHERE org_members.user_id=%d AND orgs.deleted_at IS NULL
+)`, userID, userID)}, nil)
+}
+
+func (s *explicitPermissions) list(ctx context.Context, conds []*sqlf.Query, limitOffset *LimitOffset) ([]*ExplicitRepoPermission, error) {
+	q := sqlf.Sprintf(`
+SELECT id, COALESCE(repo_name, ''), COALESCE(repo_pattern, ''), COALESCE(user_id, 0), COALESCE(org_id, 0), created_at FROM explicit_repo_permissions
+WHERE (%s)
+ORDER BY id ASC
+%s`,
+		sqlf.Join(conds, ") AND ("),
+		limitOffset.SQL(),
+	)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*ExplicitRepoPermission
+	for rows.Next() {
+		var p ExplicitRepoPermission
+		if err := rows.Scan(&p.ID, &p.RepoName, &p.RepoPattern, &p.UserID, &p.OrgID, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, &p)
+	}
+	return results, rows.Err()
+}
+
+// Count counts all explicit repository permissions that satisfy the options (ignoring limit and
+// offset).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *explicitPermissions) Count(ctx context.Context, opt ExplicitPermissionsListOptions) (int, error) {
+	q := sqlf.Sprintf("SELECT COUNT(*) FROM explicit_repo_permissions WHERE (%s)", sqlf.Join(opt.sqlConditions(), ") AND ("))
+	var count int
+	if err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Delete deletes the explicit repository permission given its ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *explicitPermissions) Delete(ctx context.Context, id int32) error {
+	if Mocks.ExplicitPermissions.Delete != nil {
+		return Mocks.ExplicitPermissions.Delete(id)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM explicit_repo_permissions WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return ErrExplicitRepoPermissionNotFound
+	}
+	return nil
+}
+
+type MockExplicitPermissions struct {
+	Create     func(perms ...*ExplicitRepoPermission) error
+	GetByID    func(id int32) (*ExplicitRepoPermission, error)
+	ListByUser func(userID int32) ([]*ExplicitRepoPermission, error)
+	Delete     func(id int32) error
+}
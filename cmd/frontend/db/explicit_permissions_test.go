@@ -0,0 +1,96 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestExplicitPermissions(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+
+	u1, err := Users.Create(ctx, NewUser{Email: "a1@example.com", Username: "u1", Password: "p1", EmailVerificationCode: "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u2, err := Users.Create(ctx, NewUser{Email: "a2@example.com", Username: "u2", Password: "p2", EmailVerificationCode: "c2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	org, err := Orgs.Create(ctx, "o1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OrgMembers.Create(ctx, org.ID, u2.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	p1 := &ExplicitRepoPermission{RepoName: "example.com/a", UserID: u1.ID}
+	p2 := &ExplicitRepoPermission{RepoPattern: "example.com/b/.*", OrgID: org.ID}
+	if err := ExplicitPermissions.Create(ctx, p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	if p1.ID == 0 || p2.ID == 0 || p1.CreatedAt.IsZero() {
+		t.Fatalf("expected ID and CreatedAt to be set, got %+v and %+v", p1, p2)
+	}
+
+	invalid := []*ExplicitRepoPermission{
+		{UserID: u1.ID},
+		{RepoName: "example.com/a", RepoPattern: "x", UserID: u1.ID},
+		{RepoName: "example.com/a"},
+		{RepoName: "example.com/a", UserID: u1.ID, OrgID: org.ID},
+		{RepoPattern: "(", UserID: u1.ID},
+	}
+	for _, p := range invalid {
+		if err := ExplicitPermissions.Create(ctx, p); err == nil {
+			t.Errorf("expected an error creating %+v", p)
+		}
+	}
+
+	repoNames := func(perms []*ExplicitRepoPermission) (names []string) {
+		for _, p := range perms {
+			names = append(names, string(p.RepoName)+p.RepoPattern)
+		}
+		return names
+	}
+	for userID, want := range map[int32][]string{
+		u1.ID: {"example.com/a"},
+		u2.ID: {"example.com/b/.*"},
+	} {
+		perms, err := ExplicitPermissions.ListByUser(ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := repoNames(perms); !reflect.DeepEqual(got, want) {
+			t.Errorf("user %d: got %q, want %q", userID, got, want)
+		}
+	}
+
+	if n, err := ExplicitPermissions.Count(ctx, ExplicitPermissionsListOptions{OrgID: org.ID}); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("got %d permissions for org, want 1", n)
+	}
+
+	if err := ExplicitPermissions.Delete(ctx, p1.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExplicitPermissions.GetByID(ctx, p1.ID); err != ErrExplicitRepoPermissionNotFound {
+		t.Errorf("got error %v, want %v", err, ErrExplicitRepoPermissionNotFound)
+	}
+	if err := ExplicitPermissions.Delete(ctx, p1.ID); err != ErrExplicitRepoPermissionNotFound {
+		t.Errorf("got error %v, want %v", err, ErrExplicitRepoPermissionNotFound)
+	}
+	got, err := ExplicitPermissions.GetByID(ctx, p2.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RepoName != "" || got.RepoPattern != p2.RepoPattern || got.OrgID != org.ID {
+		t.Errorf("got %+v, want %+v", got, p2)
+	}
+}
@@ -27,8 +27,9 @@ import (
 // The enterprise code registers additional validators at run-time and sets the
 // global instance in stores.go
 type ExternalServicesStore struct {
-	GitHubValidators []func(*schema.GitHubConnection) error
-	GitLabValidators []func(*schema.GitLabConnection, []schema.AuthProviders) error
+	GitHubValidators          []func(*schema.GitHubConnection) error
+	GitLabValidators          []func(*schema.GitLabConnection, []schema.AuthProviders) error
+	BitbucketServerValidators []func(*schema.BitbucketServerConnection) error
 }
 
 // ExternalServiceKinds contains a map of all supported kinds of
@@ -126,6 +127,13 @@ func (e *ExternalServicesStore) ValidateConfig(kind, config string, ps []schema.
 		}
 		err = e.validateGitlabConnection(&c, ps)
 
+	case "BITBUCKETSERVER":
+		var c schema.BitbucketServerConnection
+		if err = json.Unmarshal(normalized, &c); err != nil {
+			return err
+		}
+		err = e.validateBitbucketServerConnection(&c)
+
 	case "OTHER":
 		var c schema.OtherExternalServiceConnection
 		if err = json.Unmarshal(normalized, &c); err != nil {
@@ -182,6 +190,14 @@ func (e *ExternalServicesStore) validateGitlabConnection(c *schema.GitLabConnect
 	return err.ErrorOrNil()
 }
 
+func (e *ExternalServicesStore) validateBitbucketServerConnection(c *schema.BitbucketServerConnection) error {
+	err := new(multierror.Error)
+	for _, validate := range e.BitbucketServerValidators {
+		err = multierror.Append(err, validate(c))
+	}
+	return err.ErrorOrNil()
+}
+
 // Create creates a external service.
 //
 // Since this method is used before the configuration server has started
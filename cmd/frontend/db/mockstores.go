@@ -9,6 +9,7 @@ type MockStores struct {
 	DiscussionThreads         MockDiscussionThreads
 	DiscussionComments        MockDiscussionComments
 	DiscussionMailReplyTokens MockDiscussionMailReplyTokens
+	DiscussionExternal        MockDiscussionExternal
 
 	Repos         MockRepos
 	Orgs          MockOrgs
@@ -25,4 +26,7 @@ type MockStores struct {
 	OrgInvitations MockOrgInvitations
 
 	ExternalServices MockExternalServices
+
+	ExplicitPermissions MockExplicitPermissions
+	UserRepoPermissions MockUserRepoPermissions
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
 	log15 "gopkg.in/inconshreveable/log15.v2"
 )
@@ -73,6 +74,16 @@ func getFilteredRepoNames(ctx context.Context, currentUser *types.User, repos ma
 		}
 	}
 
+	// Permissions synced in the background are used instead of asking the authz providers, except
+	// for providers whose permissions were never synced for the user.
+	var synced map[[2]string]map[string]struct{}
+	if len(authzProviders) > 0 && currentUser != nil && isPermissionsBackgroundSyncEnabled() {
+		synced, err = getSyncedRepoPerms(ctx, currentUser.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	accepted = make(map[api.RepoName]struct{})  // repositories that have been claimed and have read permissions
 	unverified := make(map[authz.Repo]struct{}) // repositories that have not been claimed by any authz provider
 	for repo := range repos {
@@ -91,6 +102,18 @@ func getFilteredRepoNames(ctx context.Context, currentUser *types.User, repos ma
 			break
 		}
 
+		if syncedIDs, ok := synced[[2]string{authzProvider.ServiceType(), authzProvider.ServiceID()}]; ok {
+			myUnverified, nextUnverified := authzProvider.Repos(ctx, unverified)
+			for unverifiedRepo := range myUnverified {
+				// Only read permissions are synced.
+				if _, ok := syncedIDs[unverifiedRepo.ExternalRepoSpec.ID]; ok && p == authz.Read {
+					accepted[unverifiedRepo.RepoName] = struct{}{}
+				}
+			}
+			unverified = nextUnverified
+			continue
+		}
+
 		// determine external account to use
 		var providerAcct *extsvc.ExternalAccount
 		for _, acct := range accts {
@@ -138,3 +161,29 @@ func getFilteredRepoNames(ctx context.Context, currentUser *types.User, repos ma
 
 	return accepted, nil
 }
+
+// isPermissionsBackgroundSyncEnabled reports whether repository permissions are synced in the
+// background (see UserRepoPermissions). Synced permissions must not be used otherwise, because they
+// may be arbitrarily stale.
+func isPermissionsBackgroundSyncEnabled() bool {
+	c := conf.Get().PermissionsBackgroundSync
+	return c != nil && c.Enabled
+}
+
+// getSyncedRepoPerms returns the external IDs of the repositories the user can read, as last
+// synced in the background, keyed by the service type and service ID of their code host.
+func getSyncedRepoPerms(ctx context.Context, userID int32) (map[[2]string]map[string]struct{}, error) {
+	perms, err := UserRepoPermissions.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	synced := make(map[[2]string]map[string]struct{}, len(perms))
+	for _, perm := range perms {
+		ids := make(map[string]struct{}, len(perm.ExternalRepoIDs))
+		for _, id := range perm.ExternalRepoIDs {
+			ids[id] = struct{}{}
+		}
+		synced[[2]string{perm.ServiceType, perm.ServiceID}] = ids
+	}
+	return synced, nil
+}
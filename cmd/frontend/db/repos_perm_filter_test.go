@@ -10,7 +10,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 type authzFilter_Test struct {
@@ -647,6 +649,80 @@ func Test_authzFilter_createsNewUsers(t *testing.T) {
 	}
 }
 
+func Test_authzFilter_syncedPermissions(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		PermissionsBackgroundSync: &schema.PermissionsBackgroundSync{Enabled: true},
+	}})
+	defer conf.Mock(nil)
+
+	Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 1}, nil
+	}
+	Mocks.ExternalAccounts.AssociateUserAndSave = func(userID int32, spec extsvc.ExternalAccountSpec, data extsvc.ExternalAccountData) error { return nil }
+	Mocks.ExternalAccounts.List = func(ExternalAccountsListOptions) ([]*extsvc.ExternalAccount, error) {
+		return []*extsvc.ExternalAccount{acct(1, "gitlab", "https://gitlab.mine/", "u1")}, nil
+	}
+	Mocks.UserRepoPermissions.ListByUser = func(userID int32) ([]*UserRepoPermissions, error) {
+		if userID != 1 {
+			t.Fatalf("unexpected user ID %d", userID)
+		}
+		// Only the permissions of the GitHub code host were synced.
+		return []*UserRepoPermissions{{
+			UserID:          1,
+			ServiceType:     "github",
+			ServiceID:       "https://github.mine/",
+			ExternalRepoIDs: []string{"github.mine/u1/r0"},
+		}}, nil
+	}
+	defer func() { Mocks.UserRepoPermissions = MockUserRepoPermissions{} }()
+
+	readPerms := map[authz.Perm]bool{authz.Read: true}
+	authz.SetProviders(false, []authz.Provider{
+		&MockAuthzProvider{
+			serviceID:   "https://github.mine/",
+			serviceType: "github",
+			repos: map[api.RepoName]struct{}{
+				"github.mine/u1/r0": {},
+				"github.mine/u1/r1": {},
+			},
+			// The live permissions differ from the synced permissions, and must not be used.
+			perms: map[extsvc.ExternalAccount]map[api.RepoName]map[authz.Perm]bool{
+				{}: {
+					"github.mine/u1/r0": readPerms,
+					"github.mine/u1/r1": readPerms,
+				},
+			},
+		},
+		&MockAuthzProvider{
+			serviceID:   "https://gitlab.mine/",
+			serviceType: "gitlab",
+			repos: map[api.RepoName]struct{}{
+				"gitlab.mine/u1/r0": {},
+				"gitlab.mine/u1/r1": {},
+			},
+			perms: map[extsvc.ExternalAccount]map[api.RepoName]map[authz.Perm]bool{
+				*acct(1, "gitlab", "https://gitlab.mine/", "u1"): {
+					"gitlab.mine/u1/r1": readPerms,
+				},
+			},
+		},
+	})
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	repos := makeRepos("github.mine/u1/r0", "github.mine/u1/r1", "gitlab.mine/u1/r0", "gitlab.mine/u1/r1")
+	filteredRepos, err := authzFilter(ctx, repos, authz.Read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []api.RepoName
+	for _, r := range filteredRepos {
+		got = append(got, r.Name)
+	}
+	if want := []api.RepoName{"github.mine/u1/r0", "gitlab.mine/u1/r1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got filtered repos %v, want %v", got, want)
+	}
+}
+
 func acct(userID int32, serviceType, serviceID, accountID string) *extsvc.ExternalAccount {
 	return &extsvc.ExternalAccount{
 		UserID: userID,
@@ -138,6 +138,59 @@ func TestSavedSearchesUpdate(t *testing.T) {
 	}
 }
 
+func TestSavedSearchesWebhook(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+	_, err := Users.Create(ctx, NewUser{DisplayName: "test", Email: "test@test.com", Username: "test", Password: "test", EmailVerificationCode: "c2"})
+	if err != nil {
+		t.Fatal("can't create user", err)
+	}
+	userID := int32(1)
+	webhookURL, webhookSecret := "https://example.com/hook", "s3cr3t"
+	fake := &types.SavedSearch{
+		Query:         "test",
+		Description:   "test",
+		UserID:        &userID,
+		NotifyWebhook: true,
+		WebhookURL:    &webhookURL,
+		WebhookSecret: &webhookSecret,
+	}
+	ss, err := SavedSearches.Create(ctx, fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Updating without a secret keeps the existing secret.
+	newWebhookURL := "https://example.com/hook2"
+	if _, err := SavedSearches.Update(ctx, &types.SavedSearch{
+		ID:            ss.ID,
+		Query:         "test",
+		Description:   "test",
+		UserID:        &userID,
+		NotifyWebhook: true,
+		WebhookURL:    &newWebhookURL,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := SavedSearches.GetByID(ctx, ss.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Config.NotifyWebhook {
+		t.Error("got NotifyWebhook false, want true")
+	}
+	if got.Config.WebhookURL == nil || *got.Config.WebhookURL != newWebhookURL {
+		t.Errorf("got webhook URL %v, want %q", got.Config.WebhookURL, newWebhookURL)
+	}
+	if got.Config.WebhookSecret == nil || *got.Config.WebhookSecret != webhookSecret {
+		t.Errorf("got webhook secret %v, want %q", got.Config.WebhookSecret, webhookSecret)
+	}
+}
+
 func TestSavedSearchesDelete(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
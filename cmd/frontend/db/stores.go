@@ -6,6 +6,7 @@ var (
 	DiscussionThreads         = &discussionThreads{}
 	DiscussionComments        = &discussionComments{}
 	DiscussionMailReplyTokens = &discussionMailReplyTokens{}
+	DiscussionExternal        = &discussionExternal{}
 	Repos                     = &repos{}
 	Phabricator               = &phabricator{}
 	QueryRunnerState          = &queryRunnerState{}
@@ -21,4 +22,7 @@ var (
 	ExternalAccounts = &userExternalAccounts{}
 
 	OrgInvitations = &orgInvitations{}
+
+	ExplicitPermissions = &explicitPermissions{}
+	UserRepoPermissions = &userRepoPermissions{}
 )
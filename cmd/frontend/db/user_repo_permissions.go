@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// UserRepoPermissions are the repositories of one code host that a user can read, as last synced
+// in the background from the authz provider of the code host. authzFilter uses them instead of
+// asking the provider on the request path.
+type UserRepoPermissions struct {
+	UserID      int32
+	ServiceType string // the service type of the authz provider (and of its repositories)
+	ServiceID   string // the service ID of the authz provider (and of its repositories)
+
+	// ExternalRepoIDs are the external IDs (api.ExternalRepoSpec.ID) of the repositories the user
+	// can read.
+	ExternalRepoIDs []string
+
+	UpdatedAt time.Time // when the permissions were last synced
+}
+
+type userRepoPermissions struct{}
+
+// Upsert creates or replaces the repository permissions of the user for the code host of p, and
+// sets p.UpdatedAt.
+//
+// 🚨 SECURITY: The caller must ensure that the permissions were computed by the authz provider of
+// the code host for this user.
+func (s *userRepoPermissions) Upsert(ctx context.Context, p *UserRepoPermissions) error {
+	if Mocks.UserRepoPermissions.Upsert != nil {
+		return Mocks.UserRepoPermissions.Upsert(p)
+	}
+
+	ids := p.ExternalRepoIDs
+	if ids == nil {
+		ids = []string{}
+	}
+	q := sqlf.Sprintf(`
+INSERT INTO user_repo_permissions(user_id, service_type, service_id, external_repo_ids, updated_at)
+VALUES (%d, %s, %s, %s, now())
+ON CONFLICT (user_id, service_type, service_id) DO UPDATE SET
+  external_repo_ids=excluded.external_repo_ids,
+  updated_at=excluded.updated_at
+RETURNING updated_at`,
+		p.UserID, p.ServiceType, p.ServiceID, pq.Array(ids),
+	)
+	return dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&p.UpdatedAt)
+}
+
+// ListByUser lists the synced repository permissions of the user, one for each code host.
+//
+// 🚨 SECURITY: The caller must ensure that the result is only used to determine the permissions
+// of the specified user, or that the actor is the user or a site admin.
+func (s *userRepoPermissions) ListByUser(ctx context.Context, userID int32) ([]*UserRepoPermissions, error) {
+	if Mocks.UserRepoPermissions.ListByUser != nil {
+		return Mocks.UserRepoPermissions.ListByUser(userID)
+	}
+
+	q := sqlf.Sprintf(`
+SELECT user_id, service_type, service_id, external_repo_ids, updated_at FROM user_repo_permissions
+WHERE user_id=%d
+ORDER BY service_type ASC, service_id ASC`,
+		userID,
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*UserRepoPermissions
+	for rows.Next() {
+		var p UserRepoPermissions
+		if err := rows.Scan(&p.UserID, &p.ServiceType, &p.ServiceID, pq.Array(&p.ExternalRepoIDs), &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, &p)
+	}
+	return results, rows.Err()
+}
+
+// DeleteStale deletes the synced repository permissions of all users for code hosts which are not
+// in the given list of (service type, service ID) pairs, e.g. because their authz provider was
+// removed from the configuration.
+func (s *userRepoPermissions) DeleteStale(ctx context.Context, services [][2]string) error {
+	if Mocks.UserRepoPermissions.DeleteStale != nil {
+		return Mocks.UserRepoPermissions.DeleteStale(services)
+	}
+
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	for _, svc := range services {
+		conds = append(conds, sqlf.Sprintf("NOT (service_type=%s AND service_id=%s)", svc[0], svc[1]))
+	}
+	q := sqlf.Sprintf("DELETE FROM user_repo_permissions WHERE (%s)", sqlf.Join(conds, ") AND ("))
+	_, err := dbconn.Global.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	return err
+}
+
+type MockUserRepoPermissions struct {
+	Upsert      func(p *UserRepoPermissions) error
+	ListByUser  func(userID int32) ([]*UserRepoPermissions, error)
+	DeleteStale func(services [][2]string) error
+}
@@ -434,7 +434,7 @@ func TestUsers_Delete(t *testing.T) {
 			}
 			newComment, err := DiscussionComments.Create(ctx, &types.DiscussionComment{
 				ThreadID:     newThread.ID,
-				AuthorUserID: user.ID,
+				AuthorUserID: &user.ID,
 				Contents:     "Thread contents",
 			})
 			if err != nil {
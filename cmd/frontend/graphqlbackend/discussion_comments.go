@@ -35,9 +35,14 @@ func (r *discussionCommentResolver) Thread(ctx context.Context) (*discussionThre
 }
 
 func (r *discussionCommentResolver) Author(ctx context.Context) (*UserResolver, error) {
-	return UserByIDInt32(ctx, r.c.AuthorUserID)
+	if r.c.AuthorUserID == nil {
+		return nil, nil
+	}
+	return UserByIDInt32(ctx, *r.c.AuthorUserID)
 }
 
+func (r *discussionCommentResolver) ExternalAuthor() *string { return r.c.ExternalAuthor }
+
 func (r *discussionCommentResolver) Contents(ctx context.Context) (string, error) {
 	if strings.TrimSpace(r.c.Contents) != "" {
 		return r.c.Contents, nil
@@ -191,7 +196,7 @@ func (r *discussionsMutationResolver) AddCommentToThread(ctx context.Context, ar
 
 	updatedThread, err := discussions.InsecureAddCommentToThread(ctx, &types.DiscussionComment{
 		ThreadID:     threadID,
-		AuthorUserID: currentUser.user.ID,
+		AuthorUserID: &currentUser.user.ID,
 		Contents:     args.Contents,
 	})
 	if err != nil {
@@ -258,7 +263,13 @@ func (r *discussionsMutationResolver) UpdateComment(ctx context.Context, args *s
 		if err != nil {
 			return nil, err
 		}
-		err = backend.CheckSiteAdminOrSameUser(ctx, comment.AuthorUserID)
+		if comment.AuthorUserID == nil {
+			// Comments imported from a code host user without an account may only be
+			// updated by site admins.
+			err = backend.CheckCurrentUserIsSiteAdmin(ctx)
+		} else {
+			err = backend.CheckSiteAdminOrSameUser(ctx, *comment.AuthorUserID)
+		}
 		if err != nil {
 			return nil, err
 		}
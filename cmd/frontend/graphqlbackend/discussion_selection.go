@@ -0,0 +1,240 @@
+package graphqlbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// discussionSelectionCache caches where thread selections are in other
+// revisions. The keys contain the thread target ID and the commit IDs of the
+// revision the thread was created on and the requested revision, so entries
+// never become stale.
+var discussionSelectionCache = rcache.NewWithTTL("discussion-selection", 7*24*60*60) // 1 week
+
+// discussionRelocatedSelection is where a thread's selection is in another
+// revision of the file.
+type discussionRelocatedSelection struct {
+	// Found is whether the selection could be located in the revision. If
+	// false, the other fields are zero.
+	Found              bool
+	StartLine, EndLine int32
+
+	// Outdated is whether the selected lines differ in the revision from the
+	// lines the thread was created on.
+	Outdated bool
+}
+
+type discussionRelocatedSelectionResolver struct {
+	t   *types.DiscussionThreadTargetRepo
+	sel discussionRelocatedSelection
+}
+
+func (r *discussionRelocatedSelectionResolver) Range() *discussionSelectionRangeResolver {
+	if !r.sel.Found {
+		return nil
+	}
+	return &discussionSelectionRangeResolver{
+		startLine:      r.sel.StartLine,
+		startCharacter: *r.t.StartCharacter,
+		endLine:        r.sel.EndLine,
+		endCharacter:   *r.t.EndCharacter,
+	}
+}
+
+func (r *discussionRelocatedSelectionResolver) Outdated() bool { return r.sel.Outdated }
+
+func (r *discussionThreadTargetRepoResolver) RelocatedSelection(ctx context.Context, args *struct {
+	Rev string
+}) (*discussionRelocatedSelectionResolver, error) {
+	if !r.t.HasSelection() {
+		return nil, nil
+	}
+	sel, err := r.relocateSelection(ctx, args.Rev)
+	if err != nil || sel == nil {
+		return nil, err
+	}
+	return &discussionRelocatedSelectionResolver{t: r.t, sel: *sel}, nil
+}
+
+// relocateSelection returns where the thread's selection is in the given
+// revision, or nil if the revision does not exist.
+//
+// Precondition: r.t.HasSelection()
+func (r *discussionThreadTargetRepoResolver) relocateSelection(ctx context.Context, rev string) (*discussionRelocatedSelection, error) {
+	repo, err := repositoryByIDInt32(ctx, r.t.RepoID)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.Commit(ctx, &repositoryCommitArgs{Rev: rev})
+	if err != nil || commit == nil {
+		return nil, err
+	}
+
+	// Resolve the revision the thread was created on, if known. The branch
+	// may have moved since then, but it's the best we have.
+	var base *gitCommitResolver
+	if baseRev := r.baseRevision(); baseRev != "" {
+		base, err = repo.Commit(ctx, &repositoryCommitArgs{Rev: baseRev})
+		if err != nil {
+			return nil, err
+		}
+	}
+	var baseOID gitObjectID
+	if base != nil {
+		baseOID = base.OID()
+	}
+
+	cacheKey := fmt.Sprintf("%d:%s:%s", r.t.ID, baseOID, commit.OID())
+	if b, ok := discussionSelectionCache.Get(cacheKey); ok {
+		var sel discussionRelocatedSelection
+		if err := json.Unmarshal(b, &sel); err == nil {
+			return &sel, nil
+		}
+	}
+
+	sel, err := r.computeRelocatedSelection(ctx, repo, baseOID, commit)
+	if err != nil {
+		return nil, err
+	}
+	if b, err := json.Marshal(sel); err == nil {
+		discussionSelectionCache.Set(cacheKey, b)
+	}
+	return sel, nil
+}
+
+// baseRevision returns the revision that the thread was created on, or the
+// empty string if unknown.
+func (r *discussionThreadTargetRepoResolver) baseRevision() string {
+	if r.t.Revision != nil {
+		return *r.t.Revision
+	}
+	if r.t.Branch != nil {
+		return *r.t.Branch
+	}
+	return ""
+}
+
+func (r *discussionThreadTargetRepoResolver) computeRelocatedSelection(ctx context.Context, repo *repositoryResolver, baseOID gitObjectID, commit *gitCommitResolver) (*discussionRelocatedSelection, error) {
+	oldRange := discussions.LineRange{StartLine: int(*r.t.StartLine), EndLine: int(*r.t.EndLine)}
+	if baseOID == commit.OID() {
+		return &discussionRelocatedSelection{Found: true, StartLine: *r.t.StartLine, EndLine: *r.t.EndLine}, nil
+	}
+
+	path, err := r.RelativePath(ctx, &struct{ Rev string }{Rev: string(commit.OID())})
+	if err != nil {
+		return nil, err
+	}
+	if path == nil {
+		// The file was removed.
+		return &discussionRelocatedSelection{Outdated: true}, nil
+	}
+
+	// If we know the revision the thread was created on, map the selection
+	// through the diff of the file. This is exact if the selected lines were
+	// not changed.
+	if baseOID != "" {
+		hunks, err := discussionFileDiffHunks(ctx, repo, baseOID, commit.OID(), *r.t.Path, *path)
+		if err != nil {
+			return nil, err
+		}
+		if newRange, ok := discussions.MapLineRange(oldRange, hunks); ok {
+			return &discussionRelocatedSelection{
+				Found:     true,
+				StartLine: int32(newRange.StartLine),
+				EndLine:   int32(newRange.EndLine),
+			}, nil
+		}
+	}
+
+	// Otherwise, fall back to searching for the lines around the selection.
+	file, err := commit.File(ctx, &struct{ Path string }{Path: *path})
+	if err != nil {
+		return nil, err
+	}
+	newContent, err := file.Content(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return discussionRelocateSelectionInContent(r.t, newContent), nil
+}
+
+// discussionRelocateSelectionInContent locates the selection in newContent
+// using the lines around the selection (see discussionSelectionRelativeTo).
+func discussionRelocateSelectionInContent(oldSel *types.DiscussionThreadTargetRepo, newContent string) *discussionRelocatedSelection {
+	r := discussionSelectionRelativeTo(oldSel, newContent)
+	if r == nil {
+		return &discussionRelocatedSelection{Outdated: true}
+	}
+	_, newLines, _ := discussions.LinesForSelection(newContent, discussions.LineRange{
+		StartLine: int(r.startLine),
+		EndLine:   int(r.endLine),
+	})
+	return &discussionRelocatedSelection{
+		Found:     true,
+		StartLine: r.startLine,
+		EndLine:   r.endLine,
+		Outdated:  strings.Join(newLines, "\n") != strings.Join(*oldSel.Lines, "\n"),
+	}
+}
+
+// discussionFileDiffHunks returns the hunks of the diff (without context
+// lines) of the file at oldPath in base and newPath in head. It returns no
+// hunks if the file is unchanged.
+func discussionFileDiffHunks(ctx context.Context, repo *repositoryResolver, base, head gitObjectID, oldPath, newPath string) ([]discussions.DiffHunk, error) {
+	cachedRepo, err := backend.CachedGitRepo(ctx, repo.repo)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		"diff",
+		"--unified=0",
+		"--find-renames",
+		"--full-index",
+		"--no-prefix",
+		string(base),
+		string(head),
+		"--",
+		oldPath,
+	}
+	if newPath != oldPath {
+		args = append(args, newPath) // so that git detects the rename
+	}
+	rdr, err := git.ExecReader(ctx, *cachedRepo, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rdr.Close()
+
+	dr := diff.NewMultiFileDiffReader(rdr)
+	for {
+		fileDiff, err := dr.ReadFile()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fileDiff.OrigName != oldPath {
+			continue
+		}
+		hunks := make([]discussions.DiffHunk, len(fileDiff.Hunks))
+		for i, h := range fileDiff.Hunks {
+			hunks[i] = discussions.DiffHunk{
+				OrigStartLine: int(h.OrigStartLine),
+				OrigLines:     int(h.OrigLines),
+				NewLines:      int(h.NewLines),
+			}
+		}
+		return hunks, nil
+	}
+}
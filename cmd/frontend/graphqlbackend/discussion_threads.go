@@ -19,6 +19,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	"github.com/sourcegraph/sourcegraph/pkg/registry"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -256,7 +257,7 @@ func (r *discussionsMutationResolver) CreateThread(ctx context.Context, args *st
 	// Create the first comment in the thread.
 	newComment := &types.DiscussionComment{
 		ThreadID:     newThread.ID,
-		AuthorUserID: currentUser.user.ID,
+		AuthorUserID: &currentUser.user.ID,
 		Contents:     args.Input.Contents,
 	}
 	_, err = db.DiscussionComments.Create(ctx, newComment)
@@ -566,51 +567,11 @@ func discussionSelectionRelativeTo(oldSel *types.DiscussionThreadTargetRepo, new
 func (r *discussionThreadTargetRepoResolver) RelativeSelection(ctx context.Context, args *struct {
 	Rev string
 }) (*discussionSelectionRangeResolver, error) {
-	if !r.t.HasSelection() {
-		return nil, nil
-	}
-	path, err := r.RelativePath(ctx, args)
-	if err != nil {
-		return nil, err
-	}
-	if path == nil {
-		return nil, nil
-	}
-	repo, err := repositoryByIDInt32(ctx, r.t.RepoID)
-	if err != nil {
-		return nil, err
-	}
-	commit, err := repo.Commit(ctx, &repositoryCommitArgs{Rev: args.Rev})
-	if err != nil {
-		return nil, err
-	}
-	oldSel := &discussionSelectionRangeResolver{
-		startLine:      *r.t.StartLine,
-		startCharacter: *r.t.StartCharacter,
-		endLine:        *r.t.EndLine,
-		endCharacter:   *r.t.EndCharacter,
-	}
-	if r.t.Revision != nil && *r.t.Revision == string(commit.OID()) {
-		return oldSel, nil // nothing to do (requested relative revision is identical to the stored revision)
-	}
-	if r.t.Branch != nil {
-		branchCommit, err := repo.Commit(ctx, &repositoryCommitArgs{Rev: *r.t.Branch})
-		if err != nil {
-			return nil, err
-		}
-		if branchCommit.OID() == commit.OID() {
-			return oldSel, nil // nothing to do (requested relative revision is identical to the stored branch revision)
-		}
-	}
-	file, err := commit.File(ctx, &struct{ Path string }{Path: *path})
-	if err != nil {
-		return nil, err
-	}
-	newContent, err := file.Content(ctx)
-	if err != nil {
+	sel, err := r.RelocatedSelection(ctx, args)
+	if err != nil || sel == nil {
 		return nil, err
 	}
-	return discussionSelectionRelativeTo(r.t, newContent), nil
+	return sel.Range(), nil
 }
 
 type discussionThreadTargetResolver struct {
@@ -746,11 +707,11 @@ func viewerCanUseDiscussions(ctx context.Context) error {
 	if err := jsonc.Unmarshal(merged.Contents(), &settings); err != nil {
 		return err
 	}
-	enabled, ok := settings.Extensions["sourcegraph/code-discussions"]
+	value, ok := settings.Extensions["sourcegraph/code-discussions"]
 	if !ok {
 		return errors.New("Sourcegraph Code Discussions extension must be added for the active user to use this API")
 	}
-	if !enabled {
+	if enabled, _ := registry.ParseExtensionSetting(value); !enabled {
 		return errors.New("Sourcegraph Code Discussions extension must be enabled for the active user to use this API")
 	}
 	return nil
@@ -94,3 +94,44 @@ func TestDiscussionSelectionRelativeTo(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscussionRelocateSelectionInContent(t *testing.T) {
+	i32 := func(i int32) *int32 {
+		return &i
+	}
+	oldSelection := &types.DiscussionThreadTargetRepo{
+		StartLine: i32(3), StartCharacter: i32(0), EndLine: i32(5), EndCharacter: i32(1),
+		LinesBefore: &[]string{"0", "1", "2"},
+		Lines:       &[]string{"3", "4"},
+		LinesAfter:  &[]string{"5", "6", "7"},
+	}
+	tests := []struct {
+		name       string
+		newContent string
+		want       *discussionRelocatedSelection
+	}{
+		{
+			name:       "moved",
+			newContent: "a\nb\n0\n1\n2\n3\n4\n5\n6\n7",
+			want:       &discussionRelocatedSelection{Found: true, StartLine: 5, EndLine: 7},
+		},
+		{
+			name:       "changed",
+			newContent: "a\nb\n0\n1\n2\nthree\n4\n5\n6\n7",
+			want:       &discussionRelocatedSelection{Found: true, StartLine: 5, EndLine: 7, Outdated: true},
+		},
+		{
+			name:       "removed",
+			newContent: "a\nb\nc",
+			want:       &discussionRelocatedSelection{Outdated: true},
+		},
+	}
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			got := discussionRelocateSelectionInContent(oldSelection, tst.newContent)
+			if !reflect.DeepEqual(got, tst.want) {
+				t.Errorf("got %+v, want %+v", got, tst.want)
+			}
+		})
+	}
+}
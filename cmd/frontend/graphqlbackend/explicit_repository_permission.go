@@ -0,0 +1,80 @@
+package graphqlbackend
+
+import (
+	"context"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+)
+
+// explicitRepositoryPermissionResolver resolves read access to repositories granted explicitly by
+// a site admin.
+type explicitRepositoryPermissionResolver struct {
+	perm *db.ExplicitRepoPermission
+}
+
+func explicitRepositoryPermissionByID(ctx context.Context, id graphql.ID) (*explicitRepositoryPermissionResolver, error) {
+	// 🚨 SECURITY: Only site admins may read explicit repository permissions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	permID, err := unmarshalExplicitRepositoryPermissionID(id)
+	if err != nil {
+		return nil, err
+	}
+	perm, err := db.ExplicitPermissions.GetByID(ctx, permID)
+	if err != nil {
+		return nil, err
+	}
+	return &explicitRepositoryPermissionResolver{perm: perm}, nil
+}
+
+func marshalExplicitRepositoryPermissionID(id int32) graphql.ID {
+	return relay.MarshalID("ExplicitRepositoryPermission", id)
+}
+
+func unmarshalExplicitRepositoryPermissionID(id graphql.ID) (permID int32, err error) {
+	err = relay.UnmarshalSpec(id, &permID)
+	return
+}
+
+func (r *explicitRepositoryPermissionResolver) ID() graphql.ID {
+	return marshalExplicitRepositoryPermissionID(r.perm.ID)
+}
+
+func (r *explicitRepositoryPermissionResolver) RepositoryName() *string {
+	if r.perm.RepoName == "" {
+		return nil
+	}
+	name := string(r.perm.RepoName)
+	return &name
+}
+
+func (r *explicitRepositoryPermissionResolver) RepositoryPattern() *string {
+	if r.perm.RepoPattern == "" {
+		return nil
+	}
+	return &r.perm.RepoPattern
+}
+
+func (r *explicitRepositoryPermissionResolver) User(ctx context.Context) (*UserResolver, error) {
+	if r.perm.UserID == 0 {
+		return nil, nil
+	}
+	return UserByIDInt32(ctx, r.perm.UserID)
+}
+
+func (r *explicitRepositoryPermissionResolver) Organization(ctx context.Context) (*OrgResolver, error) {
+	if r.perm.OrgID == 0 {
+		return nil, nil
+	}
+	return OrgByIDInt32(ctx, r.perm.OrgID)
+}
+
+func (r *explicitRepositoryPermissionResolver) CreatedAt() string {
+	return r.perm.CreatedAt.Format(time.RFC3339)
+}
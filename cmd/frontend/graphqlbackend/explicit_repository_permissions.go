@@ -0,0 +1,217 @@
+package graphqlbackend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func (r *schemaResolver) ExplicitRepositoryPermissions(ctx context.Context, args *struct {
+	graphqlutil.ConnectionArgs
+	User         *graphql.ID
+	Organization *graphql.ID
+}) (*explicitRepositoryPermissionConnectionResolver, error) {
+	// 🚨 SECURITY: Only site admins may list explicit repository permissions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var opt db.ExplicitPermissionsListOptions
+	if args.User != nil {
+		var err error
+		if opt.UserID, err = UnmarshalUserID(*args.User); err != nil {
+			return nil, err
+		}
+	}
+	if args.Organization != nil {
+		var err error
+		if opt.OrgID, err = UnmarshalOrgID(*args.Organization); err != nil {
+			return nil, err
+		}
+	}
+	args.ConnectionArgs.Set(&opt.LimitOffset)
+	return &explicitRepositoryPermissionConnectionResolver{opt: opt}, nil
+}
+
+type explicitRepositoryPermissionConnectionResolver struct {
+	opt db.ExplicitPermissionsListOptions
+
+	// cache results because they are used by multiple fields
+	once  sync.Once
+	perms []*db.ExplicitRepoPermission
+	err   error
+}
+
+func (r *explicitRepositoryPermissionConnectionResolver) compute(ctx context.Context) ([]*db.ExplicitRepoPermission, error) {
+	r.once.Do(func() {
+		r.perms, r.err = db.ExplicitPermissions.List(ctx, r.opt)
+	})
+	return r.perms, r.err
+}
+
+func (r *explicitRepositoryPermissionConnectionResolver) Nodes(ctx context.Context) ([]*explicitRepositoryPermissionResolver, error) {
+	perms, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toExplicitRepositoryPermissionResolvers(perms), nil
+}
+
+func (r *explicitRepositoryPermissionConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.ExplicitPermissions.Count(ctx, r.opt)
+	return int32(count), err
+}
+
+func (r *explicitRepositoryPermissionConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	perms, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.LimitOffset != nil && len(perms) >= r.opt.Limit), nil
+}
+
+func toExplicitRepositoryPermissionResolvers(perms []*db.ExplicitRepoPermission) []*explicitRepositoryPermissionResolver {
+	resolvers := make([]*explicitRepositoryPermissionResolver, len(perms))
+	for i, perm := range perms {
+		resolvers[i] = &explicitRepositoryPermissionResolver{perm: perm}
+	}
+	return resolvers
+}
+
+func (*schemaResolver) GrantRepositoryPermission(ctx context.Context, args *struct {
+	Repository        *string
+	RepositoryPattern *string
+	User              *graphql.ID
+	Organization      *graphql.ID
+}) (*explicitRepositoryPermissionResolver, error) {
+	// 🚨 SECURITY: Only site admins may grant explicit repository permissions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var perm db.ExplicitRepoPermission
+	if args.Repository != nil {
+		perm.RepoName = api.RepoName(*args.Repository)
+	}
+	if args.RepositoryPattern != nil {
+		perm.RepoPattern = *args.RepositoryPattern
+	}
+	if args.User != nil {
+		var err error
+		if perm.UserID, err = UnmarshalUserID(*args.User); err != nil {
+			return nil, err
+		}
+	}
+	if args.Organization != nil {
+		var err error
+		if perm.OrgID, err = UnmarshalOrgID(*args.Organization); err != nil {
+			return nil, err
+		}
+	}
+	if err := db.ExplicitPermissions.Create(ctx, &perm); err != nil {
+		return nil, err
+	}
+	return &explicitRepositoryPermissionResolver{perm: &perm}, nil
+}
+
+func (*schemaResolver) RevokeRepositoryPermission(ctx context.Context, args *struct {
+	Permission graphql.ID
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: Only site admins may revoke explicit repository permissions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := unmarshalExplicitRepositoryPermissionID(args.Permission)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.ExplicitPermissions.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+// explicitRepositoryPermissionsImportEntry is an element of the JSON array accepted by the
+// importRepositoryPermissions mutation. See its documentation in the GraphQL schema.
+type explicitRepositoryPermissionsImportEntry struct {
+	Repository        string   `json:"repository"`
+	RepositoryPattern string   `json:"repositoryPattern"`
+	Users             []string `json:"users"`
+	Organizations     []string `json:"organizations"`
+}
+
+func (*schemaResolver) ImportRepositoryPermissions(ctx context.Context, args *struct {
+	Permissions string
+}) ([]*explicitRepositoryPermissionResolver, error) {
+	// 🚨 SECURITY: Only site admins may grant explicit repository permissions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	perms, err := parseExplicitRepositoryPermissionsImport(ctx, []byte(args.Permissions))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.ExplicitPermissions.Create(ctx, perms...); err != nil {
+		return nil, err
+	}
+	return toExplicitRepositoryPermissionResolvers(perms), nil
+}
+
+// parseExplicitRepositoryPermissionsImport parses the JSON document accepted by the
+// importRepositoryPermissions mutation, looking up the users and organizations by name.
+func parseExplicitRepositoryPermissionsImport(ctx context.Context, data []byte) ([]*db.ExplicitRepoPermission, error) {
+	var entries []explicitRepositoryPermissionsImportEntry
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields() // catch misspelled properties, which would otherwise grant nothing
+	if err := dec.Decode(&entries); err != nil {
+		return nil, errors.Wrap(err, "invalid repository permissions")
+	}
+
+	userIDs := map[string]int32{}
+	orgIDs := map[string]int32{}
+	var perms []*db.ExplicitRepoPermission
+	for i, e := range entries {
+		if (e.Repository == "") == (e.RepositoryPattern == "") {
+			return nil, fmt.Errorf("repository permission %d: exactly one of repository and repositoryPattern must be set", i)
+		}
+		if len(e.Users) == 0 && len(e.Organizations) == 0 {
+			return nil, fmt.Errorf("repository permission %d: no users or organizations", i)
+		}
+		for _, username := range e.Users {
+			id, ok := userIDs[username]
+			if !ok {
+				user, err := db.Users.GetByUsername(ctx, username)
+				if err != nil {
+					return nil, errors.Wrapf(err, "repository permission %d: user %q", i, username)
+				}
+				id = user.ID
+				userIDs[username] = id
+			}
+			perms = append(perms, &db.ExplicitRepoPermission{RepoName: api.RepoName(e.Repository), RepoPattern: e.RepositoryPattern, UserID: id})
+		}
+		for _, name := range e.Organizations {
+			id, ok := orgIDs[name]
+			if !ok {
+				org, err := db.Orgs.GetByName(ctx, name)
+				if err != nil {
+					return nil, errors.Wrapf(err, "repository permission %d: organization %q", i, name)
+				}
+				id = org.ID
+				orgIDs[name] = id
+			}
+			perms = append(perms, &db.ExplicitRepoPermission{RepoName: api.RepoName(e.Repository), RepoPattern: e.RepositoryPattern, OrgID: id})
+		}
+	}
+	return perms, nil
+}
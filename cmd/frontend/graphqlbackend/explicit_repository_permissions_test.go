@@ -0,0 +1,125 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestMutation_ImportRepositoryPermissions(t *testing.T) {
+	resetMocks()
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{SiteAdmin: true}, nil
+	}
+	db.Mocks.Users.GetByUsername = func(ctx context.Context, username string) (*types.User, error) {
+		return &types.User{ID: map[string]int32{"alice": 1, "bob": 2}[username], Username: username}, nil
+	}
+	db.Mocks.Orgs.GetByName = func(ctx context.Context, name string) (*types.Org, error) {
+		return &types.Org{ID: 3, Name: name}, nil
+	}
+	var created []db.ExplicitRepoPermission
+	db.Mocks.ExplicitPermissions.Create = func(perms ...*db.ExplicitRepoPermission) error {
+		for i, p := range perms {
+			p.ID = int32(i + 1)
+			created = append(created, *p)
+		}
+		return nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				mutation {
+					importRepositoryPermissions(permissions: "[{\"repository\": \"example.com/a\", \"users\": [\"alice\", \"bob\"]}, {\"repositoryPattern\": \"example.com/b/.*\", \"organizations\": [\"team\"]}]") {
+						id
+						repositoryName
+						repositoryPattern
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"importRepositoryPermissions": [
+						{
+							"id": "RXhwbGljaXRSZXBvc2l0b3J5UGVybWlzc2lvbjox",
+							"repositoryName": "example.com/a",
+							"repositoryPattern": null
+						},
+						{
+							"id": "RXhwbGljaXRSZXBvc2l0b3J5UGVybWlzc2lvbjoy",
+							"repositoryName": "example.com/a",
+							"repositoryPattern": null
+						},
+						{
+							"id": "RXhwbGljaXRSZXBvc2l0b3J5UGVybWlzc2lvbjoz",
+							"repositoryName": null,
+							"repositoryPattern": "example.com/b/.*"
+						}
+					]
+				}
+			`,
+		},
+	})
+
+	want := []db.ExplicitRepoPermission{
+		{ID: 1, RepoName: "example.com/a", UserID: 1},
+		{ID: 2, RepoName: "example.com/a", UserID: 2},
+		{ID: 3, RepoPattern: "example.com/b/.*", OrgID: 3},
+	}
+	if !reflect.DeepEqual(created, want) {
+		t.Errorf("got created permissions %+v, want %+v", created, want)
+	}
+}
+
+func TestParseExplicitRepositoryPermissionsImport(t *testing.T) {
+	resetMocks()
+	db.Mocks.Users.GetByUsername = func(ctx context.Context, username string) (*types.User, error) {
+		return &types.User{ID: 1, Username: username}, nil
+	}
+
+	for _, data := range []string{
+		`{"repository": "example.com/a", "users": ["alice"]}`,  // not an array
+		`[{"users": ["alice"]}]`,                               // no repository
+		`[{"repository": "example.com/a"}]`,                    // nobody to grant access to
+		`[{"repository": "example.com/a", "user": ["alice"]}]`, // misspelled property
+		`[{"repositry": "example.com/a", "users": ["alice"]}]`, // misspelled property
+		`[{"repository": "example.com/a", "users": "alice"}]`,  // wrong type
+	} {
+		if _, err := parseExplicitRepositoryPermissionsImport(context.Background(), []byte(data)); err == nil {
+			t.Errorf("expected an error parsing %s", data)
+		}
+	}
+}
+
+// 🚨 SECURITY: This tests that only site admins can grant explicit repository permissions.
+func TestMutation_GrantRepositoryPermission_nonSiteAdmin(t *testing.T) {
+	resetMocks()
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: 1}, nil
+	}
+	db.Mocks.ExplicitPermissions.Create = func(perms ...*db.ExplicitRepoPermission) error {
+		t.Fatal("permission should not be created")
+		return nil
+	}
+
+	name := "example.com/a"
+	user := marshalUserID(1)
+	result, err := (&schemaResolver{}).GrantRepositoryPermission(context.Background(), &struct {
+		Repository        *string
+		RepositoryPattern *string
+		User              *graphql.ID
+		Organization      *graphql.ID
+	}{Repository: &name, User: &user})
+	if err == nil {
+		t.Error("err == nil")
+	}
+	if result != nil {
+		t.Errorf("got result %v, want nil", result)
+	}
+}
@@ -7,6 +7,9 @@ import (
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	"github.com/sourcegraph/sourcegraph/pkg/registry"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 var ErrExtensionsDisabled = errors.New("extensions are disabled in site configuration (contact the site admin to enable extensions)")
@@ -28,6 +31,27 @@ func (r *schemaResolver) ExtensionRegistry(ctx context.Context) (ExtensionRegist
 // ExtensionRegistryMutation.
 var ExtensionRegistry ExtensionRegistryResolver
 
+// ViewerExtensionVersionRanges returns the version ranges (such as "^1.2.0") that the viewer's
+// settings pin enabled extensions to, keyed by extension ID. Extensions that are not pinned to a
+// version range are omitted.
+func ViewerExtensionVersionRanges(ctx context.Context) (map[string]string, error) {
+	merged, err := viewerFinalSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var settings schema.Settings
+	if err := jsonc.Unmarshal(merged.Contents(), &settings); err != nil {
+		return nil, err
+	}
+	versionRanges := map[string]string{}
+	for extensionID, value := range settings.Extensions {
+		if enabled, versionRange := registry.ParseExtensionSetting(value); enabled && versionRange != "" {
+			versionRanges[extensionID] = versionRange
+		}
+	}
+	return versionRanges, nil
+}
+
 // ExtensionRegistryResolver is the interface for the GraphQL types ExtensionRegistry and
 // ExtensionRegistryMutation.
 //
@@ -42,6 +66,9 @@ type ExtensionRegistryResolver interface {
 	UpdateExtension(context.Context, *ExtensionRegistryUpdateExtensionArgs) (ExtensionRegistryMutationResult, error)
 	PublishExtension(context.Context, *ExtensionRegistryPublishExtensionArgs) (ExtensionRegistryMutationResult, error)
 	DeleteExtension(context.Context, *ExtensionRegistryDeleteExtensionArgs) (*EmptyResponse, error)
+	SetReleaseYanked(context.Context, *ExtensionRegistrySetReleaseYankedArgs) (*EmptyResponse, error)
+	ExportExtensions(context.Context, *ExtensionRegistryExportExtensionsArgs) (string, error)
+	ImportExtensions(context.Context, *ExtensionRegistryImportExtensionsArgs) ([]RegistryExtension, error)
 	LocalExtensionIDPrefix() *string
 
 	ImplementsLocalExtensionRegistry() bool // not exposed via GraphQL
@@ -63,6 +90,7 @@ type RegistryExtensionConnectionArgs struct {
 
 type ExtensionRegistryExtensionArgs struct {
 	ExtensionID string
+	Version     *string
 }
 
 type ExtensionRegistryCreateExtensionArgs struct {
@@ -81,12 +109,28 @@ type ExtensionRegistryPublishExtensionArgs struct {
 	Bundle      *string
 	SourceMap   *string
 	Force       bool
+	Version     *string
 }
 
 type ExtensionRegistryDeleteExtensionArgs struct {
 	Extension graphql.ID
 }
 
+type ExtensionRegistrySetReleaseYankedArgs struct {
+	Extension graphql.ID
+	Version   string
+	Yanked    bool
+}
+
+type ExtensionRegistryExportExtensionsArgs struct {
+	ExtensionIDs []string
+}
+
+type ExtensionRegistryImportExtensionsArgs struct {
+	Archive   string
+	Publisher *graphql.ID
+}
+
 // ExtensionRegistryMutationResult is the interface for the GraphQL type ExtensionRegistryMutationResult.
 type ExtensionRegistryMutationResult interface {
 	Extension(context.Context) (RegistryExtension, error)
@@ -109,6 +153,8 @@ type RegistryExtension interface {
 	Publisher(ctx context.Context) (RegistryPublisher, error)
 	Name() string
 	Manifest(ctx context.Context) (ExtensionManifest, error)
+	Version(ctx context.Context) (*string, error)
+	Releases(ctx context.Context) (*[]RegistryExtensionRelease, error)
 	CreatedAt() *string
 	UpdatedAt() *string
 	PublishedAt(context.Context) (*string, error)
@@ -120,6 +166,13 @@ type RegistryExtension interface {
 	ViewerCanAdminister(ctx context.Context) (bool, error)
 }
 
+// RegistryExtensionRelease is the interface for the GraphQL type RegistryExtensionRelease.
+type RegistryExtensionRelease interface {
+	Version() *string
+	PublishedAt() string
+	Yanked() bool
+}
+
 // ExtensionManifest is the interface for the GraphQL type ExtensionManifest.
 type ExtensionManifest interface {
 	Raw() string
@@ -92,6 +92,11 @@ func (r *nodeResolver) ToProductSubscription() (ProductSubscription, bool) {
 	return n, ok
 }
 
+func (r *nodeResolver) ToExplicitRepositoryPermission() (*explicitRepositoryPermissionResolver, bool) {
+	n, ok := r.node.(*explicitRepositoryPermissionResolver)
+	return n, ok
+}
+
 func (r *nodeResolver) ToExternalAccount() (*externalAccountResolver, bool) {
 	n, ok := r.node.(*externalAccountResolver)
 	return n, ok
@@ -195,6 +200,8 @@ func nodeByID(ctx context.Context, id graphql.ID) (node, error) {
 			return f(ctx, id)
 		}
 		return nil, errors.New("not implemented")
+	case "ExplicitRepositoryPermission":
+		return explicitRepositoryPermissionByID(ctx, id)
 	case "ExternalAccount":
 		return externalAccountByID(ctx, id)
 	case externalServiceIDKind:
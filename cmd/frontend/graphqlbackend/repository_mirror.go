@@ -119,6 +119,37 @@ func (r *repositoryMirrorInfoResolver) UpdatedAt(ctx context.Context) (*string,
 	return &s, nil
 }
 
+func (r *repositoryMirrorInfoResolver) DiskUsageKB(ctx context.Context) (*int32, error) {
+	info, err := r.gitserverRepoInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Cloned {
+		return nil, nil
+	}
+	kb := int32(info.Size / 1024)
+	return &kb, nil
+}
+
+func (r *repositoryMirrorInfoResolver) Shallow(ctx context.Context) (bool, error) {
+	info, err := r.gitserverRepoInfo(ctx)
+	if err != nil {
+		return false, err
+	}
+	return info.Shallow, nil
+}
+
+func (r *repositoryMirrorInfoResolver) LastError(ctx context.Context) (*string, error) {
+	info, err := r.gitserverRepoInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.LastError == "" {
+		return nil, nil
+	}
+	return &info.LastError, nil
+}
+
 func (r *repositoryMirrorInfoResolver) UpdateSchedule(ctx context.Context) (*updateScheduleResolver, error) {
 	info, err := r.repoUpdateSchedulerInfo(ctx)
 	if err != nil {
@@ -3,6 +3,7 @@ package graphqlbackend
 import (
 	"context"
 	"errors"
+	"net/url"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
@@ -58,6 +59,8 @@ func savedSearchByID(ctx context.Context, id graphql.ID) (*savedSearchResolver,
 			UserID:          ss.Config.UserID,
 			OrgID:           ss.Config.OrgID,
 			SlackWebhookURL: ss.Config.SlackWebhookURL,
+			NotifyWebhook:   ss.Config.NotifyWebhook,
+			WebhookURL:      ss.Config.WebhookURL,
 		},
 	}
 	return savedSearch, nil
@@ -95,6 +98,10 @@ func (r savedSearchResolver) OrgID() *graphql.ID {
 }
 func (r savedSearchResolver) SlackWebhookURL() *string { return r.s.SlackWebhookURL }
 
+func (r savedSearchResolver) NotifyWebhook() bool { return r.s.NotifyWebhook }
+
+func (r savedSearchResolver) WebhookURL() *string { return r.s.WebhookURL }
+
 func toSavedSearchResolver(entry types.SavedSearch) *savedSearchResolver {
 	return &savedSearchResolver{entry}
 }
@@ -140,12 +147,15 @@ func (r *schemaResolver) SendSavedSearchTestNotification(ctx context.Context, ar
 }
 
 func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
-	Description string
-	Query       string
-	NotifyOwner bool
-	NotifySlack bool
-	OrgID       *graphql.ID
-	UserID      *graphql.ID
+	Description   string
+	Query         string
+	NotifyOwner   bool
+	NotifySlack   bool
+	OrgID         *graphql.ID
+	UserID        *graphql.ID
+	NotifyWebhook bool
+	WebhookURL    *string
+	WebhookSecret *string
 }) (*savedSearchResolver, error) {
 	var userID *int32
 	var orgID *int32
@@ -172,13 +182,20 @@ func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
 		return nil, errors.New("failed to create saved search: no Org ID or User ID associated with saved search")
 	}
 
+	if err := validateSavedSearchWebhook(args.NotifyWebhook, args.WebhookURL); err != nil {
+		return nil, err
+	}
+
 	ss, err := db.SavedSearches.Create(ctx, &types.SavedSearch{
-		Description: args.Description,
-		Query:       args.Query,
-		Notify:      args.NotifyOwner,
-		NotifySlack: args.NotifySlack,
-		UserID:      userID,
-		OrgID:       orgID,
+		Description:   args.Description,
+		Query:         args.Query,
+		Notify:        args.NotifyOwner,
+		NotifySlack:   args.NotifySlack,
+		UserID:        userID,
+		OrgID:         orgID,
+		NotifyWebhook: args.NotifyWebhook,
+		WebhookURL:    args.WebhookURL,
+		WebhookSecret: args.WebhookSecret,
 	})
 	if err != nil {
 		return nil, err
@@ -188,13 +205,16 @@ func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
 }
 
 func (r *schemaResolver) UpdateSavedSearch(ctx context.Context, args *struct {
-	ID          graphql.ID
-	Description string
-	Query       string
-	NotifyOwner bool
-	NotifySlack bool
-	OrgID       *graphql.ID
-	UserID      *graphql.ID
+	ID            graphql.ID
+	Description   string
+	Query         string
+	NotifyOwner   bool
+	NotifySlack   bool
+	OrgID         *graphql.ID
+	UserID        *graphql.ID
+	NotifyWebhook *bool
+	WebhookURL    *string
+	WebhookSecret *string
 }) (*savedSearchResolver, error) {
 	var userID, orgID *int32
 	// 🚨 SECURITY: Make sure the current user has permission to update a saved search for the specified user or org.
@@ -225,14 +245,39 @@ func (r *schemaResolver) UpdateSavedSearch(ctx context.Context, args *struct {
 		return nil, err
 	}
 
+	// Keep the existing webhook settings if they are omitted, so that clients which don't know about
+	// webhooks don't turn them off when updating other fields.
+	notifyWebhook, webhookURL := args.NotifyWebhook, args.WebhookURL
+	if notifyWebhook == nil || webhookURL == nil {
+		existing, err := db.SavedSearches.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if notifyWebhook == nil {
+			notifyWebhook = &existing.Config.NotifyWebhook
+		}
+		if webhookURL == nil {
+			webhookURL = existing.Config.WebhookURL
+		}
+	}
+	if webhookURL != nil && *webhookURL == "" {
+		webhookURL = nil
+	}
+	if err := validateSavedSearchWebhook(*notifyWebhook, webhookURL); err != nil {
+		return nil, err
+	}
+
 	ss, err := db.SavedSearches.Update(ctx, &types.SavedSearch{
-		ID:          id,
-		Description: args.Description,
-		Query:       args.Query,
-		Notify:      args.NotifyOwner,
-		NotifySlack: args.NotifySlack,
-		UserID:      userID,
-		OrgID:       orgID,
+		ID:            id,
+		Description:   args.Description,
+		Query:         args.Query,
+		Notify:        args.NotifyOwner,
+		NotifySlack:   args.NotifySlack,
+		UserID:        userID,
+		OrgID:         orgID,
+		NotifyWebhook: *notifyWebhook,
+		WebhookURL:    webhookURL,
+		WebhookSecret: args.WebhookSecret,
 	})
 	if err != nil {
 		return nil, err
@@ -241,6 +286,25 @@ func (r *schemaResolver) UpdateSavedSearch(ctx context.Context, args *struct {
 	return toSavedSearchResolver(*ss), nil
 }
 
+// validateSavedSearchWebhook checks that webhookURL is an absolute HTTP(S) URL, if set, and that it is
+// set if notifyWebhook is true.
+func validateSavedSearchWebhook(notifyWebhook bool, webhookURL *string) error {
+	if webhookURL == nil || *webhookURL == "" {
+		if notifyWebhook {
+			return errors.New("a webhook URL is required to send webhook notifications")
+		}
+		return nil
+	}
+	u, err := url.Parse(*webhookURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("the webhook URL must be an absolute http or https URL")
+	}
+	return nil
+}
+
 func (r *schemaResolver) DeleteSavedSearch(ctx context.Context, args *struct {
 	ID graphql.ID
 }) (*EmptyResponse, error) {
@@ -61,12 +61,15 @@ func TestCreateSavedSearch(t *testing.T) {
 	}
 	userID := marshalUserID(key)
 	savedSearches, err := (&schemaResolver{}).CreateSavedSearch(ctx, &struct {
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		NotifyWebhook bool
+		WebhookURL    *string
+		WebhookSecret *string
 	}{Description: "test query", Query: "test type:diff", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 
 	if err != nil {
@@ -99,6 +102,9 @@ func TestUpdateSavedSearch(t *testing.T) {
 	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
 		return &types.User{SiteAdmin: true, ID: key}, nil
 	}
+	db.Mocks.SavedSearches.GetByID = func(ctx context.Context, id int32) (*api.SavedQuerySpecAndConfig, error) {
+		return &api.SavedQuerySpecAndConfig{Config: api.ConfigSavedQuery{UserID: &key}}, nil
+	}
 	updateSavedSearchCalled := false
 
 	db.Mocks.SavedSearches.Update = func(ctx context.Context, savedSearch *types.SavedSearch) (*types.SavedSearch, error) {
@@ -107,13 +113,16 @@ func TestUpdateSavedSearch(t *testing.T) {
 	}
 	userID := marshalUserID(key)
 	savedSearches, err := (&schemaResolver{}).UpdateSavedSearch(ctx, &struct {
-		ID          graphql.ID
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		ID            graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		NotifyWebhook *bool
+		WebhookURL    *string
+		WebhookSecret *string
 	}{ID: marshalSavedSearchID(key), Description: "updated query description", Query: "test type:diff", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err != nil {
 		t.Fatal(err)
@@ -138,6 +147,46 @@ func TestUpdateSavedSearch(t *testing.T) {
 	}
 }
 
+func TestUpdateSavedSearch_keepsWebhook(t *testing.T) {
+	ctx := context.Background()
+	defer resetMocks()
+
+	key := int32(1)
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{SiteAdmin: true, ID: key}, nil
+	}
+	webhookURL := "https://example.com/hook"
+	db.Mocks.SavedSearches.GetByID = func(ctx context.Context, id int32) (*api.SavedQuerySpecAndConfig, error) {
+		return &api.SavedQuerySpecAndConfig{Config: api.ConfigSavedQuery{UserID: &key, NotifyWebhook: true, WebhookURL: &webhookURL}}, nil
+	}
+	var updated *types.SavedSearch
+	db.Mocks.SavedSearches.Update = func(ctx context.Context, savedSearch *types.SavedSearch) (*types.SavedSearch, error) {
+		updated = savedSearch
+		return savedSearch, nil
+	}
+
+	// The web app's mutation omits the webhook arguments.
+	userID := marshalUserID(key)
+	_, err := (&schemaResolver{}).UpdateSavedSearch(ctx, &struct {
+		ID            graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		NotifyWebhook *bool
+		WebhookURL    *string
+		WebhookSecret *string
+	}{ID: marshalSavedSearchID(key), Description: "d", Query: "q", UserID: &userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.NotifyWebhook || updated.WebhookURL == nil || *updated.WebhookURL != webhookURL {
+		t.Errorf("got NotifyWebhook %v and WebhookURL %v, want the existing webhook to be kept", updated.NotifyWebhook, updated.WebhookURL)
+	}
+}
+
 func TestDeleteSavedSearch(t *testing.T) {
 	ctx := context.Background()
 	defer resetMocks()
@@ -169,3 +218,26 @@ func TestDeleteSavedSearch(t *testing.T) {
 		t.Errorf("Database method db.SavedSearches.Delete not called")
 	}
 }
+
+func TestValidateSavedSearchWebhook(t *testing.T) {
+	strptr := func(s string) *string { return &s }
+	tests := []struct {
+		notifyWebhook bool
+		webhookURL    *string
+		wantErr       bool
+	}{
+		{notifyWebhook: false, webhookURL: nil},
+		{notifyWebhook: true, webhookURL: nil, wantErr: true},
+		{notifyWebhook: true, webhookURL: strptr(""), wantErr: true},
+		{notifyWebhook: true, webhookURL: strptr("https://example.com/hook")},
+		{notifyWebhook: false, webhookURL: strptr("http://example.com/hook")},
+		{notifyWebhook: true, webhookURL: strptr("example.com/hook"), wantErr: true},
+		{notifyWebhook: true, webhookURL: strptr("file:///etc/passwd"), wantErr: true},
+	}
+	for _, test := range tests {
+		err := validateSavedSearchWebhook(test.notifyWebhook, test.webhookURL)
+		if (err != nil) != test.wantErr {
+			t.Errorf("notifyWebhook=%v webhookURL=%v: got error %v, want error: %v", test.notifyWebhook, test.webhookURL, err, test.wantErr)
+		}
+	}
+}
@@ -47,6 +47,38 @@ type Mutation {
     updateExternalService(input: UpdateExternalServiceInput!): ExternalService!
     # Delete an external service. Only site admins may perform this mutation.
     deleteExternalService(externalService: ID!): EmptyResponse!
+    # Grants read access to a repository, or to all repositories whose name matches a pattern, to a
+    # user or to all members of an organization. Explicit permissions are only enforced on
+    # repositories from the code hosts listed in the "permissions.explicit" site configuration
+    # property.
+    #
+    # Exactly one of repository and repositoryPattern, and exactly one of user and organization, must
+    # be given. Only site admins may perform this mutation.
+    grantRepositoryPermission(
+        # The name of the repository, for example "gitolite.example.com/project".
+        repository: String
+        # A regular expression matching the entire name of the repositories. Like repository
+        # names, it is case-insensitive.
+        repositoryPattern: String
+        # The user to grant access to.
+        user: ID
+        # The organization whose members to grant access to.
+        organization: ID
+    ): ExplicitRepositoryPermission!
+    # Revokes an explicit repository permission. Only site admins may perform this mutation.
+    revokeRepositoryPermission(permission: ID!): EmptyResponse!
+    # Grants the explicit repository permissions described by a JSON array (see
+    # grantRepositoryPermission). Each element of the array is an object with the properties:
+    #
+    # - "repository" or "repositoryPattern": the name of the repository, or a regular expression
+    #   matching the entire name of the repositories
+    # - "users": the usernames of the users to grant access to
+    # - "organizations": the names of the organizations whose members to grant access to
+    #
+    # For example: [{"repositoryPattern": "gitolite.example.com/team/.*", "organizations": ["team"]}]
+    #
+    # Either all or none of the permissions are granted. Only site admins may perform this mutation.
+    importRepositoryPermissions(permissions: String!): [ExplicitRepositoryPermission!]!
     # DEPRECATED: All repositories are accessible or deleted. To prevent a
     # repository from being accessed on Sourcegraph add it to the external
     # service exclude configuration. This mutation will be removed in 3.6.
@@ -296,6 +328,15 @@ type Mutation {
         # with this new value.
         input: String!
     ): Boolean!
+    # Restores the site and critical configuration to their contents after a previous change in the site
+    # configuration history (see SiteConfiguration.history). The restored configuration is validated again
+    # first. Returns whether or not a restart is required for the rollback to be applied.
+    #
+    # Only site admins may perform this mutation.
+    rollbackSiteConfiguration(
+        # The ID of the site configuration change whose resulting site and critical configuration to restore.
+        changeID: Int!
+    ): Boolean!
     # Manages discussions.
     discussions: DiscussionsMutation
     # Sets whether the user with the specified user ID is a site admin.
@@ -328,6 +369,13 @@ type Mutation {
         notifySlack: Boolean!
         orgID: ID
         userID: ID
+        # Whether or not to POST a JSON payload describing new results to webhookURL.
+        notifyWebhook: Boolean = false
+        # The URL that webhook notifications are POSTed to.
+        webhookURL: String
+        # The secret used to sign webhook notification payloads. If null when updating a saved
+        # search, the existing secret is kept.
+        webhookSecret: String
     ): SavedSearch!
     # Updates a saved search
     updateSavedSearch(
@@ -338,6 +386,15 @@ type Mutation {
         notifySlack: Boolean!
         orgID: ID
         userID: ID
+        # Whether or not to POST a JSON payload describing new results to webhookURL. If null, the
+        # existing value is kept.
+        notifyWebhook: Boolean
+        # The URL that webhook notifications are POSTed to. If null, the existing URL is kept. If
+        # empty, the URL is removed.
+        webhookURL: String
+        # The secret used to sign webhook notification payloads. If null when updating a saved
+        # search, the existing secret is kept.
+        webhookSecret: String
     ): SavedSearch!
     # Deletes a saved search
     deleteSavedSearch(id: ID!): EmptyResponse
@@ -670,6 +727,17 @@ type Query {
         # Returns the first n external services from the list.
         first: Int
     ): ExternalServiceConnection!
+    # Lists the repository permissions granted explicitly by site admins. Only site admins may
+    # perform this query.
+    explicitRepositoryPermissions(
+        # Returns the first n permissions from the list.
+        first: Int
+        # Returns only the permissions granted to this user (not including those granted to the
+        # user's organizations).
+        user: ID
+        # Returns only the permissions granted to this organization.
+        organization: ID
+    ): ExplicitRepositoryPermissionConnection!
     # List all repositories.
     repositories(
         # Returns the first n repositories from the list.
@@ -991,6 +1059,11 @@ type SavedSearch implements Node {
     orgID: ID
     # The Slack webhook URL associated with this saved search, if any.
     slackWebhookURL: String
+    # Whether or not to POST a JSON payload describing new results to webhookURL.
+    notifyWebhook: Boolean!
+    # The URL that webhook notifications are POSTed to, if any. The secret used to sign the
+    # notifications is never returned.
+    webhookURL: String
 }
 
 # A search query description.
@@ -1079,6 +1152,37 @@ type ExternalServiceConnection {
     pageInfo: PageInfo!
 }
 
+# Read access to a repository, or to all repositories whose name matches a pattern, granted
+# explicitly by a site admin to a user or to all members of an organization.
+type ExplicitRepositoryPermission implements Node {
+    # The unique ID of the permission.
+    id: ID!
+    # The name of the repository, or null if the permission applies to a pattern.
+    repositoryName: String
+    # The regular expression matching the entire name of the repositories, or null if the
+    # permission applies to a single repository.
+    repositoryPattern: String
+    # The user granted access, or null if the permission is granted to an organization.
+    user: User
+    # The organization whose members are granted access, or null if the permission is granted to a
+    # user.
+    organization: Org
+    # The date when the permission was granted.
+    createdAt: String!
+}
+
+# A list of explicit repository permissions.
+type ExplicitRepositoryPermissionConnection {
+    # A list of explicit repository permissions.
+    nodes: [ExplicitRepositoryPermission!]!
+
+    # The total number of explicit repository permissions in the connection.
+    totalCount: Int!
+
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
 # A specific kind of external service.
 enum ExternalServiceKind {
     AWSCODECOMMIT
@@ -1281,6 +1385,14 @@ type MirrorRepositoryInfo {
     cloned: Boolean!
     # When the repository was last successfully updated from the remote source repository..
     updatedAt: String
+    # The disk space used by the clone of the repository in kilobytes, or null if it is not cloned.
+    diskUsageKB: Int
+    # Whether only the most recent commits of the repository were cloned, because a full clone exceeds the
+    # size limit configured in the gitRepoSizeLimits site configuration property.
+    shallow: Boolean!
+    # The error of the last attempt to clone the repository, such as the repository exceeding its size
+    # limit, or null if it succeeded or is not known.
+    lastError: String
     # The state of this repository in the update schedule.
     updateSchedule: UpdateSchedule
     # The state of this repository in the update queue.
@@ -2048,6 +2160,10 @@ type FileMatch {
     symbols: [Symbol!]!
     # The line matches.
     lineMatches: [LineMatch!]!
+    # The matches which may span several lines. This is only set if the search pattern can match a
+    # newline (for example, it contains "\n" or "(?s)"). Each line spanned by one of these matches
+    # is also included in lineMatches.
+    multilineMatches: [MultilineMatch!]!
     # Whether or not the limit was hit.
     limitHit: Boolean!
 }
@@ -2064,6 +2180,14 @@ type LineMatch {
     limitHit: Boolean!
 }
 
+# A match which may span several lines.
+type MultilineMatch {
+    # The full text of the lines spanned by the match.
+    preview: String!
+    # The range of the match in the file. Characters are measured in characters (not bytes).
+    range: Range!
+}
+
 # A hunk.
 type Hunk {
     # The startLine.
@@ -2167,6 +2291,12 @@ type User implements Node & SettingsSubject {
     # Only the currently authenticated user can access this field. Site admins are not able to access sessions for
     # other users.
     session: Session!
+    # The last time the repositories this user can read were synced from the repository permissions of code
+    # hosts, or null if they were never synced. Permissions are only synced in the background if the
+    # permissions.backgroundSync site configuration property is enabled.
+    #
+    # Only the user and site admins can access this field.
+    permissionsSyncedAt: String
     # Whether the viewer has admin privileges on this user. The user has admin privileges on their own user, and
     # site admins have admin privileges on all users.
     viewerCanAdminister: Boolean!
@@ -2506,15 +2636,37 @@ type DiscussionThreadTargetRepo {
     # Where the selection would be relative to the given Git revision specifier
     # (branch/commit/etc).
     #
-    # The implementation relies on a hueristic which is generally good enough,
-    # but under certain circumstances may not be as accurate as e.g. determining
-    # this placement by walking through the Git history.
-    #
-    # If determining the relative placement is not possible (file was renamed
-    # or removed, the selection no longer exists in the file, or the hueristic
-    # failed) null is returned and it should be assumed the selection does not
-    # exist in this revision.
+    # This is the range of relocatedSelection. If determining the relative
+    # placement is not possible (the file was removed, or the selection no
+    # longer exists in the file) null is returned and it should be assumed the
+    # selection does not exist in this revision.
     relativeSelection(rev: String!): DiscussionSelectionRange
+
+    # Where the selection is in the given Git revision specifier
+    # (branch/commit/etc), and whether the selected lines changed since the
+    # thread was created.
+    #
+    # The selection is mapped through the Git diff of the file between the
+    # revision the thread was created on and the given revision. If the
+    # selected lines were changed, or the thread's revision is unknown, it is
+    # located using a heuristic that searches for the lines around the
+    # selection.
+    #
+    # null is returned if the thread has no selection or the revision does not
+    # exist.
+    relocatedSelection(rev: String!): DiscussionRelocatedSelection
+}
+
+# The location of a discussion thread's selection in a specific revision of
+# the file.
+type DiscussionRelocatedSelection {
+    # The range of the selection in the revision, or null if the selection could
+    # not be located (e.g. the file or the selected lines were removed).
+    range: DiscussionSelectionRange
+
+    # Whether the selected lines in the revision differ from the lines the
+    # thread was created on, or the selection could not be located.
+    outdated: Boolean!
 }
 
 # The target of a discussion thread. Today, the only possible target is a
@@ -2570,8 +2722,13 @@ type DiscussionComment {
     # The discussion thread the comment was made in.
     thread: DiscussionThread!
 
-    # The user who authored this discussion thread.
-    author: User!
+    # The user who authored this discussion thread, or null if the comment was
+    # imported from a code host user who has no Sourcegraph account.
+    author: User
+
+    # The username on the code host of the author of a comment imported from a
+    # code host user who has no Sourcegraph account. Null if author is set.
+    externalAuthor: String
 
     # The actual markdown contents of the comment.
     #
@@ -2796,6 +2953,46 @@ type SiteConfiguration {
     # This includes both JSON Schema validation problems and other messages that perform more advanced checks
     # on the configuration (that can't be expressed in the JSON Schema).
     validationMessages: [String!]!
+    # The history of accepted changes to the site configuration, most recent first.
+    history(
+        # Returns the first n changes from the list.
+        first: Int
+    ): SiteConfigurationChangeConnection!
+}
+
+# A list of site configuration changes.
+type SiteConfigurationChangeConnection {
+    # A list of site configuration changes.
+    nodes: [SiteConfigurationChange!]!
+    # The total number of site configuration changes in the connection.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# An accepted change to the site configuration.
+type SiteConfigurationChange {
+    # The unique identifier of this change.
+    id: Int!
+    # The user who made the change, or null if the change was not made by a user or the user no longer
+    # exists.
+    author: User
+    # The date when the change was made.
+    createdAt: String!
+    # The names of the configuration fields whose values were changed, such as "maxReposToSearch" (or
+    # "critical::auth.providers" for critical configuration fields).
+    changedFields: [String!]!
+    # The site configuration JSON before the change. The critical configuration is not included (see
+    # criticalDiff).
+    previousContents: String!
+    # The site configuration JSON after the change. The critical configuration is not included (see
+    # criticalDiff).
+    contents: String!
+    # A unified diff from the previous to the new site configuration JSON.
+    diff: String!
+    # A unified diff from the previous to the new critical configuration JSON, or the empty string if the
+    # change did not change the critical configuration.
+    criticalDiff: String!
 }
 
 # Information about software updates for Sourcegraph.
@@ -3096,7 +3293,14 @@ type ExtensionRegistry {
     # extension name).
     #
     # To find an extension by its GraphQL ID, use Query.node.
-    extension(extensionID: String!): RegistryExtension
+    extension(
+        # The extension ID.
+        extensionID: String!
+        # A semantic version range (such as "^1.2.0") that selects the release whose manifest is returned: the
+        # non-yanked release with the greatest version in the range. If null, the version range that the viewer's
+        # settings pin the extension to (if any) is used. Otherwise, the latest non-yanked release is used.
+        version: String
+    ): RegistryExtension
     # A list of extensions published in the extension registry.
     extensions(
         # Returns the first n extensions from the list.
@@ -3204,7 +3408,46 @@ type ExtensionRegistryMutation {
         sourceMap: String
         # Force publish even if there are warnings (such as invalid JSON warnings).
         force: Boolean = false
+        # The semantic version of the release (such as "1.2.3"), or null for a release with no version. Each version
+        # may only be published once per extension.
+        version: String
     ): ExtensionRegistryCreateExtensionResult!
+    # Yank (or un-yank) a release of an extension in the extension registry. A yanked release is no longer used as
+    # the latest release or as the release for a version range, so clients fall back to the previous release. It
+    # is still served to clients that refer to it directly.
+    #
+    # Only authorized extension publishers may perform this mutation.
+    setReleaseYanked(
+        # The extension whose release to yank.
+        extension: ID!
+        # The version of the release to yank.
+        version: String!
+        # Whether the release is yanked.
+        yanked: Boolean = true
+    ): EmptyResponse!
+    # Export extensions (from the local or remote registry) into an extension registry archive, which contains their
+    # manifests, bundles, and source maps. The archive can be imported with importExtensions into the local
+    # extension registry of another Sourcegraph instance, such as one without internet access.
+    #
+    # Returns the archive (a gzipped tar file), base64-encoded.
+    #
+    # Only site admins may perform this mutation.
+    exportExtensions(
+        # The extension IDs of the extensions to export.
+        extensionIDs: [String!]!
+    ): String!
+    # Import the extensions in an extension registry archive (created with exportExtensions) into the local
+    # extension registry. The extensions keep the extension IDs they had in the registry they were exported from,
+    # so settings that refer to them keep working. Importing an extension again adds the releases it doesn't
+    # already have.
+    #
+    # Only site admins may perform this mutation.
+    importExtensions(
+        # The archive (a gzipped tar file), base64-encoded.
+        archive: String!
+        # The publisher of newly imported extensions. If null, the viewer is the publisher.
+        publisher: ID
+    ): [RegistryExtension!]!
 }
 
 # The result of Mutation.extensionRegistry.createExtension.
@@ -3243,7 +3486,16 @@ type RegistryExtension implements Node {
     # The name of the extension (not including the publisher's name).
     name: String!
     # The extension manifest, or null if none is set.
+    #
+    # The manifest is from the non-yanked release with the greatest version in the version range that the extension
+    # was requested with (see ExtensionRegistry.extension) or that the viewer's settings pin the extension to, or
+    # from the latest non-yanked release if there is no version range.
     manifest: ExtensionManifest
+    # The version of the release that the manifest is from, or null if the release has no version.
+    version: String
+    # The releases of the extension (including yanked releases), newest first. This is null if the extension's
+    # registry does not support listing releases.
+    releases: [RegistryExtensionRelease!]
     # The date when this extension was created on the registry.
     createdAt: String
     # The date when this extension was last updated on the registry (including updates to its metadata only, not
@@ -3266,6 +3518,17 @@ type RegistryExtension implements Node {
     viewerCanAdminister: Boolean!
 }
 
+# A release of an extension in the extension registry.
+type RegistryExtensionRelease {
+    # The semantic version of the release, or null if the release has no version.
+    version: String
+    # The date when the release was published.
+    publishedAt: String!
+    # Whether the publisher yanked the release. Yanked releases are not used as the latest release or as the
+    # release for a version range.
+    yanked: Boolean!
+}
+
 # A description of the extension, how to run or access it, and when to activate it.
 type ExtensionManifest {
     # The raw JSON contents of the manifest.
@@ -331,6 +331,22 @@ func (r *searchResolver) alertForMissingRepoRevs(missingRepoRevs []*search.Repos
 	}
 }
 
+// alertForUnsupportedBooleanQuery returns an alert explaining why a query
+// using AND, OR and NOT cannot be searched, or nil if it can be.
+func (r *searchResolver) alertForUnsupportedBooleanQuery() *searchAlert {
+	if !r.query.IsBoolean() {
+		return nil
+	}
+	_, _, err := r.query.PatternClauses()
+	if err == nil {
+		return nil
+	}
+	return &searchAlert{
+		title:       "Unsupported use of AND, OR and NOT",
+		description: err.Error() + " Quote a term (for example \"OR\") to search for it literally.",
+	}
+}
+
 func omitQueryFields(r *searchResolver, field string) string {
 	return syntax.ExprString(omitQueryExprWithField(r.query, field))
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"path"
 	"regexp"
+	"regexp/syntax"
 	"sort"
 	"strconv"
 	"strings"
@@ -630,12 +631,79 @@ func (r *searchResolver) getPatternInfo(opts *getPatternInfoOptions) (*search.Pa
 		PathPatternsAreRegExps:       true,
 		PathPatternsAreCaseSensitive: r.query.IsCaseSensitive(),
 	}
+	patternInfo.IsMultiline = regexpMatchesNewline(patternInfo.Pattern)
+	if r.query.IsStructural() && (opts == nil || !opts.forceFileSearch) {
+		// Structural templates are sent to searcher as written. Multiple
+		// terms are joined with a space, which matches any whitespace.
+		var terms []string
+		for _, v := range r.query.Values(query.FieldDefault) {
+			terms = append(terms, asString(v))
+		}
+		patternInfo.IsRegExp = false
+		patternInfo.IsStructuralPat = true
+		// Holes may match across lines.
+		patternInfo.IsMultiline = true
+		patternInfo.Pattern = strings.Join(terms, " ")
+	}
+	if r.query.IsBoolean() && (opts == nil || !opts.forceFileSearch) {
+		// A file matches if its content matches every include clause and
+		// no exclude clause. Unsupported queries are reported as an alert
+		// before we get here. Queries with only filters are not boolean, so
+		// include is never empty.
+		include, exclude, err := r.query.PatternClauses()
+		if err != nil {
+			return nil, err
+		}
+		patternInfo.Pattern = include[0]
+		if len(include) > 1 {
+			patternInfo.AndPatterns = include[1:]
+		}
+		patternInfo.NotPatterns = exclude
+		patternInfo.IsMultiline = false
+		for _, p := range append(include, exclude...) {
+			if regexpMatchesNewline(p) {
+				patternInfo.IsMultiline = true
+			}
+		}
+	}
 	if len(excludePatterns) > 0 {
 		patternInfo.ExcludePattern = unionRegExps(excludePatterns)
 	}
 	return patternInfo, nil
 }
 
+// regexpMatchesNewline reports whether pattern explicitly matches a newline,
+// either with a literal "\n" or with "." in (?s) mode. Such patterns are
+// searched across whole files instead of line by line. Character classes
+// which happen to include a newline (eg \s) are ignored so that common
+// patterns keep their line-oriented behavior.
+func regexpMatchesNewline(pattern string) bool {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	var walk func(*syntax.Regexp) bool
+	walk = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpAnyChar:
+			return true
+		case syntax.OpLiteral:
+			for _, r := range re.Rune {
+				if r == '\n' {
+					return true
+				}
+			}
+		}
+		for _, sub := range re.Sub {
+			if walk(sub) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(re)
+}
+
 var (
 	// The default timeout to use for queries.
 	defaultTimeout = 10 * time.Second
@@ -684,6 +752,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 	}
 	defer cancel()
 
+	if alert := r.alertForUnsupportedBooleanQuery(); alert != nil {
+		return &searchResultsResolver{alert: alert, start: start}, nil
+	}
+
 	repos, missingRepoRevs, _, overLimit, err := r.resolveRepositories(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -726,6 +798,23 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		resultTypes, _ = r.query.StringValues(query.FieldType)
 		if len(resultTypes) == 0 {
 			resultTypes = []string{"file", "path", "repo", "ref"}
+			if args.Pattern.IsStructuralPat || r.query.IsBoolean() {
+				resultTypes = []string{"file"}
+			}
+		}
+	}
+	if args.Pattern.IsStructuralPat {
+		for _, resultType := range resultTypes {
+			if resultType != "file" {
+				return nil, &badRequestError{fmt.Errorf("type:%s is not supported for structural search (patterntype:structural)", resultType)}
+			}
+		}
+	}
+	if r.query.IsBoolean() {
+		for _, resultType := range resultTypes {
+			if resultType != "file" {
+				return nil, &badRequestError{fmt.Errorf("type:%s is not supported for queries using AND, OR and NOT", resultType)}
+			}
 		}
 	}
 	seenResultTypes := make(map[string]struct{}, len(resultTypes))
@@ -855,6 +944,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 						// merge line match results with an existing symbol result
 						m.JLimitHit = m.JLimitHit || r.JLimitHit
 						m.JLineMatches = r.JLineMatches
+						m.JMultilineMatches = r.JMultilineMatches
 					} else {
 						fileMatches[key] = r
 						resultsMu.Lock()
@@ -220,6 +220,52 @@ func TestSearchResolver_getPatternInfo(t *testing.T) {
 			PathPatternsAreRegExps: true,
 			ExcludePattern:         `f|(\.graphql$|\.gql$)`,
 		},
+		`foo\nbar`: {
+			Pattern:                `foo\nbar`,
+			IsRegExp:               true,
+			IsMultiline:            true,
+			PathPatternsAreRegExps: true,
+		},
+		"patterntype:structural foo(:[args])": {
+			Pattern:                "foo(:[args])",
+			IsStructuralPat:        true,
+			IsMultiline:            true,
+			PathPatternsAreRegExps: true,
+		},
+		"patterntype:structural foo(:[a], \":[b])\" file:f": {
+			Pattern:                "foo(:[a], :[b])",
+			IsStructuralPat:        true,
+			IsMultiline:            true,
+			PathPatternsAreRegExps: true,
+			IncludePatterns:        []string{"f"},
+		},
+		"(p1 OR p2) AND NOT p3 file:f": {
+			Pattern:                "(p1)|(p2)",
+			NotPatterns:            []string{"p3"},
+			IsRegExp:               true,
+			PathPatternsAreRegExps: true,
+			IncludePatterns:        []string{"f"},
+		},
+		"file:f AND NOT file:g": {
+			Pattern:                ".",
+			IsRegExp:               true,
+			PathPatternsAreRegExps: true,
+			IncludePatterns:        []string{"f"},
+			ExcludePattern:         "g",
+		},
+		"not found": {
+			Pattern:                "(not).*?(found)",
+			IsRegExp:               true,
+			PathPatternsAreRegExps: true,
+		},
+		`p1 AND p2
+x`: {
+			Pattern:                "p1",
+			AndPatterns:            []string{`p2\nx`},
+			IsRegExp:               true,
+			IsMultiline:            true,
+			PathPatternsAreRegExps: true,
+		},
 	}
 	for queryStr, want := range tests {
 		t.Run(queryStr, func(t *testing.T) {
@@ -241,6 +287,25 @@ func TestSearchResolver_getPatternInfo(t *testing.T) {
 	}
 }
 
+func TestRegexpMatchesNewline(t *testing.T) {
+	tests := map[string]bool{
+		`foo`:          false,
+		`foo\sbar`:     false,
+		`foo[^x]bar`:   false,
+		`foo.bar`:      false,
+		`foo\nbar`:     true,
+		`foo[\n]bar`:   true,
+		`(?s)foo.*bar`: true,
+		`(a|b\n)+`:     true,
+		`(`:            false,
+	}
+	for pattern, want := range tests {
+		if got := regexpMatchesNewline(pattern); got != want {
+			t.Errorf("%q: got %v, want %v", pattern, got, want)
+		}
+	}
+}
+
 func TestSearchResolver_DynamicFilters(t *testing.T) {
 	repo := &types.Repo{
 		Name: "testRepo",
@@ -95,6 +95,10 @@ func (r *searchResolver) Suggestions(ctx context.Context, args *searchSuggestion
 	suggesters = append(suggesters, showFileSuggestions)
 
 	showSymbolMatches := func(ctx context.Context) (results []*searchSuggestionResolver, err error) {
+		// Symbol search does not understand AND, OR and NOT.
+		if r.query.IsBoolean() {
+			return nil, nil
+		}
 
 		repoRevs, _, _, _, err := r.resolveRepositories(ctx, nil)
 		if err != nil {
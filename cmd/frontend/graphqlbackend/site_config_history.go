@@ -0,0 +1,153 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/db/confdb"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/textdiff"
+)
+
+func (r *siteConfigurationResolver) History(ctx context.Context, args *graphqlutil.ConnectionArgs) (*siteConfigurationChangeConnectionResolver, error) {
+	// 🚨 SECURITY: The site configuration contains secret tokens and credentials,
+	// so only admins may view its history.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var opt confdb.ChangesListOptions
+	if args.First != nil {
+		opt.Limit = int(*args.First)
+	}
+	return &siteConfigurationChangeConnectionResolver{opt: opt}, nil
+}
+
+type siteConfigurationChangeConnectionResolver struct {
+	opt confdb.ChangesListOptions
+
+	// cache results because they are used by multiple fields
+	once    sync.Once
+	changes []*confdb.Change
+	err     error
+}
+
+func (r *siteConfigurationChangeConnectionResolver) compute(ctx context.Context) ([]*confdb.Change, error) {
+	r.once.Do(func() {
+		r.changes, r.err = confdb.ChangesList(ctx, r.opt)
+	})
+	return r.changes, r.err
+}
+
+func (r *siteConfigurationChangeConnectionResolver) Nodes(ctx context.Context) ([]*siteConfigurationChangeResolver, error) {
+	changes, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*siteConfigurationChangeResolver, len(changes))
+	for i, change := range changes {
+		resolvers[i] = &siteConfigurationChangeResolver{change: change}
+	}
+	return resolvers, nil
+}
+
+func (r *siteConfigurationChangeConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := confdb.ChangesCount(ctx)
+	return int32(count), err
+}
+
+func (r *siteConfigurationChangeConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	changes, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.Limit > 0 && len(changes) >= r.opt.Limit), nil
+}
+
+type siteConfigurationChangeResolver struct {
+	change *confdb.Change
+}
+
+func (r *siteConfigurationChangeResolver) ID() int32 { return r.change.ID }
+
+func (r *siteConfigurationChangeResolver) Author(ctx context.Context) (*UserResolver, error) {
+	if r.change.AuthorUserID == nil {
+		return nil, nil
+	}
+	user, err := UserByIDInt32(ctx, *r.change.AuthorUserID)
+	if errcode.IsNotFound(err) {
+		// The author's account was deleted.
+		return nil, nil
+	}
+	return user, err
+}
+
+func (r *siteConfigurationChangeResolver) CreatedAt() string {
+	return r.change.CreatedAt.Format(time.RFC3339)
+}
+
+func (r *siteConfigurationChangeResolver) ChangedFields() []string { return r.change.ChangedFields }
+
+func (r *siteConfigurationChangeResolver) PreviousContents() string { return r.change.PreviousSite }
+
+func (r *siteConfigurationChangeResolver) Contents() string { return r.change.Site }
+
+func (r *siteConfigurationChangeResolver) Diff() string {
+	return textdiff.Unified("site.json", r.change.PreviousSite, r.change.Site)
+}
+
+func (r *siteConfigurationChangeResolver) CriticalDiff() string {
+	if r.change.PreviousCritical == r.change.Critical {
+		return ""
+	}
+	return textdiff.Unified("critical.json", r.change.PreviousCritical, r.change.Critical)
+}
+
+func (r *schemaResolver) RollbackSiteConfiguration(ctx context.Context, args *struct {
+	ChangeID int32
+}) (bool, error) {
+	// 🚨 SECURITY: The site configuration contains secret tokens and credentials,
+	// so only admins may view or change it.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return false, err
+	}
+	if os.Getenv("SITE_CONFIG_FILE") != "" && !conf.IsDev(conf.DeployType()) {
+		return false, errors.New("updating site configuration not allowed when using SITE_CONFIG_FILE")
+	}
+
+	change, err := confdb.ChangesGetByID(ctx, args.ChangeID)
+	if err != nil {
+		return false, err
+	}
+
+	raw := globals.ConfigurationServerFrontendOnly.Raw()
+	if raw.Critical != change.Critical && os.Getenv("CRITICAL_CONFIG_FILE") != "" && !conf.IsDev(conf.DeployType()) {
+		return false, errors.New("restoring critical configuration not allowed when using CRITICAL_CONFIG_FILE")
+	}
+	raw.Critical = change.Critical
+	raw.Site = change.Site
+
+	// Validate the restored configuration again, because the schema and custom validation
+	// checks may have changed since it was written.
+	problems, err := conf.Validate(raw)
+	if err != nil {
+		return false, err
+	}
+	if len(problems) > 0 {
+		return false, fmt.Errorf("unable to roll back to site configuration change %d because the restored configuration is invalid:\n%s", change.ID, strings.Join(problems, "\n"))
+	}
+
+	if err := globals.ConfigurationServerFrontendOnly.Write(ctx, raw); err != nil {
+		return false, err
+	}
+	return globals.ConfigurationServerFrontendOnly.NeedServerRestart(), nil
+}
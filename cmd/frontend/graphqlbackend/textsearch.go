@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -23,6 +24,7 @@ import (
 
 	"github.com/google/zoekt"
 	zoektquery "github.com/google/zoekt/query"
+	"github.com/sourcegraph/go-langserver/pkg/lsp"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
@@ -55,13 +57,14 @@ var (
 
 // fileMatchResolver is a resolver for the GraphQL type `FileMatch`
 type fileMatchResolver struct {
-	JPath        string       `json:"Path"`
-	JLineMatches []*lineMatch `json:"LineMatches"`
-	JLimitHit    bool         `json:"LimitHit"`
-	symbols      []*searchSymbolResult
-	uri          string
-	repo         *types.Repo
-	commitID     api.CommitID
+	JPath             string            `json:"Path"`
+	JLineMatches      []*lineMatch      `json:"LineMatches"`
+	JMultilineMatches []*multilineMatch `json:"MultilineMatches"`
+	JLimitHit         bool              `json:"LimitHit"`
+	symbols           []*searchSymbolResult
+	uri               string
+	repo              *types.Repo
+	commitID          api.CommitID
 	// inputRev is the Git revspec that the user originally requested to search. It is used to
 	// preserve the original revision specifier from the user instead of navigating them to the
 	// absolute commit ID when they select a result.
@@ -107,6 +110,10 @@ func (fm *fileMatchResolver) LineMatches() []*lineMatch {
 	return fm.JLineMatches
 }
 
+func (fm *fileMatchResolver) MultilineMatches() []*multilineMatch {
+	return fm.JMultilineMatches
+}
+
 func (fm *fileMatchResolver) LimitHit() bool {
 	return fm.JLimitHit
 }
@@ -139,6 +146,30 @@ func (lm *lineMatch) LimitHit() bool {
 	return lm.JLimitHit
 }
 
+// multilineMatch is a match which may span several lines. It is kept in sync
+// with cmd/searcher/protocol.MultilineMatch.
+type multilineMatch struct {
+	JPreview string                 `json:"Preview"`
+	JStart   multilineMatchLocation `json:"Start"`
+	JEnd     multilineMatchLocation `json:"End"`
+}
+
+type multilineMatchLocation struct {
+	Line   int `json:"Line"`
+	Column int `json:"Column"`
+}
+
+func (mm *multilineMatch) Preview() string {
+	return mm.JPreview
+}
+
+func (mm *multilineMatch) Range() *rangeResolver {
+	return &rangeResolver{lsp.Range{
+		Start: lsp.Position{Line: mm.JStart.Line, Character: mm.JStart.Column},
+		End:   lsp.Position{Line: mm.JEnd.Line, Character: mm.JEnd.Column},
+	}}
+}
+
 // textSearch searches repo@commit with p.
 // Note: the returned matches do not set fileMatch.uri
 func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
@@ -181,9 +212,21 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 	if p.IsRegExp {
 		q.Set("IsRegExp", "true")
 	}
+	if p.IsStructuralPat {
+		q.Set("IsStructuralPat", "true")
+	}
 	if p.IsWordMatch {
 		q.Set("IsWordMatch", "true")
 	}
+	if p.IsMultiline {
+		q.Set("IsMultiline", "true")
+	}
+	for _, pattern := range p.AndPatterns {
+		q.Add("AndPatterns", pattern)
+	}
+	for _, pattern := range p.NotPatterns {
+		q.Add("NotPatterns", pattern)
+	}
 	if p.IsCaseSensitive {
 		q.Set("IsCaseSensitive", "true")
 	}
@@ -197,6 +240,10 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 	// these fields from old frontends that do not (and provide a default in the latter case).
 	q.Set("PatternMatchesContent", strconv.FormatBool(p.PatternMatchesContent))
 	q.Set("PatternMatchesPath", strconv.FormatBool(p.PatternMatchesPath))
+	// Ask searcher to stream results so that we keep the matches found so
+	// far if our deadline is hit. Old searchers ignore this and respond with
+	// a single JSON object, which textSearchURL also handles.
+	q.Set("Stream", "true")
 	rawQuery := q.Encode()
 
 	// Searcher caches the file contents for repo@commit since it is
@@ -287,6 +334,10 @@ func textSearchURL(ctx context.Context, url string) ([]*fileMatchResolver, bool,
 		return nil, false, errors.WithStack(&searcherError{StatusCode: resp.StatusCode, Message: string(body)})
 	}
 
+	if resp.Header.Get("Content-Type") == searcherStreamContentType {
+		return decodeSearcherStream(ctx, resp.Body)
+	}
+
 	r := struct {
 		Matches     []*fileMatchResolver
 		LimitHit    bool
@@ -302,6 +353,50 @@ func textSearchURL(ctx context.Context, url string) ([]*fileMatchResolver, bool,
 	return r.Matches, r.LimitHit, err
 }
 
+// searcherStreamContentType is the Content-Type searcher uses for streaming
+// responses. It is kept in sync with cmd/searcher/protocol.StreamContentType.
+const searcherStreamContentType = "application/x-ndjson"
+
+// decodeSearcherStream reads a streaming searcher response from r. Matches
+// are decoded as they arrive, so if ctx is done before the stream is
+// complete the matches received so far are returned along with ctx.Err().
+func decodeSearcherStream(ctx context.Context, r io.Reader) (matches []*fileMatchResolver, limitHit bool, err error) {
+	dec := json.NewDecoder(r)
+	for {
+		// Kept in sync with cmd/searcher/protocol.StreamMessage.
+		var msg struct {
+			FileMatch *fileMatchResolver
+			Done      *struct {
+				LimitHit    bool
+				DeadlineHit bool
+				Error       string
+			}
+		}
+		if err := dec.Decode(&msg); err != nil {
+			if ctx.Err() != nil {
+				return matches, false, ctx.Err()
+			}
+			return matches, false, errors.Wrap(err, "searcher stream invalid")
+		}
+		if msg.FileMatch != nil {
+			matches = append(matches, msg.FileMatch)
+			continue
+		}
+		if msg.Done == nil {
+			return matches, false, errors.New("searcher stream invalid: empty message")
+		}
+		// Check DeadlineHit first so that the partial matches are kept when
+		// the search timed out.
+		if msg.Done.DeadlineHit {
+			return matches, msg.Done.LimitHit, context.DeadlineExceeded
+		}
+		if msg.Done.Error != "" {
+			return matches, msg.Done.LimitHit, errors.WithStack(&searcherError{StatusCode: http.StatusInternalServerError, Message: msg.Done.Error})
+		}
+		return matches, msg.Done.LimitHit, nil
+	}
+}
+
 type searcherError struct {
 	StatusCode int
 	Message    string
@@ -599,6 +694,21 @@ func queryToZoektQuery(query *search.PatternInfo) (zoektquery.Q, error) {
 	fileRe := func(pattern string) (zoektquery.Q, error) {
 		return parseRe(pattern, true)
 	}
+	// contentRe is used for the clauses of boolean queries, which searcher
+	// only matches against file content.
+	contentRe := func(pattern string) (zoektquery.Q, error) {
+		q, err := parseRe(pattern, false)
+		if err != nil {
+			return nil, err
+		}
+		switch q := q.(type) {
+		case *zoektquery.Substring:
+			q.Content = true
+		case *zoektquery.Regexp:
+			q.Content = true
+		}
+		return q, nil
+	}
 
 	if query.IsRegExp {
 		q, err := parseRe(query.Pattern, false)
@@ -615,6 +725,20 @@ func queryToZoektQuery(query *search.PatternInfo) (zoektquery.Q, error) {
 			Content:  true,
 		})
 	}
+	for _, p := range query.AndPatterns {
+		q, err := contentRe(p)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, q)
+	}
+	for _, p := range query.NotPatterns {
+		q, err := contentRe(p)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, &zoektquery.Not{Child: q})
+	}
 
 	// zoekt also uses regular expressions for file paths
 	// TODO PathPatternsAreCaseSensitive
@@ -770,6 +894,17 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		}
 	}
 
+	if args.Pattern.IsStructuralPat || args.Pattern.IsMultiline {
+		// Zoekt does not support structural patterns or reporting matches
+		// which span lines, so search every repo with searcher.
+		if len(index) > 0 && parseYesNoOnly(index[len(index)-1]) == Only {
+			return nil, common, errors.New("index:only is not supported for structural search (patterntype:structural) or patterns which match newlines")
+		}
+		tr.LazyPrintf("structural or multiline search, bypassing zoekt (using searcher) for %d indexed repos", len(zoektRepos))
+		searcherRepos = append(searcherRepos, zoektRepos...)
+		zoektRepos = nil
+	}
+
 	var (
 		// TODO: convert wg to an errgroup
 		wg                sync.WaitGroup
@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -360,6 +361,68 @@ func Test_zoektSearchHEAD(t *testing.T) {
 	}
 }
 
+func TestDecodeSearcherStream(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantPaths    []string
+		wantLimitHit bool
+		wantErr      error
+	}{
+		{
+			name:      "empty",
+			body:      `{"Done":{}}`,
+			wantPaths: nil,
+		},
+		{
+			name:         "matches",
+			body:         `{"FileMatch":{"Path":"a.go"}}` + "\n" + `{"FileMatch":{"Path":"b.go"}}` + "\n" + `{"Done":{"LimitHit":true}}`,
+			wantPaths:    []string{"a.go", "b.go"},
+			wantLimitHit: true,
+		},
+		{
+			name:      "deadline",
+			body:      `{"FileMatch":{"Path":"a.go"}}` + "\n" + `{"Done":{"DeadlineHit":true}}`,
+			wantPaths: []string{"a.go"},
+			wantErr:   context.DeadlineExceeded,
+		},
+		{
+			name:      "deadline with error",
+			body:      `{"FileMatch":{"Path":"a.go"}}` + "\n" + `{"Done":{"DeadlineHit":true,"Error":"context deadline exceeded"}}`,
+			wantPaths: []string{"a.go"},
+			wantErr:   context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, limitHit, err := decodeSearcherStream(context.Background(), strings.NewReader(tt.body))
+			if err != tt.wantErr {
+				t.Fatalf("got err %v, want %v", err, tt.wantErr)
+			}
+			var paths []string
+			for _, fm := range matches {
+				paths = append(paths, fm.JPath)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("got paths %v, want %v", paths, tt.wantPaths)
+			}
+			if limitHit != tt.wantLimitHit {
+				t.Errorf("got limitHit %v, want %v", limitHit, tt.wantLimitHit)
+			}
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		matches, _, err := decodeSearcherStream(context.Background(), strings.NewReader(`{"FileMatch":{"Path":"a.go"}}`+"\n"))
+		if err == nil {
+			t.Fatal("expected error for stream without Done message")
+		}
+		if len(matches) != 1 {
+			t.Errorf("expected partial matches to be returned, got %d", len(matches))
+		}
+	})
+}
+
 func init() {
 	// Set both URLs to something that will fail in tests. We shouldn't be
 	// contacting them in tests.
@@ -257,6 +257,30 @@ func (r *UserResolver) SurveyResponses(ctx context.Context) ([]*surveyResponseRe
 	return surveyResponseResolvers, nil
 }
 
+func (r *UserResolver) PermissionsSyncedAt(ctx context.Context) (*string, error) {
+	// 🚨 SECURITY: Only the user and admins are allowed to see when the user's permissions were
+	// synced.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, r.user.ID); err != nil {
+		return nil, err
+	}
+
+	perms, err := db.UserRepoPermissions.ListByUser(ctx, r.user.ID)
+	if err != nil {
+		return nil, err
+	}
+	var syncedAt time.Time
+	for _, p := range perms {
+		if p.UpdatedAt.After(syncedAt) {
+			syncedAt = p.UpdatedAt
+		}
+	}
+	if syncedAt.IsZero() {
+		return nil, nil
+	}
+	s := syncedAt.Format(time.RFC3339)
+	return &s, nil
+}
+
 func (r *UserResolver) ViewerCanAdminister(ctx context.Context) (bool, error) {
 	if err := backend.CheckSiteAdminOrSameUser(ctx, r.user.ID); err == backend.ErrNotAuthenticated || err == backend.ErrMustBeSiteAdmin {
 		return false, nil
@@ -10,3 +10,7 @@ var PreAuthMiddleware func(http.Handler) http.Handler
 // AfterDBInit is called after the database is initialized, and can be used to
 // e.g. launch background services that depend on the database.
 var AfterDBInit func()
+
+// Commands are additional subcommands of the frontend program, keyed by name. A command is run
+// (instead of the server) with `frontend <name> [args...]`, after the database is initialized.
+var Commands = map[string]func(args []string) error{}
@@ -14,6 +14,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/conf/conftypes"
 	"github.com/sourcegraph/sourcegraph/pkg/db/confdb"
@@ -132,6 +133,8 @@ func handleConfigOverrides() error {
 
 type configurationSource struct{}
 
+var _ conf.HistorySource = configurationSource{}
+
 func (c configurationSource) Read(ctx context.Context) (conftypes.RawUnified, error) {
 	critical, err := confdb.CriticalGetLatest(ctx)
 	if err != nil {
@@ -174,6 +177,23 @@ func (c configurationSource) Write(ctx context.Context, input conftypes.RawUnifi
 	return nil
 }
 
+// WriteAndRecord implements conf.HistorySource. The change is attributed to the actor
+// in ctx.
+func (c configurationSource) WriteAndRecord(ctx context.Context, input conftypes.RawUnified, changedFields []string) error {
+	change := &confdb.Change{
+		Critical:      input.Critical,
+		Site:          input.Site,
+		ChangedFields: changedFields,
+	}
+	if a := actor.FromContext(ctx); a.IsAuthenticated() {
+		change.AuthorUserID = &a.UID
+	}
+	if _, err := confdb.WriteAndRecordChange(ctx, change); err != nil {
+		return errors.Wrap(err, "confdb.WriteAndRecordChange")
+	}
+	return nil
+}
+
 func postgresDSN() string {
 	username := ""
 	if user, err := user.Current(); err == nil {
@@ -21,6 +21,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/bg"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/cli/loghandlers"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions/codehostsync"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions/mailreply"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/siteid"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
@@ -140,6 +141,11 @@ func Main() error {
 			}
 
 			return nil
+
+		default:
+			if command, ok := hooks.Commands[os.Args[1]]; ok {
+				return command(os.Args[2:])
+			}
 		}
 	}
 
@@ -173,6 +179,7 @@ func Main() error {
 	goroutine.Go(func() { bg.MigrateAllSettingsMOTDToNotices(context.Background()) })
 	goroutine.Go(func() { bg.MigrateSavedQueriesAndSlackWebhookURLsFromSettingsToDatabase(context.Background()) })
 	goroutine.Go(mailreply.StartWorker)
+	goroutine.Go(codehostsync.StartWorker)
 	go updatecheck.Start()
 	if hooks.AfterDBInit != nil {
 		hooks.AfterDBInit()
@@ -7,6 +7,7 @@ import (
 	"github.com/graph-gophers/graphql-go/relay"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 )
 
 var relayHandler = &relay.Handler{Schema: graphqlbackend.GraphQLSchema}
@@ -18,6 +19,11 @@ func serveGraphQL(w http.ResponseWriter, r *http.Request) (err error) {
 		return errors.New("method must be POST")
 	}
 
+	// The resolvers of a query, such as the tree page's, run many small git
+	// commands concurrently. Send those which run at the same time against
+	// the same repository to gitserver in one batch request.
+	r = r.WithContext(gitserver.DefaultClient.WithBatchLoader(r.Context()))
+
 	relayHandler.ServeHTTP(w, r)
 	return nil
 }
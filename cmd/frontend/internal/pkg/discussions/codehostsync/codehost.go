@@ -0,0 +1,366 @@
+package codehostsync
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/gitlab"
+)
+
+// codeHost posts and reads the comments of pull requests (GitHub) or merge
+// requests (GitLab) in a single repository on a code host.
+type codeHost interface {
+	// ServiceType and ServiceID identify the code host, as in
+	// api.ExternalRepoSpec.
+	ServiceType() string
+	ServiceID() string
+
+	// FindPullRequest returns the open pull request whose head is the
+	// branch, or nil if there is none.
+	FindPullRequest(ctx context.Context, branch string) (*pullRequest, error)
+
+	// CreateThread creates a new review thread on the line (one-based) of the
+	// file at path in the pull request. It returns the external ID of the
+	// thread and its first comment.
+	CreateThread(ctx context.Context, pr *pullRequest, path string, line int, body string) (threadID string, comment *externalComment, err error)
+
+	// ListComments lists the comments in the review thread, oldest first.
+	ListComments(ctx context.Context, t *types.DiscussionThreadExternal) ([]*externalComment, error)
+
+	// CreateComment adds a comment to the review thread.
+	CreateComment(ctx context.Context, t *types.DiscussionThreadExternal, body string) (*externalComment, error)
+
+	// UpdateComment updates the body of the comment in the review thread.
+	UpdateComment(ctx context.Context, t *types.DiscussionThreadExternal, commentID, body string) (*externalComment, error)
+
+	// DeleteComment deletes the comment in the review thread. It succeeds if
+	// the comment does not exist.
+	DeleteComment(ctx context.Context, t *types.DiscussionThreadExternal, commentID string) error
+}
+
+// pullRequest is an open pull request on a code host.
+type pullRequest struct {
+	ID      string // the number (GitHub) or IID (GitLab) of the pull request
+	HeadSHA string // the commit ID of the head of the pull request, if known
+}
+
+// externalComment is a pull request comment on a code host.
+type externalComment struct {
+	ID string
+
+	// AuthorID is the user ID of the author on the code host (the AccountID of
+	// their external account, if they have one).
+	AuthorID string
+	Author   string // the username of the author on the code host
+
+	Body      string
+	UpdatedAt time.Time
+}
+
+// mockCodeHostForRepo, if non-nil, is called instead of codeHostForRepo.
+var mockCodeHostForRepo func(ctx context.Context, repo *types.Repo) (codeHost, error)
+
+// codeHostForRepo returns the code host of the repository, using the token of
+// the external service that the repository is from. It returns nil if the
+// code host is not supported or no token is configured for it.
+func codeHostForRepo(ctx context.Context, repo *types.Repo) (codeHost, error) {
+	if mockCodeHostForRepo != nil {
+		return mockCodeHostForRepo(ctx, repo)
+	}
+	if repo.ExternalRepo == nil {
+		return nil, nil
+	}
+
+	switch repo.ExternalRepo.ServiceType {
+	case github.ServiceType:
+		conns, err := db.ExternalServices.ListGitHubConnections(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range conns {
+			baseURL, err := url.Parse(c.Url)
+			if err != nil || c.Token == "" {
+				continue
+			}
+			baseURL = extsvc.NormalizeBaseURL(baseURL)
+			if baseURL.String() != repo.ExternalRepo.ServiceID {
+				continue
+			}
+			apiURL, _ := github.APIRoot(baseURL)
+			client := github.NewClient(apiURL, c.Token, nil)
+			ghRepo, err := client.GetRepositoryByNodeID(ctx, "", repo.ExternalRepo.ID)
+			if err != nil {
+				return nil, errors.Wrap(err, "GetRepositoryByNodeID")
+			}
+			owner, name, err := github.SplitRepositoryNameWithOwner(ghRepo.NameWithOwner)
+			if err != nil {
+				return nil, err
+			}
+			return &githubCodeHost{serviceID: baseURL.String(), client: client, owner: owner, name: name}, nil
+		}
+
+	case gitlab.ServiceType:
+		conns, err := db.ExternalServices.ListGitLabConnections(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range conns {
+			baseURL, err := url.Parse(c.Url)
+			if err != nil || c.Token == "" {
+				continue
+			}
+			baseURL = extsvc.NormalizeBaseURL(baseURL)
+			if baseURL.String() != repo.ExternalRepo.ServiceID {
+				continue
+			}
+			projectID, err := strconv.Atoi(repo.ExternalRepo.ID)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid GitLab project ID")
+			}
+			client := gitlab.NewClientProvider(baseURL, nil).GetPATClient(c.Token, "")
+			return &gitlabCodeHost{serviceID: baseURL.String(), client: client, projectID: projectID}, nil
+		}
+	}
+	return nil, nil
+}
+
+// githubCodeHost mirrors threads to pull request review comments on GitHub.
+type githubCodeHost struct {
+	serviceID   string
+	client      *github.Client
+	owner, name string
+}
+
+func (h *githubCodeHost) ServiceType() string { return github.ServiceType }
+func (h *githubCodeHost) ServiceID() string   { return h.serviceID }
+
+func (h *githubCodeHost) FindPullRequest(ctx context.Context, branch string) (*pullRequest, error) {
+	prs, err := h.client.ListOpenPullRequestsForBranch(ctx, h.owner, h.name, branch)
+	if err != nil || len(prs) == 0 {
+		return nil, err
+	}
+	return &pullRequest{ID: strconv.Itoa(prs[0].Number), HeadSHA: prs[0].Head.SHA}, nil
+}
+
+func (h *githubCodeHost) CreateThread(ctx context.Context, pr *pullRequest, path string, line int, body string) (string, *externalComment, error) {
+	number, err := strconv.Atoi(pr.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	c, err := h.client.CreatePullRequestReviewComment(ctx, h.owner, h.name, number, &github.NewPullRequestReviewComment{
+		Body:     body,
+		CommitID: pr.HeadSHA,
+		Path:     path,
+		Line:     line,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	comment := githubComment(c)
+	return comment.ID, comment, nil
+}
+
+func (h *githubCodeHost) ListComments(ctx context.Context, t *types.DiscussionThreadExternal) ([]*externalComment, error) {
+	number, rootID, err := githubThreadIDs(t)
+	if err != nil {
+		return nil, err
+	}
+	all, err := h.client.ListPullRequestReviewComments(ctx, h.owner, h.name, number)
+	if err != nil {
+		return nil, err
+	}
+	var comments []*externalComment
+	for _, c := range all {
+		if c.ID == rootID || c.InReplyToID == rootID {
+			comments = append(comments, githubComment(c))
+		}
+	}
+	return comments, nil
+}
+
+func (h *githubCodeHost) CreateComment(ctx context.Context, t *types.DiscussionThreadExternal, body string) (*externalComment, error) {
+	number, rootID, err := githubThreadIDs(t)
+	if err != nil {
+		return nil, err
+	}
+	c, err := h.client.ReplyToPullRequestReviewComment(ctx, h.owner, h.name, number, rootID, body)
+	if err != nil {
+		return nil, err
+	}
+	return githubComment(c), nil
+}
+
+func (h *githubCodeHost) UpdateComment(ctx context.Context, t *types.DiscussionThreadExternal, commentID, body string) (*externalComment, error) {
+	id, err := strconv.ParseInt(commentID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	c, err := h.client.UpdatePullRequestReviewComment(ctx, h.owner, h.name, id, body)
+	if err != nil {
+		return nil, err
+	}
+	return githubComment(c), nil
+}
+
+func (h *githubCodeHost) DeleteComment(ctx context.Context, t *types.DiscussionThreadExternal, commentID string) error {
+	id, err := strconv.ParseInt(commentID, 10, 64)
+	if err != nil {
+		return err
+	}
+	if err := h.client.DeletePullRequestReviewComment(ctx, h.owner, h.name, id); err != nil && !github.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// githubThreadIDs returns the pull request number and the ID of the first
+// review comment of the mirrored thread.
+func githubThreadIDs(t *types.DiscussionThreadExternal) (number int, rootID int64, err error) {
+	number, err = strconv.Atoi(t.PullRequestID)
+	if err != nil {
+		return 0, 0, err
+	}
+	rootID, err = strconv.ParseInt(t.ExternalID, 10, 64)
+	return number, rootID, err
+}
+
+func githubComment(c *github.PullRequestReviewComment) *externalComment {
+	return &externalComment{
+		ID:        strconv.FormatInt(c.ID, 10),
+		AuthorID:  strconv.FormatInt(c.User.ID, 10),
+		Author:    c.User.Login,
+		Body:      c.Body,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+// gitlabCodeHost mirrors threads to merge request discussions on GitLab.
+type gitlabCodeHost struct {
+	serviceID string
+	client    *gitlab.Client
+	projectID int
+}
+
+func (h *gitlabCodeHost) ServiceType() string { return gitlab.ServiceType }
+func (h *gitlabCodeHost) ServiceID() string   { return h.serviceID }
+
+func (h *gitlabCodeHost) FindPullRequest(ctx context.Context, branch string) (*pullRequest, error) {
+	mrs, err := h.client.ListOpenMergeRequestsForBranch(ctx, h.projectID, branch)
+	if err != nil || len(mrs) == 0 {
+		return nil, err
+	}
+	return &pullRequest{ID: strconv.Itoa(mrs[0].IID)}, nil
+}
+
+func (h *gitlabCodeHost) CreateThread(ctx context.Context, pr *pullRequest, path string, line int, body string) (string, *externalComment, error) {
+	iid, err := strconv.Atoi(pr.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	// The diff refs are needed to position the discussion on the diff, and
+	// are only returned when getting a single merge request.
+	mr, err := h.client.GetMergeRequest(ctx, h.projectID, iid)
+	if err != nil {
+		return "", nil, err
+	}
+	if mr.DiffRefs == nil {
+		return "", nil, errors.Errorf("GitLab merge request %d has no diff refs", iid)
+	}
+	d, err := h.client.CreateMergeRequestDiscussion(ctx, h.projectID, iid, body, &gitlab.DiffPosition{
+		DiffRefs:     *mr.DiffRefs,
+		PositionType: "text",
+		NewPath:      path,
+		OldPath:      path,
+		NewLine:      line,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(d.Notes) == 0 {
+		return "", nil, errors.Errorf("GitLab discussion %s has no notes", d.ID)
+	}
+	return d.ID, gitlabComment(d.Notes[0]), nil
+}
+
+func (h *gitlabCodeHost) ListComments(ctx context.Context, t *types.DiscussionThreadExternal) ([]*externalComment, error) {
+	iid, err := strconv.Atoi(t.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	d, err := h.client.GetMergeRequestDiscussion(ctx, h.projectID, iid, t.ExternalID)
+	if err != nil {
+		if gitlab.HTTPErrorCode(err) == http.StatusNotFound {
+			return nil, nil // the discussion (and all its notes) was deleted
+		}
+		return nil, err
+	}
+	var comments []*externalComment
+	for _, n := range d.Notes {
+		if n.System {
+			continue
+		}
+		comments = append(comments, gitlabComment(n))
+	}
+	return comments, nil
+}
+
+func (h *gitlabCodeHost) CreateComment(ctx context.Context, t *types.DiscussionThreadExternal, body string) (*externalComment, error) {
+	iid, err := strconv.Atoi(t.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := h.client.CreateMergeRequestDiscussionNote(ctx, h.projectID, iid, t.ExternalID, body)
+	if err != nil {
+		return nil, err
+	}
+	return gitlabComment(n), nil
+}
+
+func (h *gitlabCodeHost) UpdateComment(ctx context.Context, t *types.DiscussionThreadExternal, commentID, body string) (*externalComment, error) {
+	iid, err := strconv.Atoi(t.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	noteID, err := strconv.Atoi(commentID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := h.client.UpdateMergeRequestDiscussionNote(ctx, h.projectID, iid, t.ExternalID, noteID, body)
+	if err != nil {
+		return nil, err
+	}
+	return gitlabComment(n), nil
+}
+
+func (h *gitlabCodeHost) DeleteComment(ctx context.Context, t *types.DiscussionThreadExternal, commentID string) error {
+	iid, err := strconv.Atoi(t.PullRequestID)
+	if err != nil {
+		return err
+	}
+	noteID, err := strconv.Atoi(commentID)
+	if err != nil {
+		return err
+	}
+	if err := h.client.DeleteMergeRequestDiscussionNote(ctx, h.projectID, iid, t.ExternalID, noteID); err != nil && gitlab.HTTPErrorCode(err) != http.StatusNotFound {
+		return err
+	}
+	return nil
+}
+
+func gitlabComment(n *gitlab.Note) *externalComment {
+	return &externalComment{
+		ID:        strconv.Itoa(n.ID),
+		AuthorID:  strconv.Itoa(n.Author.ID),
+		Author:    n.Author.Username,
+		Body:      n.Body,
+		UpdatedAt: n.UpdatedAt,
+	}
+}
@@ -0,0 +1,362 @@
+package codehostsync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// Overridden in tests.
+var (
+	timeNow          = time.Now
+	notifyNewComment = discussions.NotifyNewComment
+)
+
+// syncAll syncs the comments of all mirrored threads, and mirrors the threads
+// that are on a branch with an open pull request and are not yet mirrored.
+//
+// Errors syncing a single thread are logged and do not stop the other threads
+// from being synced.
+func syncAll(ctx context.Context) error {
+	s := &syncer{codeHosts: map[api.RepoID]codeHost{}}
+
+	mirrored, err := db.DiscussionExternal.ListThreads(ctx)
+	if err != nil {
+		return errors.Wrap(err, "DiscussionExternal.ListThreads")
+	}
+	isMirrored := make(map[int64]bool, len(mirrored))
+	for _, ext := range mirrored {
+		isMirrored[ext.ThreadID] = true
+		if err := s.syncThread(ctx, ext); err != nil {
+			log15.Warn("discussions: failed to sync thread with code host", "thread", ext.ThreadID, "error", err)
+		}
+	}
+
+	threads, err := db.DiscussionThreads.List(ctx, &db.DiscussionThreadsListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "DiscussionThreads.List")
+	}
+	pullRequests := map[string]*pullRequest{} // keyed by repo ID and branch
+	for _, t := range threads {
+		if isMirrored[t.ID] || !canMirror(t) {
+			continue
+		}
+		host, err := s.codeHost(ctx, t.TargetRepo.RepoID)
+		if err != nil {
+			log15.Warn("discussions: failed to get code host of repository", "repo", t.TargetRepo.RepoID, "error", err)
+			continue
+		}
+		if host == nil {
+			continue
+		}
+		key := fmt.Sprintf("%d:%s", t.TargetRepo.RepoID, *t.TargetRepo.Branch)
+		pr, ok := pullRequests[key]
+		if !ok {
+			pr, err = host.FindPullRequest(ctx, *t.TargetRepo.Branch)
+			if err != nil {
+				log15.Warn("discussions: failed to find pull request for branch", "repo", t.TargetRepo.RepoID, "branch", *t.TargetRepo.Branch, "error", err)
+				continue
+			}
+			pullRequests[key] = pr
+		}
+		if pr == nil {
+			continue
+		}
+		if err := s.mirrorThread(ctx, host, t, pr); err != nil {
+			log15.Warn("discussions: failed to mirror thread to code host", "thread", t.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// canMirror reports whether the thread can be mirrored to a pull request: it
+// must be on a line of a file on a branch.
+func canMirror(t *types.DiscussionThread) bool {
+	return t.ArchivedAt == nil && t.TargetRepo != nil && t.TargetRepo.Branch != nil && t.TargetRepo.Path != nil && t.TargetRepo.StartLine != nil
+}
+
+type syncer struct {
+	codeHosts map[api.RepoID]codeHost // cache of codeHostForRepo results (nil if unsupported)
+}
+
+func (s *syncer) codeHost(ctx context.Context, repoID api.RepoID) (codeHost, error) {
+	if host, ok := s.codeHosts[repoID]; ok {
+		return host, nil
+	}
+	repo, err := db.Repos.Get(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	host, err := codeHostForRepo(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	s.codeHosts[repoID] = host
+	return host, nil
+}
+
+// mirrorThread creates a review thread for the thread on the pull request, and
+// then syncs its other comments.
+func (s *syncer) mirrorThread(ctx context.Context, host codeHost, t *types.DiscussionThread, pr *pullRequest) error {
+	comments, err := db.DiscussionComments.List(ctx, &db.DiscussionCommentsListOptions{ThreadID: &t.ID})
+	if err != nil {
+		return errors.Wrap(err, "DiscussionComments.List")
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+	first := comments[0]
+	body, err := formatComment(ctx, t, first)
+	if err != nil {
+		return err
+	}
+	line := int(*t.TargetRepo.StartLine) + 1 // StartLine is zero-based
+	threadID, extComment, err := host.CreateThread(ctx, pr, *t.TargetRepo.Path, line, body)
+	if err != nil {
+		return errors.Wrap(err, "CreateThread")
+	}
+
+	ext := &types.DiscussionThreadExternal{
+		ThreadID:      t.ID,
+		RepoID:        t.TargetRepo.RepoID,
+		ServiceType:   host.ServiceType(),
+		ServiceID:     host.ServiceID(),
+		PullRequestID: pr.ID,
+		ExternalID:    threadID,
+	}
+	if err := db.DiscussionExternal.CreateThread(ctx, ext); err != nil {
+		return errors.Wrap(err, "DiscussionExternal.CreateThread")
+	}
+	if err := db.DiscussionExternal.UpsertComment(ctx, &types.DiscussionCommentExternal{
+		CommentID:         first.ID,
+		ThreadID:          t.ID,
+		ExternalID:        extComment.ID,
+		SyncedAt:          timeNow(),
+		ExternalUpdatedAt: extComment.UpdatedAt,
+	}); err != nil {
+		return errors.Wrap(err, "DiscussionExternal.UpsertComment")
+	}
+	return s.syncThread(ctx, ext)
+}
+
+// syncThread syncs the comments of the mirrored thread with the comments of
+// its review thread on the code host:
+//
+// - Comments created on either side are created on the other.
+// - Edits are propagated from the side that the comment was created on.
+// - Comments deleted on either side are deleted on the other.
+func (s *syncer) syncThread(ctx context.Context, ext *types.DiscussionThreadExternal) error {
+	host, err := s.codeHost(ctx, ext.RepoID)
+	if err != nil {
+		return err
+	}
+	if host == nil || host.ServiceType() != ext.ServiceType || host.ServiceID() != ext.ServiceID {
+		return nil // the code host is no longer configured
+	}
+
+	links, err := db.DiscussionExternal.ListComments(ctx, ext.ThreadID)
+	if err != nil {
+		return errors.Wrap(err, "DiscussionExternal.ListComments")
+	}
+
+	t, err := db.DiscussionThreads.Get(ctx, ext.ThreadID)
+	if _, ok := err.(*db.ErrThreadNotFound); ok {
+		// The thread was deleted, so delete the comments we posted.
+		for _, link := range links {
+			if link.Imported {
+				continue
+			}
+			if err := host.DeleteComment(ctx, ext, link.ExternalID); err != nil {
+				return errors.Wrap(err, "DeleteComment")
+			}
+		}
+		return db.DiscussionExternal.DeleteThread(ctx, ext.ThreadID)
+	} else if err != nil {
+		return errors.Wrap(err, "DiscussionThreads.Get")
+	}
+
+	comments, err := db.DiscussionComments.List(ctx, &db.DiscussionCommentsListOptions{ThreadID: &t.ID})
+	if err != nil {
+		return errors.Wrap(err, "DiscussionComments.List")
+	}
+	extComments, err := host.ListComments(ctx, ext)
+	if err != nil {
+		return errors.Wrap(err, "ListComments")
+	}
+
+	commentsByID := make(map[int64]*types.DiscussionComment, len(comments))
+	for _, c := range comments {
+		commentsByID[c.ID] = c
+	}
+	extCommentsByID := make(map[string]*externalComment, len(extComments))
+	for _, c := range extComments {
+		extCommentsByID[c.ID] = c
+	}
+	linkedComments := make(map[int64]bool, len(links))
+	linkedExtComments := make(map[string]bool, len(links))
+
+	// Propagate edits and deletions of comments that are already mirrored.
+	for _, link := range links {
+		linkedComments[link.CommentID] = true
+		linkedExtComments[link.ExternalID] = true
+		comment, extComment := commentsByID[link.CommentID], extCommentsByID[link.ExternalID]
+		switch {
+		case comment == nil:
+			// Deleted on Sourcegraph. Comments imported from the code host are
+			// not ours to delete there, so keep their mapping until they are
+			// deleted there (so that they are not imported again).
+			if link.Imported && extComment != nil {
+				continue
+			}
+			if extComment != nil {
+				if err := host.DeleteComment(ctx, ext, link.ExternalID); err != nil {
+					return errors.Wrap(err, "DeleteComment")
+				}
+			}
+			if err := db.DiscussionExternal.DeleteComment(ctx, link.CommentID); err != nil {
+				return errors.Wrap(err, "DiscussionExternal.DeleteComment")
+			}
+
+		case extComment == nil:
+			// Deleted on the code host. Deleting the first comment deletes the
+			// thread, which is cleaned up the next time the thread is synced.
+			if _, err := db.DiscussionComments.Update(ctx, comment.ID, &db.DiscussionCommentsUpdateOptions{Delete: true}); err != nil {
+				return errors.Wrap(err, "DiscussionComments.Update")
+			}
+			if err := db.DiscussionExternal.DeleteComment(ctx, link.CommentID); err != nil {
+				return errors.Wrap(err, "DiscussionExternal.DeleteComment")
+			}
+			if comment.ID == comments[0].ID {
+				return nil
+			}
+
+		case !link.Imported && comment.UpdatedAt.After(link.SyncedAt):
+			// Edited on Sourcegraph.
+			body, err := formatComment(ctx, t, comment)
+			if err != nil {
+				return err
+			}
+			updated, err := host.UpdateComment(ctx, ext, link.ExternalID, body)
+			if err != nil {
+				return errors.Wrap(err, "UpdateComment")
+			}
+			link.SyncedAt, link.ExternalUpdatedAt = timeNow(), updated.UpdatedAt
+			if err := db.DiscussionExternal.UpsertComment(ctx, link); err != nil {
+				return errors.Wrap(err, "DiscussionExternal.UpsertComment")
+			}
+
+		case link.Imported && extComment.UpdatedAt.After(link.ExternalUpdatedAt):
+			// Edited on the code host.
+			if _, err := db.DiscussionComments.Update(ctx, comment.ID, &db.DiscussionCommentsUpdateOptions{Contents: &extComment.Body}); err != nil {
+				return errors.Wrap(err, "DiscussionComments.Update")
+			}
+			link.SyncedAt, link.ExternalUpdatedAt = timeNow(), extComment.UpdatedAt
+			if err := db.DiscussionExternal.UpsertComment(ctx, link); err != nil {
+				return errors.Wrap(err, "DiscussionExternal.UpsertComment")
+			}
+		}
+	}
+
+	// Post new comments from Sourcegraph to the code host.
+	for _, comment := range comments {
+		if linkedComments[comment.ID] || comment.AuthorUserID == nil {
+			continue // already synced, or imported from a code host user without an account
+		}
+		body, err := formatComment(ctx, t, comment)
+		if err != nil {
+			return err
+		}
+		extComment, err := host.CreateComment(ctx, ext, body)
+		if err != nil {
+			return errors.Wrap(err, "CreateComment")
+		}
+		if err := db.DiscussionExternal.UpsertComment(ctx, &types.DiscussionCommentExternal{
+			CommentID:         comment.ID,
+			ThreadID:          t.ID,
+			ExternalID:        extComment.ID,
+			SyncedAt:          timeNow(),
+			ExternalUpdatedAt: extComment.UpdatedAt,
+		}); err != nil {
+			return errors.Wrap(err, "DiscussionExternal.UpsertComment")
+		}
+	}
+
+	// Import new comments from the code host.
+	for _, extComment := range extComments {
+		if linkedExtComments[extComment.ID] {
+			continue
+		}
+		authorUserID, externalAuthor, err := importCommentAuthor(ctx, host, extComment)
+		if err != nil {
+			return err
+		}
+		comment, err := db.DiscussionComments.Create(ctx, &types.DiscussionComment{
+			ThreadID:       t.ID,
+			AuthorUserID:   authorUserID,
+			ExternalAuthor: externalAuthor,
+			Contents:       extComment.Body,
+		})
+		if err != nil {
+			return errors.Wrap(err, "DiscussionComments.Create")
+		}
+		if err := db.DiscussionExternal.UpsertComment(ctx, &types.DiscussionCommentExternal{
+			CommentID:         comment.ID,
+			ThreadID:          t.ID,
+			ExternalID:        extComment.ID,
+			Imported:          true,
+			SyncedAt:          timeNow(),
+			ExternalUpdatedAt: extComment.UpdatedAt,
+		}); err != nil {
+			return errors.Wrap(err, "DiscussionExternal.UpsertComment")
+		}
+		if comment.AuthorUserID != nil {
+			// Only notify for comments by Sourcegraph users, so that a code host user
+			// without an account can't cause emails to be sent.
+			notifyNewComment(t, comment)
+		}
+	}
+	return nil
+}
+
+// formatComment returns the body of the pull request comment for the
+// comment, which credits its author and links to it on Sourcegraph (because
+// it is posted by the user whose token is used).
+func formatComment(ctx context.Context, t *types.DiscussionThread, c *types.DiscussionComment) (string, error) {
+	author, err := db.Users.GetByID(ctx, *c.AuthorUserID)
+	if err != nil {
+		return "", errors.Wrap(err, "Users.GetByID")
+	}
+	u, err := discussions.URLToInlineComment(ctx, t, c)
+	if err != nil {
+		return "", errors.Wrap(err, "URLToInlineComment")
+	}
+	if u == nil {
+		return fmt.Sprintf("%s\n\n_Comment by @%s on Sourcegraph_", c.Contents, author.Username), nil
+	}
+	return fmt.Sprintf("%s\n\n_Comment by @%s on [Sourcegraph](%s)_", c.Contents, author.Username, globals.ExternalURL.ResolveReference(u)), nil
+}
+
+// importCommentAuthor returns the author of the Sourcegraph comment for the
+// comment on the code host. If the author has an external account for the code
+// host, the comment is theirs. Otherwise, it has no Sourcegraph author and is
+// attributed to the author's username on the code host.
+func importCommentAuthor(ctx context.Context, host codeHost, c *externalComment) (authorUserID *int32, externalAuthor *string, err error) {
+	accounts, err := db.ExternalAccounts.List(ctx, db.ExternalAccountsListOptions{AccountID: c.AuthorID})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "ExternalAccounts.List")
+	}
+	for _, a := range accounts {
+		if a.ServiceType == host.ServiceType() && a.ServiceID == host.ServiceID() {
+			return &a.UserID, nil, nil
+		}
+	}
+	return nil, &c.Author, nil
+}
@@ -0,0 +1,382 @@
+package codehostsync
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+)
+
+// fakeCodeHost is a code host with a single open pull request and review
+// thread.
+type fakeCodeHost struct {
+	nextID      int
+	pr          *pullRequest
+	threadID    string
+	threadLine  int
+	comments    []*externalComment
+	deletedByUs []string
+}
+
+func (h *fakeCodeHost) ServiceType() string { return "fake" }
+func (h *fakeCodeHost) ServiceID() string   { return "https://fake.example.com/" }
+
+func (h *fakeCodeHost) FindPullRequest(ctx context.Context, branch string) (*pullRequest, error) {
+	if branch != "feature" {
+		return nil, nil
+	}
+	return h.pr, nil
+}
+
+func (h *fakeCodeHost) CreateThread(ctx context.Context, pr *pullRequest, path string, line int, body string) (string, *externalComment, error) {
+	h.threadID, h.threadLine = "t", line
+	c, err := h.CreateComment(ctx, nil, body)
+	return h.threadID, c, err
+}
+
+func (h *fakeCodeHost) ListComments(ctx context.Context, t *types.DiscussionThreadExternal) ([]*externalComment, error) {
+	return h.comments, nil
+}
+
+func (h *fakeCodeHost) add(authorID, author, body string) *externalComment {
+	h.nextID++
+	c := &externalComment{
+		ID:        strconv.Itoa(h.nextID),
+		AuthorID:  authorID,
+		Author:    author,
+		Body:      body,
+		UpdatedAt: timeNow(),
+	}
+	h.comments = append(h.comments, c)
+	return c
+}
+
+func (h *fakeCodeHost) CreateComment(ctx context.Context, t *types.DiscussionThreadExternal, body string) (*externalComment, error) {
+	return h.add("1", "sourcegraph-bot", body), nil
+}
+
+func (h *fakeCodeHost) UpdateComment(ctx context.Context, t *types.DiscussionThreadExternal, commentID, body string) (*externalComment, error) {
+	for _, c := range h.comments {
+		if c.ID == commentID {
+			c.Body, c.UpdatedAt = body, timeNow()
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (h *fakeCodeHost) DeleteComment(ctx context.Context, t *types.DiscussionThreadExternal, commentID string) error {
+	h.deletedByUs = append(h.deletedByUs, commentID)
+	h.delete(commentID)
+	return nil
+}
+
+func (h *fakeCodeHost) delete(commentID string) {
+	for i, c := range h.comments {
+		if c.ID == commentID {
+			h.comments = append(h.comments[:i], h.comments[i+1:]...)
+			return
+		}
+	}
+}
+
+func (h *fakeCodeHost) bodies() (bodies []string) {
+	for _, c := range h.comments {
+		bodies = append(bodies, c.Body)
+	}
+	return bodies
+}
+
+// fakeDB mocks the DB stores used by the syncer with in-memory data.
+type fakeDB struct {
+	threads  map[int64]*types.DiscussionThread
+	comments []*types.DiscussionComment
+
+	extThreads  map[int64]*types.DiscussionThreadExternal
+	extComments map[int64]*types.DiscussionCommentExternal
+}
+
+func (d *fakeDB) addComment(threadID int64, authorUserID *int32, externalAuthor *string, contents string) *types.DiscussionComment {
+	c := &types.DiscussionComment{
+		ID:             int64(len(d.comments) + 1),
+		ThreadID:       threadID,
+		AuthorUserID:   authorUserID,
+		ExternalAuthor: externalAuthor,
+		Contents:       contents,
+		CreatedAt:      timeNow(),
+		UpdatedAt:      timeNow(),
+	}
+	d.comments = append(d.comments, c)
+	return c
+}
+
+func (d *fakeDB) threadComments(threadID int64) (comments []*types.DiscussionComment) {
+	for _, c := range d.comments {
+		if c.ThreadID == threadID && c.DeletedAt == nil {
+			comments = append(comments, c)
+		}
+	}
+	return comments
+}
+
+func (d *fakeDB) mock() {
+	db.Mocks.Repos.Get = func(ctx context.Context, id api.RepoID) (*types.Repo, error) {
+		return &types.Repo{ID: id, Name: "fake.example.com/r"}, nil
+	}
+	db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id, Username: "user" + strconv.Itoa(int(id))}, nil
+	}
+	db.Mocks.ExternalAccounts.List = func(opt db.ExternalAccountsListOptions) ([]*extsvc.ExternalAccount, error) {
+		if opt.AccountID != "42" {
+			return nil, nil
+		}
+		return []*extsvc.ExternalAccount{{
+			UserID:              3,
+			ExternalAccountSpec: extsvc.ExternalAccountSpec{ServiceType: "fake", ServiceID: "https://fake.example.com/", AccountID: "42"},
+		}}, nil
+	}
+
+	db.Mocks.DiscussionThreads.List = func(ctx context.Context, opt *db.DiscussionThreadsListOptions) ([]*types.DiscussionThread, error) {
+		var threads []*types.DiscussionThread
+		for _, t := range d.threads {
+			if t.DeletedAt != nil || (len(opt.ThreadIDs) > 0 && opt.ThreadIDs[0] != t.ID) {
+				continue
+			}
+			threads = append(threads, t)
+		}
+		return threads, nil
+	}
+	db.Mocks.DiscussionComments.List = func(ctx context.Context, opt *db.DiscussionCommentsListOptions) ([]*types.DiscussionComment, error) {
+		return d.threadComments(*opt.ThreadID), nil
+	}
+	db.Mocks.DiscussionComments.Create = func(ctx context.Context, c *types.DiscussionComment) (*types.DiscussionComment, error) {
+		return d.addComment(c.ThreadID, c.AuthorUserID, c.ExternalAuthor, c.Contents), nil
+	}
+	db.Mocks.DiscussionComments.Update = func(ctx context.Context, id int64, opt *db.DiscussionCommentsUpdateOptions) (*types.DiscussionComment, error) {
+		c := d.comments[id-1]
+		if opt.Contents != nil {
+			c.Contents = *opt.Contents
+		}
+		if opt.Delete {
+			now := timeNow()
+			if first := d.threadComments(c.ThreadID)[0]; first.ID == c.ID {
+				d.threads[c.ThreadID].DeletedAt = &now
+			}
+			c.DeletedAt = &now
+		}
+		c.UpdatedAt = timeNow()
+		return c, nil
+	}
+
+	db.Mocks.DiscussionExternal.CreateThread = func(ctx context.Context, t *types.DiscussionThreadExternal) error {
+		d.extThreads[t.ThreadID] = t
+		return nil
+	}
+	db.Mocks.DiscussionExternal.ListThreads = func(ctx context.Context) (threads []*types.DiscussionThreadExternal, err error) {
+		for _, t := range d.extThreads {
+			threads = append(threads, t)
+		}
+		return threads, nil
+	}
+	db.Mocks.DiscussionExternal.DeleteThread = func(ctx context.Context, threadID int64) error {
+		delete(d.extThreads, threadID)
+		for id, c := range d.extComments {
+			if c.ThreadID == threadID {
+				delete(d.extComments, id)
+			}
+		}
+		return nil
+	}
+	db.Mocks.DiscussionExternal.ListComments = func(ctx context.Context, threadID int64) (comments []*types.DiscussionCommentExternal, err error) {
+		for id := int64(1); id <= int64(len(d.comments)); id++ {
+			if c, ok := d.extComments[id]; ok && c.ThreadID == threadID {
+				link := *c
+				comments = append(comments, &link)
+			}
+		}
+		return comments, nil
+	}
+	db.Mocks.DiscussionExternal.UpsertComment = func(ctx context.Context, c *types.DiscussionCommentExternal) error {
+		link := *c
+		d.extComments[c.CommentID] = &link
+		return nil
+	}
+	db.Mocks.DiscussionExternal.DeleteComment = func(ctx context.Context, commentID int64) error {
+		delete(d.extComments, commentID)
+		return nil
+	}
+}
+
+func TestSyncAll(t *testing.T) {
+	defer func() {
+		db.Mocks = db.MockStores{}
+		mockCodeHostForRepo = nil
+		timeNow = time.Now
+		notifyNewComment = nil
+	}()
+
+	// Each call to timeNow returns a later time.
+	now := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	var notified []string
+	notifyNewComment = func(_ *types.DiscussionThread, c *types.DiscussionComment) {
+		notified = append(notified, c.Contents)
+	}
+
+	host := &fakeCodeHost{pr: &pullRequest{ID: "7", HeadSHA: "c0ffee"}}
+	mockCodeHostForRepo = func(ctx context.Context, repo *types.Repo) (codeHost, error) {
+		return host, nil
+	}
+
+	strPtr := func(s string) *string { return &s }
+	i32Ptr := func(i int32) *int32 { return &i }
+	fdb := &fakeDB{
+		threads: map[int64]*types.DiscussionThread{
+			1: {ID: 1, AuthorUserID: 1, TargetRepo: &types.DiscussionThreadTargetRepo{
+				RepoID:    1,
+				Path:      strPtr("a.go"),
+				Branch:    strPtr("feature"),
+				StartLine: i32Ptr(9),
+			}},
+			// Not mirrored because its branch has no pull request.
+			2: {ID: 2, AuthorUserID: 1, TargetRepo: &types.DiscussionThreadTargetRepo{
+				RepoID:    1,
+				Path:      strPtr("a.go"),
+				Branch:    strPtr("master"),
+				StartLine: i32Ptr(9),
+			}},
+		},
+		extThreads:  map[int64]*types.DiscussionThreadExternal{},
+		extComments: map[int64]*types.DiscussionCommentExternal{},
+	}
+	fdb.mock()
+	fdb.addComment(1, i32Ptr(1), nil, "first")
+	fdb.addComment(2, i32Ptr(1), nil, "other")
+
+	sync := func(t *testing.T) {
+		t.Helper()
+		if err := syncAll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantCodeHostBodies := func(t *testing.T, want ...string) {
+		t.Helper()
+		got := host.bodies()
+		if len(got) != len(want) {
+			t.Fatalf("got code host comments %q, want %q", got, want)
+		}
+		for i := range want {
+			if !strings.HasPrefix(got[i], want[i]) {
+				t.Errorf("got code host comment %q, want prefix %q", got[i], want[i])
+			}
+		}
+	}
+	wantComments := func(t *testing.T, want ...string) {
+		t.Helper()
+		var got []string
+		for _, c := range fdb.threadComments(1) {
+			author := "user"
+			if c.AuthorUserID != nil {
+				author += strconv.Itoa(int(*c.AuthorUserID))
+			} else {
+				author = "external " + *c.ExternalAuthor
+			}
+			got = append(got, author+": "+c.Contents)
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("got comments %q, want %q", got, want)
+		}
+	}
+
+	t.Run("mirror thread", func(t *testing.T) {
+		sync(t)
+		if len(fdb.extThreads) != 1 || fdb.extThreads[1] == nil {
+			t.Fatalf("got mirrored threads %+v, want only thread 1", fdb.extThreads)
+		}
+		if ext := fdb.extThreads[1]; ext.PullRequestID != "7" || ext.ExternalID != "t" || ext.ServiceType != "fake" {
+			t.Errorf("unexpected mirrored thread %+v", ext)
+		}
+		if host.threadLine != 10 {
+			t.Errorf("got line %d, want 10", host.threadLine)
+		}
+		wantCodeHostBodies(t, "first\n\n_Comment by @user1 on [Sourcegraph](http://example.com/fake.example.com/r/-/blob/a.go#L10")
+	})
+
+	t.Run("create comments", func(t *testing.T) {
+		fdb.addComment(1, i32Ptr(2), nil, "from sourcegraph")
+		host.add("7", "bob", "from bob")
+		host.add("42", "carol", "from carol")
+		sync(t)
+		wantCodeHostBodies(t, "first", "from bob", "from carol", "from sourcegraph\n\n_Comment by @user2")
+		wantComments(t,
+			"user1: first",
+			"user2: from sourcegraph",
+			"external bob: from bob", // bob has no Sourcegraph account
+			"user3: from carol",
+		)
+		// bob's comment has no Sourcegraph author, so it does not notify.
+		if want := []string{"from carol"}; strings.Join(notified, ",") != strings.Join(want, ",") {
+			t.Errorf("got notifications for %q, want %q", notified, want)
+		}
+
+		// Syncing again does nothing.
+		sync(t)
+		wantCodeHostBodies(t, "first", "from bob", "from carol", "from sourcegraph")
+		if len(fdb.threadComments(1)) != 4 {
+			t.Errorf("got %d comments, want 4", len(fdb.threadComments(1)))
+		}
+	})
+
+	t.Run("edit comments", func(t *testing.T) {
+		c := fdb.comments[2] // "from sourcegraph"
+		c.Contents, c.UpdatedAt = "edited on sourcegraph", timeNow()
+		bob := host.comments[1]
+		bob.Body, bob.UpdatedAt = "edited by bob", timeNow()
+		sync(t)
+		wantCodeHostBodies(t, "first", "edited by bob", "from carol", "edited on sourcegraph")
+		wantComments(t,
+			"user1: first",
+			"user2: edited on sourcegraph",
+			"external bob: edited by bob",
+			"user3: from carol",
+		)
+	})
+
+	t.Run("delete comments", func(t *testing.T) {
+		// Delete "edited on sourcegraph" on Sourcegraph and carol's comment on
+		// the code host.
+		if _, err := db.DiscussionComments.Update(context.Background(), 3, &db.DiscussionCommentsUpdateOptions{Delete: true}); err != nil {
+			t.Fatal(err)
+		}
+		host.delete(host.comments[2].ID)
+		// Deleting bob's imported comment on Sourcegraph does not delete it on
+		// the code host, nor import it again.
+		if _, err := db.DiscussionComments.Update(context.Background(), 4, &db.DiscussionCommentsUpdateOptions{Delete: true}); err != nil {
+			t.Fatal(err)
+		}
+		sync(t)
+		sync(t)
+		wantCodeHostBodies(t, "first", "edited by bob")
+		wantComments(t, "user1: first")
+	})
+
+	t.Run("delete thread", func(t *testing.T) {
+		if _, err := db.DiscussionComments.Update(context.Background(), 1, &db.DiscussionCommentsUpdateOptions{Delete: true}); err != nil {
+			t.Fatal(err)
+		}
+		sync(t)
+		wantCodeHostBodies(t, "edited by bob")
+		if len(fdb.extThreads) != 0 || len(fdb.extComments) != 0 {
+			t.Errorf("got mirrored threads %+v and comments %+v, want none", fdb.extThreads, fdb.extComments)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+// Package codehostsync mirrors discussion threads to and from the review
+// comments of pull requests on code hosts (GitHub and GitLab).
+package codehostsync
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// syncInterval is how often threads are synced with code hosts.
+const syncInterval = 5 * time.Minute
+
+// enabled reports whether the site configuration enables syncing threads with
+// code hosts.
+func enabled() bool {
+	dc := conf.Get().Discussions
+	return dc != nil && dc.SyncPullRequestComments
+}
+
+// StartWorker should be invoked only after the DB has been initialized. It
+// starts the background worker which periodically syncs discussion threads
+// with the review comments of pull requests on code hosts, if enabled in the
+// site configuration.
+//
+// It should be invoked in a separate goroutine.
+func StartWorker() {
+	for {
+		if !enabled() {
+			time.Sleep(syncInterval)
+			continue
+		}
+
+		// Only one frontend instance should ever run this worker, so we use a
+		// distributed lock to guarantee this. If the frontend with the lock
+		// acquired dies, it will be released after 1 minute.
+		ctx, release, ok := rcache.TryAcquireMutex(context.Background(), "discussionsCodeHostSyncWorker")
+		if !ok {
+			// Failed to acquire the mutex. Wait before trying again.
+			time.Sleep(30 * time.Second)
+			continue
+		}
+
+		// Acquired the mutex, perform work under it.
+		log15.Debug("discussions: code host sync worker running")
+		for enabled() && ctx.Err() == nil {
+			if err := syncAll(ctx); err != nil {
+				log15.Error("discussions: failed to sync threads with code hosts", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(syncInterval):
+			}
+		}
+		log15.Debug("discussions: code host sync worker stopped", "ctx", ctx.Err())
+		release()
+	}
+}
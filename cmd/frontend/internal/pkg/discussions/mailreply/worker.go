@@ -120,7 +120,7 @@ func workForever(ctx context.Context) {
 
 			_, err = discussions.InsecureAddCommentToThread(ctx, &types.DiscussionComment{
 				ThreadID:     threadID,
-				AuthorUserID: userID,
+				AuthorUserID: &userID,
 				Contents:     contents,
 			})
 			if err != nil {
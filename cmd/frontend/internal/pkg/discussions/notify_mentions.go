@@ -25,9 +25,12 @@ import (
 //
 // It returns immediately and does not block.
 func NotifyNewThread(newThread *types.DiscussionThread, newComment *types.DiscussionComment) {
+	if newComment.AuthorUserID == nil {
+		return // comments by external authors do not send notifications
+	}
 	notifyMentions(&notifier{
 		typ:               newThreadNotification,
-		eventAuthorUserID: newComment.AuthorUserID,
+		eventAuthorUserID: *newComment.AuthorUserID,
 		thread:            newThread,
 		comment:           newComment,
 		template:          newThreadEmailTemplate,
@@ -39,9 +42,12 @@ func NotifyNewThread(newThread *types.DiscussionThread, newComment *types.Discus
 //
 // It returns immediately and does not block.
 func NotifyNewComment(updatedThread *types.DiscussionThread, newComment *types.DiscussionComment) {
+	if newComment.AuthorUserID == nil {
+		return // comments by external authors do not send notifications
+	}
 	notifyMentions(&notifier{
 		typ:               newCommentNotification,
-		eventAuthorUserID: newComment.AuthorUserID,
+		eventAuthorUserID: *newComment.AuthorUserID,
 		thread:            updatedThread,
 		comment:           newComment,
 		template:          newCommentEmailTemplate,
@@ -107,13 +113,15 @@ func (n *notifier) subscribers(ctx context.Context) ([]string, error) {
 		}
 	}
 	for _, comment := range comments {
-		commentAuthor, err := db.Users.GetByID(ctx, comment.AuthorUserID)
-		if err != nil {
-			return nil, errors.Wrap(err, "CommentAuthor: GetByID")
-		}
-		if _, ok := set[commentAuthor.Username]; !ok {
-			set[commentAuthor.Username] = struct{}{}
-			subscribers = append(subscribers, commentAuthor.Username)
+		if comment.AuthorUserID != nil {
+			commentAuthor, err := db.Users.GetByID(ctx, *comment.AuthorUserID)
+			if err != nil {
+				return nil, errors.Wrap(err, "CommentAuthor: GetByID")
+			}
+			if _, ok := set[commentAuthor.Username]; !ok {
+				set[commentAuthor.Username] = struct{}{}
+				subscribers = append(subscribers, commentAuthor.Username)
+			}
 		}
 		for _, mention := range mentions.Parse(comment.Contents) {
 			if _, ok := set[mention]; !ok {
@@ -238,7 +246,7 @@ func (n *notifier) notifyUsername(ctx context.Context, username string) error {
 		}
 	}
 
-	commentAuthor, err := db.Users.GetByID(ctx, n.comment.AuthorUserID)
+	commentAuthor, err := db.Users.GetByID(ctx, n.eventAuthorUserID)
 	if err != nil {
 		return errors.Wrap(err, "CommentAuthor: GetByID")
 	}
@@ -0,0 +1,47 @@
+package discussions
+
+// DiffHunk describes a changed region between two versions of a file, as in
+// the hunk header of a unified diff with zero lines of context (`git diff -U0`).
+type DiffHunk struct {
+	// OrigStartLine is the first line (one-based) of the region in the
+	// original file. If OrigLines is zero (lines were only added), it is the
+	// line after which the new lines were added.
+	OrigStartLine int
+
+	// OrigLines is the number of lines of the region in the original file.
+	OrigLines int
+
+	// NewLines is the number of lines of the region in the new file.
+	NewLines int
+}
+
+// MapLineRange maps the line range r in the original file to the same lines
+// in the new file, given the hunks of the diff between the two files, in
+// order.
+//
+// It returns false if any of the hunks changed, removed or added lines within
+// r, in which case the lines of r do not exist unchanged in the new file.
+func MapLineRange(r LineRange, hunks []DiffHunk) (LineRange, bool) {
+	offset := 0
+	for _, h := range hunks {
+		// The region of the hunk in the original file (zero-based, end exclusive).
+		start := h.OrigStartLine - 1
+		if h.OrigLines == 0 {
+			start = h.OrigStartLine // lines were added after OrigStartLine
+		}
+		end := start + h.OrigLines
+
+		switch {
+		case end <= r.StartLine:
+			// The hunk is before the range (lines added immediately before
+			// the range are before it, too).
+			offset += h.NewLines - h.OrigLines
+		case start >= r.EndLine:
+			// The hunk is after the range, and so are all following hunks.
+			return LineRange{StartLine: r.StartLine + offset, EndLine: r.EndLine + offset}, true
+		default:
+			return LineRange{}, false
+		}
+	}
+	return LineRange{StartLine: r.StartLine + offset, EndLine: r.EndLine + offset}, true
+}
@@ -0,0 +1,86 @@
+package discussions
+
+import "testing"
+
+func TestMapLineRange(t *testing.T) {
+	// The selection is lines 10-11 (zero-based), i.e. lines 11-12 (one-based).
+	sel := LineRange{StartLine: 10, EndLine: 12}
+	tests := []struct {
+		name   string
+		hunks  []DiffHunk
+		want   LineRange
+		wantOK bool
+	}{
+		{
+			name:   "no_changes",
+			want:   sel,
+			wantOK: true,
+		},
+		{
+			name:   "added_before",
+			hunks:  []DiffHunk{{OrigStartLine: 2, OrigLines: 0, NewLines: 3}},
+			want:   LineRange{StartLine: 13, EndLine: 15},
+			wantOK: true,
+		},
+		{
+			name:   "added_immediately_before",
+			hunks:  []DiffHunk{{OrigStartLine: 10, OrigLines: 0, NewLines: 1}},
+			want:   LineRange{StartLine: 11, EndLine: 13},
+			wantOK: true,
+		},
+		{
+			name:   "removed_before",
+			hunks:  []DiffHunk{{OrigStartLine: 1, OrigLines: 4, NewLines: 0}},
+			want:   LineRange{StartLine: 6, EndLine: 8},
+			wantOK: true,
+		},
+		{
+			name:   "changed_immediately_before",
+			hunks:  []DiffHunk{{OrigStartLine: 10, OrigLines: 1, NewLines: 2}},
+			want:   LineRange{StartLine: 11, EndLine: 13},
+			wantOK: true,
+		},
+		{
+			name:   "added_immediately_after",
+			hunks:  []DiffHunk{{OrigStartLine: 12, OrigLines: 0, NewLines: 5}},
+			want:   sel,
+			wantOK: true,
+		},
+		{
+			name: "changed_before_and_after",
+			hunks: []DiffHunk{
+				{OrigStartLine: 1, OrigLines: 1, NewLines: 3},
+				{OrigStartLine: 5, OrigLines: 2, NewLines: 0},
+				{OrigStartLine: 13, OrigLines: 1, NewLines: 10},
+			},
+			want:   sel,
+			wantOK: true,
+		},
+		{
+			name:   "changed_first_line",
+			hunks:  []DiffHunk{{OrigStartLine: 11, OrigLines: 1, NewLines: 1}},
+			wantOK: false,
+		},
+		{
+			name:   "changed_overlapping_last_line",
+			hunks:  []DiffHunk{{OrigStartLine: 12, OrigLines: 3, NewLines: 0}},
+			wantOK: false,
+		},
+		{
+			name:   "added_within",
+			hunks:  []DiffHunk{{OrigStartLine: 11, OrigLines: 0, NewLines: 1}},
+			wantOK: false,
+		},
+	}
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			got, ok := MapLineRange(sel, tst.hunks)
+			if ok != tst.wantOK {
+				t.Fatalf("got ok %v, want %v", ok, tst.wantOK)
+			}
+			if ok && got != tst.want {
+				t.Errorf("got %+v, want %+v", got, tst.want)
+			}
+		})
+	}
+}
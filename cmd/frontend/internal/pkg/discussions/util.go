@@ -23,8 +23,8 @@ import (
 // It does NOT verify that the user has permission to create this comment. That
 // is the responsibility of the caller.
 func InsecureAddCommentToThread(ctx context.Context, newComment *types.DiscussionComment) (*types.DiscussionThread, error) {
-	if dc := conf.Get().Discussions; dc != nil && dc.AbuseProtection {
-		if mustWait := ratelimit.TimeUntilUserCanAddCommentToThread(ctx, newComment.AuthorUserID, newComment.Contents); mustWait != 0 {
+	if dc := conf.Get().Discussions; dc != nil && dc.AbuseProtection && newComment.AuthorUserID != nil {
+		if mustWait := ratelimit.TimeUntilUserCanAddCommentToThread(ctx, *newComment.AuthorUserID, newComment.Contents); mustWait != 0 {
 			return nil, fmt.Errorf("You are creating comments too quickly. You may create a new one after %v", mustWait.Round(time.Second))
 		}
 	}
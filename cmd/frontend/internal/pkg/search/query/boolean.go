@@ -0,0 +1,162 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/syntax"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/types"
+	searchquery "github.com/sourcegraph/sourcegraph/pkg/search/query"
+)
+
+// maxPatternClauses is the maximum number of clauses PatternClauses will
+// produce. Converting to conjunctive normal form can grow the query
+// exponentially, eg (a AND b) OR (c AND d) OR ...
+const maxPatternClauses = 16
+
+// UnsupportedBooleanError is returned for boolean queries which are valid,
+// but which cannot be searched. Its message explains why to the user.
+type UnsupportedBooleanError struct {
+	Msg string
+}
+
+func (e *UnsupportedBooleanError) Error() string {
+	return e.Msg
+}
+
+// IsBoolean reports whether the query uses the boolean operators AND, OR or
+// NOT in a way that affects how it is searched. A query whose operators only
+// combine filters with AND, such as "repo:r AND NOT file:f", means the same as
+// the query without operators, so it is searched like one and IsBoolean is
+// false.
+func (q *Query) IsBoolean() bool {
+	if q.Syntax.Bool == nil {
+		return false
+	}
+	return len(q.Fields[FieldDefault]) > 0 || !isConjunction(q.Syntax.Bool)
+}
+
+// isConjunction reports whether b only combines single expressions with AND.
+func isConjunction(b searchquery.Q) bool {
+	switch b := b.(type) {
+	case *syntax.Expr:
+		return true
+	case *searchquery.And:
+		for _, operand := range b.Children {
+			if _, ok := operand.(*syntax.Expr); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// PatternClauses returns the search patterns of a boolean query as regexps
+// in conjunctive normal form: a file matches the query if its content
+// matches every pattern in include and no pattern in exclude.
+//
+// Other fields (such as repo: and file:) must be combined with the rest of
+// the query with AND, since they are used as filters. The returned error is
+// an *UnsupportedBooleanError if they are not, or if the patterns cannot be
+// represented as include and exclude patterns (eg "a OR NOT b"). include is
+// never empty if err is nil.
+func (q *Query) PatternClauses() (include, exclude []string, err error) {
+	if !q.IsBoolean() {
+		return nil, nil, &UnsupportedBooleanError{Msg: "the query does not use boolean operators"}
+	}
+	if q.IsStructural() {
+		return nil, nil, &UnsupportedBooleanError{Msg: "boolean operators are not supported for structural search (patterntype:structural)"}
+	}
+
+	values := map[*syntax.Expr]*types.Value{}
+	for _, v := range q.Fields[FieldDefault] {
+		values[v.Syntax()] = v
+	}
+
+	// Every operand of the top-level AND is either a filter (a single
+	// non-pattern expression) or a combination of patterns.
+	conjuncts := []searchquery.Q{q.Syntax.Bool}
+	if and, ok := q.Syntax.Bool.(*searchquery.And); ok {
+		conjuncts = and.Children
+	}
+	var operands []searchquery.Q
+	for _, c := range conjuncts {
+		if expr, ok := c.(*syntax.Expr); ok && values[expr] == nil {
+			continue
+		}
+		var filter *syntax.Expr
+		searchquery.VisitAtoms(c, func(atom searchquery.Q) {
+			if expr := atom.(*syntax.Expr); filter == nil && values[expr] == nil {
+				filter = expr
+			}
+		})
+		if filter != nil {
+			return nil, nil, &UnsupportedBooleanError{Msg: fmt.Sprintf("%q can only be combined with the rest of the query using AND. Move it out of the OR or NOT expression it is in.", filter.String())}
+		}
+		operands = append(operands, c)
+	}
+
+	cnf, ok := searchquery.ConjunctiveNormalForm(searchquery.NewAnd(operands...), maxPatternClauses)
+	if !ok {
+		return nil, nil, &UnsupportedBooleanError{Msg: "the query has too many combinations of OR and NOT to be searched. Try simplifying it."}
+	}
+	for _, clause := range cnf.Children {
+		literals := clause.(*searchquery.Or).Children
+		if expr, not := patternLiteral(literals[0]); len(literals) == 1 && not {
+			exclude = appendUnique(exclude, valuePattern(values[expr]))
+			continue
+		}
+		patterns := make([]string, 0, len(literals))
+		for _, lit := range literals {
+			expr, not := patternLiteral(lit)
+			if not {
+				return nil, nil, &UnsupportedBooleanError{Msg: "a negated search term can only be combined with other terms using AND, not OR."}
+			}
+			patterns = appendUnique(patterns, valuePattern(values[expr]))
+		}
+		include = appendUnique(include, unionRegexps(patterns))
+	}
+	if len(include) == 0 {
+		return nil, nil, &UnsupportedBooleanError{Msg: "the query must contain at least one search term which is not negated."}
+	}
+	return include, exclude, nil
+}
+
+// patternLiteral returns the search term of a literal in a clause of
+// ConjunctiveNormalForm, and whether it is negated. A term may be negated
+// both by a searchquery.Not and its own Not field.
+func patternLiteral(lit searchquery.Q) (expr *syntax.Expr, not bool) {
+	if n, ok := lit.(*searchquery.Not); ok {
+		lit, not = n.Child, true
+	}
+	expr = lit.(*syntax.Expr)
+	return expr, expr.Not != not
+}
+
+// valuePattern returns the regexp for a search term. Quoted terms are
+// matched literally.
+func valuePattern(v *types.Value) string {
+	if v.String != nil {
+		return regexp.QuoteMeta(*v.String)
+	}
+	return v.Regexp.String()
+}
+
+// unionRegexps returns a regexp which matches any of patterns.
+func unionRegexps(patterns []string) string {
+	if len(patterns) == 1 {
+		return patterns[0]
+	}
+	return "(" + strings.Join(patterns, ")|(") + ")"
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
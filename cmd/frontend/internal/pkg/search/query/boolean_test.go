@@ -0,0 +1,90 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuery_PatternClauses(t *testing.T) {
+	tests := map[string]struct {
+		include, exclude []string
+		wantErr          bool
+	}{
+		"a OR b":                         {include: []string{"(a)|(b)"}},
+		"a AND b":                        {include: []string{"a", "b"}},
+		"(a OR b) AND NOT c file:\\.go$": {include: []string{"(a)|(b)"}, exclude: []string{"c"}},
+		"a AND -b -repo:r":               {include: []string{"a"}, exclude: []string{"b"}},
+		`"a.b" OR c`:                     {include: []string{`(a\.b)|(c)`}},
+		"(a AND b) OR c":                 {include: []string{"(a)|(c)", "(b)|(c)"}},
+		"NOT (a OR b) AND c":             {include: []string{"c"}, exclude: []string{"a", "b"}},
+		"a OR a":                         {include: []string{"a"}},
+		"a AND NOT repo:r AND file:f":    {include: []string{"a"}},
+		"not found OR missing":           {include: []string{"(not)|(missing)", "(found)|(missing)"}},
+
+		// Unsupported combinations.
+		"a OR NOT b":          {wantErr: true},
+		"NOT (a AND b) AND c": {wantErr: true},
+		"NOT a":               {wantErr: true},
+		"a OR repo:r":         {wantErr: true},
+		"NOT (a OR file:f)":   {wantErr: true},
+		"repo:a OR repo:b":    {wantErr: true},
+		"(a AND b) OR (c AND d) OR (e AND f) OR (g AND h) OR (i AND j)": {wantErr: true},
+		"patterntype:structural a OR b":                                 {wantErr: true},
+	}
+	for input, test := range tests {
+		t.Run(input, func(t *testing.T) {
+			q, err := ParseAndCheck(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !q.IsBoolean() {
+				t.Fatal("IsBoolean() == false, want true")
+			}
+			include, exclude, err := q.PatternClauses()
+			if test.wantErr {
+				if _, ok := err.(*UnsupportedBooleanError); !ok {
+					t.Fatalf("got err %v, want *UnsupportedBooleanError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(include, test.include) {
+				t.Errorf("include: got %q, want %q", include, test.include)
+			}
+			if !reflect.DeepEqual(exclude, test.exclude) {
+				t.Errorf("exclude: got %q, want %q", exclude, test.exclude)
+			}
+		})
+	}
+}
+
+func TestQuery_IsBoolean(t *testing.T) {
+	tests := map[string]bool{
+		"a OR b": true,
+		"NOT a":  true,
+
+		// Lowercase keywords are searched for literally.
+		"not found":      false,
+		"read and write": false,
+		"foo or":         false,
+
+		// Filters combined with AND are searched like queries without
+		// operators.
+		"repo:r AND file:f AND NOT lang:go": false,
+		"(repo:r AND file:f) AND lang:go":   false,
+		"repo:a OR repo:b":                  true,
+	}
+	for input, want := range tests {
+		t.Run(input, func(t *testing.T) {
+			q, err := ParseAndCheck(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := q.IsBoolean(); got != want {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
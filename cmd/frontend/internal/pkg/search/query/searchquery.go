@@ -9,15 +9,16 @@ import (
 
 // All field names.
 const (
-	FieldDefault   = ""
-	FieldCase      = "case"
-	FieldRepo      = "repo"
-	FieldRepoGroup = "repogroup"
-	FieldFile      = "file"
-	FieldFork      = "fork"
-	FieldArchived  = "archived"
-	FieldLang      = "lang"
-	FieldType      = "type"
+	FieldDefault     = ""
+	FieldCase        = "case"
+	FieldRepo        = "repo"
+	FieldRepoGroup   = "repogroup"
+	FieldFile        = "file"
+	FieldFork        = "fork"
+	FieldArchived    = "archived"
+	FieldLang        = "lang"
+	FieldType        = "type"
+	FieldPatternType = "patterntype"
 
 	// For diff and commit search only:
 	FieldBefore    = "before"
@@ -39,15 +40,16 @@ var (
 
 	conf = types.Config{
 		FieldTypes: map[string]types.FieldType{
-			FieldDefault:   {Literal: types.RegexpType, Quoted: types.StringType},
-			FieldCase:      {Literal: types.BoolType, Quoted: types.BoolType, Singular: true},
-			FieldRepo:      regexpNegatableFieldType,
-			FieldRepoGroup: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
-			FieldFile:      regexpNegatableFieldType,
-			FieldFork:      {Literal: types.StringType, Quoted: types.StringType, Singular: true},
-			FieldArchived:  {Literal: types.StringType, Quoted: types.StringType, Singular: true},
-			FieldLang:      {Literal: types.StringType, Quoted: types.StringType, Negatable: true},
-			FieldType:      stringFieldType,
+			FieldDefault:     {Literal: types.RegexpType, Quoted: types.StringType},
+			FieldCase:        {Literal: types.BoolType, Quoted: types.BoolType, Singular: true},
+			FieldRepo:        regexpNegatableFieldType,
+			FieldRepoGroup:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldFile:        regexpNegatableFieldType,
+			FieldFork:        {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldArchived:    {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldLang:        {Literal: types.StringType, Quoted: types.StringType, Negatable: true},
+			FieldType:        stringFieldType,
+			FieldPatternType: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 
 			FieldBefore:    stringFieldType,
 			FieldAfter:     stringFieldType,
@@ -75,6 +77,43 @@ var (
 	}
 )
 
+// Pattern types that may be given in the patterntype: field.
+const (
+	PatternTypeRegexp     = "regexp"
+	PatternTypeStructural = "structural"
+)
+
+// structuralConf is conf for queries with patterntype:structural. Unquoted
+// search terms are structural templates, not regexps, so they are typechecked
+// as strings.
+var structuralConf = func() types.Config {
+	c := types.Config{
+		FieldTypes:   map[string]types.FieldType{},
+		FieldAliases: conf.FieldAliases,
+	}
+	for field, typ := range conf.FieldTypes {
+		c.FieldTypes[field] = typ
+	}
+	c.FieldTypes[FieldDefault] = stringFieldType
+	return c
+}()
+
+// booleanConf is conf for queries which use boolean operators. Search terms
+// may be negated with not or -, which is not supported in other queries.
+var booleanConf = func() types.Config {
+	c := types.Config{
+		FieldTypes:   map[string]types.FieldType{},
+		FieldAliases: conf.FieldAliases,
+	}
+	for field, typ := range conf.FieldTypes {
+		c.FieldTypes[field] = typ
+	}
+	typ := c.FieldTypes[FieldDefault]
+	typ.Negatable = true
+	c.FieldTypes[FieldDefault] = typ
+	return c
+}()
+
 // A Query is the parsed representation of a search query.
 type Query struct {
 	conf *types.Config // the typechecker config used to produce this query
@@ -85,7 +124,15 @@ type Query struct {
 // ParseAndCheck parses and typechecks a search query using the default
 // query type configuration.
 func ParseAndCheck(input string) (*Query, error) {
-	return parseAndCheck(&conf, input)
+	c := &conf
+	if syntaxQuery, err := syntax.Parse(input); err == nil {
+		if isStructural(syntaxQuery) {
+			c = &structuralConf
+		} else if syntaxQuery.Bool != nil {
+			c = &booleanConf
+		}
+	}
+	return parseAndCheck(c, input)
 }
 
 func parseAndCheck(conf *types.Config, input string) (*Query, error) {
@@ -100,6 +147,24 @@ func parseAndCheck(conf *types.Config, input string) (*Query, error) {
 	return &Query{conf: conf, Query: checkedQuery}, nil
 }
 
+// isStructural reports whether the query contains patterntype:structural. It
+// is checked before typechecking since it changes the type of search terms.
+func isStructural(q *syntax.Query) bool {
+	for _, expr := range q.Expr {
+		if expr.Field == FieldPatternType && !expr.Not && expr.ValueType == syntax.TokenLiteral && expr.Value == PatternTypeStructural {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStructural reports whether the query's search terms are structural
+// match templates (patterntype:structural).
+func (q *Query) IsStructural() bool {
+	v, _ := q.StringValue(FieldPatternType)
+	return v == PatternTypeStructural
+}
+
 // BoolValue returns the last boolean value (yes/no) for the field. For example, if the query is
 // "foo:yes foo:no foo:yes", then the last boolean value for the "foo" field is true ("yes"). The
 // default boolean value is false.
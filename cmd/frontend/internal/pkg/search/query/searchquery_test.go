@@ -131,6 +131,29 @@ func TestQuery_StringValues(t *testing.T) {
 	})
 }
 
+func TestParseAndCheck_structural(t *testing.T) {
+	t.Run("regexp (default)", func(t *testing.T) {
+		// An unclosed group is not a valid regexp.
+		if _, err := ParseAndCheck("foo(:[args]"); err == nil {
+			t.Fatal("expected regexp type error")
+		}
+	})
+
+	t.Run("structural", func(t *testing.T) {
+		query, err := ParseAndCheck("patterntype:structural foo(:[args]")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !query.IsStructural() {
+			t.Error("IsStructural() == false, want true")
+		}
+		values := query.Values(FieldDefault)
+		if len(values) != 1 || values[0].String == nil || *values[0].String != "foo(:[args]" {
+			t.Errorf("got default values %v, want string %q", values, "foo(:[args]")
+		}
+	})
+}
+
 func checkPanic(t *testing.T, msg string, f func()) {
 	t.Helper()
 	defer func() {
@@ -1,6 +1,10 @@
 package syntax
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/sourcegraph/sourcegraph/pkg/search/query"
+)
 
 // ParseError describes an error in query parsing.
 type ParseError struct {
@@ -32,10 +36,29 @@ type context struct {
 //   expr      := fieldExpr | lit | quoted | pattern
 //   fieldExpr := lit ":" value
 //   value     := lit | quoted
+//
+// If the query contains the keywords AND, OR or NOT, it is parsed as a
+// boolean query instead. Juxtaposed expressions are implicitly combined with
+// AND, which binds tighter than OR:
+//
+//   orExpr    := andExpr (sep "OR" sep andExpr)*
+//   andExpr   := notExpr (sep ["AND" sep] notExpr)*
+//   notExpr   := "NOT" sep notExpr | {"-"} "(" orExpr ")" | exprSign
 func Parse(input string) (*Query, error) {
 	tokens := Scan(input)
 	p := parser{tokens: tokens}
 	ctx := context{field: ""}
+	if isBoolean(tokens) {
+		b, err := p.parseBoolQuery(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var exprs []*Expr
+		query.VisitAtoms(b, func(q query.Q) {
+			exprs = append(exprs, q.(*Expr))
+		})
+		return &Query{Expr: exprs, Bool: b, Input: input}, nil
+	}
 	exprs, err := p.parseExprList(ctx)
 	if err != nil {
 		return nil, err
@@ -43,6 +66,18 @@ func Parse(input string) (*Query, error) {
 	return &Query{Expr: exprs, Input: input}, nil
 }
 
+// isBoolean reports whether tokens contain operator keywords. Scan only
+// produces operator and grouping tokens for such queries.
+func isBoolean(tokens []Token) bool {
+	for _, tok := range tokens {
+		switch tok.Type {
+		case TokenAnd, TokenOr, TokenNot:
+			return true
+		}
+	}
+	return false
+}
+
 // peek returns the next token without consuming it. Peeking beyond the end of
 // the token stream will return TokenEOF.
 func (p *parser) peek() Token {
@@ -129,29 +164,169 @@ func (p *parser) parseExpr(ctx context) (*Expr, error) {
 			valueTok := p.next()
 			switch valueTok.Type {
 			case TokenLiteral, TokenQuoted:
-				if tok3 := p.next(); tok3.Type != TokenSep && tok3.Type != TokenEOF {
-					return nil, &ParseError{Pos: tok3.Pos, Msg: fmt.Sprintf("got %s, want separator or EOF", tok3.Type)}
+				if err := p.parseExprEnd(); err != nil {
+					return nil, err
 				}
 				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: valueTok.Value, ValueType: valueTok.Type}, nil
+			case TokenRParen:
+				p.backup()
+				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: "", ValueType: TokenLiteral}, nil
 			case TokenSep, TokenEOF:
 				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: "", ValueType: TokenLiteral}, nil
 			default:
 				return nil, &ParseError{Pos: valueTok.Pos, Msg: fmt.Sprintf("got %s, want value", valueTok.Type)}
 			}
+		case TokenRParen:
+			p.backup()
+			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 		case TokenSep, TokenEOF:
 			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 		default:
 			panic("unreachable")
 		}
 	case TokenQuoted, TokenPattern:
-		tok2 := p.next()
-		switch tok2.Type {
-		case TokenSep, TokenEOF:
-			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
-		default:
-			return nil, &ParseError{Pos: tok2.Pos, Msg: fmt.Sprintf("got %s, want separator or EOF", tok2.Type)}
+		if err := p.parseExprEnd(); err != nil {
+			return nil, err
 		}
+		return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 	}
 
 	return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want expr", tok.Type)}
 }
+
+// parseExprEnd consumes the separator or EOF which ends an expression. A
+// closing parenthesis also ends an expression, but is left for the caller.
+func (p *parser) parseExprEnd() error {
+	switch tok := p.next(); tok.Type {
+	case TokenSep, TokenEOF:
+		return nil
+	case TokenRParen:
+		p.backup()
+		return nil
+	default:
+		return &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want separator or EOF", tok.Type)}
+	}
+}
+
+// skipSep consumes any separators.
+func (p *parser) skipSep() {
+	for p.peek().Type == TokenSep {
+		p.next()
+	}
+}
+
+// parseBoolQuery parses a whole boolean query. Leaves are *Expr, combined
+// with the And, Or and Not nodes of pkg/search/query.
+func (p *parser) parseBoolQuery(ctx context) (query.Q, error) {
+	p.skipSep()
+	q, err := p.parseOrExpr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.next(); tok.Type != TokenEOF {
+		return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want EOF", tok.Type)}
+	}
+	return query.Simplify(q), nil
+}
+
+// orExpr := andExpr (sep "OR" sep andExpr)*
+func (p *parser) parseOrExpr(ctx context) (query.Q, error) {
+	var operands []query.Q
+	for {
+		q, err := p.parseAndExpr(ctx)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, q)
+		if p.peek().Type != TokenOr {
+			break
+		}
+		p.next()
+		p.skipSep()
+	}
+	return query.NewOr(operands...), nil
+}
+
+// andExpr := notExpr (sep ["AND" sep] notExpr)*
+func (p *parser) parseAndExpr(ctx context) (query.Q, error) {
+	var operands []query.Q
+	for {
+		q, err := p.parseNotExpr(ctx)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, q)
+		p.skipSep()
+		switch p.peek().Type {
+		case TokenAnd:
+			p.next()
+			p.skipSep()
+			continue
+		case TokenOr, TokenRParen, TokenEOF:
+			return query.NewAnd(operands...), nil
+		}
+	}
+}
+
+// notExpr := "NOT" sep notExpr | {"-"} "(" orExpr ")" | exprSign
+func (p *parser) parseNotExpr(ctx context) (query.Q, error) {
+	tok := p.peek()
+	switch tok.Type {
+	case TokenNot:
+		p.next()
+		p.skipSep()
+		q, err := p.parseNotExpr(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return negate(q), nil
+
+	case TokenMinus:
+		p.next()
+		if p.peek().Type != TokenLParen {
+			p.backup()
+			break
+		}
+		q, err := p.parseGroup(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return negate(q), nil
+
+	case TokenLParen:
+		return p.parseGroup(ctx)
+
+	case TokenAnd, TokenOr, TokenRParen, TokenEOF:
+		return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want expr", tok.Type)}
+	}
+
+	expr, err := p.parseExprSign(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+// parseGroup parses "(" orExpr ")".
+func (p *parser) parseGroup(ctx context) (query.Q, error) {
+	p.next() // "("
+	p.skipSep()
+	q, err := p.parseOrExpr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.next(); tok.Type != TokenRParen {
+		return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want )", tok.Type)}
+	}
+	return q, nil
+}
+
+// negate returns the negation of q. A single expression is negated by
+// setting its Not field, so that negated filters are typechecked as usual.
+func negate(q query.Q) query.Q {
+	if expr, ok := q.(*Expr); ok {
+		expr.Not = !expr.Not
+		return expr
+	}
+	return &query.Not{Child: q}
+}
@@ -111,3 +111,65 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_boolean(t *testing.T) {
+	tests := map[string]struct {
+		wantBool string // Bool.String of the result
+		wantErr  *ParseError
+	}{
+		"a OR b":                      {wantBool: "(or a b)"},
+		"a AND b":                     {wantBool: "(and a b)"},
+		"a b OR c":                    {wantBool: "(or (and a b) c)"},
+		"a OR b c":                    {wantBool: "(or a (and b c))"},
+		"a OR b AND c":                {wantBool: "(or a (and b c))"},
+		"(a OR b) AND NOT c":          {wantBool: "(and (or a b) -c)"},
+		"(a OR b) -c file:d":          {wantBool: "(and (or a b) -c file:d)"},
+		"NOT (a OR b)":                {wantBool: "(not (or a b))"},
+		"NOT -(a OR b)":               {wantBool: "(or a b)"},
+		"-(a OR b)":                   {wantBool: "(not (or a b))"},
+		"NOT NOT a":                   {wantBool: "a"},
+		"((a OR b) AND (c OR d))":     {wantBool: "(and (or a b) (or c d))"},
+		"(foo(bar) OR baz)":           {wantBool: "(or foo(bar) baz)"},
+		"(a|b) OR c":                  {wantBool: "(or (a|b) c)"},
+		`("a b" OR /c d/) repo:r`:     {wantBool: `(and (or "a b" /c d/) repo:r)`},
+		"(repo:OR OR file:AND) NOT x": {wantBool: "(and (or repo:OR file:AND) -x)"},
+		"a and b OR c":                {wantBool: "(or (and a and b) c)"},
+
+		"OR":       {wantErr: &ParseError{Pos: 0, Msg: "got TokenOr, want expr"}},
+		"a OR":     {wantErr: &ParseError{Pos: 4, Msg: "got TokenEOF, want expr"}},
+		"NOT":      {wantErr: &ParseError{Pos: 3, Msg: "got TokenEOF, want expr"}},
+		"a AND OR": {wantErr: &ParseError{Pos: 6, Msg: "got TokenOr, want expr"}},
+	}
+	for input, test := range tests {
+		t.Run(input, func(t *testing.T) {
+			query, err := Parse(input)
+			if test.wantErr != nil {
+				if !reflect.DeepEqual(err, test.wantErr) {
+					t.Fatalf("got err == %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query.Bool == nil {
+				t.Fatal("got Bool == nil")
+			}
+			if got := query.Bool.String(); got != test.wantBool {
+				t.Errorf("got %s, want %s", got, test.wantBool)
+			}
+		})
+	}
+
+	// Queries without operator keywords are not boolean queries. Lowercase
+	// keywords are searched for literally.
+	for _, input := range []string{"a b", "(a b)", "a (b c)", "order nothing", "not found", "read and write", "foo or", "(a or b)"} {
+		query, err := Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query.Bool != nil {
+			t.Errorf("%q: got Bool %s, want nil", input, query.Bool)
+		}
+	}
+}
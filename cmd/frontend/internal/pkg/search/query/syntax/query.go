@@ -3,12 +3,20 @@ package syntax
 import (
 	"bytes"
 	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/search/query"
 )
 
 // A Query contains the parse tree of a query.
 type Query struct {
 	Input string  // the original input query string
 	Expr  []*Expr // expressions in this query
+
+	// Bool is the boolean structure of Expr, or nil if the query does not
+	// use boolean operators. Its atoms are the *Expr values in Expr. Negating
+	// a single expression (with NOT or -) sets its Not field instead of
+	// adding a query.Not node.
+	Bool query.Q
 }
 
 // An Expr describes an expression in a query.
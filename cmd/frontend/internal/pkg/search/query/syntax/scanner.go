@@ -22,6 +22,11 @@ const (
 	TokenColon
 	TokenMinus
 	TokenSep // separator (like a semicolon)
+	TokenLParen
+	TokenRParen
+	TokenAnd
+	TokenOr
+	TokenNot
 )
 
 var singleCharTokens = map[rune]TokenType{
@@ -36,9 +41,34 @@ type Token struct {
 	Pos   int       // starting character position
 }
 
+// operatorTokens maps the keywords of boolean queries to their tokens. Only
+// the uppercase keywords are operators, so that existing queries such as
+// "not found" or "read and write" keep searching for the words.
+var operatorTokens = map[string]TokenType{
+	"AND": TokenAnd,
+	"OR":  TokenOr,
+	"NOT": TokenNot,
+}
+
 // Scan scans the query and returns a list of tokens.
+//
+// The keywords AND, OR and NOT and grouping parentheses are only recognized
+// if the query contains at least one of the keywords. Otherwise the query is
+// scanned as a flat list of terms, so that parentheses in patterns keep their
+// usual meaning.
 func Scan(input string) []Token {
-	s := &scanner{input: input}
+	tokens := scan(input, true)
+	for _, tok := range tokens {
+		switch tok.Type {
+		case TokenAnd, TokenOr, TokenNot:
+			return tokens
+		}
+	}
+	return scan(input, false)
+}
+
+func scan(input string, boolean bool) []Token {
+	s := &scanner{input: input, boolean: boolean}
 
 	for state := scanDefault; state != nil; {
 		state = state(s)
@@ -54,6 +84,17 @@ type scanner struct {
 	pos     int
 	prevPos int
 	start   int
+
+	// boolean is whether operator keywords and grouping parentheses are
+	// recognized.
+	boolean bool
+
+	// groups is the number of open grouping parentheses.
+	groups int
+
+	// depth is the number of open parentheses within the current term.
+	// They are part of the term rather than grouping parentheses.
+	depth int
 }
 
 func (s *scanner) next() rune {
@@ -88,6 +129,39 @@ func (s *scanner) emit(typ TokenType) {
 	s.start = s.pos
 }
 
+// emitLiteral emits the current term as a TokenLiteral, or as an operator
+// token if it is an operator keyword. Field values are never keywords.
+func (s *scanner) emitLiteral() {
+	afterColon := len(s.tokens) > 0 && s.tokens[len(s.tokens)-1].Type == TokenColon
+	if s.boolean && !afterColon {
+		if typ, ok := operatorTokens[s.input[s.start:s.pos]]; ok {
+			s.emit(typ)
+			return
+		}
+	}
+	s.emit(TokenLiteral)
+}
+
+// endsTerm reports whether r, which was just read, closes a grouping
+// parenthesis and so ends the current term. It updates the term's depth.
+func (s *scanner) endsTerm(r rune) bool {
+	if !s.boolean {
+		return false
+	}
+	switch r {
+	case '(':
+		s.depth++
+	case ')':
+		if s.depth == 0 && s.groups > 0 {
+			return true
+		}
+		if s.depth > 0 {
+			s.depth--
+		}
+	}
+	return false
+}
+
 func (s *scanner) emitError(msg string) {
 	s.tokens = append(s.tokens, Token{
 		Type:  TokenError,
@@ -106,6 +180,21 @@ func scanDefault(s *scanner) stateFn {
 	if !unicode.IsSpace(r) {
 		s.backup()
 		s.ignore()
+		s.depth = 0
+		if s.boolean {
+			if r == '(' && isGroup(s.input[s.pos+1:]) {
+				s.next()
+				s.groups++
+				s.emit(TokenLParen)
+				return scanDefault
+			}
+			if r == ')' && s.groups > 0 {
+				s.next()
+				s.groups--
+				s.emit(TokenRParen)
+				return scanDefault
+			}
+		}
 		if typ, ok := singleCharTokens[r]; ok {
 			s.next()
 			s.emit(typ)
@@ -133,7 +222,7 @@ func scanText(s *scanner) stateFn {
 			break
 		}
 		r := s.next()
-		if unicode.IsSpace(r) {
+		if unicode.IsSpace(r) || s.endsTerm(r) {
 			s.backup()
 			break
 		}
@@ -150,7 +239,7 @@ func scanText(s *scanner) stateFn {
 		}
 	}
 
-	s.emit(TokenLiteral)
+	s.emitLiteral()
 	return scanDefault
 }
 
@@ -159,7 +248,7 @@ func scanValue(s *scanner) stateFn {
 		return scanDefault
 	}
 	r := s.peek()
-	if unicode.IsSpace(r) {
+	if unicode.IsSpace(r) || (s.boolean && r == ')' && s.groups > 0) {
 		return scanDefault
 	}
 	if r == '"' || r == '\'' {
@@ -174,16 +263,39 @@ func scanLiteral(s *scanner) stateFn {
 			break
 		}
 		r := s.next()
-		if unicode.IsSpace(r) {
+		if unicode.IsSpace(r) || s.endsTerm(r) {
 			s.backup()
 			break
 		}
 	}
 
-	s.emit(TokenLiteral)
+	s.emitLiteral()
 	return scanDefault
 }
 
+// isGroup reports whether the text following an opening parenthesis at the
+// start of a term opens a group of terms. It does if the parenthesis is
+// balanced and contains whitespace, as in "(a OR b)". Otherwise it is part of
+// a pattern, as in "(a|b)".
+func isGroup(rest string) bool {
+	depth := 0
+	space := false
+	for _, r := range rest {
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth == 0 {
+				return space
+			}
+			depth--
+		}
+	}
+	return false
+}
+
 func scanQuoted(s *scanner) stateFn {
 	q := s.next()
 	escaped := false
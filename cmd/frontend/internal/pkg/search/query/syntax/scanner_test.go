@@ -67,6 +67,23 @@ func TestScanner(t *testing.T) {
 		"a /b/ c":  {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenPattern, TokenSep, TokenLiteral}, wantValues: []string{"a", " ", "b", " ", "c"}},
 		"a /b c":   {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenPattern}, wantValues: []string{"a", " ", "b c"}},
 		"a /b c/":  {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenPattern}, wantValues: []string{"a", " ", "b c"}},
+
+		// Boolean queries.
+		"a OR b":      {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenOr, TokenSep, TokenLiteral}},
+		"a AND NOT b": {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenAnd, TokenSep, TokenNot, TokenSep, TokenLiteral}},
+		"(a OR b)":    {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenOr, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a", " ", "OR", " ", "b", ")"}},
+		"(a) OR b":    {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenOr, TokenSep, TokenLiteral}, wantValues: []string{"(a)", " ", "OR", " ", "b"}},
+		"(f(x) OR b)": {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenOr, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", "f(x)", " ", "OR", " ", "b", ")"}},
+		"-(a OR b)":   {wantTypes: []TokenType{TokenMinus, TokenLParen, TokenLiteral, TokenSep, TokenOr, TokenSep, TokenLiteral, TokenRParen}},
+		"(a:OR OR b)": {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenColon, TokenLiteral, TokenSep, TokenOr, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a", ":", "OR", " ", "OR", " ", "b", ")"}},
+		"(a b)":       {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenLiteral}, wantValues: []string{"(a", " ", "b)"}},
+		"a) OR b":     {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenOr, TokenSep, TokenLiteral}, wantValues: []string{"a)", " ", "OR", " ", "b"}},
+
+		// Lowercase keywords are not operators.
+		"not found":      {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenLiteral}, wantValues: []string{"not", " ", "found"}},
+		"read and write": {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenLiteral, TokenSep, TokenLiteral}, wantValues: []string{"read", " ", "and", " ", "write"}},
+		"foo or":         {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenLiteral}, wantValues: []string{"foo", " ", "or"}},
+		"(a or b)":       {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenLiteral, TokenSep, TokenLiteral}, wantValues: []string{"(a", " ", "or", " ", "b)"}},
 	}
 	for input, test := range tests {
 		t.Run(input, func(t *testing.T) {
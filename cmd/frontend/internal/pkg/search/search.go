@@ -12,12 +12,21 @@ import (
 type PatternInfo struct {
 	Pattern         string
 	IsRegExp        bool
+	IsStructuralPat bool
 	IsWordMatch     bool
 	IsCaseSensitive bool
 	FileMatchLimit  int32
 
-	// We do not support IsMultiline
-	//IsMultiline     bool
+	// IsMultiline is true if the pattern may match across lines, so it must
+	// be evaluated against whole files.
+	IsMultiline bool
+
+	// AndPatterns are additional regexps which must all match a file's
+	// content, and NotPatterns are regexps which must not match it. They are
+	// set for queries using the boolean operators AND, OR and NOT.
+	AndPatterns []string
+	NotPatterns []string
+
 	IncludePattern  string
 	IncludePatterns []string
 	ExcludePattern  string
@@ -35,11 +44,16 @@ func (p *PatternInfo) IsEmpty() bool {
 
 // Validate returns a non-nil error if PatternInfo is not valid.
 func (p *PatternInfo) Validate() error {
-	if p.IsRegExp {
+	if p.IsRegExp && !p.IsStructuralPat {
 		if _, err := syntax.Parse(p.Pattern, syntax.Perl); err != nil {
 			return err
 		}
 	}
+	for _, expr := range append(append([]string(nil), p.AndPatterns...), p.NotPatterns...) {
+		if _, err := syntax.Parse(expr, syntax.Perl); err != nil {
+			return err
+		}
+	}
 
 	if p.PathPatternsAreRegExps {
 		if p.IncludePattern != "" {
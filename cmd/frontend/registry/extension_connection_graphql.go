@@ -82,6 +82,27 @@ func (r *registryExtensionConnectionResolver) compute(ctx context.Context) ([]gr
 			}
 			remote = append(remote, xs...)
 		}
+		if len(remote) > 0 {
+			if err := resolveRemoteExtensionVersionRanges(ctx, remote); err != nil {
+				r.err = err
+			}
+		}
+
+		// Omit remote extensions that are shadowed by local extensions with the same extension ID
+		// (which happens when they were imported from an extension registry archive).
+		if len(local) > 0 && len(remote) > 0 {
+			localIDs := make(map[string]struct{}, len(local))
+			for _, x := range local {
+				localIDs[x.ExtensionID()] = struct{}{}
+			}
+			keep := remote[:0]
+			for _, x := range remote {
+				if _, shadowed := localIDs[x.ExtensionID]; !shadowed {
+					keep = append(keep, x)
+				}
+			}
+			remote = keep
+		}
 
 		r.registryExtensions = make([]graphqlbackend.RegistryExtension, len(local)+len(remote))
 		copy(r.registryExtensions, local)
@@ -108,6 +129,32 @@ func (r *registryExtensionConnectionResolver) compute(ctx context.Context) ([]gr
 	return r.registryExtensions, r.err
 }
 
+// resolveRemoteExtensionVersionRanges replaces each remote extension that the viewer's settings pin
+// to a version range with the release with the greatest version in the range. Extensions whose
+// pinned release can't be fetched are left as-is (and the first such error is returned).
+func resolveRemoteExtensionVersionRanges(ctx context.Context, remote []*registry.Extension) error {
+	versionRanges, err := graphqlbackend.ViewerExtensionVersionRanges(ctx)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for i, x := range remote {
+		versionRange, ok := versionRanges[x.ExtensionID]
+		if !ok {
+			continue
+		}
+		pinned, err := getRemoteRegistryExtension(ctx, "extensionID", x.ExtensionID, versionRange)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		remote[i] = pinned
+	}
+	return firstErr
+}
+
 func (r *registryExtensionConnectionResolver) Nodes(ctx context.Context) ([]graphqlbackend.RegistryExtension, error) {
 	// See (*registryExtensionConnectionResolver).Error for why we ignore the error.
 	xs, _ := r.compute(ctx)
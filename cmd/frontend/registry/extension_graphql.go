@@ -54,7 +54,7 @@ func registryExtensionByID(ctx context.Context, id graphql.ID) (graphqlbackend.R
 	case registryExtensionID.LocalID != 0 && RegistryExtensionByIDInt32 != nil:
 		return RegistryExtensionByIDInt32(ctx, registryExtensionID.LocalID)
 	case registryExtensionID.RemoteID != nil:
-		x, err := getRemoteRegistryExtension(ctx, "uuid", registryExtensionID.RemoteID.UUID)
+		x, err := getRemoteRegistryExtension(ctx, "uuid", registryExtensionID.RemoteID.UUID, "")
 		if err != nil {
 			return nil, err
 		}
@@ -8,6 +8,7 @@ import (
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/app/ui/router"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 	"github.com/sourcegraph/sourcegraph/pkg/registry"
 )
 
@@ -49,6 +50,44 @@ func (r *registryExtensionRemoteResolver) Manifest(context.Context) (graphqlback
 	return NewExtensionManifest(r.v.Manifest), nil
 }
 
+func (r *registryExtensionRemoteResolver) Version(context.Context) (*string, error) {
+	return r.v.Version, nil
+}
+
+func (r *registryExtensionRemoteResolver) Releases(ctx context.Context) (*[]graphqlbackend.RegistryExtensionRelease, error) {
+	registryURL, err := url.Parse(r.v.RegistryURL)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := registry.ListReleases(ctx, registryURL, r.v.ExtensionID)
+	if err != nil {
+		if errcode.IsNotFound(err) {
+			// The remote registry does not support listing releases.
+			return nil, nil
+		}
+		return nil, err
+	}
+	resolvers := make([]graphqlbackend.RegistryExtensionRelease, len(releases))
+	for i, release := range releases {
+		resolvers[i] = &registryExtensionRemoteReleaseResolver{v: release}
+	}
+	return &resolvers, nil
+}
+
+// registryExtensionRemoteReleaseResolver implements the GraphQL type RegistryExtensionRelease with
+// data from a remote registry.
+type registryExtensionRemoteReleaseResolver struct {
+	v *registry.Release
+}
+
+func (r *registryExtensionRemoteReleaseResolver) Version() *string { return r.v.Version }
+
+func (r *registryExtensionRemoteReleaseResolver) PublishedAt() string {
+	return r.v.PublishedAt.Format(time.RFC3339)
+}
+
+func (r *registryExtensionRemoteReleaseResolver) Yanked() bool { return r.v.Yanked }
+
 func (r *registryExtensionRemoteResolver) CreatedAt() *string {
 	return strptr(r.v.CreatedAt.Format(time.RFC3339))
 }
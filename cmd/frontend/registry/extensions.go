@@ -17,6 +17,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/env"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 	"github.com/sourcegraph/sourcegraph/pkg/httputil"
 	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
 	"github.com/sourcegraph/sourcegraph/pkg/registry"
@@ -91,8 +92,16 @@ func ParseExtensionID(extensionID string) (prefix, extensionIDWithoutPrefix stri
 }
 
 // GetLocalExtensionByExtensionID looks up and returns the registry extension in the local registry
-// with the given extension ID. If there is no local extension registry, it is not implemented.
-var GetLocalExtensionByExtensionID func(ctx context.Context, extensionIDWithoutPrefix string) (local graphqlbackend.RegistryExtension, err error)
+// with the given extension ID. If versionRange is not empty, the extension's manifest is from the
+// release with the greatest version in the range. If there is no local extension registry, it is
+// not implemented.
+var GetLocalExtensionByExtensionID func(ctx context.Context, extensionIDWithoutPrefix, versionRange string) (local graphqlbackend.RegistryExtension, err error)
+
+// GetImportedExtensionByExtensionID looks up and returns the registry extension in the local
+// registry that was imported from an extension registry archive with the given (original)
+// extension ID. If versionRange is not empty, the extension's manifest is from the release with the
+// greatest version in the range. If there is no local extension registry, it is not implemented.
+var GetImportedExtensionByExtensionID func(ctx context.Context, extensionID, versionRange string) (local graphqlbackend.RegistryExtension, err error)
 
 // GetExtensionByExtensionID gets the extension with the given extension ID.
 //
@@ -102,7 +111,28 @@ var GetLocalExtensionByExtensionID func(ctx context.Context, extensionIDWithoutP
 // to the remote registry specified in site configuration (usually sourcegraph.com). The host must
 // be specified to refer to a local extension on the current Sourcegraph site (e.g.,
 // sourcegraph.example.com/publisher/name).
+//
+// Extensions imported into the local registry from an extension registry archive keep the
+// extension ID they had in the registry they were exported from, and they take precedence over
+// remote extensions with the same extension ID.
 func GetExtensionByExtensionID(ctx context.Context, extensionID string) (local graphqlbackend.RegistryExtension, remote *registry.Extension, err error) {
+	return GetExtensionByExtensionIDInVersionRange(ctx, extensionID, "")
+}
+
+// GetExtensionByExtensionIDInVersionRange is like GetExtensionByExtensionID, except that if
+// versionRange is not empty (such as "^1.2.0"), the extension's manifest is from the release with
+// the greatest version in the range.
+func GetExtensionByExtensionIDInVersionRange(ctx context.Context, extensionID, versionRange string) (local graphqlbackend.RegistryExtension, remote *registry.Extension, err error) {
+	if GetImportedExtensionByExtensionID != nil {
+		x, err := GetImportedExtensionByExtensionID(ctx, extensionID, versionRange)
+		if err == nil {
+			return x, nil, nil
+		}
+		if !errcode.IsNotFound(err) {
+			return nil, nil, err
+		}
+	}
+
 	_, extensionIDWithoutPrefix, isLocal, err := ParseExtensionID(extensionID)
 	if err != nil {
 		return nil, nil, err
@@ -110,12 +140,12 @@ func GetExtensionByExtensionID(ctx context.Context, extensionID string) (local g
 
 	if isLocal {
 		if GetLocalExtensionByExtensionID != nil {
-			x, err := GetLocalExtensionByExtensionID(ctx, extensionIDWithoutPrefix)
+			x, err := GetLocalExtensionByExtensionID(ctx, extensionIDWithoutPrefix, versionRange)
 			return x, nil, err
 		}
 	}
 
-	x, err := getRemoteRegistryExtension(ctx, "extensionID", extensionIDWithoutPrefix)
+	x, err := getRemoteRegistryExtension(ctx, "extensionID", extensionIDWithoutPrefix, versionRange)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -168,8 +198,10 @@ var IsRemoteExtensionAllowed = func(extensionID string) bool {
 var mockGetRemoteRegistryExtension func(field, value string) (*registry.Extension, error)
 
 // getRemoteRegistryExtension gets the remote registry extension and rewrites its fields to be from
-// the frame-of-reference of this site. The field is either "uuid" or "extensionID".
-func getRemoteRegistryExtension(ctx context.Context, field, value string) (*registry.Extension, error) {
+// the frame-of-reference of this site. The field is either "uuid" or "extensionID". If versionRange
+// is not empty (which is only supported for the "extensionID" field), the extension's manifest is
+// from the release with the greatest version in the range.
+func getRemoteRegistryExtension(ctx context.Context, field, value, versionRange string) (*registry.Extension, error) {
 	if mockGetRemoteRegistryExtension != nil {
 		return mockGetRemoteRegistryExtension(field, value)
 	}
@@ -184,7 +216,11 @@ func getRemoteRegistryExtension(ctx context.Context, field, value string) (*regi
 	case "uuid":
 		x, err = registry.GetByUUID(ctx, registryURL, value)
 	case "extensionID":
-		x, err = registry.GetByExtensionID(ctx, registryURL, value)
+		if versionRange != "" {
+			x, err = registry.GetByExtensionIDInVersionRange(ctx, registryURL, value, versionRange)
+		} else {
+			x, err = registry.GetByExtensionID(ctx, registryURL, value)
+		}
 	default:
 		panic("unexpected field: " + field)
 	}
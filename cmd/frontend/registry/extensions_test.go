@@ -105,7 +105,7 @@ func TestGetExtensionByExtensionID(t *testing.T) {
 		defer func() { mockLocalRegistryExtensionIDPrefix = nil }()
 
 		t.Run("2-part", func(t *testing.T) {
-			GetLocalExtensionByExtensionID = func(ctx context.Context, extensionID string) (graphqlbackend.RegistryExtension, error) {
+			GetLocalExtensionByExtensionID = func(ctx context.Context, extensionID, versionRange string) (graphqlbackend.RegistryExtension, error) {
 				if want := "a/b"; extensionID != want {
 					t.Errorf("got %q, want %q", extensionID, want)
 				}
@@ -129,6 +129,19 @@ func TestGetExtensionByExtensionID(t *testing.T) {
 				t.Fatal()
 			}
 		})
+
+		t.Run("version range", func(t *testing.T) {
+			GetLocalExtensionByExtensionID = func(ctx context.Context, extensionID, versionRange string) (graphqlbackend.RegistryExtension, error) {
+				if want := "^1.2.0"; versionRange != want {
+					t.Errorf("got version range %q, want %q", versionRange, want)
+				}
+				return &mockRegistryExtension{id: 1, name: "b"}, nil
+			}
+			defer func() { GetLocalExtensionByExtensionID = nil }()
+			if _, _, err := GetExtensionByExtensionIDInVersionRange(ctx, "a/b", "^1.2.0"); err != nil {
+				t.Fatal(err)
+			}
+		})
 	})
 
 	t.Run("non-root", func(t *testing.T) {
@@ -159,7 +172,7 @@ func TestGetExtensionByExtensionID(t *testing.T) {
 		})
 
 		t.Run("3-part", func(t *testing.T) {
-			GetLocalExtensionByExtensionID = func(ctx context.Context, extensionID string) (graphqlbackend.RegistryExtension, error) {
+			GetLocalExtensionByExtensionID = func(ctx context.Context, extensionID, versionRange string) (graphqlbackend.RegistryExtension, error) {
 				if want := "b/c"; extensionID != want {
 					t.Errorf("got %q, want %q", extensionID, want)
 				}
@@ -179,6 +192,44 @@ func TestGetExtensionByExtensionID(t *testing.T) {
 		})
 	})
 
+	t.Run("imported", func(t *testing.T) {
+		mockLocalRegistryExtensionIDPrefix = strptrptr("x")
+		defer func() { mockLocalRegistryExtensionIDPrefix = nil }()
+		GetImportedExtensionByExtensionID = func(ctx context.Context, extensionID, versionRange string) (graphqlbackend.RegistryExtension, error) {
+			if extensionID != "a/b" {
+				return nil, importedNotFoundError{}
+			}
+			return &mockRegistryExtension{id: 1, name: "b"}, nil
+		}
+		defer func() { GetImportedExtensionByExtensionID = nil }()
+		mockGetRemoteRegistryExtension = func(field, value string) (*registry.Extension, error) {
+			return &registry.Extension{UUID: "u", ExtensionID: value}, nil
+		}
+		defer func() { mockGetRemoteRegistryExtension = nil }()
+
+		local, remote, err := GetExtensionByExtensionID(ctx, "a/b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (&mockRegistryExtension{id: 1, name: "b"}); !reflect.DeepEqual(local, want) {
+			t.Errorf("got %+v, want %+v", local, want)
+		}
+		if remote != nil {
+			t.Error("imported extension should take precedence over remote extension")
+		}
+
+		local, remote, err = GetExtensionByExtensionID(ctx, "a/c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if local != nil {
+			t.Error()
+		}
+		if want := (&registry.Extension{UUID: "u", ExtensionID: "a/c"}); !reflect.DeepEqual(remote, want) {
+			t.Errorf("got %+v, want %+v", remote, want)
+		}
+	})
+
 	t.Run("invalid extension ID", func(t *testing.T) {
 		if _, _, err := GetExtensionByExtensionID(ctx, "a/b/c/d"); err == nil {
 			t.Fatal()
@@ -186,6 +237,11 @@ func TestGetExtensionByExtensionID(t *testing.T) {
 	})
 }
 
+type importedNotFoundError struct{}
+
+func (importedNotFoundError) Error() string  { return "not found" }
+func (importedNotFoundError) NotFound() bool { return true }
+
 func TestIsWorkInProgressExtension(t *testing.T) {
 	tests := map[*string]bool{
 		nil:                                        true,
@@ -33,6 +33,9 @@ type extensionRegistryResolver struct {
 	UpdateExtensionFunc  func(context.Context, *graphqlbackend.ExtensionRegistryUpdateExtensionArgs) (graphqlbackend.ExtensionRegistryMutationResult, error)
 	PublishExtensionFunc func(context.Context, *graphqlbackend.ExtensionRegistryPublishExtensionArgs) (graphqlbackend.ExtensionRegistryMutationResult, error)
 	DeleteExtensionFunc  func(context.Context, *graphqlbackend.ExtensionRegistryDeleteExtensionArgs) (*graphqlbackend.EmptyResponse, error)
+	SetReleaseYankedFunc func(context.Context, *graphqlbackend.ExtensionRegistrySetReleaseYankedArgs) (*graphqlbackend.EmptyResponse, error)
+	ExportExtensionsFunc func(context.Context, *graphqlbackend.ExtensionRegistryExportExtensionsArgs) (string, error)
+	ImportExtensionsFunc func(context.Context, *graphqlbackend.ExtensionRegistryImportExtensionsArgs) ([]graphqlbackend.RegistryExtension, error)
 }
 
 var errNoLocalExtensionRegistry = errors.New("no local extension registry exists")
@@ -52,11 +55,23 @@ func (r *extensionRegistryResolver) ViewerPublishers(ctx context.Context) ([]gra
 }
 
 func (*extensionRegistryResolver) Extension(ctx context.Context, args *graphqlbackend.ExtensionRegistryExtensionArgs) (graphqlbackend.RegistryExtension, error) {
-	return getExtensionByExtensionID(ctx, args.ExtensionID)
+	// An explicit version range takes precedence over the version range (if any) that the viewer's
+	// settings pin the extension to.
+	var versionRange string
+	if args.Version != nil {
+		versionRange = *args.Version
+	} else {
+		versionRanges, err := graphqlbackend.ViewerExtensionVersionRanges(ctx)
+		if err != nil {
+			return nil, err
+		}
+		versionRange = versionRanges[args.ExtensionID]
+	}
+	return getExtensionByExtensionID(ctx, args.ExtensionID, versionRange)
 }
 
-func getExtensionByExtensionID(ctx context.Context, extensionID string) (graphqlbackend.RegistryExtension, error) {
-	local, remote, err := GetExtensionByExtensionID(ctx, extensionID)
+func getExtensionByExtensionID(ctx context.Context, extensionID, versionRange string) (graphqlbackend.RegistryExtension, error) {
+	local, remote, err := GetExtensionByExtensionIDInVersionRange(ctx, extensionID, versionRange)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +112,27 @@ func (r *extensionRegistryResolver) DeleteExtension(ctx context.Context, args *g
 	return r.DeleteExtensionFunc(ctx, args)
 }
 
+func (r *extensionRegistryResolver) SetReleaseYanked(ctx context.Context, args *graphqlbackend.ExtensionRegistrySetReleaseYankedArgs) (*graphqlbackend.EmptyResponse, error) {
+	if r.SetReleaseYankedFunc == nil {
+		return nil, errNoLocalExtensionRegistry
+	}
+	return r.SetReleaseYankedFunc(ctx, args)
+}
+
+func (r *extensionRegistryResolver) ExportExtensions(ctx context.Context, args *graphqlbackend.ExtensionRegistryExportExtensionsArgs) (string, error) {
+	if r.ExportExtensionsFunc == nil {
+		return "", errNoLocalExtensionRegistry
+	}
+	return r.ExportExtensionsFunc(ctx, args)
+}
+
+func (r *extensionRegistryResolver) ImportExtensions(ctx context.Context, args *graphqlbackend.ExtensionRegistryImportExtensionsArgs) ([]graphqlbackend.RegistryExtension, error) {
+	if r.ImportExtensionsFunc == nil {
+		return nil, errNoLocalExtensionRegistry
+	}
+	return r.ImportExtensionsFunc(ctx, args)
+}
+
 func (*extensionRegistryResolver) LocalExtensionIDPrefix() *string {
 	return GetLocalRegistryExtensionIDPrefix()
 }
@@ -104,7 +140,7 @@ func (*extensionRegistryResolver) LocalExtensionIDPrefix() *string {
 // ImplementsLocalExtensionRegistry reports whether there is an implementation of a local extension
 // registry (which is a Sourcegraph Enterprise feature).
 func (r *extensionRegistryResolver) ImplementsLocalExtensionRegistry() bool {
-	return r.ViewerPublishersFunc != nil && r.PublishersFunc != nil && r.CreateExtensionFunc != nil && r.UpdateExtensionFunc != nil && r.PublishExtensionFunc != nil && r.DeleteExtensionFunc != nil
+	return r.ViewerPublishersFunc != nil && r.PublishersFunc != nil && r.CreateExtensionFunc != nil && r.UpdateExtensionFunc != nil && r.PublishExtensionFunc != nil && r.DeleteExtensionFunc != nil && r.SetReleaseYankedFunc != nil && r.ExportExtensionsFunc != nil && r.ImportExtensionsFunc != nil
 }
 
 func (r *extensionRegistryResolver) FilterRemoteExtensions(ids []string) []string {
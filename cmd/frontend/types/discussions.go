@@ -47,12 +47,36 @@ func (d *DiscussionThreadTargetRepo) HasSelection() bool {
 // DiscussionComment mirrors the underlying discussion_comments field types exactly.
 // It intentionally does not try to e.g. alleviate null fields.
 type DiscussionComment struct {
-	ID           int64
-	ThreadID     int64
-	AuthorUserID int32
-	Contents     string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	DeletedAt    *time.Time
-	Reports      []string
+	ID             int64
+	ThreadID       int64
+	AuthorUserID   *int32  // nil if ExternalAuthor is set
+	ExternalAuthor *string // the author of a comment imported from a code host user without a Sourcegraph account
+	Contents       string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      *time.Time
+	Reports        []string
+}
+
+// DiscussionThreadExternal mirrors the underlying discussion_threads_external field types exactly.
+// It intentionally does not try to e.g. alleviate null fields.
+type DiscussionThreadExternal struct {
+	ThreadID      int64
+	RepoID        api.RepoID
+	ServiceType   string
+	ServiceID     string
+	PullRequestID string
+	ExternalID    string
+	CreatedAt     time.Time
+}
+
+// DiscussionCommentExternal mirrors the underlying discussion_comments_external field types exactly.
+// It intentionally does not try to e.g. alleviate null fields.
+type DiscussionCommentExternal struct {
+	CommentID         int64
+	ThreadID          int64
+	ExternalID        string
+	Imported          bool
+	SyncedAt          time.Time
+	ExternalUpdatedAt time.Time
 }
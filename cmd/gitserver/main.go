@@ -18,14 +18,16 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
 	"github.com/sourcegraph/sourcegraph/pkg/debugserver"
 	"github.com/sourcegraph/sourcegraph/pkg/env"
+	gitserverclient "github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/tracer"
 )
 
 var (
-	reposDir          = env.Get("SRC_REPOS_DIR", "/data/repos", "Root dir containing repos.")
-	runRepoCleanup, _ = strconv.ParseBool(env.Get("SRC_RUN_REPO_CLEANUP", "", "Periodically remove inactive repositories."))
-	wantFreeG         = env.Get("SRC_REPOS_DESIRED_FREE_GB", "10", "How many gigabytes of space to keep free on the disk with the repos")
-	janitorInterval   = env.Get("SRC_REPOS_JANITOR_INTERVAL", "1m", "Interval between cleanup runs")
+	reposDir            = env.Get("SRC_REPOS_DIR", "/data/repos", "Root dir containing repos.")
+	runRepoCleanup, _   = strconv.ParseBool(env.Get("SRC_RUN_REPO_CLEANUP", "", "Periodically remove inactive repositories."))
+	wantFreeG           = env.Get("SRC_REPOS_DESIRED_FREE_GB", "10", "How many gigabytes of space to keep free on the disk with the repos")
+	janitorInterval     = env.Get("SRC_REPOS_JANITOR_INTERVAL", "1m", "Interval between cleanup runs")
+	migrateFromPeers, _ = strconv.ParseBool(env.Get("SRC_GITSERVER_MIGRATE_FROM_PEERS", "true", "Clone repositories from other gitservers and hand over repositories this gitserver no longer owns."))
 )
 
 func main() {
@@ -44,10 +46,17 @@ func main() {
 	if err != nil {
 		log.Fatalf("parsing $SRC_REPOS_DESIRED_FREE_GB: %v", err)
 	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("failed to get hostname: %s", err)
+	}
 	gitserver := server.Server{
 		ReposDir:                reposDir,
 		DeleteStaleRepositories: runRepoCleanup,
 		DesiredFreeDiskSpace:    uint64(wantFreeG2 * 1024 * 1024 * 1024),
+		MigrateFromPeers:        migrateFromPeers,
+		Hostname:                hostname,
+		PeerAddrs:               gitserverclient.DefaultClient.Addrs,
 	}
 	gitserver.RegisterMetrics()
 
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/repotrackutil"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// A batch exec request runs several git commands against one repository, so
+// that clients which need the output of many small commands (eg to render a
+// page) make one request instead of one per command. Only read-only commands
+// are allowed in a batch.
+
+// batchSubcommands are the git subcommands allowed in a batch.
+var batchSubcommands = map[string]bool{
+	"branch":       true, // only when listing, see checkBatchArgs
+	"cat-file":     true,
+	"diff":         true,
+	"for-each-ref": true,
+	"log":          true,
+	"ls-tree":      true,
+	"merge-base":   true,
+	"rev-list":     true,
+	"rev-parse":    true,
+	"show":         true,
+	"show-ref":     true,
+}
+
+// checkBatchArgs returns an error if args may not be run in a batch.
+func checkBatchArgs(args []string) error {
+	if len(args) == 0 {
+		return errors.New("empty command")
+	}
+	if !batchSubcommands[args[0]] {
+		return errors.Errorf("git %s is not allowed in a batch", args[0])
+	}
+	if args[0] == "branch" && !isBranchListArgs(args[1:]) {
+		return errors.New("git branch is only allowed in a batch when listing branches")
+	}
+	for _, arg := range args[1:] {
+		// 🚨 SECURITY: --output writes to an arbitrary file and --no-index
+		// diffs arbitrary files outside of the repository.
+		if arg == "--output" || strings.HasPrefix(arg, "--output=") || arg == "--no-index" {
+			return errors.Errorf("git %s %s is not allowed in a batch", args[0], arg)
+		}
+	}
+	return nil
+}
+
+// isBranchListArgs reports whether git branch with args lists branches
+// instead of creating, renaming or deleting one. Only the argument lists
+// sent by pkg/vcs/git are allowed: no arguments, --list, or a single
+// --merged or --contains option with its value.
+func isBranchListArgs(args []string) bool {
+	isValue := func(v string) bool { return v != "" && !strings.HasPrefix(v, "-") }
+	switch len(args) {
+	case 0:
+		return true
+	case 1:
+		a := args[0]
+		switch {
+		case a == "--list":
+			return true
+		case strings.HasPrefix(a, "--merged="):
+			return isValue(strings.TrimPrefix(a, "--merged="))
+		case strings.HasPrefix(a, "--contains="):
+			return isValue(strings.TrimPrefix(a, "--contains="))
+		}
+	case 2:
+		return (args[0] == "--merged" || args[0] == "--contains") && isValue(args[1])
+	}
+	return false
+}
+
+func (s *Server) handleBatchExec(w http.ResponseWriter, r *http.Request) {
+	var req protocol.BatchExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Cmds) > protocol.MaxBatchExecCmds {
+		http.Error(w, fmt.Sprintf("too many commands in batch (%d > %d)", len(req.Cmds), protocol.MaxBatchExecCmds), http.StatusBadRequest)
+		return
+	}
+	for _, args := range req.Cmds {
+		if err := checkBatchArgs(args); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Flush each result as soon as it is written, so clients can start
+	// reading results before the whole batch has run.
+	if fw := newFlushingResponseWriter(w); fw != nil {
+		w = fw
+		defer fw.Close()
+	}
+
+	ctx := r.Context()
+	req.Repo = protocol.NormalizeRepo(req.Repo)
+	repo := repotrackutil.GetTrackedRepo(req.Repo)
+
+	var tr *trace.Trace
+	tr, ctx = trace.New(ctx, "batchExec", string(req.Repo))
+	tr.LazyPrintf("cmds: %d", len(req.Cmds))
+	defer tr.Finish()
+
+	dir := path.Join(s.ReposDir, string(req.Repo))
+	if status := s.respondIfNotCloned(ctx, w, req.Repo, req.URL, dir); status != "" {
+		tr.LazyPrintf("status: %s", status)
+		return
+	}
+	s.ensureRevision(ctx, req.Repo, req.URL, req.EnsureRevision, dir)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	for _, args := range req.Cmds {
+		start := time.Now()
+		execRunning.WithLabelValues(args[0], repo).Inc()
+		result := s.batchExecOne(ctx, dir, args)
+		execRunning.WithLabelValues(args[0], repo).Dec()
+
+		duration := time.Since(start)
+		execDuration.WithLabelValues(args[0], repo, strconv.Itoa(result.ExitStatus)).Observe(duration.Seconds())
+		if duration > shortGitCommandSlow(args) {
+			log15.Warn("Long batch exec command", "repo", req.Repo, "args", args, "duration", duration.Round(time.Millisecond))
+		}
+
+		if err := enc.Encode(result); err != nil {
+			// The client went away.
+			tr.SetError(err)
+			return
+		}
+	}
+}
+
+// batchExecOne runs git with args in dir and returns its result.
+func (s *Server) batchExecOne(ctx context.Context, dir string, args []string) *protocol.BatchExecResult {
+	// See the special case of `git rev-parse HEAD` in handleExec.
+	if len(args) == 2 && args[0] == "rev-parse" && args[1] == "HEAD" {
+		if resolved, err := quickRevParseHead(dir); err == nil && git.IsAbsoluteRevision(resolved) {
+			return &protocol.BatchExecResult{Stdout: []byte(resolved)}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, shortGitCommandTimeout(args))
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	exitStatus, err := runCommand(ctx, cmd)
+
+	stderrStr := stderr.String()
+	if len(stderrStr) > 1024 {
+		stderrStr = stderrStr[:1024]
+	}
+	return &protocol.BatchExecResult{
+		Stdout:     stdout.Bytes(),
+		Stderr:     stderrStr,
+		ExitStatus: exitStatus,
+		Error:      errorString(err),
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+)
+
+func TestCheckBatchArgs(t *testing.T) {
+	allowed := [][]string{
+		{"rev-parse", "HEAD"},
+		{"ls-tree", "--full-name", "HEAD", "--", "dir/"},
+		{"log", "-n", "1", "--format=%H", "HEAD"},
+		{"branch"},
+		{"branch", "--merged", "master"},
+		{"branch", "--contains=abc"},
+		{"branch", "--list"},
+		{"diff", "--output-indicator-new=>", "HEAD~1", "HEAD"},
+	}
+	for _, args := range allowed {
+		if err := checkBatchArgs(args); err != nil {
+			t.Errorf("expected %q to be allowed, got error: %s", args, err)
+		}
+	}
+
+	disallowed := [][]string{
+		{},
+		{"fetch", "origin"},
+		{"update-ref", "refs/heads/x", "HEAD"},
+		{"branch", "new-branch"},
+		{"branch", "-D", "master"},
+		{"branch", "--merged", "master", "-D", "other"},
+		{"branch", "--contains=abc", "new-branch"},
+		{"branch", "--list", "-d", "master"},
+		{"branch", "--merged", "--delete"},
+		{"branch", "--mergedx"},
+		{"log", "--output=/tmp/x"},
+		{"diff", "--no-index", "/etc/passwd", "/dev/null"},
+	}
+	for _, args := range disallowed {
+		if err := checkBatchArgs(args); err == nil {
+			t.Errorf("expected %q to be disallowed", args)
+		}
+	}
+}
+
+func TestHandleBatchExec(t *testing.T) {
+	reposDir, cleanup := tmpDir(t)
+	defer cleanup()
+
+	dir := filepath.Join(reposDir, "example.com", "repo")
+	runGit(t, reposDir, "init", "--quiet", dir)
+	runGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "first")
+	head := runGit(t, dir, "rev-parse", "HEAD")
+
+	s := &Server{ReposDir: reposDir}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	post := func(req *protocol.BatchExecRequest) *http.Response {
+		t.Helper()
+		body, err := json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Post(srv.URL+"/batch-exec", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := post(&protocol.BatchExecRequest{
+		Repo: "example.com/repo",
+		Cmds: [][]string{
+			{"rev-parse", "HEAD"},
+			{"log", "-n", "1", "--format=%s", "HEAD"},
+			{"rev-parse", "--verify", "doesnotexist"},
+		},
+	})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+	var results []protocol.BatchExecResult
+	dec := json.NewDecoder(resp.Body)
+	for dec.More() {
+		var result protocol.BatchExecResult
+		if err := dec.Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, result)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3: %+v", len(results), results)
+	}
+	if got := strings.TrimSpace(string(results[0].Stdout)); got != head || results[0].ExitStatus != 0 {
+		t.Errorf("got rev-parse result %+v, want %s", results[0], head)
+	}
+	if got := strings.TrimSpace(string(results[1].Stdout)); got != "first" {
+		t.Errorf("got log output %q, want %q", got, "first")
+	}
+	if results[2].ExitStatus == 0 || results[2].Error == "" || results[2].Stderr == "" {
+		t.Errorf("expected rev-parse of a missing revision to fail, got %+v", results[2])
+	}
+
+	resp = post(&protocol.BatchExecRequest{
+		Repo: "example.com/repo",
+		Cmds: [][]string{{"rev-parse", "HEAD"}, {"push", "origin"}},
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d for a disallowed command, want 400", resp.StatusCode)
+	}
+
+	resp = post(&protocol.BatchExecRequest{
+		Repo: "example.com/missing",
+		Cmds: [][]string{{"rev-parse", "HEAD"}},
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %d for a missing repo, want 404", resp.StatusCode)
+	}
+}
+
+func TestBatchRun_manyCommands(t *testing.T) {
+	reposDir, cleanup := tmpDir(t)
+	defer cleanup()
+
+	dir := filepath.Join(reposDir, "example.com", "repo")
+	runGit(t, reposDir, "init", "--quiet", dir)
+	runGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "first")
+	head := runGit(t, dir, "rev-parse", "HEAD")
+
+	s := &Server{ReposDir: reposDir}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	client := &gitserver.Client{
+		HTTPClient: http.DefaultClient,
+		Addrs: func(context.Context) []string {
+			return []string{strings.TrimPrefix(srv.URL, "http://")}
+		},
+	}
+
+	// The batch is larger than a single request may be.
+	batch := client.Batch(gitserver.Repo{Name: "example.com/repo"})
+	cmds := make([]*gitserver.BatchCmd, 2*protocol.MaxBatchExecCmds+1)
+	for i := range cmds {
+		cmds[i] = batch.Command("git", "rev-parse", "HEAD")
+	}
+	if err := batch.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i, cmd := range cmds {
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("command %d: %s", i, err)
+		}
+		if got := strings.TrimSpace(string(out)); got != head {
+			t.Errorf("command %d: got %q, want %q", i, got, head)
+		}
+	}
+}
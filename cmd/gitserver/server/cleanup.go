@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
-	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -29,8 +28,6 @@ func init() {
 	prometheus.MustRegister(reposRecloned)
 }
 
-const repoTTL = time.Hour * 24 * 45
-
 var reposRemoved = prometheus.NewCounter(prometheus.CounterOpts{
 	Namespace: "src",
 	Subsystem: "gitserver",
@@ -41,7 +38,7 @@ var reposRecloned = prometheus.NewCounter(prometheus.CounterOpts{
 	Namespace: "src",
 	Subsystem: "gitserver",
 	Name:      "repos_recloned",
-	Help:      "number of repos removed and recloned due to age",
+	Help:      "number of repos removed and recloned due to corruption",
 })
 
 // cleanupRepos walks the repos directory and performs maintenance tasks:
@@ -49,7 +46,9 @@ var reposRecloned = prometheus.NewCounter(prometheus.CounterOpts{
 // 1. Remove corrupt repos.
 // 2. Remove stale lock files.
 // 3. Remove inactive repos on sourcegraph.com
-// 4. Reclone repos after a while. (simulate git gc)
+// 4. Hand over repos owned by another gitserver. (if MigrateFromPeers)
+// 5. Reclone repos git reported as corrupt during maintenance.
+// 6. Repack repos with too many loose objects or packs.
 func (s *Server) cleanupRepos() {
 	bCtx, bCancel := s.serverContext()
 	defer bCancel()
@@ -70,28 +69,26 @@ func (s *Server) cleanupRepos() {
 		return true, nil
 	}
 
+	maybeHandOver := func(gitDir string) (done bool, err error) {
+		return s.maybeHandOver(bCtx, gitDir)
+	}
+
 	ensureGitAttributes := func(gitDir string) (done bool, err error) {
 		return false, setGitAttributes(gitDir)
 	}
 
-	maybeReclone := func(gitDir string) (done bool, err error) {
-		recloneTime, err := getRecloneTime(gitDir)
-		if err != nil {
+	maybeRecloneCorrupt := func(gitDir string) (done bool, err error) {
+		result, err := readMaintenanceResult(gitDir)
+		if err != nil || result == nil || !result.Corrupt {
 			return false, err
 		}
 
-		// Add a jitter to spread out recloning of repos cloned at the same
-		// time.
-		if time.Since(recloneTime) <= repoTTL+randDuration(repoTTL/4) {
-			return false, nil
-		}
-
 		ctx, cancel := context.WithTimeout(bCtx, longGitCommandTimeout)
 		defer cancel()
 
 		// name is the relative path to ReposDir, but without the .git suffix.
 		repo := protocol.NormalizeRepo(api.RepoName(strings.TrimPrefix(filepath.Dir(gitDir), s.ReposDir+"/")))
-		log15.Info("recloning expired repo", "repo", repo)
+		log15.Info("recloning corrupt repo", "repo", repo, "error", result.Error)
 
 		remoteURL, err := repoRemoteURL(ctx, gitDir)
 		if err != nil {
@@ -105,6 +102,12 @@ func (s *Server) cleanupRepos() {
 		return true, nil
 	}
 
+	maintain := func(gitDir string) (done bool, err error) {
+		ctx, cancel := context.WithTimeout(bCtx, longGitCommandTimeout)
+		defer cancel()
+		return false, s.maintainRepo(ctx, gitDir)
+	}
+
 	removeStaleLocks := func(gitDir string) (done bool, err error) {
 		// if removing a lock fails, we still want to try the other locks.
 		var multi error
@@ -148,18 +151,22 @@ func (s *Server) cleanupRepos() {
 	cleanups := []cleanupFn{
 		// Do some sanity checks on the repository.
 		{"maybe remove corrupt", maybeRemoveCorrupt},
+		// Repositories this gitserver no longer owns are handed over to
+		// their owner rather than maintained.
+		{"maybe hand over", maybeHandOver},
 		// If git is interrupted it can leave lock files lying around. It does
 		// not clean these up, and instead fails commands.
 		{"remove stale locks", removeStaleLocks},
 		// We always want to have the same git attributes file at
 		// info/attributes.
 		{"ensure git attributes", ensureGitAttributes},
+		// A repository git reported as corrupt can not be repaired, so we
+		// replace it with a fresh clone.
+		{"maybe reclone corrupt", maybeRecloneCorrupt},
+		// Old git clones accumulate loose git objects and packs that waste
+		// space and slow down git operations.
+		{"maintain", maintain},
 	}
-	// Old git clones accumulate loose git objects that waste space and
-	// slow down git operations. Periodically do a fresh clone to avoid
-	// these problems. git gc is slow and resource intensive. It is
-	// cheaper and faster to just reclone the repository.
-	cleanups = append(cleanups, cleanupFn{"maybe reclone", maybeReclone})
 
 	err := filepath.Walk(s.ReposDir, func(gitDir string, fi os.FileInfo, fileErr error) error {
 		if fileErr != nil {
@@ -586,11 +593,6 @@ func getRecloneTime(gitDir string) (time.Time, error) {
 	return time.Unix(sec, 0), nil
 }
 
-// randDuration returns a psuedo-random duration between [0, d)
-func randDuration(d time.Duration) time.Duration {
-	return time.Duration(rand.Int63n(int64(d)))
-}
-
 // wrapCmdError will wrap errors for cmd to include the arguments. If the error
 // is an exec.ExitError and cmd was invoked with Output(), it will also include
 // the captured stderr.
@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -53,28 +54,142 @@ func TestCleanupInactive(t *testing.T) {
 	}
 }
 
-func TestCleanupExpired(t *testing.T) {
-	root, err := ioutil.TempDir("", "gitserver-test-")
-	if err != nil {
+func TestCleanupMaintenance(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+
+	defer func(loose, packs int) {
+		looseObjectsThreshold, packsThreshold = loose, packs
+	}(looseObjectsThreshold, packsThreshold)
+	looseObjectsThreshold, packsThreshold = 3, 2
+
+	// repoA has too many loose objects, repoB has too many packs and repoC
+	// has neither.
+	repoA := filepath.Join(root, testRepoA)
+	repoB := filepath.Join(root, testRepoB)
+	repoC := filepath.Join(root, testRepoC)
+	for _, dir := range []string{repoA, repoB, repoC} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "init", ".")
+	}
+	for i := 0; i < 3; i++ {
+		runGit(t, repoA, "commit", "--allow-empty", "-m", strconv.Itoa(i))
+		runGit(t, repoB, "commit", "--allow-empty", "-m", strconv.Itoa(i))
+		runGit(t, repoB, "repack", "-q")
+	}
+	runGit(t, repoC, "commit", "--allow-empty", "-m", "first")
+	runGit(t, repoC, "gc", "--quiet")
+	runGit(t, repoC, "commit-graph", "write", "--reachable")
+
+	s := &Server{ReposDir: root}
+	s.Handler() // Handler as a side-effect sets up Server
+	s.cleanupRepos()
+
+	for dir, wantTasks := range map[string][]string{
+		repoA: {"repack", "commit-graph"},
+		repoB: {"gc", "commit-graph"},
+	} {
+		gitDir := filepath.Join(dir, ".git")
+		counts, err := countObjects(context.Background(), gitDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counts.loose != 0 || counts.packs != 1 {
+			t.Errorf("%s: got %d loose objects and %d packs after maintenance, want 0 and 1", dir, counts.loose, counts.packs)
+		}
+		if !commitGraphExists(gitDir) {
+			t.Errorf("%s: expected commit-graph to be written", dir)
+		}
+		result, err := readMaintenanceResult(gitDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result == nil {
+			t.Fatalf("%s: expected maintenance result to be recorded", dir)
+		}
+		if !reflect.DeepEqual(result.Tasks, wantTasks) || result.Error != "" || result.Corrupt {
+			t.Errorf("%s: unexpected maintenance result %+v", dir, result)
+		}
+	}
+
+	if result, err := readMaintenanceResult(filepath.Join(repoC, ".git")); err != nil {
 		t.Fatal(err)
+	} else if result != nil {
+		t.Errorf("expected repoC to not be maintained, got %+v", result)
 	}
-	defer os.RemoveAll(root)
+}
 
-	repoA := path.Join(root, testRepoA, ".git")
-	cmd := exec.Command("git", "--bare", "init", repoA)
-	if err := cmd.Run(); err != nil {
+func TestCleanupMaintenanceBackoff(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+
+	defer func(loose int) { looseObjectsThreshold = loose }(looseObjectsThreshold)
+	looseObjectsThreshold = 0
+
+	repoA := filepath.Join(root, testRepoA)
+	if err := os.MkdirAll(repoA, os.ModePerm); err != nil {
 		t.Fatal(err)
 	}
-	repoB := path.Join(root, testRepoB, ".git")
-	cmd = exec.Command("git", "--bare", "init", repoB)
-	if err := cmd.Run(); err != nil {
+	runGit(t, repoA, "init", ".")
+	runGit(t, repoA, "commit", "--allow-empty", "-m", "first")
+	gitDir := filepath.Join(repoA, ".git")
+
+	s := &Server{ReposDir: root}
+	s.Handler() // Handler as a side-effect sets up Server
+
+	// A recently failed run is not retried.
+	failed := &maintenanceResult{Time: time.Now(), Tasks: []string{"repack"}, Error: "failed", Failures: 2}
+	if err := writeMaintenanceResult(gitDir, failed); err != nil {
 		t.Fatal(err)
 	}
-	remote := path.Join(root, testRepoC, ".git")
-	cmd = exec.Command("git", "--bare", "init", remote)
-	if err := cmd.Run(); err != nil {
+	s.cleanupRepos()
+	if result, err := readMaintenanceResult(gitDir); err != nil {
+		t.Fatal(err)
+	} else if result.Error != "failed" {
+		t.Errorf("expected maintenance to be skipped, got %+v", result)
+	}
+
+	// Once the backoff has passed it is retried.
+	failed.Time = time.Now().Add(-maintenanceRetryDelay(failed.Failures) - time.Minute)
+	if err := writeMaintenanceResult(gitDir, failed); err != nil {
+		t.Fatal(err)
+	}
+	s.cleanupRepos()
+	if result, err := readMaintenanceResult(gitDir); err != nil {
+		t.Fatal(err)
+	} else if result.Error != "" || result.Failures != 0 || result.LooseObjects == 0 {
+		t.Errorf("expected maintenance to be retried and succeed, got %+v", result)
+	}
+
+	for failures, want := range map[int]time.Duration{
+		0:  maintenanceBackoff,
+		1:  maintenanceBackoff,
+		2:  2 * maintenanceBackoff,
+		3:  4 * maintenanceBackoff,
+		20: maxMaintenanceBackoff,
+	} {
+		if got := maintenanceRetryDelay(failures); got != want {
+			t.Errorf("maintenanceRetryDelay(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
+
+func TestCleanupRecloneCorrupt(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+
+	defer func(loose int) { looseObjectsThreshold = loose }(looseObjectsThreshold)
+	looseObjectsThreshold = 0
+
+	remote := filepath.Join(root, testRepoC)
+	if err := os.MkdirAll(remote, os.ModePerm); err != nil {
 		t.Fatal(err)
 	}
+	runGit(t, remote, "init", ".")
+	runGit(t, remote, "commit", "--allow-empty", "-m", "first")
+	wantCommit := runGit(t, remote, "rev-parse", "HEAD")
 
 	origRepoRemoteURL := repoRemoteURL
 	repoRemoteURL = func(ctx context.Context, dir string) (string, error) {
@@ -82,38 +197,37 @@ func TestCleanupExpired(t *testing.T) {
 	}
 	defer func() { repoRemoteURL = origRepoRemoteURL }()
 
-	atime, err := os.Stat(filepath.Join(repoA, "HEAD"))
-	if err != nil {
-		t.Fatal(err)
-	}
-	cmd = exec.Command("git", "config", "--add", "sourcegraph.recloneTimestamp", strconv.FormatInt(time.Now().Add(-(2*repoTTL)).Unix(), 10))
-	cmd.Dir = repoB
-	if err := cmd.Run(); err != nil {
+	// Corrupt repoA by truncating the loose object of its commit.
+	repoA := filepath.Join(root, testRepoA)
+	runGit(t, root, "clone", "--quiet", remote, repoA)
+	runGit(t, repoA, "commit", "--allow-empty", "-m", "second")
+	commit := runGit(t, repoA, "rev-parse", "HEAD")
+	if err := os.Truncate(filepath.Join(repoA, ".git", "objects", commit[:2], commit[2:]), 0); err != nil {
 		t.Fatal(err)
 	}
 
 	s := &Server{ReposDir: root}
 	s.Handler() // Handler as a side-effect sets up Server
-	s.cleanupRepos()
 
-	fi, err := os.Stat(filepath.Join(repoA, "HEAD"))
+	// The first cleanup detects the corruption, the second reclones.
+	s.cleanupRepos()
+	gitDir := filepath.Join(repoA, ".git")
+	result, err := readMaintenanceResult(gitDir)
 	if err != nil {
-		// repoA should still exist.
 		t.Fatal(err)
 	}
-	if atime.ModTime().Before(fi.ModTime()) {
-		// repoA should not have been recloned.
-		t.Error("expected repoA to not be modified")
+	if result == nil || !result.Corrupt {
+		t.Fatalf("expected repoA to be detected as corrupt, got %+v", result)
 	}
-	fi, err = os.Stat(repoB)
-	if err != nil {
-		// repoB should still exist after being recloned.
-		t.Fatal(err)
+
+	s.cleanupRepos()
+	if got := runGit(t, gitDir, "rev-parse", "HEAD"); got != wantCommit {
+		t.Errorf("expected repoA to be recloned at %s, got %s", wantCommit, got)
 	}
-	// Expect the repo to be recloned hand have a recent mod time.
-	ti := time.Now().Add(-repoTTL)
-	if fi.ModTime().Before(ti) {
-		t.Error("expected repoB to be recloned during clean up")
+	if result, err := readMaintenanceResult(gitDir); err != nil {
+		t.Fatal(err)
+	} else if result != nil && result.Corrupt {
+		t.Errorf("expected recloned repoA to not be corrupt, got %+v", result)
 	}
 }
 
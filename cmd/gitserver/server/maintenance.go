@@ -0,0 +1,269 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// Fetches accumulate loose objects and packs, which waste space and slow down
+// git operations. During cleanup the janitor counts the loose objects and
+// packs of each repository and, when there are too many, repacks it. After
+// repacking the commit-graph is rewritten, which speeds up walking the
+// history.
+//
+// The result of the last maintenance run is stored in the sg_maintenance file
+// in $GIT_DIR. If git reports the repository is corrupt, the repository is
+// recloned from its remote the next time the janitor visits it. Other failed
+// runs are retried after a delay which grows with each consecutive failure.
+
+func init() {
+	prometheus.MustRegister(repoMaintenance)
+}
+
+var repoMaintenance = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "repo_maintenance",
+	Help:      "number of maintenance tasks run on repos during cleanup",
+}, []string{"task", "status"})
+
+var (
+	// looseObjectsThreshold is the number of loose objects above which a
+	// repository's loose objects are packed.
+	looseObjectsThreshold = 1024
+
+	// packsThreshold is the number of packs above which all of a
+	// repository's packs are combined into one by git gc.
+	packsThreshold = 20
+
+	// maintenanceBackoff is how long the janitor waits before retrying a
+	// failed maintenance run. It doubles with each consecutive failure, up
+	// to maxMaintenanceBackoff.
+	maintenanceBackoff    = time.Hour
+	maxMaintenanceBackoff = 24 * time.Hour
+)
+
+// maintenanceFile is the name of the file in $GIT_DIR recording the result of
+// the last maintenance run.
+const maintenanceFile = "sg_maintenance"
+
+// maintenanceResult is the result of a maintenance run, stored as JSON in
+// maintenanceFile.
+type maintenanceResult struct {
+	// Time is when the run started.
+	Time time.Time
+	// Duration is how long the run took.
+	Duration time.Duration
+	// LooseObjects and Packs are the counts which triggered the run.
+	LooseObjects, Packs int
+	// Tasks are the names of the tasks which were run, in order.
+	Tasks []string
+	// Error is the error of the failed task, if any.
+	Error string `json:",omitempty"`
+	// Corrupt is true if git reported the repository is corrupt. A corrupt
+	// repository is recloned.
+	Corrupt bool `json:",omitempty"`
+	// Failures is the number of consecutive failed runs, including this
+	// one.
+	Failures int `json:",omitempty"`
+}
+
+// corruptOutput matches git output reporting a corrupt repository.
+var corruptOutput = regexp.MustCompile(`(?i)(corrupt|bad object|missing (blob|tree|commit|tag)|object file .* is empty|unable to read [0-9a-f]{40}|packfile .* cannot be accessed)`)
+
+// maintainRepo repacks the repository at gitDir if it has too many loose
+// objects or packs. It holds the repository's update lock while doing so, so
+// it never runs concurrently with a fetch. The result is recorded in
+// maintenanceFile. After a failed run the repository is skipped until
+// maintenanceRetryDelay has passed.
+func (s *Server) maintainRepo(ctx context.Context, gitDir string) error {
+	dir := filepath.Dir(gitDir)
+	if _, cloning := s.locker.Status(dir); cloning {
+		return nil
+	}
+
+	last, err := readMaintenanceResult(gitDir)
+	if err != nil {
+		log15.Warn("failed to read last maintenance result", "dir", gitDir, "error", err)
+		last = nil
+	}
+	if last != nil && last.Error != "" && time.Since(last.Time) < maintenanceRetryDelay(last.Failures) {
+		return nil
+	}
+
+	counts, err := countObjects(ctx, gitDir)
+	if err != nil {
+		return err
+	}
+	loose, packs := counts.loose, counts.packs
+	var tasks [][]string
+	switch {
+	case packs > packsThreshold:
+		// gc also packs the loose objects, removes unreachable loose
+		// objects and packs refs.
+		tasks = append(tasks, []string{"gc", "--quiet"})
+	case loose > looseObjectsThreshold:
+		// An incremental repack only packs the loose objects, which is much
+		// cheaper than gc rewriting every pack.
+		tasks = append(tasks, []string{"repack", "-d", "-l", "-q"})
+	}
+	if len(tasks) > 0 || (loose+packs > 0 && !commitGraphExists(gitDir)) {
+		tasks = append(tasks, []string{"commit-graph", "write", "--reachable"})
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	repo := protocol.NormalizeRepo(api.RepoName(strings.TrimPrefix(dir, s.ReposDir+"/")))
+	s.repoUpdateLocksMu.Lock()
+	mu := s.repoUpdateLocksLocked(repo).mu
+	s.repoUpdateLocksMu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	result := maintenanceResult{
+		Time:         time.Now(),
+		LooseObjects: loose,
+		Packs:        packs,
+	}
+	for _, args := range tasks {
+		task := args[0]
+		result.Tasks = append(result.Tasks, task)
+
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = gitDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			repoMaintenance.WithLabelValues(task, "error").Inc()
+			result.Error = errors.Wrapf(err, "git %s failed with output: %s", strings.Join(args, " "), out).Error()
+			result.Corrupt = corruptOutput.Match(out)
+			result.Failures = 1
+			if last != nil && last.Error != "" {
+				// Results written before Failures was recorded count as
+				// one failure.
+				result.Failures = last.Failures + 1
+				if last.Failures == 0 {
+					result.Failures = 2
+				}
+			}
+			break
+		}
+		repoMaintenance.WithLabelValues(task, "success").Inc()
+	}
+	result.Duration = time.Since(result.Time)
+	if _, err := updateRepoSize(ctx, gitDir); err != nil {
+		log15.Warn("failed to update repo size", "repo", repo, "error", err)
+	}
+
+	log15.Debug("maintained repo", "repo", repo, "tasks", result.Tasks, "duration", result.Duration, "error", result.Error)
+	if err := writeMaintenanceResult(gitDir, &result); err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+// maintenanceRetryDelay returns how long to wait before retrying maintenance
+// of a repository after failures consecutive failed runs.
+func maintenanceRetryDelay(failures int) time.Duration {
+	d := maintenanceBackoff
+	for i := 1; i < failures && d < maxMaintenanceBackoff; i++ {
+		d *= 2
+	}
+	if d > maxMaintenanceBackoff {
+		d = maxMaintenanceBackoff
+	}
+	return d
+}
+
+// objectCounts are the object counts reported by git count-objects.
+type objectCounts struct {
+	loose, packs int
+	// size is the disk space in bytes used by loose objects, packs and
+	// garbage files in the object directory.
+	size int64
+}
+
+// countObjects returns the object counts of the repository at gitDir.
+func countObjects(ctx context.Context, gitDir string) (*objectCounts, error) {
+	cmd := exec.CommandContext(ctx, "git", "count-objects", "-v")
+	cmd.Dir = gitDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, wrapCmdError(cmd, err)
+	}
+	var counts objectCounts
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ": ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		n, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unexpected git count-objects output %q", scanner.Text())
+		}
+		switch fields[0] {
+		case "count":
+			counts.loose = int(n)
+		case "packs":
+			counts.packs = int(n)
+		case "size", "size-pack", "size-garbage":
+			counts.size += n * 1024 // reported in KiB
+		}
+	}
+	return &counts, nil
+}
+
+// commitGraphExists reports whether the repository at gitDir has a
+// commit-graph.
+func commitGraphExists(gitDir string) bool {
+	for _, p := range []string{"objects/info/commit-graph", "objects/info/commit-graphs/commit-graph-chain"} {
+		if _, err := os.Stat(filepath.Join(gitDir, p)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// readMaintenanceResult returns the result of the last maintenance run of the
+// repository at gitDir, or nil if it has never been maintained.
+func readMaintenanceResult(gitDir string) (*maintenanceResult, error) {
+	b, err := ioutil.ReadFile(filepath.Join(gitDir, maintenanceFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var result maintenanceResult
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", maintenanceFile)
+	}
+	return &result, nil
+}
+
+func writeMaintenanceResult(gitDir string, result *maintenanceResult) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(gitDir, maintenanceFile), b, 0600)
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// When the list of gitserver addresses changes, the repositories owned by
+// each gitserver change as well. Rather than recloning every repository which
+// moved from its code host, a gitserver with MigrateFromPeers set will:
+//
+// 1. Clone a repository it does not have from a peer which has a clone of it,
+//    falling back to the code host if no peer can serve it.
+// 2. During cleanup, hand over the clones it no longer owns by asking the new
+//    owner to clone them, and remove its copy once the new owner has one.
+//
+// Peers serve their clones read-only over git's smart HTTP protocol at /git/.
+
+func init() {
+	prometheus.MustRegister(reposMigrated)
+	prometheus.MustRegister(reposClonedFromPeer)
+}
+
+var reposMigrated = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "repos_migrated",
+	Help:      "number of repos removed after being handed over to the gitserver which owns them",
+})
+var reposClonedFromPeer = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "repos_cloned_from_peer",
+	Help:      "number of repos cloned from another gitserver instead of the code host",
+})
+
+// peerRequestTimeout is the timeout for requests made to other gitservers
+// which do not transfer a repository.
+const peerRequestTimeout = 10 * time.Second
+
+// gitHTTPBackend returns a handler serving the clones in s.ReposDir read-only
+// over git's smart HTTP protocol. A repository is cloned from
+// http://addr/git/<repo>/.git.
+func (s *Server) gitHTTPBackend() (http.Handler, error) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		return nil, err
+	}
+	return &cgi.Handler{
+		Path: git,
+		Root: "/git",
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + s.ReposDir,
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}, nil
+}
+
+// peerCloneURL returns the URL to clone repo from the gitserver at addr.
+func peerCloneURL(addr string, repo api.RepoName) string {
+	return "http://" + addr + "/git/" + string(protocol.NormalizeRepo(repo)) + "/.git"
+}
+
+// isSelf reports whether addr is the address of this gitserver. addr is
+// usually of the form host:port, where host is either our hostname or a
+// fully qualified name starting with our hostname.
+func (s *Server) isSelf(addr string) bool {
+	if s.Hostname == "" {
+		return false
+	}
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	return host == s.Hostname || strings.HasPrefix(host, s.Hostname+".")
+}
+
+// peers returns the addresses in addrs other than this gitserver's. ok is
+// false if this gitserver could not be found in addrs, in which case
+// ownership can not be determined and no clones should be handed over.
+func (s *Server) peers(addrs []string) (peers []string, ok bool) {
+	for _, addr := range addrs {
+		if s.isSelf(addr) {
+			ok = true
+			continue
+		}
+		peers = append(peers, addr)
+	}
+	return peers, ok
+}
+
+// peerAddrs returns the addresses of all gitservers, including this one.
+func (s *Server) peerAddrs(ctx context.Context) []string {
+	if s.PeerAddrs == nil {
+		return nil
+	}
+	return s.PeerAddrs(ctx)
+}
+
+// setMigratingFrom records that the clone in dir is being copied from the
+// gitserver at addr. An empty addr clears the record.
+func (s *Server) setMigratingFrom(dir, addr string) {
+	s.migrationsMu.Lock()
+	defer s.migrationsMu.Unlock()
+	if addr == "" {
+		delete(s.migrations, dir)
+	} else {
+		s.migrations[dir] = addr
+	}
+}
+
+// migratingFrom returns the address of the gitserver the clone in dir is
+// being copied from, or the empty string.
+func (s *Server) migratingFrom(dir string) string {
+	s.migrationsMu.Lock()
+	defer s.migrationsMu.Unlock()
+	return s.migrations[dir]
+}
+
+// peerWithClone returns the address of a peer which has a complete clone of
+// repo, or the empty string if there is none.
+func (s *Server) peerWithClone(ctx context.Context, repo api.RepoName) string {
+	peers, _ := s.peers(s.peerAddrs(ctx))
+	for _, addr := range peers {
+		info, err := peerRepoInfo(ctx, addr, repo)
+		if err != nil {
+			log15.Debug("failed to get repo info from peer", "repo", repo, "peer", addr, "error", err)
+			continue
+		}
+		if info.Cloned && !info.CloneInProgress {
+			return addr
+		}
+	}
+	return ""
+}
+
+// cloneFromPeer clones repo from the gitserver at addr into tmpPath, setting
+// its origin to remoteURL. Progress is reported via lock. If the clone grows
+// larger than maxSize bytes, a *repoTooLargeError is returned.
+func (s *Server) cloneFromPeer(ctx context.Context, repo api.RepoName, addr, remoteURL string, maxSize int64, lock *RepositoryLock, tmpPath string) error {
+	cloneURL := peerCloneURL(addr, repo)
+	log15.Info("cloning repo from peer", "repo", repo, "peer", addr, "tmp", tmpPath)
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go readCloneProgress(repo, cloneURL, lock, pr)
+
+	// The clone of the peer may predate the size limit, so it is enforced
+	// here too.
+	args := []string{"clone", "--mirror", "--progress", cloneURL, tmpPath}
+	output, err := s.cloneWithSizeLimit(ctx, args, tmpPath, maxSize, false, pw)
+	if _, tooLarge := err.(*repoTooLargeError); tooLarge {
+		return err
+	} else if err != nil {
+		return errors.Wrapf(err, "clone from peer failed. Output: %s", string(output))
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "remote", "set-url", "origin", remoteURL)
+	cmd.Dir = tmpPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to set remote URL. Output: %s", string(output))
+	}
+	return nil
+}
+
+// maybeHandOver hands over the clone in gitDir if another gitserver owns it.
+// If the owner has a complete clone our copy is removed, otherwise the owner
+// is asked to clone it (from us, since we are its peer). done is true if the
+// clone is not owned by this gitserver.
+func (s *Server) maybeHandOver(ctx context.Context, gitDir string) (done bool, err error) {
+	if !s.MigrateFromPeers {
+		return false, nil
+	}
+	addrs := s.peerAddrs(ctx)
+	if peers, ok := s.peers(addrs); !ok || len(peers) == 0 {
+		return false, nil
+	}
+
+	repo := protocol.NormalizeRepo(api.RepoName(strings.TrimPrefix(filepath.Dir(gitDir), s.ReposDir+"/")))
+	owner := gitserver.AddrForRepo(repo, addrs)
+	if s.isSelf(owner) {
+		return false, nil
+	}
+
+	info, err := peerRepoInfo(ctx, owner, repo)
+	if err != nil {
+		return true, errors.Wrapf(err, "failed to get repo info from %s", owner)
+	}
+	if info.CloneInProgress {
+		// Keep serving our copy until the owner has finished.
+		return true, nil
+	}
+	if info.Cloned {
+		log15.Info("removing repo handed over to its owner", "repo", repo, "owner", owner)
+		if err := s.removeRepoDirectory(gitDir); err != nil {
+			return true, err
+		}
+		reposMigrated.Inc()
+		return true, nil
+	}
+
+	remoteURL, err := repoRemoteURL(ctx, gitDir)
+	if err != nil {
+		return true, errors.Wrap(err, "failed to get remote URL")
+	}
+	log15.Info("handing over repo to its owner", "repo", repo, "owner", owner)
+
+	// The owner checks the repository is cloneable before responding, so
+	// allow more time than for other peer requests.
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return true, peerPost(ctx, owner, "repo-update", &protocol.RepoUpdateRequest{Repo: repo, URL: remoteURL}, nil)
+}
+
+// peerRepoInfo returns the information the gitserver at addr has about repo.
+func peerRepoInfo(ctx context.Context, addr string, repo api.RepoName) (*protocol.RepoInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, peerRequestTimeout)
+	defer cancel()
+
+	var resp protocol.RepoInfoResponse
+	if err := peerPost(ctx, addr, "repos", &protocol.RepoInfoRequest{Repos: []api.RepoName{repo}}, &resp); err != nil {
+		return nil, err
+	}
+	info, ok := resp.Results[repo]
+	if !ok || info == nil {
+		return nil, fmt.Errorf("no repo info for %s", repo)
+	}
+	return info, nil
+}
+
+// peerPost sends a JSON request to method on the gitserver at addr. If result
+// is non-nil the response is decoded into it.
+func peerPost(ctx context.Context, addr, method string, payload, result interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", "http://"+addr+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: unexpected status code %d", addr, method, resp.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+)
+
+func TestIsSelf(t *testing.T) {
+	s := &Server{Hostname: "gitserver-1"}
+	cases := map[string]bool{
+		"gitserver-1:3178":                  true,
+		"gitserver-1":                       true,
+		"gitserver-1.gitserver:3178":        true,
+		"gitserver-1.gitserver.default.svc": true,
+		"gitserver-10:3178":                 false,
+		"gitserver-0.gitserver:3178":        false,
+		"gitserver-1-canary.gitserver:3178": false,
+		"10.0.0.1:3178":                     false,
+		"not-gitserver-1.gitserver:3178":    false,
+	}
+	for addr, want := range cases {
+		if got := s.isSelf(addr); got != want {
+			t.Errorf("isSelf(%q) = %v, want %v", addr, got, want)
+		}
+	}
+
+	if (&Server{}).isSelf("gitserver-1:3178") {
+		t.Error("expected isSelf to be false when Hostname is unknown")
+	}
+}
+
+func TestCloneRepo_fromPeer(t *testing.T) {
+	remote, cleanup := tmpDir(t)
+	defer cleanup()
+
+	runGit(t, remote, "init", ".")
+	runGit(t, remote, "commit", "--allow-empty", "-m", "first")
+	wantCommit := runGit(t, remote, "rev-parse", "HEAD")
+
+	peer, peerAddr, cleanup := newMigrationTestServer(t, nil)
+	defer cleanup()
+	if _, err := peer.cloneRepo(context.Background(), "example.com/foo/bar", remote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A commit the peer does not have. If we clone from the remote we will
+	// see it.
+	runGit(t, remote, "commit", "--allow-empty", "-m", "second")
+
+	s, _, cleanup := newMigrationTestServer(t, []string{peerAddr, "self:3178"})
+	defer cleanup()
+	if _, err := s.cloneRepo(context.Background(), "example.com/foo/bar", remote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(s.ReposDir, "example.com/foo/bar/.git")
+	if got := runGit(t, dir, "rev-parse", "HEAD"); got != wantCommit {
+		t.Fatalf("expected clone from peer at %s, got %s", wantCommit, got)
+	}
+	if got := runGit(t, dir, "remote", "get-url", "origin"); got != remote {
+		t.Fatalf("expected origin to be %s, got %s", remote, got)
+	}
+	if from := s.migratingFrom(filepath.Join(s.ReposDir, "example.com/foo/bar")); from != "" {
+		t.Fatalf("expected migration to be done, still migrating from %s", from)
+	}
+}
+
+func TestMaybeHandOver(t *testing.T) {
+	remote, cleanup := tmpDir(t)
+	defer cleanup()
+
+	runGit(t, remote, "init", ".")
+	runGit(t, remote, "commit", "--allow-empty", "-m", "first")
+
+	owner, ownerAddr, cleanup := newMigrationTestServer(t, nil)
+	defer cleanup()
+
+	addrs := []string{ownerAddr, "self:3178"}
+	s, _, cleanup := newMigrationTestServer(t, addrs)
+	defer cleanup()
+
+	// Find two repositories owned by the other gitserver, and one we own.
+	var handedOver, notHandedOver []api.RepoName
+	for i := 0; len(handedOver) < 2 || len(notHandedOver) < 1; i++ {
+		repo := api.RepoName(fmt.Sprintf("example.com/repo%d", i))
+		if gitserver.AddrForRepo(repo, addrs) == ownerAddr {
+			handedOver = append(handedOver, repo)
+		} else {
+			notHandedOver = append(notHandedOver, repo)
+		}
+	}
+	mine := notHandedOver[0]
+	alreadyCloned, notCloned := handedOver[0], handedOver[1]
+
+	for _, repo := range []api.RepoName{mine, alreadyCloned, notCloned} {
+		if _, err := s.cloneRepo(context.Background(), repo, remote, &cloneOptions{Block: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := owner.cloneRepo(context.Background(), alreadyCloned, remote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	gitDir := func(s *Server, repo api.RepoName) string {
+		return filepath.Join(s.ReposDir, string(repo), ".git")
+	}
+	handOver := func(repo api.RepoName) bool {
+		t.Helper()
+		done, err := s.maybeHandOver(context.Background(), gitDir(s, repo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return done
+	}
+
+	if handOver(mine) {
+		t.Fatal("expected repository we own to not be handed over")
+	}
+	if !repoCloned(gitDir(s, mine)) {
+		t.Fatal("expected repository we own to remain")
+	}
+
+	if !handOver(alreadyCloned) {
+		t.Fatal("expected repository cloned by its owner to be handed over")
+	}
+	if repoCloned(gitDir(s, alreadyCloned)) {
+		t.Fatal("expected repository cloned by its owner to be removed")
+	}
+
+	// The owner does not have the repository yet, so it is asked to clone
+	// it and we keep our copy until it has.
+	if !handOver(notCloned) {
+		t.Fatal("expected repository to be handed over")
+	}
+	if !repoCloned(gitDir(s, notCloned)) {
+		t.Fatal("expected repository to remain until its owner has cloned it")
+	}
+	for i := 0; i < 1000 && !repoCloned(gitDir(owner, notCloned)); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !repoCloned(gitDir(owner, notCloned)) {
+		t.Fatal("expected owner to clone the repository")
+	}
+	handOver(notCloned)
+	if repoCloned(gitDir(s, notCloned)) {
+		t.Fatal("expected repository cloned by its owner to be removed")
+	}
+}
+
+// newMigrationTestServer returns a Server migrating from the gitservers in
+// addrs, which must contain "self:3178" if it is non-empty. The server is
+// served over HTTP at the returned address.
+func newMigrationTestServer(t *testing.T, addrs []string) (*Server, string, func()) {
+	t.Helper()
+	reposDir, cleanup := tmpDir(t)
+	s := &Server{
+		ReposDir:         reposDir,
+		MigrateFromPeers: true,
+		Hostname:         "self",
+		PeerAddrs:        func(context.Context) []string { return addrs },
+	}
+	srv := httptest.NewServer(s.Handler())
+	return s, strings.TrimPrefix(srv.URL, "http://"), func() {
+		srv.Close()
+		s.Stop()
+		cleanup()
+	}
+}
+
+// runGit runs git in dir and returns its trimmed output.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	b, err := gitCommandInDir(dir, args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %s\n%s", strings.Join(args, " "), err, b)
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// gitCommandInDir returns a git command run in dir with a fixed author and
+// committer.
+func gitCommandInDir(dir string, args ...string) *exec.Cmd {
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	c.Env = append(os.Environ(),
+		"GIT_COMMITTER_NAME=a",
+		"GIT_COMMITTER_EMAIL=a@a.com",
+		"GIT_AUTHOR_NAME=a",
+		"GIT_AUTHOR_EMAIL=a@a.com",
+	)
+	return c
+}
@@ -0,0 +1,625 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// The object endpoints (read-blob, list-tree, resolve-revision and
+// get-commit) serve the most frequent git reads by decoding loose objects and
+// packfiles in-process, rather than forking git like /exec does. They only
+// handle the common cases. Anything else, such as a repository which is not
+// cloned, a missing commit or an unusual revision spec, is reported with an
+// ObjectErrorPayload so that the client falls back to /exec, which also takes
+// care of cloning and fetching.
+
+func init() {
+	prometheus.MustRegister(objectRequests)
+}
+
+var objectRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "object_requests",
+	Help:      "number of requests to the object endpoints by result",
+}, []string{"endpoint", "status"})
+
+const (
+	// objectCacheSize is the size of the cache of decoded objects shared by
+	// all repositories. Objects are immutable and named by their hash, so
+	// sharing the cache across repositories is safe.
+	objectCacheSize = 256 * cache.MiByte
+
+	// maxOpenObjectStores is the number of repositories whose pack indexes
+	// are kept in memory.
+	maxOpenObjectStores = 64
+)
+
+// errUnavailable is returned when a request can not be served by reading
+// objects directly and the client should fall back to running git.
+var errUnavailable = errors.New("not available")
+
+// errPathNotFound is returned when the requested path does not exist in the
+// commit.
+var errPathNotFound = errors.New("path not found")
+
+// objectStores is a cache of objectStore by git directory.
+type objectStores struct {
+	mu     sync.Mutex
+	stores *lru.Cache
+	cache  cache.Object
+}
+
+func newObjectStores() *objectStores {
+	return &objectStores{
+		stores: lru.New(maxOpenObjectStores),
+		cache:  cache.NewObjectLRU(objectCacheSize),
+	}
+}
+
+// objectStore reads the objects of a single repository.
+type objectStore struct {
+	// mu serializes access to storage, since the go-git storage lazily
+	// loads pack indexes and is not safe for concurrent use.
+	mu      sync.Mutex
+	storage *filesystem.Storage
+
+	// packsModTime is the modification time of the pack directory when
+	// storage was created. Pack indexes are only read once, so storage is
+	// replaced when packs are added or removed by a fetch or reclone.
+	packsModTime time.Time
+
+	// mailmapHead is the commit HEAD pointed to when hasMailmap was
+	// computed, or the zero hash if it has not been computed yet.
+	mailmapHead plumbing.Hash
+	hasMailmap  bool
+}
+
+// get returns the objectStore for the repository cloned in dir.
+func (o *objectStores) get(dir string) (*objectStore, error) {
+	gitDir := filepath.Join(dir, ".git")
+	fi, err := os.Stat(filepath.Join(gitDir, "objects", "pack"))
+	if os.IsNotExist(err) {
+		// Old style clones are bare repositories in dir.
+		gitDir = dir
+		fi, err = os.Stat(filepath.Join(gitDir, "objects", "pack"))
+	}
+	if err != nil {
+		// Not cloned.
+		return nil, errUnavailable
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if v, ok := o.stores.Get(gitDir); ok {
+		if st := v.(*objectStore); st.packsModTime.Equal(fi.ModTime()) {
+			return st, nil
+		}
+	}
+	st := &objectStore{
+		storage:      filesystem.NewStorage(osfs.New(gitDir), o.cache),
+		packsModTime: fi.ModTime(),
+	}
+	o.stores.Add(gitDir, st)
+	return st, nil
+}
+
+// commit returns the commit with the given ID.
+func (st *objectStore) commit(id api.CommitID) (*object.Commit, error) {
+	if !git.IsAbsoluteRevision(string(id)) {
+		return nil, errUnavailable
+	}
+	c, err := object.GetCommit(st.storage, plumbing.NewHash(string(id)))
+	if err == plumbing.ErrObjectNotFound {
+		// The client may need to fetch it.
+		return nil, errUnavailable
+	}
+	return c, err
+}
+
+// findEntry returns the entry at the slash-separated path p in tree. Unlike
+// (*object.Tree).FindEntry, it reports errPathNotFound when a parent of p is
+// not a directory.
+func (st *objectStore) findEntry(tree *object.Tree, p string) (*object.TreeEntry, error) {
+	parts := strings.Split(p, "/")
+	for i, name := range parts {
+		var entry *object.TreeEntry
+		for j := range tree.Entries {
+			if tree.Entries[j].Name == name {
+				entry = &tree.Entries[j]
+				break
+			}
+		}
+		if entry == nil {
+			return nil, errPathNotFound
+		}
+		if i == len(parts)-1 {
+			return entry, nil
+		}
+		if entry.Mode != filemode.Dir {
+			return nil, errPathNotFound
+		}
+		var err error
+		if tree, err = object.GetTree(st.storage, entry.Hash); err != nil {
+			return nil, err
+		}
+	}
+	panic("unreachable")
+}
+
+// readBlob returns the contents of the file at p in commit.
+func (st *objectStore) readBlob(commit api.CommitID, p string) ([]byte, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	c, err := st.commit(commit)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := st.findEntry(tree, p)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.Mode.IsFile() {
+		// `git show` of a tree lists it, and submodules need special
+		// handling by the client.
+		return nil, errUnavailable
+	}
+	blob, err := object.GetBlob(st.storage, entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// listTree returns the entries at p in commit with the semantics of `git
+// ls-tree --full-name commit -- p`, adding -r -t if recurse is true.
+func (st *objectStore) listTree(commit api.CommitID, p string, recurse bool) ([]protocol.TreeEntry, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	c, err := st.commit(commit)
+	if err != nil {
+		return nil, err
+	}
+	root, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []protocol.TreeEntry{}
+	p = strings.TrimPrefix(p, "./")
+	if recurse {
+		// Like `git ls-tree -r -t`, list the trees leading up to p.
+		parent := path.Dir(p)
+		if strings.HasSuffix(p, "/") {
+			parent = strings.TrimSuffix(p, "/")
+		}
+		if err := st.appendParentTrees(&entries, root, parent); err != nil {
+			return nil, err
+		}
+	}
+	if p == "" || strings.HasSuffix(p, "/") {
+		// List the contents of the directory.
+		dir := strings.TrimSuffix(p, "/")
+		tree := root
+		if dir != "" {
+			entry, err := st.findEntry(root, dir)
+			if err == errPathNotFound {
+				return entries, nil
+			} else if err != nil {
+				return nil, err
+			}
+			if entry.Mode != filemode.Dir {
+				return entries, nil
+			}
+			if tree, err = object.GetTree(st.storage, entry.Hash); err != nil {
+				return nil, err
+			}
+		}
+		return entries, st.appendTreeEntries(&entries, tree, dir, recurse)
+	}
+
+	// List the entry itself.
+	entry, err := st.findEntry(root, p)
+	if err == errPathNotFound {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := st.appendTreeEntry(&entries, entry, p); err != nil {
+		return nil, err
+	}
+	if recurse && entry.Mode == filemode.Dir {
+		tree, err := object.GetTree(st.storage, entry.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if err := st.appendTreeEntries(&entries, tree, p, true); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// appendParentTrees appends the trees named by each prefix of dir to entries,
+// stopping at the first prefix which is not a tree.
+func (st *objectStore) appendParentTrees(entries *[]protocol.TreeEntry, root *object.Tree, dir string) error {
+	if dir == "" || dir == "." {
+		return nil
+	}
+	components := strings.Split(dir, "/")
+	for i := range components {
+		p := strings.Join(components[:i+1], "/")
+		entry, err := st.findEntry(root, p)
+		if err == errPathNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if entry.Mode != filemode.Dir {
+			return nil
+		}
+		if err := st.appendTreeEntry(entries, entry, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendTreeEntries appends the entries of tree, whose path is dir, to
+// entries.
+func (st *objectStore) appendTreeEntries(entries *[]protocol.TreeEntry, tree *object.Tree, dir string, recurse bool) error {
+	for i := range tree.Entries {
+		entry := &tree.Entries[i]
+		p := path.Join(dir, entry.Name)
+		if err := st.appendTreeEntry(entries, entry, p); err != nil {
+			return err
+		}
+		if recurse && entry.Mode == filemode.Dir {
+			subtree, err := object.GetTree(st.storage, entry.Hash)
+			if err != nil {
+				return err
+			}
+			if err := st.appendTreeEntries(entries, subtree, p, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (st *objectStore) appendTreeEntry(entries *[]protocol.TreeEntry, entry *object.TreeEntry, p string) error {
+	e := protocol.TreeEntry{
+		Path: p,
+		Mode: uint32(entry.Mode),
+		OID:  entry.Hash.String(),
+		Size: -1,
+	}
+	switch entry.Mode {
+	case filemode.Dir:
+		e.Type = "tree"
+	case filemode.Submodule:
+		e.Type = "commit"
+	default:
+		e.Type = "blob"
+		size, err := st.storage.EncodedObjectSize(entry.Hash)
+		if err != nil {
+			return err
+		}
+		e.Size = size
+	}
+	*entries = append(*entries, e)
+	return nil
+}
+
+// resolveRevision returns the commit spec refers to. Only ref names and full
+// commit IDs are supported.
+func (st *objectStore) resolveRevision(spec string) (api.CommitID, error) {
+	if spec == "" {
+		spec = "HEAD"
+	}
+	if !isPlainRefName(spec) {
+		return "", errUnavailable
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if git.IsAbsoluteRevision(spec) {
+		// Like git, prefer the object over a ref with the same name.
+		c, err := st.commit(api.CommitID(spec))
+		if err != nil {
+			return "", err
+		}
+		return api.CommitID(c.Hash.String()), nil
+	}
+
+	// The same rules as `git rev-parse`, except that names directly in
+	// $GIT_DIR other than HEAD (such as FETCH_HEAD) are not supported.
+	var candidates []string
+	if spec == "HEAD" || strings.HasPrefix(spec, "refs/") {
+		candidates = append(candidates, spec)
+	}
+	candidates = append(candidates,
+		"refs/"+spec,
+		"refs/tags/"+spec,
+		"refs/heads/"+spec,
+		"refs/remotes/"+spec,
+		"refs/remotes/"+spec+"/HEAD",
+	)
+	for _, name := range candidates {
+		ref, err := storer.ResolveReference(st.storage, plumbing.ReferenceName(name))
+		if err == plumbing.ErrReferenceNotFound {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		return st.peelToCommit(ref.Hash())
+	}
+	return "", errUnavailable
+}
+
+// peelToCommit follows annotated tags starting at h until it finds a commit.
+func (st *objectStore) peelToCommit(h plumbing.Hash) (api.CommitID, error) {
+	for {
+		obj, err := st.storage.EncodedObject(plumbing.AnyObject, h)
+		if err == plumbing.ErrObjectNotFound {
+			return "", errUnavailable
+		} else if err != nil {
+			return "", err
+		}
+		switch obj.Type() {
+		case plumbing.CommitObject:
+			return api.CommitID(h.String()), nil
+		case plumbing.TagObject:
+			tag, err := object.DecodeTag(st.storage, obj)
+			if err != nil {
+				return "", err
+			}
+			h = tag.Target
+		default:
+			return "", errUnavailable
+		}
+	}
+}
+
+// isPlainRefName reports whether spec is a ref name or object ID, as opposed
+// to a revision expression such as "HEAD~2" or "master@{yesterday}".
+func isPlainRefName(spec string) bool {
+	if spec == "" || strings.HasPrefix(spec, "-") || strings.HasPrefix(spec, "/") || strings.HasSuffix(spec, "/") ||
+		strings.HasSuffix(spec, ".") || strings.HasSuffix(spec, ".lock") ||
+		strings.Contains(spec, "..") || strings.Contains(spec, "//") || strings.Contains(spec, "@{") {
+		return false
+	}
+	for _, r := range spec {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// getCommit returns the metadata of commit in the same form as `git log`.
+func (st *objectStore) getCommit(commit api.CommitID) (*protocol.GetCommitResponse, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	// `git log` maps author and committer names and emails using the
+	// .mailmap at HEAD in bare repositories. We don't implement that, so
+	// leave it to git.
+	if st.headHasMailmap() {
+		return nil, errUnavailable
+	}
+
+	c, err := st.commit(commit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &protocol.GetCommitResponse{
+		ID:        api.CommitID(c.Hash.String()),
+		Author:    protocol.Signature{Name: c.Author.Name, Email: c.Author.Email, Date: time.Unix(c.Author.When.Unix(), 0).UTC()},
+		Committer: protocol.Signature{Name: c.Committer.Name, Email: c.Committer.Email, Date: time.Unix(c.Committer.When.Unix(), 0).UTC()},
+		Message:   strings.TrimSuffix(c.Message, "\n"),
+	}
+	for _, p := range c.ParentHashes {
+		resp.Parents = append(resp.Parents, api.CommitID(p.String()))
+	}
+	return resp, nil
+}
+
+// headHasMailmap reports whether the tree of the commit HEAD points to may
+// have a .mailmap file. The result is cached until HEAD changes. st.mu must
+// be held.
+func (st *objectStore) headHasMailmap() bool {
+	head, err := storer.ResolveReference(st.storage, plumbing.HEAD)
+	if err != nil {
+		return false
+	}
+	if head.Hash() == st.mailmapHead {
+		return st.hasMailmap
+	}
+
+	has := false
+	if c, err := object.GetCommit(st.storage, head.Hash()); err == nil {
+		if tree, err := c.Tree(); err == nil {
+			_, err := st.findEntry(tree, ".mailmap")
+			has = err != errPathNotFound
+		}
+	}
+	st.mailmapHead, st.hasMailmap = head.Hash(), has
+	return has
+}
+
+// objectStore returns the objectStore for repo.
+func (s *Server) objectStore(repo api.RepoName) (*objectStore, error) {
+	return s.objects.get(path.Join(s.ReposDir, string(protocol.NormalizeRepo(repo))))
+}
+
+// writeObjectError writes the response for an error returned by an
+// objectStore, and records the request.
+func writeObjectError(w http.ResponseWriter, endpoint string, err error) {
+	var (
+		status  int
+		payload protocol.ObjectErrorPayload
+		label   string
+	)
+	switch err {
+	case errPathNotFound:
+		status, label = http.StatusNotFound, "not-found"
+		payload.NotFound = true
+	case errUnavailable:
+		status, label = http.StatusUnprocessableEntity, "unavailable"
+	default:
+		status, label = http.StatusInternalServerError, "error"
+	}
+	payload.Error = err.Error()
+	objectRequests.WithLabelValues(endpoint, label).Inc()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&payload)
+}
+
+// cleanBlobPath returns the cleaned form of a path in a commit, or ok false
+// if it does not name an entry below the repository root.
+func cleanBlobPath(p string) (cleaned string, ok bool) {
+	p = path.Clean(p)
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") || strings.HasPrefix(p, "/") {
+		return "", false
+	}
+	return p, true
+}
+
+func (s *Server) handleReadBlob(w http.ResponseWriter, r *http.Request) {
+	var req protocol.ReadBlobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	st, err := s.objectStore(req.Repo)
+	if err != nil {
+		writeObjectError(w, "read-blob", err)
+		return
+	}
+	p, ok := cleanBlobPath(req.Path)
+	if !ok {
+		writeObjectError(w, "read-blob", errUnavailable)
+		return
+	}
+	content, err := st.readBlob(req.Commit, p)
+	if err != nil {
+		writeObjectError(w, "read-blob", err)
+		return
+	}
+	objectRequests.WithLabelValues("read-blob", "ok").Inc()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(content)
+}
+
+func (s *Server) handleListTree(w http.ResponseWriter, r *http.Request) {
+	var req protocol.ListTreeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	st, err := s.objectStore(req.Repo)
+	if err != nil {
+		writeObjectError(w, "list-tree", err)
+		return
+	}
+	// Leave paths git would need to normalize to git.
+	if trimmed := strings.TrimSuffix(strings.TrimPrefix(req.Path, "./"), "/"); trimmed != "" {
+		if p, ok := cleanBlobPath(trimmed); !ok || p != trimmed {
+			writeObjectError(w, "list-tree", errUnavailable)
+			return
+		}
+	}
+	entries, err := st.listTree(req.Commit, req.Path, req.Recurse)
+	if err != nil {
+		writeObjectError(w, "list-tree", err)
+		return
+	}
+	objectRequests.WithLabelValues("list-tree", "ok").Inc()
+	if err := json.NewEncoder(w).Encode(&protocol.ListTreeResponse{Entries: entries}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleResolveRevision(w http.ResponseWriter, r *http.Request) {
+	var req protocol.ResolveRevisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	st, err := s.objectStore(req.Repo)
+	if err != nil {
+		writeObjectError(w, "resolve-revision", err)
+		return
+	}
+	commit, err := st.resolveRevision(req.Spec)
+	if err != nil {
+		writeObjectError(w, "resolve-revision", err)
+		return
+	}
+	objectRequests.WithLabelValues("resolve-revision", "ok").Inc()
+	if err := json.NewEncoder(w).Encode(&protocol.ResolveRevisionResponse{Commit: commit}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleGetCommit(w http.ResponseWriter, r *http.Request) {
+	var req protocol.GetCommitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	st, err := s.objectStore(req.Repo)
+	if err != nil {
+		writeObjectError(w, "get-commit", err)
+		return
+	}
+	resp, err := st.getCommit(req.Commit)
+	if err != nil {
+		writeObjectError(w, "get-commit", err)
+		return
+	}
+	objectRequests.WithLabelValues("get-commit", "ok").Inc()
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
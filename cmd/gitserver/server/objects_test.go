@@ -0,0 +1,183 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+)
+
+func TestObjectStore(t *testing.T) {
+	reposDir, cleanup := tmpDir(t)
+	defer cleanup()
+
+	dir := filepath.Join(reposDir, "example.com/foo/bar")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "init", ".")
+	mkFiles(t, dir, "a/b/c.txt", "a/d.txt", "e.txt", "empty/.keep")
+	for _, p := range []string{"a/b/c.txt", "a/d.txt", "e.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, p), []byte("contents of "+p+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(dir, "e.txt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a/d.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "first")
+	runGit(t, dir, "tag", "-a", "-m", "annotated", "v1")
+	runGit(t, dir, "gc", "--quiet") // move the objects into a pack
+	mkFiles(t, dir, "a/new.txt")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "second\n\nbody\n")
+	commit := api.CommitID(runGit(t, dir, "rev-parse", "HEAD"))
+	first := api.CommitID(runGit(t, dir, "rev-parse", "HEAD^"))
+
+	s := &Server{ReposDir: reposDir}
+	s.Handler()
+	st, err := s.objectStore("example.com/foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("listTree", func(t *testing.T) {
+		for _, p := range []string{"", "./", "a", "a/", "a/b", "a/b/", "a/b/c.txt", "a/d.txt/", "e.txt", "link", "missing", "missing/", "a/missing"} {
+			for _, recurse := range []bool{false, true} {
+				got, err := st.listTree(commit, p, recurse)
+				if err != nil {
+					t.Fatalf("listTree(%q, %v): %s", p, recurse, err)
+				}
+				want := gitLsTree(t, dir, commit, p, recurse)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("listTree(%q, %v) mismatch\ngot  %+v\nwant %+v", p, recurse, got, want)
+				}
+			}
+		}
+	})
+
+	t.Run("readBlob", func(t *testing.T) {
+		for _, p := range []string{"a/b/c.txt", "e.txt", "link", "a/new.txt"} {
+			got, err := st.readBlob(commit, p)
+			if err != nil {
+				t.Fatalf("readBlob(%q): %s", p, err)
+			}
+			if want := runGitRaw(t, dir, "show", string(commit)+":"+p); string(got) != want {
+				t.Errorf("readBlob(%q) = %q, want %q", p, got, want)
+			}
+		}
+		for _, p := range []string{"missing", "a/missing", "e.txt/x"} {
+			if _, err := st.readBlob(commit, p); err != errPathNotFound {
+				t.Errorf("readBlob(%q): got error %v, want %v", p, err, errPathNotFound)
+			}
+		}
+		if _, err := st.readBlob(commit, "a"); err != errUnavailable {
+			t.Errorf("readBlob of a tree: got error %v, want %v", err, errUnavailable)
+		}
+		if _, err := st.readBlob(api.CommitID(strings.Repeat("a", 40)), "e.txt"); err != errUnavailable {
+			t.Errorf("readBlob of missing commit: got error %v, want %v", err, errUnavailable)
+		}
+	})
+
+	t.Run("resolveRevision", func(t *testing.T) {
+		tests := map[string]api.CommitID{
+			"":                  commit,
+			"HEAD":              commit,
+			"master":            commit,
+			"refs/heads/master": commit,
+			"heads/master":      commit,
+			"v1":                first,
+			string(first):       first,
+		}
+		for spec, want := range tests {
+			got, err := st.resolveRevision(spec)
+			if err != nil {
+				t.Errorf("resolveRevision(%q): %s", spec, err)
+			} else if got != want {
+				t.Errorf("resolveRevision(%q) = %s, want %s", spec, got, want)
+			}
+		}
+		for _, spec := range []string{"HEAD~1", "master^", "master@{1}", "missing", strings.Repeat("a", 40), "a..b", "HEAD:e.txt"} {
+			if _, err := st.resolveRevision(spec); err != errUnavailable {
+				t.Errorf("resolveRevision(%q): got error %v, want %v", spec, err, errUnavailable)
+			}
+		}
+	})
+
+	t.Run("getCommit", func(t *testing.T) {
+		got, err := st.getCommit(commit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		log := strings.Split(runGit(t, dir, "log", "-n1", "--format=%H%x00%aN%x00%aE%x00%at%x00%cN%x00%cE%x00%ct%x00%P%x00%B", string(commit)), "\x00")
+		if string(got.ID) != log[0] || got.Author.Name != log[1] || got.Author.Email != log[2] || strconv.FormatInt(got.Author.Date.Unix(), 10) != log[3] ||
+			got.Committer.Name != log[4] || got.Committer.Email != log[5] || strconv.FormatInt(got.Committer.Date.Unix(), 10) != log[6] ||
+			fmt.Sprint(got.Parents) != "["+log[7]+"]" || got.Message != log[8] {
+			t.Errorf("getCommit mismatch\ngot  %+v\nwant %q", got, log)
+		}
+
+		// Commits are left to git once HEAD has a .mailmap.
+		if err := ioutil.WriteFile(filepath.Join(dir, ".mailmap"), []byte("A <a@a.com> <b@b.com>\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", ".mailmap")
+		runGit(t, dir, "commit", "-m", "mailmap")
+		if _, err := st.getCommit(commit); err != errUnavailable {
+			t.Errorf("getCommit with a .mailmap: got error %v, want %v", err, errUnavailable)
+		}
+	})
+}
+
+// gitLsTree returns the entries listed by `git ls-tree`.
+func gitLsTree(t *testing.T, dir string, commit api.CommitID, p string, recurse bool) []protocol.TreeEntry {
+	t.Helper()
+	args := []string{"ls-tree", "--long", "--full-name", "-z", string(commit)}
+	if recurse {
+		args = append(args, "-r", "-t")
+	}
+	if p != "" {
+		args = append(args, "--", p)
+	}
+	entries := []protocol.TreeEntry{}
+	for _, line := range strings.Split(runGitRaw(t, dir, args...), "\x00") {
+		if line == "" {
+			continue
+		}
+		tab := strings.IndexByte(line, '\t')
+		info := strings.Fields(line[:tab])
+		mode, _ := strconv.ParseUint(info[0], 8, 32)
+		size := int64(-1)
+		if info[3] != "-" {
+			size, _ = strconv.ParseInt(info[3], 10, 64)
+		}
+		entries = append(entries, protocol.TreeEntry{
+			Path: line[tab+1:],
+			Mode: uint32(mode),
+			Type: info[1],
+			OID:  info[2],
+			Size: size,
+		})
+	}
+	return entries
+}
+
+// runGitRaw is like runGit, but returns the output without trimming it.
+func runGitRaw(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := gitCommandInDir(dir, args...).Output()
+	if err != nil {
+		t.Fatalf("git %s failed: %s", strings.Join(args, " "), err)
+	}
+	return string(out)
+}
@@ -1,17 +1,21 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 	log15 "gopkg.in/inconshreveable/log15.v2"
 )
@@ -25,22 +29,131 @@ func (s *Server) handleCreateCommitFromPatch(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if _, err := s.createCommit(r.Context(), &protocol.CreateCommitRequest{
+		Repo:       req.Repo,
+		BaseCommit: req.BaseCommit,
+		Patch:      req.Patch,
+		TargetRef:  req.TargetRef,
+		CommitInfo: req.CommitInfo,
+	}, true); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendResp(w, "refs/"+req.TargetRef)
+}
+
+func (s *Server) handleCreateCommit(w http.ResponseWriter, r *http.Request) {
+	var req protocol.CreateCommitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateCreateCommitRequest(r.Context(), &req); err != nil {
+		http.Error(w, "gitserver: invalid request - "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.createCommit(r.Context(), &req, false)
+	if _, ok := err.(*refExistsError); ok {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// validateCreateCommitRequest checks the refs and paths of req. Requests to
+// the older create-commit-from-patch endpoint are not validated, since it
+// accepts target refs without the refs/ prefix.
+func validateCreateCommitRequest(ctx context.Context, req *protocol.CreateCommitRequest) error {
+	if req.Patch == "" && len(req.Files) == 0 {
+		return errors.New("no patch or files")
+	}
+	if err := checkRefFormat(ctx, "refs/", req.TargetRef); err != nil {
+		return errors.Wrap(err, "target ref")
+	}
+	if req.Push != nil && req.Push.RemoteRef != "" {
+		if err := checkRefFormat(ctx, "refs/heads/", req.Push.RemoteRef); err != nil {
+			return errors.Wrap(err, "remote ref")
+		}
+	} else if req.Push != nil && !strings.HasPrefix(req.TargetRef, "refs/heads/") {
+		return errors.Errorf("target ref %q is not a branch, so a remote ref must be given", req.TargetRef)
+	}
+	for _, f := range req.Files {
+		if !validFilePath(f.Path) {
+			return errors.Errorf("invalid file path %q", f.Path)
+		}
+	}
+	return nil
+}
+
+// validFilePath reports whether p is a clean relative path inside the
+// repository's tree.
+func validFilePath(p string) bool {
+	if p == "" || path.IsAbs(p) || path.Clean(p) != p {
+		return false
+	}
+	for _, c := range strings.Split(p, "/") {
+		if c == ".." || strings.EqualFold(c, ".git") {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRefFormat returns an error if ref does not start with prefix or is not
+// a valid ref name.
+func checkRefFormat(ctx context.Context, prefix, ref string) error {
+	if !strings.HasPrefix(ref, prefix) {
+		return errors.Errorf("%q does not start with %s", ref, prefix)
+	}
+	// ref starts with prefix, so it cannot be mistaken for a flag.
+	cmd := exec.CommandContext(ctx, "git", "check-ref-format", ref)
+	if err := cmd.Run(); err != nil {
+		return errors.Errorf("%q is not a valid ref name", ref)
+	}
+	return nil
+}
+
+// refExistsError is returned by createCommit when the target ref already
+// exists and may not be overwritten.
+type refExistsError struct {
+	ref string
+}
+
+func (e *refExistsError) Error() string {
+	return fmt.Sprintf("gitserver: ref %s already exists", e.ref)
+}
+
+// createCommit creates the commit described by req in a temporary repository
+// using the objects of the repository as alternates, moves the new objects
+// into the repository and points req.TargetRef at the commit. If req.Push is
+// set the commit is then pushed to the repository's remote.
+//
+// An existing req.TargetRef is only moved to the new commit if overwrite is
+// true. Otherwise a *refExistsError is returned.
+func (s *Server) createCommit(ctx context.Context, req *protocol.CreateCommitRequest, overwrite bool) (*protocol.CreateCommitResponse, error) {
 	repo := string(protocol.NormalizeRepo(req.Repo))
 	repoGitDir := filepath.Join(s.ReposDir, repo, ".git")
 	if _, err := os.Stat(repoGitDir); os.IsNotExist(err) {
 		repoGitDir = filepath.Join(s.ReposDir, repo)
 		if _, err := os.Stat(repoGitDir); os.IsNotExist(err) {
-			http.Error(w, "gitserver: repo does not exist - "+err.Error(), http.StatusInternalServerError)
+			return nil, errors.New("gitserver: repo does not exist - " + err.Error())
 		}
 	}
 
-	ref := req.TargetRef
-
 	// Ensure tmp directory exists
 	tmpRepoDir, err := s.tempDir("patch-repo-")
 	if err != nil {
-		http.Error(w, "gitserver: make tmp repo - "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, errors.New("gitserver: make tmp repo - " + err.Error())
 	}
 	defer cleanUpTmpRepo(tmpRepoDir)
 
@@ -58,8 +171,6 @@ func (s *Server) handleCreateCommitFromPatch(w http.ResponseWriter, r *http.Requ
 		return out, err
 	}
 
-	ctx := r.Context()
-
 	tmpGitPathEnv := "GIT_DIR=" + filepath.Join(tmpRepoDir, ".git")
 
 	tmpObjectsDir := filepath.Join(tmpRepoDir, ".git", "objects")
@@ -67,36 +178,45 @@ func (s *Server) handleCreateCommitFromPatch(w http.ResponseWriter, r *http.Requ
 
 	altObjectsEnv := "GIT_ALTERNATE_OBJECT_DIRECTORIES=" + repoObjectsDir
 
+	// tmpCommand returns a git command run against the temporary repository.
+	tmpCommand := func(args ...string) *exec.Cmd {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = tmpRepoDir
+		cmd.Env = append(cmd.Env, tmpGitPathEnv, altObjectsEnv)
+		return cmd
+	}
+
 	cmd := exec.CommandContext(ctx, "git", "init")
 	cmd.Dir = tmpRepoDir
 	cmd.Env = append(cmd.Env, tmpGitPathEnv)
 
 	if _, err := run(cmd); err != nil {
-		http.Error(w, "gitserver: init tmp repo - "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, errors.New("gitserver: init tmp repo - " + err.Error())
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "reset", "-q", string(req.BaseCommit))
-	cmd.Dir = tmpRepoDir
-	cmd.Env = append(cmd.Env, tmpGitPathEnv, altObjectsEnv)
-
-	if out, err := run(cmd); err != nil {
+	if out, err := run(tmpCommand("reset", "-q", string(req.BaseCommit))); err != nil {
 		log15.Error("Failed to base the temporary repo on the base revision.", "ref", req.TargetRef, "base", req.BaseCommit, "output", string(out))
 
-		http.Error(w, "gitserver: basing staging on base rev - "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, errors.New("gitserver: basing staging on base rev - " + err.Error())
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "apply", "--cached")
-	cmd.Dir = tmpRepoDir
-	cmd.Env = append(cmd.Env, tmpGitPathEnv, altObjectsEnv)
-	cmd.Stdin = strings.NewReader(req.Patch)
+	if req.Patch != "" {
+		cmd := tmpCommand("apply", "--cached")
+		cmd.Stdin = strings.NewReader(req.Patch)
 
-	if out, err := run(cmd); err != nil {
-		log15.Error("Failed to apply patch.", "ref", req.TargetRef, "output", string(out))
+		if out, err := run(cmd); err != nil {
+			log15.Error("Failed to apply patch.", "ref", req.TargetRef, "output", string(out))
 
-		http.Error(w, "gitserver: applying patch - "+err.Error(), http.StatusInternalServerError)
-		return
+			return nil, errors.New("gitserver: applying patch - " + err.Error())
+		}
+	}
+
+	for _, f := range req.Files {
+		if err := updateIndex(tmpCommand, f); err != nil {
+			log15.Error("Failed to write file.", "ref", req.TargetRef, "path", f.Path, "error", err)
+
+			return nil, errors.New("gitserver: writing file - " + err.Error())
+		}
 	}
 
 	message := req.CommitInfo.Message
@@ -112,11 +232,8 @@ func (s *Server) handleCreateCommitFromPatch(w http.ResponseWriter, r *http.Requ
 		authorEmail = "support@sourcegraph.com"
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "commit", "-m", message)
-	cmd.Dir = tmpRepoDir
+	cmd = tmpCommand("commit", "-m", message)
 	cmd.Env = append(cmd.Env, []string{
-		tmpGitPathEnv,
-		altObjectsEnv,
 		"GIT_COMMITTER_NAME=sourcegraph-committer",
 		"GIT_COMMITTER_EMAIL=support@sourcegraph.com",
 		fmt.Sprintf("GIT_AUTHOR_NAME=%s", authorName),
@@ -128,18 +245,12 @@ func (s *Server) handleCreateCommitFromPatch(w http.ResponseWriter, r *http.Requ
 	if out, err := run(cmd); err != nil {
 		log15.Error("Failed to commit patch.", "ref", req.TargetRef, "output", out)
 
-		http.Error(w, "gitserver: commiting patch - "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, errors.New("gitserver: commiting patch - " + err.Error())
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
-	cmd.Dir = tmpRepoDir
-	cmd.Env = append(cmd.Env, tmpGitPathEnv, altObjectsEnv)
-
-	out, err := cmd.Output()
+	out, err := tmpCommand("rev-parse", "HEAD").Output()
 	if err != nil {
-		http.Error(w, "gitserver: retrieving new commit id - "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, errors.New("gitserver: retrieving new commit id - " + err.Error())
 	}
 
 	cmtHash := strings.TrimSpace(string(out))
@@ -168,21 +279,112 @@ func (s *Server) handleCreateCommitFromPatch(w http.ResponseWriter, r *http.Requ
 		return nil
 	})
 	if err != nil {
-		http.Error(w, "gitserver: copying git objects - "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, errors.New("gitserver: copying git objects - " + err.Error())
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "update-ref", req.TargetRef, cmtHash)
+	updateRefArgs := []string{"update-ref", req.TargetRef, cmtHash}
+	if !overwrite {
+		// An empty old value makes git refuse to update a ref which
+		// already exists.
+		updateRefArgs = append(updateRefArgs, "")
+	}
+	cmd = exec.CommandContext(ctx, "git", updateRefArgs...)
 	cmd.Dir = repoGitDir
 
 	if out, err = run(cmd); err != nil {
+		if !overwrite && refExists(ctx, repoGitDir, req.TargetRef) {
+			return nil, &refExistsError{ref: req.TargetRef}
+		}
 		log15.Error("Failed to create ref for commit.", "ref", req.TargetRef, "commit", cmtHash, "output", string(out))
 
-		http.Error(w, "gitserver: creating ref - "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, errors.New("gitserver: creating ref - " + err.Error())
 	}
 
-	sendResp(w, "refs/"+ref)
+	resp := &protocol.CreateCommitResponse{
+		Ref:    req.TargetRef,
+		Commit: api.CommitID(cmtHash),
+	}
+	if req.Push != nil {
+		remoteRef := req.Push.RemoteRef
+		if remoteRef == "" {
+			remoteRef = req.TargetRef
+		}
+		if err := s.pushCommit(ctx, repoGitDir, cmtHash, remoteRef, req.Push.Force); err != nil {
+			log15.Error("Failed to push commit.", "repo", repo, "ref", remoteRef, "commit", cmtHash, "error", err)
+
+			// Remove the ref again so that the request can be retried.
+			if !overwrite {
+				cmd = exec.CommandContext(ctx, "git", "update-ref", "-d", req.TargetRef, cmtHash)
+				cmd.Dir = repoGitDir
+				if out, err := run(cmd); err != nil {
+					log15.Warn("Failed to remove ref of unpushed commit.", "ref", req.TargetRef, "commit", cmtHash, "output", string(out))
+				}
+			}
+
+			return nil, errors.New("gitserver: pushing commit - " + err.Error())
+		}
+		resp.PushedRef = remoteRef
+	}
+	return resp, nil
+}
+
+// refExists reports whether ref exists in the repository at gitDir.
+func refExists(ctx context.Context, gitDir, ref string) bool {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", ref)
+	cmd.Dir = gitDir
+	return cmd.Run() == nil
+}
+
+// updateIndex applies the change f to the index of the temporary repository
+// tmpCommand runs git against.
+func updateIndex(tmpCommand func(args ...string) *exec.Cmd, f protocol.FileChange) error {
+	if f.Delete {
+		if out, err := tmpCommand("update-index", "--force-remove", "--", f.Path).CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "removing %s: %s", f.Path, out)
+		}
+		return nil
+	}
+
+	cmd := tmpCommand("hash-object", "-w", "--stdin")
+	cmd.Stdin = strings.NewReader(string(f.Content))
+	out, err := cmd.Output()
+	if err != nil {
+		return errors.Wrapf(err, "hashing %s", f.Path)
+	}
+	mode := "100644"
+	if f.Executable {
+		mode = "100755"
+	}
+	cacheInfo := mode + "," + strings.TrimSpace(string(out)) + "," + f.Path
+	if out, err := tmpCommand("update-index", "--add", "--cacheinfo", cacheInfo).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "adding %s: %s", f.Path, out)
+	}
+	return nil
+}
+
+// pushCommit pushes commit from the repository at gitDir to ref on its
+// remote, using the remote URL the repository was cloned with.
+func (s *Server) pushCommit(ctx context.Context, gitDir, commit, ref string, force bool) error {
+	remoteURL, err := repoRemoteURL(ctx, gitDir)
+	if err != nil {
+		return errors.Wrap(err, "failed to get remote URL")
+	}
+
+	refspec := commit + ":" + ref
+	if force {
+		refspec = "+" + refspec
+	}
+	ctx, cancel := context.WithTimeout(ctx, longGitCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "push", remoteURL, refspec)
+	cmd.Dir = gitDir
+	if out, err := s.runWithRemoteOpts(ctx, cmd, nil); err != nil {
+		// 🚨 SECURITY: The output could include the remote URL, which may
+		// contain a sensitive token.
+		redactor := newURLRedactor(remoteURL)
+		return errors.Errorf("%s: %s", redactor.redact(err.Error()), redactor.redact(string(out)))
+	}
+	return nil
 }
 
 func sendResp(w http.ResponseWriter, commitID string) {
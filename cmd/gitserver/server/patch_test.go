@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+)
+
+func TestCreateCommit(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+
+	work := filepath.Join(root, "work")
+	runGit(t, root, "init", "--quiet", work)
+	if err := ioutil.WriteFile(filepath.Join(work, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(work, "old.txt"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "--quiet", "-m", "initial")
+	base := runGit(t, work, "rev-parse", "HEAD")
+	remote := filepath.Join(root, "remote.git")
+	runGit(t, root, "clone", "--quiet", "--bare", work, remote)
+
+	reposDir := filepath.Join(root, "repos")
+	runGit(t, root, "clone", "--quiet", remote, filepath.Join(reposDir, "example.com", "repo"))
+	s := &Server{ReposDir: reposDir}
+
+	resp, err := s.createCommit(context.Background(), &protocol.CreateCommitRequest{
+		Repo:       "example.com/repo",
+		BaseCommit: api.CommitID(base),
+		Patch: `diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-a
++b
+`,
+		Files: []protocol.FileChange{
+			{Path: "bin/run.sh", Content: []byte("#!/bin/sh\n"), Executable: true},
+			{Path: "old.txt", Delete: true},
+		},
+		TargetRef: "refs/heads/feature",
+		CommitInfo: protocol.PatchCommitInfo{
+			Message:     "change things",
+			AuthorName:  "b",
+			AuthorEmail: "b@b.com",
+			Date:        time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		Push: &protocol.PushConfig{},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Ref != "refs/heads/feature" || resp.PushedRef != "refs/heads/feature" || len(resp.Commit) != 40 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	if got := runGit(t, remote, "rev-parse", "refs/heads/feature"); got != string(resp.Commit) {
+		t.Errorf("remote branch points at %s, want %s", got, resp.Commit)
+	}
+	if got := runGit(t, remote, "show", "feature:a.txt"); got != "b" {
+		t.Errorf("got a.txt %q, want %q", got, "b")
+	}
+	if got, want := runGit(t, remote, "ls-tree", "-r", "--name-only", "feature"), "a.txt\nbin/run.sh"; got != want {
+		t.Errorf("got files %q, want %q", got, want)
+	}
+	if got := runGit(t, remote, "ls-tree", "feature", "bin/run.sh"); !strings.HasPrefix(got, "100755 ") {
+		t.Errorf("expected bin/run.sh to be executable, got %q", got)
+	}
+	if got, want := runGit(t, remote, "log", "-1", "--format=%an <%ae> %s", "feature"), "b <b@b.com> change things"; got != want {
+		t.Errorf("got commit %q, want %q", got, want)
+	}
+
+	// Pushing a commit which does not descend from the remote branch
+	// requires Force.
+	req := &protocol.CreateCommitRequest{
+		Repo:       "example.com/repo",
+		BaseCommit: api.CommitID(base),
+		Files:      []protocol.FileChange{{Path: "c.txt", Content: []byte("c\n")}},
+		TargetRef:  "refs/heads/other",
+		Push:       &protocol.PushConfig{RemoteRef: "refs/heads/feature"},
+	}
+	if _, err := s.createCommit(context.Background(), req, false); err == nil {
+		t.Error("expected non-fast-forward push to fail")
+	}
+	req.Push.Force = true
+	resp, err = s.createCommit(context.Background(), req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, remote, "rev-parse", "refs/heads/feature"); got != string(resp.Commit) {
+		t.Errorf("remote branch points at %s, want %s", got, resp.Commit)
+	}
+
+	// Existing refs are only moved to the new commit if overwrite is set.
+	req.Push = nil
+	if _, err := s.createCommit(context.Background(), req, false); err == nil {
+		t.Error("expected creating an existing ref to fail")
+	} else if _, ok := err.(*refExistsError); !ok {
+		t.Errorf("got error %v, want *refExistsError", err)
+	}
+	if _, err := s.createCommit(context.Background(), req, true); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateCreateCommitRequest(t *testing.T) {
+	valid := protocol.CreateCommitRequest{
+		Repo:      "example.com/repo",
+		Patch:     "diff",
+		TargetRef: "refs/heads/feature",
+		Push:      &protocol.PushConfig{},
+	}
+	if err := validateCreateCommitRequest(context.Background(), &valid); err != nil {
+		t.Fatalf("unexpected error for valid request: %s", err)
+	}
+
+	tests := map[string]func(req *protocol.CreateCommitRequest){
+		"no changes":            func(req *protocol.CreateCommitRequest) { req.Patch = "" },
+		"target ref not a ref":  func(req *protocol.CreateCommitRequest) { req.TargetRef = "feature" },
+		"invalid target ref":    func(req *protocol.CreateCommitRequest) { req.TargetRef = "refs/heads/a..b" },
+		"push tag":              func(req *protocol.CreateCommitRequest) { req.TargetRef = "refs/sourcegraph/x" },
+		"remote ref not branch": func(req *protocol.CreateCommitRequest) { req.Push.RemoteRef = "refs/tags/v1" },
+		"absolute path": func(req *protocol.CreateCommitRequest) {
+			req.Files = []protocol.FileChange{{Path: "/etc/passwd"}}
+		},
+		"parent path": func(req *protocol.CreateCommitRequest) {
+			req.Files = []protocol.FileChange{{Path: "../x"}}
+		},
+		"unclean path": func(req *protocol.CreateCommitRequest) {
+			req.Files = []protocol.FileChange{{Path: "a//b"}}
+		},
+		"git dir": func(req *protocol.CreateCommitRequest) {
+			req.Files = []protocol.FileChange{{Path: "a/.git/config"}}
+		},
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := valid
+			req.Push = &protocol.PushConfig{}
+			modify(&req)
+			if err := validateCreateCommitRequest(context.Background(), &req); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -33,6 +33,8 @@ func (s *Server) repoInfo(ctx context.Context, repo api.RepoName) (*protocol.Rep
 			resp.CloneInProgress = true
 			resp.CloneProgress = "This will never finish cloning"
 		}
+		resp.MigratingFrom = s.migratingFrom(dir)
+		resp.LastError = s.lastError(dir)
 	}
 	if resp.Cloned {
 		if mtime, err := repoLastFetched(dir); err != nil {
@@ -52,6 +54,14 @@ func (s *Server) repoInfo(ctx context.Context, repo api.RepoName) (*protocol.Rep
 		} else {
 			resp.LastChanged = &lastChanged
 		}
+
+		gitDir := filepath.Join(dir, ".git")
+		if size, err := repoSize(ctx, gitDir); err != nil {
+			log15.Warn("error getting repo size", "repo", repo, "err", err)
+		} else {
+			resp.Size = size
+		}
+		resp.Shallow = repoShallow(gitDir)
 	}
 	return &resp, nil
 }
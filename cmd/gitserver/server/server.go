@@ -114,6 +114,19 @@ type Server struct {
 	// DesiredFreeDiskSpace is how much space we need to keep free in bytes.
 	DesiredFreeDiskSpace uint64
 
+	// MigrateFromPeers when true will clone repositories from the gitserver
+	// which has them rather than from the code host, and hand over
+	// repositories this gitserver no longer owns when the Janitor job runs.
+	MigrateFromPeers bool
+
+	// Hostname is the hostname of this gitserver. It is used to find this
+	// gitserver in the addresses returned by PeerAddrs.
+	Hostname string
+
+	// PeerAddrs returns the addresses of all gitservers, including this one.
+	// It is only used if MigrateFromPeers is true.
+	PeerAddrs func(ctx context.Context) []string
+
 	// skipCloneForTests is set by tests to avoid clones.
 	skipCloneForTests bool
 
@@ -136,6 +149,15 @@ type Server struct {
 
 	repoUpdateLocksMu sync.Mutex // protects the map below and also updates to locks.once
 	repoUpdateLocks   map[api.RepoName]*locks
+
+	migrationsMu sync.Mutex        // protects the map below
+	migrations   map[string]string // repo dir -> address of the peer it is being cloned from
+
+	lastErrorsMu sync.Mutex               // protects the maps below
+	lastErrors   map[string]string        // repo dir -> error of the last failed clone
+	refusals     map[string]*cloneRefusal // repo dir -> refusal of the last clone for exceeding its size limit
+
+	objects *objectStores // used by the object endpoints
 }
 
 type locks struct {
@@ -197,6 +219,8 @@ func (s *Server) Handler() http.Handler {
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 	s.locker = &RepositoryLocker{}
 	s.repoUpdateLocks = make(map[api.RepoName]*locks)
+	s.migrations = make(map[string]string)
+	s.objects = newObjectStores()
 
 	// GitMaxConcurrentClones controls the maximum number of clones that
 	// can happen at once on a single gitserver.
@@ -222,19 +246,33 @@ func (s *Server) Handler() http.Handler {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/exec", s.handleExec)
+	mux.HandleFunc("/batch-exec", s.handleBatchExec)
 	mux.HandleFunc("/list", s.handleList)
 	mux.HandleFunc("/list-gitolite", s.handleListGitolite)
 	mux.HandleFunc("/is-repo-cloneable", s.handleIsRepoCloneable)
 	mux.HandleFunc("/is-repo-cloned", s.handleIsRepoCloned)
 	mux.HandleFunc("/repo", s.handleDeprecatedRepoInfo) // TODO(slimsag): Remove this after 3.3 is released.
 	mux.HandleFunc("/repos", s.handleRepoInfo)
+	mux.HandleFunc("/repo-sizes", s.handleRepoSizes)
 	mux.HandleFunc("/delete", s.handleRepoDelete)
 	mux.HandleFunc("/repo-update", s.handleRepoUpdate)
 	mux.HandleFunc("/getGitolitePhabricatorMetadata", s.handleGetGitolitePhabricatorMetadata)
 	mux.HandleFunc("/create-commit-from-patch", s.handleCreateCommitFromPatch)
+	mux.HandleFunc("/create-commit", s.handleCreateCommit)
+	mux.HandleFunc("/read-blob", s.handleReadBlob)
+	mux.HandleFunc("/list-tree", s.handleListTree)
+	mux.HandleFunc("/resolve-revision", s.handleResolveRevision)
+	mux.HandleFunc("/get-commit", s.handleGetCommit)
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	if s.MigrateFromPeers {
+		if h, err := s.gitHTTPBackend(); err != nil {
+			log15.Error("failed to serve repositories to peers", "error", err)
+		} else {
+			mux.Handle("/git/", h)
+		}
+	}
 	return mux
 }
 
@@ -545,37 +583,7 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 	}
 
 	dir := path.Join(s.ReposDir, string(req.Repo))
-	cloneProgress, cloneInProgress := s.locker.Status(dir)
-	if cloneInProgress {
-		status = "clone-in-progress"
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
-			CloneInProgress: true,
-			CloneProgress:   cloneProgress,
-		})
-		return
-	}
-	if !repoCloned(dir) {
-		if req.URL == "" {
-			status = "repo-not-found"
-			w.WriteHeader(http.StatusNotFound)
-			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
-			return
-		}
-		cloneProgress, err := s.cloneRepo(ctx, req.Repo, req.URL, nil)
-		if err != nil {
-			log15.Debug("error cloning repo", "repo", req.Repo, "err", err)
-			status = "repo-not-found"
-			w.WriteHeader(http.StatusNotFound)
-			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
-			return
-		}
-		status = "clone-in-progress"
-		w.WriteHeader(http.StatusNotFound)
-		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
-			CloneInProgress: true,
-			CloneProgress:   cloneProgress,
-		})
+	if status = s.respondIfNotCloned(ctx, w, req.Repo, req.URL, dir); status != "" {
 		return
 	}
 
@@ -631,6 +639,43 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Exec-Stderr", string(stderr))
 }
 
+// respondIfNotCloned writes a 404 response with a protocol.NotFoundPayload if
+// the repository in dir is not cloned, starting to clone it if remoteURL is
+// set. It returns the status of the request for instrumentation, or the empty
+// string if the repository is cloned and nothing was written.
+func (s *Server) respondIfNotCloned(ctx context.Context, w http.ResponseWriter, repo api.RepoName, remoteURL, dir string) (status string) {
+	cloneProgress, cloneInProgress := s.locker.Status(dir)
+	if cloneInProgress {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
+			CloneInProgress: true,
+			CloneProgress:   cloneProgress,
+		})
+		return "clone-in-progress"
+	}
+	if !repoCloned(dir) {
+		if remoteURL == "" {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
+			return "repo-not-found"
+		}
+		cloneProgress, err := s.cloneRepo(ctx, repo, remoteURL, nil)
+		if err != nil {
+			log15.Debug("error cloning repo", "repo", repo, "err", err)
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
+			return "repo-not-found"
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
+			CloneInProgress: true,
+			CloneProgress:   cloneProgress,
+		})
+		return "clone-in-progress"
+	}
+	return ""
+}
+
 // setGitAttributes writes our global gitattributes to
 // gitDir/info/attributes. This will override .gitattributes inside of
 // repositories. It is used to unset attributes such as export-ignore.
@@ -680,6 +725,12 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		return progress, nil
 	}
 
+	// Don't keep cloning a repository which is too large.
+	limit := repoSizeLimitFor(url)
+	if err := s.checkCloneRefused(dir, limit); err != nil {
+		return "", errors.Wrapf(err, "error cloning repo: repo %s", repo)
+	}
+
 	// isCloneable causes a network request, so we limit the number that can
 	// run at one time. We use a separate semaphore to cloning since these
 	// checks being blocked by a few slow clones will lead to poor feedback to
@@ -744,15 +795,55 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		defer os.RemoveAll(tmpPath)
 		tmpPath = filepath.Join(tmpPath, ".git")
 
-		cmd := exec.CommandContext(ctx, "git", "clone", "--mirror", "--progress", url, tmpPath)
-		log15.Info("cloning repo", "repo", repo, "tmp", tmpPath, "dst", dstPath)
+		// Prefer copying an existing clone from a peer over cloning from the
+		// code host. This is not done when overwriting, since recloning is
+		// meant to produce a fresh clone.
+		clonedFromPeer := false
+		if s.MigrateFromPeers && !overwrite {
+			if peer := s.peerWithClone(ctx, repo); peer != "" {
+				s.setMigratingFrom(dir, peer)
+				err := s.cloneFromPeer(ctx, repo, peer, url, limit.maxSize, lock, tmpPath)
+				s.setMigratingFrom(dir, "")
+				if _, tooLarge := err.(*repoTooLargeError); tooLarge && limit.action == repoSizeLimitRefuse {
+					return err
+				} else if err != nil {
+					log15.Warn("failed to clone repo from peer, cloning from remote", "repo", repo, "peer", peer, "error", err)
+					if err := os.RemoveAll(tmpPath); err != nil {
+						return err
+					}
+				} else {
+					clonedFromPeer = true
+					reposClonedFromPeer.Inc()
+				}
+			}
+		}
+
+		if !clonedFromPeer {
+			log15.Info("cloning repo", "repo", repo, "tmp", tmpPath, "dst", dstPath)
 
-		pr, pw := io.Pipe()
-		defer pw.Close()
-		go readCloneProgress(repo, url, lock, pr)
+			pr, pw := io.Pipe()
+			defer pw.Close()
+			go readCloneProgress(repo, url, lock, pr)
+
+			args := []string{"clone", "--mirror", "--progress", url, tmpPath}
+			output, err := s.cloneWithSizeLimit(ctx, args, tmpPath, limit.maxSize, false, pw)
+			if _, tooLarge := err.(*repoTooLargeError); tooLarge && limit.action == repoSizeLimitShallow {
+				log15.Info("repo exceeds size limit, cloning most recent commits only", "repo", repo, "limit", limit.maxSize)
+				if err := os.RemoveAll(tmpPath); err != nil {
+					return err
+				}
+				args := []string{"clone", "--mirror", "--depth=1", "--no-single-branch", "--progress", url, tmpPath}
+				output, err = s.cloneWithSizeLimit(ctx, args, tmpPath, limit.maxSize, true, pw)
+			}
+			if _, tooLarge := err.(*repoTooLargeError); tooLarge {
+				return err
+			} else if err != nil {
+				return errors.Wrapf(err, "clone failed. Output: %s", string(output))
+			}
+		}
 
-		if output, err := s.runWithRemoteOpts(ctx, cmd, pw); err != nil {
-			return errors.Wrapf(err, "clone failed. Output: %s", string(output))
+		if _, err := updateRepoSize(ctx, tmpPath); err != nil {
+			log15.Warn("failed to compute repo size", "repo", repo, "error", err)
 		}
 
 		// Update the last-changed stamp.
@@ -786,9 +877,26 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		return nil
 	}
 
+	// doCloneAndRecordError records the error of doClone so it can be shown
+	// in the repository's mirroring status.
+	doCloneAndRecordError := func(ctx context.Context) error {
+		err := doClone(ctx)
+		if _, tooLarge := err.(*repoTooLargeError); tooLarge || err == nil {
+			s.setCloneRefused(dir, limit, tooLarge)
+		}
+		if err != nil {
+			// 🚨 SECURITY: The error could include the clone url with may
+			// contain a sensitive token.
+			s.setLastError(dir, newURLRedactor(url).redact(err.Error()))
+		} else {
+			s.setLastError(dir, "")
+		}
+		return err
+	}
+
 	if opts != nil && opts.Block {
 		// We are blocking, so use the passed in context.
-		if err := doClone(ctx); err != nil {
+		if err := doCloneAndRecordError(ctx); err != nil {
 			return "", errors.Wrapf(err, "failed to clone %s", repo)
 		}
 		return "", nil
@@ -798,7 +906,7 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		// Create a new context because this is in a background goroutine.
 		ctx, cancel := s.serverContext()
 		defer cancel()
-		if err := doClone(ctx); err != nil {
+		if err := doCloneAndRecordError(ctx); err != nil {
 			log15.Error("failed to clone repo", "repo", repo, "error", err)
 		}
 	}()
@@ -972,14 +1080,7 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string
 	defer span.Finish()
 
 	s.repoUpdateLocksMu.Lock()
-	l, ok := s.repoUpdateLocks[repo]
-	if !ok {
-		l = &locks{
-			once: new(sync.Once),
-			mu:   new(sync.Mutex),
-		}
-		s.repoUpdateLocks[repo] = l
-	}
+	l := s.repoUpdateLocksLocked(repo)
 	once := l.once
 	mu := l.mu
 	s.repoUpdateLocksMu.Unlock()
@@ -1012,6 +1113,21 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string
 	}
 }
 
+// repoUpdateLocksLocked returns the locks for updating repo, creating them if
+// necessary. The caller must hold s.repoUpdateLocksMu.
+func (s *Server) repoUpdateLocksLocked(repo api.RepoName) *locks {
+	repo = protocol.NormalizeRepo(repo)
+	l, ok := s.repoUpdateLocks[repo]
+	if !ok {
+		l = &locks{
+			once: new(sync.Once),
+			mu:   new(sync.Mutex),
+		}
+		s.repoUpdateLocks[repo] = l
+	}
+	return l
+}
+
 // setLastChanged discerns an approximate last-changed timestamp for a
 // repository. This can be approximate; it's used to determine how often we
 // should run `git fetch`, but is not relied on strongly. The basic plan
@@ -1209,6 +1325,10 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 		log15.Warn("Failed to update last changed time", "repo", repo, "error", err)
 	}
 
+	if _, err := updateRepoSize(ctx, filepath.Join(dir, ".git")); err != nil {
+		log15.Warn("Failed to update repo size", "repo", repo, "error", err)
+	}
+
 	headBranch := "master"
 
 	// try to fetch HEAD from origin
@@ -102,9 +102,11 @@ func TestRequest(t *testing.T) {
 	s := &Server{ReposDir: "/testroot", skipCloneForTests: true}
 	h := s.Handler()
 
+	origRepoCloned := repoCloned
 	repoCloned = func(dir string) bool {
 		return dir == "/testroot/github.com/gorilla/mux" || dir == "/testroot/my-mux"
 	}
+	defer func() { repoCloned = origRepoCloned }()
 
 	testRepoExists = func(ctx context.Context, url string) error {
 		if url == "https://github.com/nicksnyder/go-i18n.git" {
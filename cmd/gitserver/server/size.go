@@ -0,0 +1,342 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// The disk space used by each clone is recorded in the sg_size file in
+// $GIT_DIR after it is cloned, fetched or maintained. Computing it from the
+// object counts git keeps is cheap, so the size stays up to date without
+// walking every repository.
+//
+// The gitRepoSizeLimits site configuration limits the size of clones. While
+// cloning, the size of the partial clone is checked periodically and the clone
+// is aborted once it exceeds its limit.
+
+// sizeFile is the name of the file in $GIT_DIR recording the size of the
+// repository in bytes.
+const sizeFile = "sg_size"
+
+// sizeCheckInterval is how often the size of a running clone is checked
+// against its limit.
+var sizeCheckInterval = 5 * time.Second
+
+// After a clone is refused for exceeding its size limit, the repository is not
+// cloned again for refusedCloneBackoff, doubling with every further refusal up
+// to maxRefusedCloneBackoff. Changing the limit allows an immediate retry.
+var (
+	refusedCloneBackoff    = time.Hour
+	maxRefusedCloneBackoff = 24 * time.Hour
+)
+
+// Actions taken when a clone exceeds its size limit.
+const (
+	repoSizeLimitRefuse  = "refuse"
+	repoSizeLimitShallow = "shallow"
+)
+
+// repoSizeLimit is the size limit of a repository.
+type repoSizeLimit struct {
+	maxSize int64 // in bytes, 0 means no limit
+	action  string
+}
+
+// repoSizeLimitFor returns the size limit for the repository cloned from
+// remoteURL.
+func repoSizeLimitFor(remoteURL string) repoSizeLimit {
+	return repoSizeLimitFromConfig(conf.Get().GitRepoSizeLimits, remoteURL)
+}
+
+func repoSizeLimitFromConfig(c *schema.GitRepoSizeLimits, remoteURL string) repoSizeLimit {
+	if c == nil {
+		return repoSizeLimit{}
+	}
+	maxSizeMB, action := c.MaxSizeMB, c.Action
+	if e := externalServiceRepoSizeLimit(c.ExternalServices, remoteURL); e != nil {
+		maxSizeMB = e.MaxSizeMB
+		if e.Action != "" {
+			action = e.Action
+		}
+	}
+	if action == "" {
+		action = repoSizeLimitRefuse
+	}
+	return repoSizeLimit{maxSize: int64(maxSizeMB) * 1024 * 1024, action: action}
+}
+
+// externalServiceRepoSizeLimit returns the limit in limits of the code host
+// remoteURL is cloned from, or nil. A limit matches if its URL has the same
+// host name as remoteURL and its path is a prefix of the path of remoteURL.
+// The matching limit with the longest path wins, so code hosts which share a
+// host name can have different limits.
+func externalServiceRepoSizeLimit(limits []*schema.ExternalServiceRepoSizeLimit, remoteURL string) *schema.ExternalServiceRepoSizeLimit {
+	host, path := remoteHostAndPath(remoteURL)
+	if host == "" {
+		return nil
+	}
+	var (
+		match     *schema.ExternalServiceRepoSizeLimit
+		matchPath string
+	)
+	for _, e := range limits {
+		h, p := remoteHostAndPath(e.Url)
+		if h != host || (p != "" && path != p && !strings.HasPrefix(path, p+"/")) {
+			continue
+		}
+		if match == nil || len(p) > len(matchPath) {
+			match, matchPath = e, p
+		}
+	}
+	return match
+}
+
+// remoteHostAndPath returns the lowercase host name and path (without leading
+// or trailing slashes) of a git remote URL, which may use the scp-like syntax
+// user@host:path.
+func remoteHostAndPath(remoteURL string) (host, path string) {
+	if u, err := url.Parse(remoteURL); err == nil && u.Host != "" {
+		return strings.ToLower(u.Hostname()), strings.Trim(strings.ToLower(u.Path), "/")
+	}
+	i := strings.Index(remoteURL, ":")
+	if i < 0 {
+		return "", ""
+	}
+	host, path = remoteURL[:i], remoteURL[i+1:]
+	if j := strings.LastIndex(host, "@"); j >= 0 {
+		host = host[j+1:]
+	}
+	return strings.ToLower(host), strings.Trim(strings.ToLower(path), "/")
+}
+
+// cloneRefusal records that the clone of a repository was refused because it
+// exceeded its size limit.
+type cloneRefusal struct {
+	limit   repoSizeLimit // the limit which was exceeded
+	count   int           // the number of consecutive refusals
+	retryAt time.Time     // when the repository may be cloned again
+}
+
+// repoTooLargeError is returned when a clone exceeds its size limit.
+type repoTooLargeError struct {
+	maxSize int64
+	shallow bool
+}
+
+func (e *repoTooLargeError) Error() string {
+	mb := e.maxSize / 1024 / 1024
+	if e.shallow {
+		return fmt.Sprintf("repository exceeds the size limit of %d MB, even when only its most recent commits are cloned", mb)
+	}
+	return fmt.Sprintf("repository exceeds the size limit of %d MB", mb)
+}
+
+// cloneWithSizeLimit runs git with args to clone a repository into dir,
+// killing it if dir grows larger than maxSize bytes. If it is killed, a
+// *repoTooLargeError is returned. shallow is true if args make a shallow
+// clone.
+func (s *Server) cloneWithSizeLimit(ctx context.Context, args []string, dir string, maxSize int64, shallow bool, progress io.Writer) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if maxSize <= 0 {
+		return s.runWithRemoteOpts(ctx, cmd, progress)
+	}
+
+	var tooLarge int32
+	go func() {
+		ticker := time.NewTicker(sizeCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if size, err := dirSize(dir); err == nil && size > maxSize {
+				atomic.StoreInt32(&tooLarge, 1)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	output, err := s.runWithRemoteOpts(ctx, cmd, progress)
+	if err != nil && atomic.LoadInt32(&tooLarge) == 1 {
+		return output, &repoTooLargeError{maxSize: maxSize, shallow: shallow}
+	}
+	if err == nil {
+		// The clone may have finished between two checks.
+		if size, err := dirSize(dir); err == nil && size > maxSize {
+			return output, &repoTooLargeError{maxSize: maxSize, shallow: shallow}
+		}
+	}
+	return output, err
+}
+
+// updateRepoSize computes the size of the repository at gitDir and records it
+// in sizeFile.
+func updateRepoSize(ctx context.Context, gitDir string) (int64, error) {
+	counts, err := countObjects(ctx, gitDir)
+	if err != nil {
+		return 0, err
+	}
+	size := counts.size
+	if err := ioutil.WriteFile(filepath.Join(gitDir, sizeFile), []byte(strconv.FormatInt(size, 10)), 0600); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// repoSize returns the size of the repository at gitDir recorded in
+// sizeFile, computing it if it has not been recorded yet.
+func repoSize(ctx context.Context, gitDir string) (int64, error) {
+	b, err := ioutil.ReadFile(filepath.Join(gitDir, sizeFile))
+	if os.IsNotExist(err) {
+		return updateRepoSize(ctx, gitDir)
+	} else if err != nil {
+		return 0, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return updateRepoSize(ctx, gitDir)
+	}
+	return size, nil
+}
+
+// repoShallow reports whether the repository at gitDir is a shallow clone.
+func repoShallow(gitDir string) bool {
+	_, err := os.Stat(filepath.Join(gitDir, "shallow"))
+	return err == nil
+}
+
+// setLastError records the error of the last attempt to clone the repository
+// in dir. An empty err clears the record.
+func (s *Server) setLastError(dir, err string) {
+	s.lastErrorsMu.Lock()
+	defer s.lastErrorsMu.Unlock()
+	if err == "" {
+		delete(s.lastErrors, dir)
+		return
+	}
+	if s.lastErrors == nil {
+		s.lastErrors = make(map[string]string)
+	}
+	s.lastErrors[dir] = err
+}
+
+// lastError returns the error of the last attempt to clone the repository in
+// dir, or the empty string.
+func (s *Server) lastError(dir string) string {
+	s.lastErrorsMu.Lock()
+	defer s.lastErrorsMu.Unlock()
+	return s.lastErrors[dir]
+}
+
+// setCloneRefused records whether the last attempt to clone the repository in
+// dir was refused because it exceeded limit.
+func (s *Server) setCloneRefused(dir string, limit repoSizeLimit, refused bool) {
+	s.lastErrorsMu.Lock()
+	defer s.lastErrorsMu.Unlock()
+	if !refused {
+		delete(s.refusals, dir)
+		return
+	}
+	if s.refusals == nil {
+		s.refusals = make(map[string]*cloneRefusal)
+	}
+	r := s.refusals[dir]
+	if r == nil || r.limit != limit {
+		r = &cloneRefusal{limit: limit}
+		s.refusals[dir] = r
+	}
+	r.count++
+	backoff := maxRefusedCloneBackoff
+	if r.count <= 16 && refusedCloneBackoff<<uint(r.count-1) < maxRefusedCloneBackoff {
+		backoff = refusedCloneBackoff << uint(r.count-1)
+	}
+	r.retryAt = time.Now().Add(backoff)
+}
+
+// checkCloneRefused returns an error if the repository in dir was refused
+// because it exceeded limit, and may not be cloned again yet.
+func (s *Server) checkCloneRefused(dir string, limit repoSizeLimit) error {
+	s.lastErrorsMu.Lock()
+	defer s.lastErrorsMu.Unlock()
+	r := s.refusals[dir]
+	if r == nil || r.limit != limit || time.Now().After(r.retryAt) {
+		return nil
+	}
+	return fmt.Errorf("%s (not cloning it again until %s)", s.lastErrors[dir], r.retryAt.Format(time.RFC3339))
+}
+
+// repoSizes returns the cloned repositories and their sizes, largest first. If
+// limit is positive, at most limit repositories are returned.
+func (s *Server) repoSizes(ctx context.Context, limit int) ([]protocol.RepoSize, error) {
+	gitDirs, err := s.findGitDirs(s.ReposDir)
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]protocol.RepoSize, 0, len(gitDirs))
+	for _, gitDir := range gitDirs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		size, err := repoSize(ctx, gitDir)
+		if err != nil {
+			log15.Warn("error getting repo size", "repo", gitDir, "error", err)
+			continue
+		}
+		repos = append(repos, protocol.RepoSize{
+			Repo: api.RepoName(strings.TrimPrefix(filepath.Dir(gitDir), s.ReposDir+"/")),
+			Size: size,
+		})
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		return repos[i].Size > repos[j].Size
+	})
+	if limit > 0 && len(repos) > limit {
+		repos = repos[:limit]
+	}
+	return repos, nil
+}
+
+func (s *Server) handleRepoSizes(w http.ResponseWriter, r *http.Request) {
+	var req protocol.RepoSizesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repos, err := s.repoSizes(r.Context(), req.Limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := protocol.RepoSizesResponse{Repos: repos}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
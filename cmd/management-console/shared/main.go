@@ -224,13 +224,15 @@ func serveUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	critical, err := confdb.CriticalCreateIfUpToDate(r.Context(), &lastIDInt32, args.Contents)
+	// Record the change in the configuration history (shown to site admins), so that changes
+	// made in the management console can be reviewed and rolled back like other changes.
+	critical, err := confdb.CriticalWriteAndRecordChange(r.Context(), &lastIDInt32, args.Contents)
 	if err != nil {
 		if err == confdb.ErrNewerEdit {
 			httpError(w, confdb.ErrNewerEdit.Error(), "newer_edit")
 			return
 		}
-		logger.Error("confdb.CriticalCreateIfUpToDate failed", "error", err)
+		logger.Error("confdb.CriticalWriteAndRecordChange failed", "error", err)
 		httpError(w, errors.Wrap(err, "Error updating latest critical configuration").Error(), "internal_error")
 		return
 	}
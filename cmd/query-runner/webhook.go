@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// Webhook event types, sent in the X-Sourcegraph-Event header and the event field of the payload.
+const (
+	webhookEventResults = "results" // new results were found for the saved search
+	webhookEventTest    = "test"    // a site admin requested a test notification
+)
+
+var (
+	// webhookMaxAttempts is the number of times a webhook notification is sent before giving up,
+	// if the webhook URL does not respond with a 2xx status code.
+	webhookMaxAttempts = 3
+
+	// webhookRetryBackoff is how long to wait before the first retry. It doubles for each
+	// subsequent retry.
+	webhookRetryBackoff = 5 * time.Second
+
+	webhookClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// webhookPayload is the JSON body of a webhook notification.
+type webhookPayload struct {
+	Event       string             `json:"event"`
+	SentAt      time.Time          `json:"sentAt"`
+	SavedSearch webhookSavedSearch `json:"savedSearch"`
+
+	// The following fields are only set for "results" events.
+	ApproximateResultCount string        `json:"approximateResultCount,omitempty"`
+	SearchURL              string        `json:"searchURL,omitempty"`
+	Results                []interface{} `json:"results,omitempty"` // the new search results, as returned by the GraphQL API
+}
+
+type webhookSavedSearch struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Query       string `json:"query"`
+	UserID      *int32 `json:"userID,omitempty"`
+	OrgID       *int32 `json:"orgID,omitempty"`
+}
+
+func newWebhookPayload(event string, query api.ConfigSavedQuery) *webhookPayload {
+	return &webhookPayload{
+		Event:  event,
+		SentAt: time.Now().UTC(),
+		SavedSearch: webhookSavedSearch{
+			Key:         query.Key,
+			Description: query.Description,
+			Query:       query.Query,
+			UserID:      query.UserID,
+			OrgID:       query.OrgID,
+		},
+	}
+}
+
+func (n *notifier) webhookNotify(ctx context.Context) {
+	if !n.query.NotifyWebhook {
+		return
+	}
+
+	payload := newWebhookPayload(webhookEventResults, n.query)
+	payload.ApproximateResultCount = n.results.Data.Search.Results.ApproximateResultCount
+	payload.SearchURL = searchURL(n.newQuery, utmSourceWebhook)
+	payload.Results = n.results.Data.Search.Results.Results
+	if err := webhookNotify(ctx, n.query, payload); err != nil {
+		log15.Error("Failed to send webhook notification for new saved search results.", "description", n.query.Description, "error", err)
+		return
+	}
+	logEvent(0, "", "SavedSearchWebhookNotificationSent", "results")
+}
+
+func webhookNotifyTest(ctx context.Context, query api.ConfigSavedQuery) error {
+	if !query.NotifyWebhook {
+		return nil
+	}
+	return webhookNotify(ctx, query, newWebhookPayload(webhookEventTest, query))
+}
+
+// webhookNotify POSTs the payload to the saved search's webhook URL, retrying with exponential
+// backoff if the request fails or the response has a 429 or 5xx status code.
+//
+// If the saved search has a webhook secret, the request has an X-Sourcegraph-Signature header
+// "sha256=HEX", where HEX is the hex-encoded HMAC-SHA256 of the request body keyed by the secret.
+func webhookNotify(ctx context.Context, query api.ConfigSavedQuery, payload *webhookPayload) error {
+	if query.WebhookURL == nil || *query.WebhookURL == "" {
+		return errors.New("unable to send webhook notification because the saved search has no webhook URL configured")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "marshaling webhook payload")
+	}
+	var signature string
+	if query.WebhookSecret != nil && *query.WebhookSecret != "" {
+		signature = "sha256=" + signWebhookPayload(body, *query.WebhookSecret)
+	}
+
+	backoff := webhookRetryBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := postWebhook(ctx, *query.WebhookURL, payload.Event, signature, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= webhookMaxAttempts {
+			return errors.Wrapf(err, "webhook notification failed after %d attempt(s)", attempt)
+		}
+		log15.Warn("Webhook notification failed, retrying.", "description", query.Description, "attempt", attempt, "backoff", backoff, "error", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// postWebhook sends one webhook notification request. It returns whether the request should be
+// retried if it failed.
+func postWebhook(ctx context.Context, webhookURL, event, signature string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Sourcegraph-Webhook")
+	req.Header.Set("X-Sourcegraph-Event", event)
+	if signature != "" {
+		req.Header.Set("X-Sourcegraph-Signature", signature)
+	}
+
+	resp, err := webhookClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook URL responded with HTTP status %d", resp.StatusCode)
+}
+
+// signWebhookPayload returns the hex-encoded HMAC-SHA256 of body keyed by secret.
+func signWebhookPayload(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
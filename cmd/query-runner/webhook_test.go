@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestWebhookNotify(t *testing.T) {
+	defer func(backoff time.Duration) { webhookRetryBackoff = backoff }(webhookRetryBackoff)
+	webhookRetryBackoff = time.Millisecond
+
+	// failures is the number of requests the server fails with failStatus before succeeding.
+	var (
+		failures   int
+		failStatus int
+		requests   int
+		gotBody    []byte
+		gotHeader  http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(failStatus)
+			return
+		}
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		gotHeader = r.Header
+	}))
+	defer srv.Close()
+
+	webhookURL, secret := srv.URL, "s3cr3t"
+	query := api.ConfigSavedQuery{
+		Key:           "1",
+		Description:   "d",
+		Query:         "q type:diff",
+		NotifyWebhook: true,
+		WebhookURL:    &webhookURL,
+		WebhookSecret: &secret,
+	}
+	newPayload := func() *webhookPayload {
+		p := newWebhookPayload(webhookEventResults, query)
+		p.ApproximateResultCount = "1"
+		p.Results = []interface{}{map[string]interface{}{"__typename": "CommitSearchResult"}}
+		return p
+	}
+
+	t.Run("signed", func(t *testing.T) {
+		failures, requests = 0, 0
+		if err := webhookNotify(context.Background(), query, newPayload()); err != nil {
+			t.Fatal(err)
+		}
+		if requests != 1 {
+			t.Errorf("got %d requests, want 1", requests)
+		}
+		if want := "sha256=" + signWebhookPayload(gotBody, secret); gotHeader.Get("X-Sourcegraph-Signature") != want {
+			t.Errorf("got signature %q, want %q", gotHeader.Get("X-Sourcegraph-Signature"), want)
+		}
+		if got := gotHeader.Get("X-Sourcegraph-Event"); got != webhookEventResults {
+			t.Errorf("got event %q, want %q", got, webhookEventResults)
+		}
+		var payload webhookPayload
+		if err := json.Unmarshal(gotBody, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.SavedSearch.Query != query.Query || payload.ApproximateResultCount != "1" || len(payload.Results) != 1 {
+			t.Errorf("unexpected payload %s", gotBody)
+		}
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		failures, requests = 0, 0
+		query := query
+		query.WebhookSecret = nil
+		if err := webhookNotify(context.Background(), query, newPayload()); err != nil {
+			t.Fatal(err)
+		}
+		if got := gotHeader.Get("X-Sourcegraph-Signature"); got != "" {
+			t.Errorf("got signature %q, want none", got)
+		}
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		failures, failStatus, requests = 2, http.StatusBadGateway, 0
+		if err := webhookNotify(context.Background(), query, newPayload()); err != nil {
+			t.Fatal(err)
+		}
+		if requests != 3 {
+			t.Errorf("got %d requests, want 3", requests)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		failures, failStatus, requests = webhookMaxAttempts, http.StatusServiceUnavailable, 0
+		if err := webhookNotify(context.Background(), query, newPayload()); err == nil {
+			t.Fatal("expected error")
+		}
+		if requests != webhookMaxAttempts {
+			t.Errorf("got %d requests, want %d", requests, webhookMaxAttempts)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		failures, failStatus, requests = 1, http.StatusNotFound, 0
+		if err := webhookNotify(context.Background(), query, newPayload()); err == nil {
+			t.Fatal("expected error")
+		}
+		if requests != 1 {
+			t.Errorf("got %d requests, want 1", requests)
+		}
+	})
+
+	t.Run("no webhook URL", func(t *testing.T) {
+		query := query
+		query.WebhookURL = nil
+		if err := webhookNotify(context.Background(), query, newPayload()); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
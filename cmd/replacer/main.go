@@ -59,8 +59,9 @@ func main() {
 	store.SetMaxConcurrentFetchTar(10)
 	store.Start()
 	service := &replace.Service{
-		Store: &store,
-		Log:   log15.Root(),
+		Store:        &store,
+		Log:          log15.Root(),
+		CreateCommit: gitserver.DefaultClient.CreateCommit,
 	}
 	handler := nethttp.Middleware(opentracing.GlobalTracer(), service)
 
@@ -34,10 +34,43 @@ type Request struct {
 	// the fetch will still happen in the background so future requests don't have to wait.
 	FetchTimeout string
 
+	// Preview, if true, returns only the path and diff of each changed file.
+	Preview bool
+
+	// CommitBranch, if set, creates a commit containing all replacements
+	// instead of streaming results. The response is a CommitResponse.
+	//
+	// The commit is stored in gitserver's copy of the repository at
+	// refs/replacer/<CommitBranch>, which must not exist yet. With
+	// CommitPush set it is also pushed to the branch CommitBranch of the
+	// repository's code host, which must either not exist or be an ancestor
+	// of the commit.
+	CommitBranch string
+
+	// CommitPush pushes the commit created for CommitBranch to the
+	// repository's code host.
+	CommitPush bool
+
+	// CommitMessage, CommitAuthorName and CommitAuthorEmail describe the
+	// commit created for CommitBranch. Gitserver uses defaults for any left
+	// empty.
+	CommitMessage     string
+	CommitAuthorName  string
+	CommitAuthorEmail string
+
 	RewriteSpecification
 }
 
 type RewriteSpecification struct {
+	// Engine is the name of the rewrite engine to use. It is "comby" (the
+	// default), "regexp", or the name of an engine in the replacer.engines
+	// site configuration.
+	//
+	// For the regexp engine, MatchTemplate is a Go regular expression and
+	// RewriteTemplate may reference its capture groups, eg "$1" or
+	// "${name}".
+	Engine string
+
 	// A template pattern that expresses what to match.
 	MatchTemplate string
 
@@ -48,5 +81,60 @@ type RewriteSpecification struct {
 	FileExtension string
 }
 
+// Result is the result of rewriting a single file. Replacer streams one
+// JSON-encoded Result per line.
+type Result struct {
+	// Path is the path of the file relative to the repository root.
+	Path string
+
+	// Diff is a unified diff from the original to the rewritten file. Its
+	// headers are "a/<Path>" and "b/<Path>", so it can be passed to git
+	// apply.
+	Diff string
+
+	// Content is the rewritten file. It is omitted in preview mode.
+	Content string `json:",omitempty"`
+
+	// Replacements are the ranges of Content which were replaced. They are
+	// omitted in preview mode.
+	Replacements []Replacement `json:",omitempty"`
+}
+
+// Replacement is a range of the rewritten file which was replaced.
+type Replacement struct {
+	Range   Range
+	Content string
+}
+
+// Range is a range of a file. Start is inclusive and End is exclusive.
+type Range struct {
+	Start, End Location
+}
+
+// Location is a position in a file. Offset is a 0-based byte offset, Line and
+// Column are 1-based.
+type Location struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// CommitResponse is the response to a request with CommitBranch set.
+type CommitResponse struct {
+	// Ref is the ref of the created commit in gitserver's copy of the
+	// repository, eg "refs/replacer/my-branch".
+	Ref string
+
+	// Commit is the ID of the created commit.
+	Commit api.CommitID
+
+	// PushedRef is the branch of the code host the commit was pushed to, eg
+	// "refs/heads/my-branch". It is empty unless CommitPush was set.
+	PushedRef string `json:",omitempty"`
+
+	// Results are the changed files, in preview form.
+	Results []Result
+}
+
 // GitserverRepo returns the repository information necessary to perform gitserver requests.
 func (r Request) GitserverRepo() gitserver.Repo { return gitserver.Repo{Name: r.Repo, URL: r.URL} }
@@ -0,0 +1,84 @@
+package replace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/store"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// commandEngine runs a command configured in the replacer.engines site
+// configuration on each file. The command reads the file on stdin and writes
+// the rewritten file to stdout.
+type commandEngine struct {
+	name    string
+	command []*template.Template
+}
+
+func newCommandEngine(c *schema.ReplacerEngine) (*commandEngine, error) {
+	if len(c.Command) == 0 {
+		return nil, errors.Errorf("replacer engine %q has no command", c.Name)
+	}
+	e := &commandEngine{name: c.Name}
+	for i, arg := range c.Command {
+		t, err := template.New(fmt.Sprintf("%s[%d]", c.Name, i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "replacer engine %q", c.Name)
+		}
+		e.command = append(e.command, t)
+	}
+	return e, nil
+}
+
+// commandData is the data available to the templates of a command.
+type commandData struct {
+	MatchTemplate   string
+	RewriteTemplate string
+	FileExtension   string
+	Path            string
+}
+
+func (e *commandEngine) rewrite(ctx context.Context, spec *protocol.RewriteSpecification, zipPath string, zf *store.ZipFile, preview bool, emit func(protocol.Result) error) error {
+	return rewriteFiles(ctx, zf, spec.FileExtension, preview, func(path string, data []byte) ([]byte, []protocol.Replacement, error) {
+		args, err := e.args(commandData{
+			MatchTemplate:   spec.MatchTemplate,
+			RewriteTemplate: spec.RewriteTemplate,
+			FileExtension:   spec.FileExtension,
+			Path:            path,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var stdout bytes.Buffer
+		stderr := &stderrBuffer{}
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stdout = &stdout
+		cmd.Stderr = stderr
+		if err := cmd.Run(); err != nil {
+			return nil, nil, errors.Wrapf(err, "replacer engine %q failed on %s: %s", e.name, path, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.Bytes(), nil, nil
+	}, emit)
+}
+
+// args returns the command line for the file described by data.
+func (e *commandEngine) args(data commandData) ([]string, error) {
+	args := make([]string, len(e.command))
+	for i, t := range e.command {
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return nil, errors.Wrapf(err, "replacer engine %q", e.name)
+		}
+		args[i] = b.String()
+	}
+	return args, nil
+}
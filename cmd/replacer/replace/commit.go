@@ -0,0 +1,72 @@
+package replace
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+)
+
+// createCommit creates a commit at refs/replacer/<p.CommitBranch> which
+// applies the diffs of results, pushes it to the branch p.CommitBranch if
+// p.CommitPush is set, and writes a protocol.CommitResponse to w.
+//
+// The commit is not created under refs/heads/, since gitserver mirrors the
+// branches of the code host there and the next fetch would remove it.
+func (s *Service) createCommit(ctx context.Context, p *protocol.Request, results []protocol.Result, w http.ResponseWriter) error {
+	if len(results) == 0 {
+		return badRequestError{"no files were changed, so there is nothing to commit"}
+	}
+	if s.CreateCommit == nil {
+		return errors.New("creating commits is not supported by this replacer")
+	}
+
+	req := gitserverprotocol.CreateCommitRequest{
+		Repo:       p.Repo,
+		BaseCommit: p.Commit,
+		Patch:      combinedPatch(results),
+		TargetRef:  "refs/replacer/" + p.CommitBranch,
+		CommitInfo: gitserverprotocol.PatchCommitInfo{
+			Message:     p.CommitMessage,
+			AuthorName:  p.CommitAuthorName,
+			AuthorEmail: p.CommitAuthorEmail,
+			Date:        time.Now(),
+		},
+	}
+	if p.CommitPush {
+		req.Push = &gitserverprotocol.PushConfig{RemoteRef: "refs/heads/" + p.CommitBranch}
+	}
+	resp, err := s.CreateCommit(ctx, req)
+	if err != nil {
+		return errors.Wrap(err, "creating commit")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(protocol.CommitResponse{
+		Ref:       resp.Ref,
+		Commit:    resp.Commit,
+		PushedRef: resp.PushedRef,
+		Results:   results,
+	})
+}
+
+// isValidBranchName reports whether name is safe to use as a branch name. It
+// is stricter than git check-ref-format.
+func isValidBranchName(name string) bool {
+	if strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") ||
+		strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, ".") ||
+		strings.Contains(name, "..") || strings.Contains(name, "//") || strings.Contains(name, "@{") {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r == 0x7f || strings.ContainsRune("~^:?*[\\", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package replace
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+)
+
+func TestCreateCommit(t *testing.T) {
+	var got gitserverprotocol.CreateCommitRequest
+	s := &Service{
+		CreateCommit: func(ctx context.Context, req gitserverprotocol.CreateCommitRequest) (*gitserverprotocol.CreateCommitResponse, error) {
+			got = req
+			resp := &gitserverprotocol.CreateCommitResponse{Ref: req.TargetRef, Commit: "cafebabecafebabecafebabecafebabecafebabe"}
+			if req.Push != nil {
+				resp.PushedRef = req.Push.RemoteRef
+			}
+			return resp, nil
+		},
+	}
+	p := &protocol.Request{
+		Repo:          "foo",
+		Commit:        "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+		CommitBranch:  "replace-foo",
+		CommitMessage: "Replace foo with bar",
+	}
+	results := []protocol.Result{{Path: "main.go", Diff: mainDiff}, {Path: "dir/a.go", Diff: aDiff}}
+
+	w := httptest.NewRecorder()
+	if err := s.createCommit(context.Background(), p, results, w); err != nil {
+		t.Fatal(err)
+	}
+	if got.Repo != p.Repo || got.BaseCommit != p.Commit || got.TargetRef != "refs/replacer/replace-foo" || got.Patch != mainDiff+aDiff || got.CommitInfo.Message != p.CommitMessage || got.Push != nil {
+		t.Errorf("unexpected CreateCommit request %+v", got)
+	}
+	var resp protocol.CommitResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := (protocol.CommitResponse{Ref: "refs/replacer/replace-foo", Commit: "cafebabecafebabecafebabecafebabecafebabe", Results: results}); !reflect.DeepEqual(resp, want) {
+		t.Errorf("got response %+v, want %+v", resp, want)
+	}
+
+	p.CommitPush = true
+	w = httptest.NewRecorder()
+	if err := s.createCommit(context.Background(), p, results, w); err != nil {
+		t.Fatal(err)
+	}
+	if got.Push == nil || got.Push.RemoteRef != "refs/heads/replace-foo" || got.Push.Force {
+		t.Errorf("unexpected push config %+v", got.Push)
+	}
+	resp = protocol.CommitResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.PushedRef != "refs/heads/replace-foo" {
+		t.Errorf("got pushed ref %q, want %q", resp.PushedRef, "refs/heads/replace-foo")
+	}
+
+	if err := s.createCommit(context.Background(), p, nil, httptest.NewRecorder()); !isBadRequest(err) {
+		t.Errorf("no results: got err %v, want bad request", err)
+	}
+}
+
+func TestIsValidBranchName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"replace-foo":     true,
+		"user/replace.1":  true,
+		"-f":              false,
+		"a..b":            false,
+		"a b":             false,
+		"a:b":             false,
+		"a.lock":          false,
+		"/a":              false,
+		"a//b":            false,
+		"a@{1}":           false,
+		"refs/heads/a~1":  false,
+		"a\\b":            false,
+		"trailing/slash/": false,
+	} {
+		if got := isValidBranchName(name); got != want {
+			t.Errorf("isValidBranchName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
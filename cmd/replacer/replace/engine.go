@@ -0,0 +1,88 @@
+package replace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/store"
+	"github.com/sourcegraph/sourcegraph/pkg/textdiff"
+)
+
+// An engine rewrites the files of a repository archive.
+type engine interface {
+	// rewrite rewrites the files of the archive zf, which is stored at
+	// zipPath, as specified by spec. It calls emit with the result for each
+	// changed file. If preview is true, the results only contain the path
+	// and diff.
+	rewrite(ctx context.Context, spec *protocol.RewriteSpecification, zipPath string, zf *store.ZipFile, preview bool, emit func(protocol.Result) error) error
+}
+
+// engineFor returns the engine selected by spec.Engine.
+func engineFor(spec *protocol.RewriteSpecification) (engine, error) {
+	switch spec.Engine {
+	case "", "comby":
+		return &ExternalTool{Name: "comby", BinaryPath: "comby"}, nil
+	case "regexp":
+		re, err := regexp.Compile(spec.MatchTemplate)
+		if err != nil {
+			return nil, badRequestError{fmt.Sprintf("invalid regular expression in MatchTemplate: %s", err)}
+		}
+		return &regexpEngine{re: re}, nil
+	}
+	for _, c := range conf.Get().ReplacerEngines {
+		if c.Name == spec.Engine {
+			return newCommandEngine(c)
+		}
+	}
+	return nil, badRequestError{fmt.Sprintf("unknown rewrite engine %q", spec.Engine)}
+}
+
+// newResult returns the result of rewriting the file at path from old to new.
+// replacements are the ranges of new which were replaced, if known.
+func newResult(path string, old, new []byte, replacements []protocol.Replacement, preview bool) protocol.Result {
+	result := protocol.Result{
+		Path: path,
+		Diff: textdiff.Unified(path, string(old), string(new)),
+	}
+	if !preview {
+		result.Content = string(new)
+		result.Replacements = replacements
+	}
+	return result
+}
+
+// rewriteFiles calls rewrite for each file of zf which has the extension ext
+// (or every file, if ext is empty) and is not binary. rewrite returns the new
+// contents of the file and the ranges which were replaced. For each file
+// which changed, rewriteFiles calls emit with its result.
+func rewriteFiles(ctx context.Context, zf *store.ZipFile, ext string, preview bool, rewrite func(path string, data []byte) ([]byte, []protocol.Replacement, error), emit func(protocol.Result) error) error {
+	for i := range zf.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f := &zf.Files[i]
+		if !strings.HasSuffix(f.Name, ext) {
+			continue
+		}
+		data := zf.DataFor(f)
+		if bytes.IndexByte(data, 0) >= 0 {
+			continue
+		}
+		out, replacements, err := rewrite(f.Name, data)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(out, data) {
+			continue
+		}
+		if err := emit(newResult(f.Name, data, out, replacements, preview)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
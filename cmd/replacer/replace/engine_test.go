@@ -0,0 +1,180 @@
+package replace
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/store"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestEngineFor(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ReplacerEngines: []*schema.ReplacerEngine{
+			{Name: "sed", Command: []string{"sed", "s/{{.MatchTemplate}}/{{.RewriteTemplate}}/g"}},
+			{Name: "bad", Command: []string{"{{"}},
+		},
+	}})
+	defer conf.Mock(nil)
+
+	tests := map[string]struct {
+		spec           protocol.RewriteSpecification
+		want           string
+		wantBadRequest bool
+		wantErr        bool
+	}{
+		"default":        {spec: protocol.RewriteSpecification{}, want: "*replace.ExternalTool"},
+		"comby":          {spec: protocol.RewriteSpecification{Engine: "comby"}, want: "*replace.ExternalTool"},
+		"regexp":         {spec: protocol.RewriteSpecification{Engine: "regexp", MatchTemplate: "a+"}, want: "*replace.regexpEngine"},
+		"invalid regexp": {spec: protocol.RewriteSpecification{Engine: "regexp", MatchTemplate: "a("}, wantBadRequest: true},
+		"command":        {spec: protocol.RewriteSpecification{Engine: "sed"}, want: "*replace.commandEngine"},
+		"invalid config": {spec: protocol.RewriteSpecification{Engine: "bad"}, wantErr: true},
+		"unknown":        {spec: protocol.RewriteSpecification{Engine: "foo"}, wantBadRequest: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e, err := engineFor(&test.spec)
+			if test.wantBadRequest || test.wantErr {
+				if err == nil || isBadRequest(err) != test.wantBadRequest {
+					t.Fatalf("got err %v, want bad request %v", err, test.wantBadRequest)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := reflect.TypeOf(e).String(); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRegexpEngine(t *testing.T) {
+	zf := mockZipFile(t, map[string]string{
+		"a.go":   "package a\n\nvar x = foo(1) + foo(22)\n",
+		"b.go":   "package b\n",
+		"c.txt":  "foo(3)\n",
+		"d.go":   "foo(4)\x00",
+		"dir.go": "// foo(5)\n",
+	})
+	spec := &protocol.RewriteSpecification{
+		Engine:          "regexp",
+		MatchTemplate:   `foo\((?P<arg>\d+)\)`,
+		RewriteTemplate: "bar(${arg}, $1)",
+		FileExtension:   ".go",
+	}
+	e, err := engineFor(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []protocol.Result
+	err = e.rewrite(context.Background(), spec, "", zf, false, func(r protocol.Result) error {
+		got = append(got, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []protocol.Result{
+		{
+			Path:    "a.go",
+			Diff:    "--- a/a.go\n+++ b/a.go\n@@ -1,3 +1,3 @@\n package a\n \n-var x = foo(1) + foo(22)\n+var x = bar(1, 1) + bar(22, 22)\n",
+			Content: "package a\n\nvar x = bar(1, 1) + bar(22, 22)\n",
+			Replacements: []protocol.Replacement{
+				{
+					Range:   protocol.Range{Start: protocol.Location{Offset: 19, Line: 3, Column: 9}, End: protocol.Location{Offset: 28, Line: 3, Column: 18}},
+					Content: "bar(1, 1)",
+				},
+				{
+					Range:   protocol.Range{Start: protocol.Location{Offset: 31, Line: 3, Column: 21}, End: protocol.Location{Offset: 42, Line: 3, Column: 32}},
+					Content: "bar(22, 22)",
+				},
+			},
+		},
+		{
+			Path:    "dir.go",
+			Diff:    "--- a/dir.go\n+++ b/dir.go\n@@ -1 +1 @@\n-// foo(5)\n+// bar(5, 5)\n",
+			Content: "// bar(5, 5)\n",
+			Replacements: []protocol.Replacement{{
+				Range:   protocol.Range{Start: protocol.Location{Offset: 3, Line: 1, Column: 4}, End: protocol.Location{Offset: 12, Line: 1, Column: 13}},
+				Content: "bar(5, 5)",
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCommandEngine(t *testing.T) {
+	zf := mockZipFile(t, map[string]string{
+		"a.txt": "foo\nbar\n",
+		"b.txt": "bar\n",
+	})
+	spec := &protocol.RewriteSpecification{MatchTemplate: "foo", RewriteTemplate: "baz"}
+	e, err := newCommandEngine(&schema.ReplacerEngine{
+		Name:    "sed",
+		Command: []string{"sed", "s/{{.MatchTemplate}}/{{.RewriteTemplate}}/g"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []protocol.Result
+	err = e.rewrite(context.Background(), spec, "", zf, true, func(r protocol.Result) error {
+		got = append(got, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []protocol.Result{{Path: "a.txt", Diff: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n-foo\n+baz\n bar\n"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	e, err = newCommandEngine(&schema.ReplacerEngine{Name: "false", Command: []string{"false"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.rewrite(context.Background(), spec, "", zf, true, func(protocol.Result) error { return nil }); err == nil {
+		t.Error("got nil error from failing command")
+	}
+}
+
+func mockZipFile(t *testing.T, files map[string]string) *store.ZipFile {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zf, err := store.MockZipFile(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zf
+}
@@ -0,0 +1,66 @@
+package replace
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/store"
+)
+
+// regexpEngine replaces each match of a regular expression with the
+// RewriteTemplate, expanded as by (*regexp.Regexp).Expand.
+type regexpEngine struct {
+	re *regexp.Regexp
+}
+
+func (e *regexpEngine) rewrite(ctx context.Context, spec *protocol.RewriteSpecification, zipPath string, zf *store.ZipFile, preview bool, emit func(protocol.Result) error) error {
+	template := []byte(spec.RewriteTemplate)
+	return rewriteFiles(ctx, zf, spec.FileExtension, preview, func(path string, data []byte) ([]byte, []protocol.Replacement, error) {
+		matches := e.re.FindAllSubmatchIndex(data, -1)
+		if len(matches) == 0 {
+			return data, nil, nil
+		}
+
+		var (
+			out     []byte
+			offsets = make([]int, 0, 2*len(matches))
+			last    = 0
+		)
+		for _, m := range matches {
+			out = append(out, data[last:m[0]]...)
+			offsets = append(offsets, len(out))
+			out = e.re.Expand(out, template, data, m)
+			offsets = append(offsets, len(out))
+			last = m[1]
+		}
+		out = append(out, data[last:]...)
+
+		locations := locate(out, offsets)
+		replacements := make([]protocol.Replacement, len(matches))
+		for i := range replacements {
+			start, end := locations[2*i], locations[2*i+1]
+			replacements[i] = protocol.Replacement{
+				Range:   protocol.Range{Start: start, End: end},
+				Content: string(out[start.Offset:end.Offset]),
+			}
+		}
+		return out, replacements, nil
+	}, emit)
+}
+
+// locate returns the locations of the ascending byte offsets in data.
+func locate(data []byte, offsets []int) []protocol.Location {
+	locations := make([]protocol.Location, len(offsets))
+	line, lineStart, pos := 1, 0, 0
+	for i, offset := range offsets {
+		for ; pos < offset; pos++ {
+			if data[pos] == '\n' {
+				line++
+				lineStart = pos + 1
+			}
+		}
+		locations[i] = protocol.Location{Offset: offset, Line: line, Column: offset - lineStart + 1}
+	}
+	return locations
+}
@@ -10,15 +10,18 @@
 //
 // - Here is where replacer.go differs
 // * Pass the zip file path to external replacer tool(s) after validating
-// * Read tool stdout and write it out on the HTTP connection
-// * Input from stdout is expected to use JSON lines format, but the format isn't checked here: line-buffering is done on the frontend
+// * Read tool stdout as JSON lines and write a protocol.Result per changed file on the HTTP connection
+// * In preview mode, only the diff of each file is returned
+// * With CommitBranch set, the combined diff is committed by gitserver and optionally pushed
 
 package replace
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os/exec"
@@ -32,6 +35,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/store"
 	"gopkg.in/inconshreveable/log15.v2"
 
@@ -41,8 +45,14 @@ import (
 type Service struct {
 	Store *store.Store
 	Log   log15.Logger
+
+	// CreateCommit creates a commit in a repository. It is used for
+	// requests with CommitBranch set.
+	CreateCommit func(context.Context, gitserverprotocol.CreateCommitRequest) (*gitserverprotocol.CreateCommitResponse, error)
 }
 
+// ExternalTool is an engine which passes the zip archive to an external
+// tool which outputs JSON lines in the format of comby -json-lines.
 type ExternalTool struct {
 	Name       string
 	BinaryPath string
@@ -58,6 +68,30 @@ func (t *ExternalTool) command(spec *protocol.RewriteSpecification, zipPath stri
 	}
 }
 
+func (t *ExternalTool) rewrite(ctx context.Context, spec *protocol.RewriteSpecification, zipPath string, zf *store.ZipFile, preview bool, emit func(protocol.Result) error) error {
+	cmd, err := t.command(spec, zipPath)
+	if err != nil {
+		return err
+	}
+	stderr := &stderrBuffer{}
+	cmd.Stderr = stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.Wrap(err, "connecting to replacer tool stdout")
+	}
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "starting replacer tool")
+	}
+
+	err = readResults(stdout, preview, emit)
+	io.Copy(ioutil.Discard, stdout)
+	if waitErr := cmd.Wait(); waitErr != nil && err == nil {
+		err = errors.Wrapf(waitErr, "replacer tool failed: %s", stderr)
+	}
+	return err
+}
+
 var decoder = schema.NewDecoder()
 
 func init() {
@@ -157,6 +191,11 @@ func (s *Service) replace(ctx context.Context, p *protocol.Request, w http.Respo
 		}
 	}(time.Now())
 
+	eng, err := engineFor(&p.RewriteSpecification)
+	if err != nil {
+		return false, err
+	}
+
 	if p.FetchTimeout == "" {
 		p.FetchTimeout = "500ms"
 	}
@@ -191,41 +230,49 @@ func (s *Service) replace(ctx context.Context, p *protocol.Request, w http.Respo
 	archiveFiles.Observe(float64(nFiles))
 	archiveSize.Observe(float64(bytes))
 
-	w.Header().Set("Transfer-Encoding", "chunked")
-	w.WriteHeader(http.StatusOK)
-
-	t := &ExternalTool{
-		Name:       "comby",
-		BinaryPath: "comby",
+	if p.CommitBranch != "" {
+		var results []protocol.Result
+		err := eng.rewrite(ctx, &p.RewriteSpecification, zipPath, zf, true, func(result protocol.Result) error {
+			results = append(results, result)
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+		return false, s.createCommit(ctx, p, results, w)
 	}
 
-	cmd, err := t.command(&p.RewriteSpecification, zipPath)
-
-	if err != nil {
-		log15.Info("Invalid command: " + err.Error())
-		return
-	}
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(http.StatusOK)
 
-	stdout, err := cmd.StdoutPipe()
+	enc := json.NewEncoder(w)
+	err = eng.rewrite(ctx, &p.RewriteSpecification, zipPath, zf, p.Preview, func(result protocol.Result) error {
+		return enc.Encode(result)
+	})
 	if err != nil {
-		log15.Info("Could not connect to command stdout: " + err.Error())
-		return
-	}
-
-	if err := cmd.Start(); err != nil {
-		log15.Info("Error starting command: " + err.Error())
+		// The status has been sent, so we can only log the error.
+		log15.Info("Error writing replacer results: " + err.Error())
 	}
+	return false, nil
+}
 
-	_, err = io.Copy(w, stdout)
-	if err != nil {
-		log15.Info("Error copying external command output to HTTP writer: " + err.Error())
-	}
+// stderrBuffer keeps the last few kilobytes written to the stderr of the
+// replacer tool for error messages.
+type stderrBuffer struct {
+	buf []byte
+}
 
-	if err := cmd.Wait(); err != nil {
-		log15.Info("Error after executing command: " + err.Error())
+func (e *stderrBuffer) Write(p []byte) (int, error) {
+	const max = 4096
+	e.buf = append(e.buf, p...)
+	if len(e.buf) > max {
+		e.buf = e.buf[len(e.buf)-max:]
 	}
+	return len(p), nil
+}
 
-	return false, nil
+func (e *stderrBuffer) String() string {
+	return string(e.buf)
 }
 
 func validateParams(p *protocol.Request) error {
@@ -240,9 +287,17 @@ func validateParams(p *protocol.Request) error {
 	if p.RewriteSpecification.MatchTemplate == "" {
 		return errors.New("MatchTemplate must be non-empty")
 	}
+	if p.CommitBranch != "" && !isValidBranchName(p.CommitBranch) {
+		return errors.Errorf("CommitBranch is not a valid branch name (CommitBranch=%q)", p.CommitBranch)
+	}
 	return nil
 }
 
+type badRequestError struct{ msg string }
+
+func (e badRequestError) Error() string    { return e.msg }
+func (e badRequestError) BadRequest() bool { return true }
+
 const megabyte = float64(1000 * 1000)
 
 var (
@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -99,6 +100,13 @@ func TestReplace_badrequest(t *testing.T) {
 			Commit: "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
 			// No MatchTemplate
 		},
+		{
+			Repo:                 "foo",
+			URL:                  "u",
+			Commit:               "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+			RewriteSpecification: protocol.RewriteSpecification{MatchTemplate: "foo"},
+			CommitBranch:         "not a branch",
+		},
 	}
 
 	store, cleanup, err := newStore(nil)
@@ -129,6 +137,8 @@ func doReplace(u string, p *protocol.Request) (string, error) {
 		"MatchTemplate":   []string{p.RewriteSpecification.MatchTemplate},
 		"RewriteTemplate": []string{p.RewriteSpecification.RewriteTemplate},
 		"FileExtension":   []string{p.RewriteSpecification.FileExtension},
+		"Preview":         []string{strconv.FormatBool(p.Preview)},
+		"CommitBranch":    []string{p.CommitBranch},
 	}
 	resp, err := http.PostForm(u, form)
 	if err != nil {
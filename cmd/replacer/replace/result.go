@@ -0,0 +1,100 @@
+package replace
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+)
+
+// combyResult is a line of the output of comby -json-lines.
+type combyResult struct {
+	URI             string `json:"uri"`
+	RewrittenSource string `json:"rewritten_source"`
+	Substitutions   []struct {
+		Range struct {
+			Start combyLocation `json:"start"`
+			End   combyLocation `json:"end"`
+		} `json:"range"`
+		ReplacementContent string `json:"replacement_content"`
+	} `json:"in_place_substitutions"`
+	Diff string `json:"diff"`
+}
+
+type combyLocation struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// readResults reads the JSON lines output by comby from r and calls emit for
+// each changed file. If preview is true, the results only contain the path
+// and diff.
+func readResults(r io.Reader, preview bool, emit func(protocol.Result) error) error {
+	dec := json.NewDecoder(r)
+	for {
+		var cr combyResult
+		if err := dec.Decode(&cr); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "decoding replacer tool output")
+		}
+		if cr.URI == "" {
+			return errors.New("replacer tool output is missing a file path")
+		}
+		if cr.Diff == "" {
+			continue
+		}
+
+		result := protocol.Result{
+			Path: cr.URI,
+			Diff: normalizeDiff(cr.URI, cr.Diff),
+		}
+		if !preview {
+			result.Content = cr.RewrittenSource
+			for _, s := range cr.Substitutions {
+				result.Replacements = append(result.Replacements, protocol.Replacement{
+					Range: protocol.Range{
+						Start: protocol.Location(s.Range.Start),
+						End:   protocol.Location(s.Range.End),
+					},
+					Content: s.ReplacementContent,
+				})
+			}
+		}
+		if err := emit(result); err != nil {
+			return err
+		}
+	}
+}
+
+// normalizeDiff replaces the file headers of the unified diff of the file at
+// path with "a/<path>" and "b/<path>", as expected by git apply, and ensures
+// it ends with a newline unless its last line is a "\ No newline at end of
+// file" marker.
+func normalizeDiff(path, diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	if len(lines) >= 2 && strings.HasPrefix(lines[0], "--- ") && strings.HasPrefix(lines[1], "+++ ") {
+		lines = lines[2:]
+	}
+	diff = "--- a/" + path + "\n+++ b/" + path + "\n" + strings.Join(lines, "")
+	if lastLine := lines[len(lines)-1]; !strings.HasSuffix(diff, "\n") && lastLine != noNewlineMarker {
+		diff += "\n"
+	}
+	return diff
+}
+
+// noNewlineMarker is the line which follows a line without a trailing newline
+// in a unified diff.
+const noNewlineMarker = `\ No newline at end of file`
+
+// combinedPatch returns a patch which applies the diffs of all results.
+func combinedPatch(results []protocol.Result) string {
+	var b strings.Builder
+	for _, r := range results {
+		b.WriteString(r.Diff)
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package replace
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+)
+
+const combyOutput = `{"uri":"main.go","rewritten_source":"package main\n\nfunc main() {\n\tprintln(\"bar\")\n}\n","in_place_substitutions":[{"range":{"start":{"offset":37,"line":4,"column":11},"end":{"offset":40,"line":4,"column":14}},"replacement_content":"bar","environment":[]}],"diff":"--- main.go\n+++ main.go\n@@ -1,5 +1,5 @@\n package main\n \n func main() {\n-\tprintln(\"foo\")\n+\tprintln(\"bar\")\n }"}
+{"uri":"README.md","rewritten_source":"# Hello\n","in_place_substitutions":[],"diff":""}
+{"uri":"dir/a.go","rewritten_source":"bar\n","in_place_substitutions":[{"range":{"start":{"offset":0,"line":1,"column":1},"end":{"offset":3,"line":1,"column":4}},"replacement_content":"bar","environment":[]}],"diff":"@@ -1 +1 @@\n-foo\n+bar\n"}
+`
+
+var (
+	mainDiff = "--- a/main.go\n+++ b/main.go\n@@ -1,5 +1,5 @@\n package main\n \n func main() {\n-\tprintln(\"foo\")\n+\tprintln(\"bar\")\n }\n"
+	aDiff    = "--- a/dir/a.go\n+++ b/dir/a.go\n@@ -1 +1 @@\n-foo\n+bar\n"
+)
+
+func TestReadResults(t *testing.T) {
+	read := func(preview bool) []protocol.Result {
+		var results []protocol.Result
+		err := readResults(strings.NewReader(combyOutput), preview, func(r protocol.Result) error {
+			results = append(results, r)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+
+	got := read(false)
+	want := []protocol.Result{
+		{
+			Path:    "main.go",
+			Diff:    mainDiff,
+			Content: "package main\n\nfunc main() {\n\tprintln(\"bar\")\n}\n",
+			Replacements: []protocol.Replacement{{
+				Range:   protocol.Range{Start: protocol.Location{Offset: 37, Line: 4, Column: 11}, End: protocol.Location{Offset: 40, Line: 4, Column: 14}},
+				Content: "bar",
+			}},
+		},
+		{
+			Path:    "dir/a.go",
+			Diff:    aDiff,
+			Content: "bar\n",
+			Replacements: []protocol.Replacement{{
+				Range:   protocol.Range{Start: protocol.Location{Offset: 0, Line: 1, Column: 1}, End: protocol.Location{Offset: 3, Line: 1, Column: 4}},
+				Content: "bar",
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got = read(true)
+	want = []protocol.Result{{Path: "main.go", Diff: mainDiff}, {Path: "dir/a.go", Diff: aDiff}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("preview: got %+v, want %+v", got, want)
+	}
+
+	if want := mainDiff + aDiff; combinedPatch(got) != want {
+		t.Errorf("combinedPatch: got %q, want %q", combinedPatch(got), want)
+	}
+}
+
+func TestReadResults_invalid(t *testing.T) {
+	for _, output := range []string{"not json\n", `{"diff":"@@ -1 +1 @@\n-a\n+b\n"}`} {
+		err := readResults(strings.NewReader(output), false, func(protocol.Result) error { return nil })
+		if err == nil {
+			t.Errorf("%q: got nil error", output)
+		}
+	}
+}
+
+func TestNormalizeDiff(t *testing.T) {
+	tests := map[string]string{
+		"--- a.go\n+++ a.go\n@@ -1 +1 @@\n-foo\n+bar":                               "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-foo\n+bar\n",
+		"@@ -1 +1 @@\n-foo\n+bar\n":                                                 "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-foo\n+bar\n",
+		"--- a.go\n+++ a.go\n@@ -1 +1 @@\n-foo\n+bar\n\\ No newline at end of file": "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-foo\n+bar\n\\ No newline at end of file",
+	}
+	for diff, want := range tests {
+		if got := normalizeDiff("a.go", diff); got != want {
+			t.Errorf("normalizeDiff(%q): got %q, want %q", diff, got, want)
+		}
+	}
+}
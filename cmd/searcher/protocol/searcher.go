@@ -39,6 +39,11 @@ type Request struct {
 	// The deadline for the search request.
 	// It is parsed with time.Time.UnmarshalText.
 	Deadline string
+
+	// Stream if true will write the response as newline-delimited JSON
+	// StreamMessages instead of a single Response. Each FileMatch is sent
+	// as soon as it is found, followed by a final message with Done set.
+	Stream bool
 }
 
 // GitserverRepo returns the repository information necessary to perform gitserver requests.
@@ -54,9 +59,31 @@ type PatternInfo struct {
 	// IsRegExp if true will treat the Pattern as a regular expression.
 	IsRegExp bool
 
+	// IsStructuralPat if true will treat the Pattern as a structural match
+	// template (eg "fmt.Sprintf(:[args])"). Holes match text with balanced
+	// delimiters and are aware of string literals and comments. It takes
+	// precedence over IsRegExp, IsWordMatch and IsCaseSensitive.
+	IsStructuralPat bool
+
 	// IsWordMatch if true will only match the pattern at word boundaries.
 	IsWordMatch bool
 
+	// IsMultiline if true will match the pattern against the whole content
+	// of a file instead of line by line, so matches may span several lines.
+	// Each match is returned in FileMatch.MultilineMatches. LineMatches is
+	// still populated with the part of each match on every line it spans.
+	IsMultiline bool
+
+	// AndPatterns are regular expressions which must all match the content
+	// of a file for it to be returned, in addition to Pattern. Matches of
+	// every pattern are highlighted. eg "(foo or bar) and baz" is sent as
+	// Pattern "(foo)|(bar)" and AndPatterns ["baz"].
+	AndPatterns []string
+
+	// NotPatterns are regular expressions which must not match the content
+	// of a returned file.
+	NotPatterns []string
+
 	// IsCaseSensitive if false will ignore the case of text and pattern
 	// when finding matches.
 	IsCaseSensitive bool
@@ -125,11 +152,42 @@ type Response struct {
 	DeadlineHit bool
 }
 
+// StreamContentType is the Content-Type of a streaming search response.
+const StreamContentType = "application/x-ndjson"
+
+// StreamMessage is a single line of a streaming search response. Exactly one
+// of its fields is set.
+type StreamMessage struct {
+	// FileMatch is a match found in the archive.
+	FileMatch *FileMatch `json:",omitempty"`
+
+	// Done is the last message in the stream.
+	Done *StreamDone `json:",omitempty"`
+}
+
+// StreamDone is the trailer of a streaming search response. It carries the
+// fields of Response which are only known once the search has completed.
+type StreamDone struct {
+	// LimitHit is true if the stream may not include all FileMatches because a match limit was hit.
+	LimitHit bool
+
+	// DeadlineHit is true if the stream may not include all FileMatches because a deadline was hit.
+	DeadlineHit bool
+
+	// Error is set if the search failed after FileMatches had already been
+	// sent. Errors which occur before the first FileMatch are reported with
+	// a non-200 HTTP response like non-streaming requests.
+	Error string `json:",omitempty"`
+}
+
 // FileMatch is the struct used by vscode to receive search results
 type FileMatch struct {
 	Path        string
 	LineMatches []LineMatch
 
+	// MultilineMatches is only set if PatternInfo.IsMultiline is true.
+	MultilineMatches []MultilineMatch `json:",omitempty"`
+
 	// LimitHit is true if LineMatches may not include all LineMatches.
 	LimitHit bool
 }
@@ -151,3 +209,29 @@ type LineMatch struct {
 	// LimitHit is true if OffsetAndLengths may not include all OffsetAndLengths.
 	LimitHit bool
 }
+
+// MultilineMatch is a match which may span several lines.
+type MultilineMatch struct {
+	// Preview is the full text of the lines the match spans, without the
+	// trailing newline.
+	Preview string
+
+	// Start is the location of the first character of the match.
+	Start Location
+
+	// End is the location just after the last character of the match.
+	End Location
+}
+
+// Location is a position in a file.
+type Location struct {
+	// Offset is the 0-based byte offset from the start of the file.
+	Offset int
+
+	// Line is the 0-based line number.
+	Line int
+
+	// Column is the 0-based offset from the start of Line, measured in
+	// characters, not bytes.
+	Column int
+}
@@ -40,6 +40,10 @@ const (
 	// maxOffsets is the limit on number of matches to return on a line.
 	maxOffsets = 10
 
+	// maxMultilinePreviewSize is the maximum size in bytes of the lines
+	// spanned by a multiline match. Larger matches are not returned.
+	maxMultilinePreviewSize = 10 * maxLineSize
+
 	// numWorkers is how many concurrent readerGreps run per
 	// concurrentFind
 	numWorkers = 8
@@ -65,9 +69,23 @@ type readerGrep struct {
 	// re is the regexp to match, or nil if empty ("match all files' content").
 	re *regexp.Regexp
 
+	// structural is the structural pattern to match instead of re. It is
+	// nil unless the pattern is structural.
+	structural *structuralPattern
+
+	// andRes and notRes are set for boolean queries. A file's content must
+	// match every regexp in andRes and none in notRes. re is then the union
+	// of andRes, so that the matches of every clause are highlighted.
+	andRes []*regexp.Regexp
+	notRes []*regexp.Regexp
+
 	// ignoreCase if true means we need to do case insensitive matching.
 	ignoreCase bool
 
+	// multiline if true means we match against the whole file instead of
+	// line by line, and report MultilineMatches.
+	multiline bool
+
 	// transformBuf is reused between file searches to avoid
 	// re-allocating. It is only used if we need to transform the input
 	// before matching. For example we lower case the input in the case of
@@ -83,54 +101,73 @@ type readerGrep struct {
 	// re. It is the output of the longestLiteral function. It is only set if
 	// the regex has an empty LiteralPrefix.
 	literalSubstring []byte
+
+	// indexLiteral is guaranteed to appear in any match found by re or
+	// structural. It is the output of the longestLiteral function and is
+	// used to skip files with the archive's trigram index.
+	indexLiteral []byte
 }
 
 // compile returns a readerGrep for matching p.
 func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 	var (
 		re               *regexp.Regexp
+		andRes, notRes   []*regexp.Regexp
+		structural       *structuralPattern
 		literalSubstring []byte
+		indexLiteral     []byte
 	)
-	if p.Pattern != "" {
-		expr := p.Pattern
-		if !p.IsRegExp {
-			expr = regexp.QuoteMeta(expr)
-		}
-		if p.IsWordMatch {
-			expr = `\b` + expr + `\b`
-		}
-		if p.IsRegExp {
-			// We don't do the search line by line, therefore we want the
-			// regex engine to consider newlines for anchors (^$).
-			expr = "(?m:" + expr + ")"
-		}
-		if !p.IsCaseSensitive {
-			// We don't just use (?i) because regexp library doesn't seem
-			// to contain good optimizations for case insensitive
-			// search. Instead we lowercase the input and pattern.
-			re, err := syntax.Parse(expr, syntax.Perl)
-			if err != nil {
-				return nil, err
-			}
-			lowerRegexpASCII(re)
-			expr = re.String()
+	if p.Pattern != "" && p.IsStructuralPat {
+		var err error
+		structural, err = compileStructural(p.Pattern)
+		if err != nil {
+			return nil, err
 		}
-
+		literalSubstring = structural.longestLiteral()
+		indexLiteral = literalSubstring
+	} else if p.Pattern != "" {
 		var err error
-		re, err = regexp.Compile(expr)
+		var ast *syntax.Regexp
+		re, ast, err = compileRegexp(p, p.Pattern, p.IsRegExp)
 		if err != nil {
 			return nil, err
 		}
+		indexLiteral = []byte(longestLiteral(ast))
 
 		// Only use literalSubstring optimization if the regex engine doesn't
 		// have a prefix to use.
 		if pre, _ := re.LiteralPrefix(); pre == "" {
-			ast, err := syntax.Parse(expr, syntax.Perl)
-			if err != nil {
-				return nil, err
+			literalSubstring = indexLiteral
+		}
+
+		// A file matching a boolean query must match Pattern, so the
+		// literals above can still be used to skip files.
+		if len(p.AndPatterns) > 0 || len(p.NotPatterns) > 0 {
+			andRes = []*regexp.Regexp{re}
+			for _, pattern := range p.AndPatterns {
+				andRe, _, err := compileRegexp(p, pattern, true)
+				if err != nil {
+					return nil, err
+				}
+				andRes = append(andRes, andRe)
+			}
+			for _, pattern := range p.NotPatterns {
+				notRe, _, err := compileRegexp(p, pattern, true)
+				if err != nil {
+					return nil, err
+				}
+				notRes = append(notRes, notRe)
+			}
+			if len(andRes) > 1 {
+				exprs := make([]string, len(andRes))
+				for i, andRe := range andRes {
+					exprs[i] = andRe.String()
+				}
+				re, err = regexp.Compile("(" + strings.Join(exprs, ")|(") + ")")
+				if err != nil {
+					return nil, err
+				}
 			}
-			ast = ast.Simplify()
-			literalSubstring = []byte(longestLiteral(ast))
 		}
 	}
 
@@ -144,13 +181,60 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 	}
 
 	return &readerGrep{
-		re:               re,
-		ignoreCase:       !p.IsCaseSensitive,
+		re:         re,
+		andRes:     andRes,
+		notRes:     notRes,
+		structural: structural,
+		// Structural patterns are always matched case sensitively.
+		ignoreCase:       !p.IsCaseSensitive && !p.IsStructuralPat,
+		multiline:        p.IsMultiline,
 		matchPath:        matchPath,
 		literalSubstring: literalSubstring,
+		indexLiteral:     indexLiteral,
 	}, nil
 }
 
+// compileRegexp returns the regexp used to match content for pattern, which
+// is transformed according to the options of p, as well as its simplified
+// syntax tree. Lowercasing for case insensitive search is done on the
+// pattern, see readerGrep.buffers.
+func compileRegexp(p *protocol.PatternInfo, pattern string, isRegExp bool) (*regexp.Regexp, *syntax.Regexp, error) {
+	expr := pattern
+	if !isRegExp {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if p.IsWordMatch {
+		expr = `\b` + expr + `\b`
+	}
+	if isRegExp {
+		// We don't do the search line by line, therefore we want the
+		// regex engine to consider newlines for anchors (^$).
+		expr = "(?m:" + expr + ")"
+	}
+	if !p.IsCaseSensitive {
+		// We don't just use (?i) because regexp library doesn't seem
+		// to contain good optimizations for case insensitive
+		// search. Instead we lowercase the input and pattern.
+		re, err := syntax.Parse(expr, syntax.Perl)
+		if err != nil {
+			return nil, nil, err
+		}
+		lowerRegexpASCII(re)
+		expr = re.String()
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ast, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, nil, err
+	}
+	return re, ast.Simplify(), nil
+}
+
 // Copy returns a copied version of rg that is safe to use from another
 // goroutine.
 func (rg *readerGrep) Copy() *readerGrep {
@@ -158,17 +242,40 @@ func (rg *readerGrep) Copy() *readerGrep {
 	if rg.re != nil {
 		reCopy = rg.re.Copy()
 	}
+	copyAll := func(res []*regexp.Regexp) []*regexp.Regexp {
+		if res == nil {
+			return nil
+		}
+		copies := make([]*regexp.Regexp, len(res))
+		for i, re := range res {
+			copies[i] = re.Copy()
+		}
+		return copies
+	}
+	var structuralCopy *structuralPattern
+	if rg.structural != nil {
+		structuralCopy = rg.structural.Copy()
+	}
 	return &readerGrep{
 		re:               reCopy,
+		andRes:           copyAll(rg.andRes),
+		notRes:           copyAll(rg.notRes),
+		structural:       structuralCopy,
 		ignoreCase:       rg.ignoreCase,
+		multiline:        rg.multiline,
 		matchPath:        rg.matchPath.Copy(),
 		literalSubstring: rg.literalSubstring,
+		indexLiteral:     rg.indexLiteral,
 	}
 }
 
 // matchString returns whether rg's regexp pattern matches s. It is intended to be
 // used to match file paths.
 func (rg *readerGrep) matchString(s string) bool {
+	if rg.structural != nil {
+		// Structural patterns describe code, not file paths.
+		return false
+	}
 	if rg.re == nil {
 		return true
 	}
@@ -182,23 +289,14 @@ func (rg *readerGrep) matchString(s string) bool {
 // LimitHit is true if some matches may not have been included in the result.
 // NOTE: This is not safe to use concurrently.
 func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile) (matches []protocol.LineMatch, limitHit bool, err error) {
-	if rg.ignoreCase && rg.transformBuf == nil {
-		rg.transformBuf = make([]byte, zf.MaxLen)
-	}
-
 	// fileMatchBuf is what we run match on, fileBuf is the original
 	// data (for Preview).
-	fileBuf := zf.DataFor(f)
-	fileMatchBuf := fileBuf
+	fileBuf, fileMatchBuf := rg.buffers(zf, f)
 
-	// If we are ignoring case, we transform the input instead of
-	// relying on the regular expression engine which can be
-	// slow. compile has already lowercased the pattern. We also
-	// trade some correctness for perf by using a non-utf8 aware
-	// lowercase function.
-	if rg.ignoreCase {
-		fileMatchBuf = rg.transformBuf[:len(fileBuf)]
-		bytesToLowerASCII(fileMatchBuf, fileBuf)
+	if rg.structural != nil {
+		ranges, limitHit := rg.findStructural(fileBuf)
+		matches = lineMatchesForRanges(fileBuf, ranges)
+		return matches, limitHit || len(matches) == maxLineMatches, nil
 	}
 
 	// Most files will not have a match and we bound the number of matched
@@ -208,7 +306,7 @@ func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile) (matches []proto
 	// searching for results. We use the same approach when we search
 	// per-line. Additionally if we have a non-empty literalSubstring, we use
 	// that to prune out files since doing bytes.Index is very fast.
-	if !bytes.Contains(fileMatchBuf, rg.literalSubstring) {
+	if !bytes.Contains(fileMatchBuf, rg.literalSubstring) || !rg.matchesClauses(fileMatchBuf) {
 		return nil, false, nil
 	}
 	first := rg.re.FindIndex(fileMatchBuf)
@@ -275,8 +373,208 @@ func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile) (matches []proto
 	return matches, limitHit, nil
 }
 
+// matchesClauses reports whether the transformed content of a file matches
+// every clause of a boolean query. It is true if rg is not for a boolean
+// query.
+func (rg *readerGrep) matchesClauses(fileMatchBuf []byte) bool {
+	for _, re := range rg.andRes {
+		if !re.Match(fileMatchBuf) {
+			return false
+		}
+	}
+	for _, re := range rg.notRes {
+		if re.Match(fileMatchBuf) {
+			return false
+		}
+	}
+	return true
+}
+
+// buffers returns the content of f, and the content of f that rg should
+// match against.
+func (rg *readerGrep) buffers(zf *store.ZipFile, f *store.SrcFile) (fileBuf, fileMatchBuf []byte) {
+	fileBuf = zf.DataFor(f)
+
+	// If we are ignoring case, we transform the input instead of
+	// relying on the regular expression engine which can be
+	// slow. compile has already lowercased the pattern. We also
+	// trade some correctness for perf by using a non-utf8 aware
+	// lowercase function.
+	if rg.ignoreCase {
+		if rg.transformBuf == nil {
+			rg.transformBuf = make([]byte, zf.MaxLen)
+		}
+		fileMatchBuf = rg.transformBuf[:len(fileBuf)]
+		bytesToLowerASCII(fileMatchBuf, fileBuf)
+		return fileBuf, fileMatchBuf
+	}
+	return fileBuf, fileBuf
+}
+
+// FindMultiline returns a MultilineMatch for each match of rg in f. Unlike
+// Find, the pattern is run against the whole file so a match may span
+// several lines. The returned LineMatches contain the part of each match on
+// every line it spans, for clients which only understand LineMatches.
+// LimitHit is true if some matches may not have been included in the result.
+// NOTE: This is not safe to use concurrently.
+func (rg *readerGrep) FindMultiline(zf *store.ZipFile, f *store.SrcFile) (lineMatches []protocol.LineMatch, multilineMatches []protocol.MultilineMatch, limitHit bool) {
+	fileBuf, fileMatchBuf := rg.buffers(zf, f)
+
+	var (
+		ranges             [][2]int
+		structuralLimitHit bool
+	)
+	if rg.structural != nil {
+		ranges, structuralLimitHit = rg.findStructural(fileBuf)
+	} else {
+		if !bytes.Contains(fileMatchBuf, rg.literalSubstring) || !rg.matchesClauses(fileMatchBuf) {
+			return nil, nil, false
+		}
+		for _, loc := range rg.re.FindAllIndex(fileMatchBuf, maxLineMatches) {
+			// Empty matches (eg of ^) are not useful to highlight across a
+			// whole file.
+			if loc[0] < loc[1] {
+				ranges = append(ranges, [2]int{loc[0], loc[1]})
+			}
+		}
+	}
+	if len(ranges) == 0 {
+		return nil, nil, false
+	}
+
+	multilineMatches = multilineMatchesForRanges(fileBuf, ranges)
+	if len(multilineMatches) == 0 {
+		return nil, nil, false
+	}
+	lineMatches = lineMatchesForRanges(fileBuf, ranges)
+	return lineMatches, multilineMatches, structuralLimitHit || len(ranges) == maxLineMatches
+}
+
+// lineMatchesForRanges returns a LineMatch for each line of fileBuf covered
+// by one of ranges, which are sorted non-overlapping byte ranges. A range
+// spanning several lines is reported as a highlighted part of each of those
+// lines.
+func lineMatchesForRanges(fileBuf []byte, ranges [][2]int) (matches []protocol.LineMatch) {
+	lineStart := 0
+	for lineNumber := 0; lineStart < len(fileBuf) && len(ranges) > 0 && len(matches) < maxLineMatches; lineNumber++ {
+		lineEnd := bytes.IndexByte(fileBuf[lineStart:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(fileBuf)
+		} else {
+			lineEnd += lineStart
+		}
+		line := fileBuf[lineStart:lineEnd]
+
+		var offsetAndLengths [][2]int
+		for _, r := range ranges {
+			if r[0] > lineEnd {
+				break
+			}
+			start, end := r[0], r[1]
+			if start < lineStart {
+				start = lineStart
+			}
+			if end > lineEnd {
+				end = lineEnd
+			}
+			if start >= end {
+				continue
+			}
+			offset := utf8.RuneCount(fileBuf[lineStart:start])
+			length := utf8.RuneCount(fileBuf[start:end])
+			offsetAndLengths = append(offsetAndLengths, [2]int{offset, length})
+		}
+		// Drop ranges which end on this line.
+		for len(ranges) > 0 && ranges[0][1] <= lineEnd+1 {
+			ranges = ranges[1:]
+		}
+
+		if len(offsetAndLengths) > 0 && len(line) <= maxLineSize {
+			lineLimitHit := len(offsetAndLengths) > maxOffsets
+			if lineLimitHit {
+				offsetAndLengths = offsetAndLengths[:maxOffsets]
+			}
+			matches = append(matches, protocol.LineMatch{
+				Preview:          string(line),
+				LineNumber:       lineNumber,
+				OffsetAndLengths: offsetAndLengths,
+				LimitHit:         lineLimitHit,
+			})
+		}
+		lineStart = lineEnd + 1
+	}
+	return matches
+}
+
+// multilineMatchesForRanges returns a MultilineMatch for each of ranges in
+// fileBuf, which are sorted non-overlapping non-empty byte ranges. Ranges
+// whose lines are larger than maxMultilinePreviewSize are skipped.
+func multilineMatchesForRanges(fileBuf []byte, ranges [][2]int) []protocol.MultilineMatch {
+	var (
+		matches []protocol.MultilineMatch
+
+		// line is the line number of the line starting at lineStart. Both
+		// only move forwards since ranges are sorted.
+		line      = 0
+		lineStart = 0
+	)
+	location := func(offset int) protocol.Location {
+		for {
+			i := bytes.IndexByte(fileBuf[lineStart:offset], '\n')
+			if i < 0 {
+				break
+			}
+			line++
+			lineStart += i + 1
+		}
+		return protocol.Location{
+			Offset: offset,
+			Line:   line,
+			Column: utf8.RuneCount(fileBuf[lineStart:offset]),
+		}
+	}
+
+	for _, r := range ranges {
+		start := location(r[0])
+		previewStart := lineStart
+		end := location(r[1])
+
+		// The preview ends at the end of the line containing the last
+		// character of the match.
+		previewEnd := r[1]
+		if fileBuf[previewEnd-1] != '\n' {
+			if i := bytes.IndexByte(fileBuf[previewEnd:], '\n'); i >= 0 {
+				previewEnd += i
+			} else {
+				previewEnd = len(fileBuf)
+			}
+		} else {
+			previewEnd--
+		}
+		if previewEnd-previewStart > maxMultilinePreviewSize {
+			continue
+		}
+
+		matches = append(matches, protocol.MultilineMatch{
+			Preview: string(fileBuf[previewStart:previewEnd]),
+			Start:   start,
+			End:     end,
+		})
+	}
+	return matches
+}
+
 // FindZip is a convenience function to run Find on f.
 func (rg *readerGrep) FindZip(zf *store.ZipFile, f *store.SrcFile) (protocol.FileMatch, error) {
+	if rg.multiline {
+		lm, mm, limitHit := rg.FindMultiline(zf, f)
+		return protocol.FileMatch{
+			Path:             f.Name,
+			LineMatches:      lm,
+			MultilineMatches: mm,
+			LimitHit:         limitHit,
+		}, nil
+	}
 	lm, limitHit, err := rg.Find(zf, f)
 	return protocol.FileMatch{
 		Path:        f.Name,
@@ -287,11 +585,26 @@ func (rg *readerGrep) FindZip(zf *store.ZipFile, f *store.SrcFile) (protocol.Fil
 
 // concurrentFind searches files in zr looking for matches using rg.
 func concurrentFind(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMatchLimit int, patternMatchesContent, patternMatchesPaths bool) (fm []protocol.FileMatch, limitHit bool, err error) {
+	matches := []protocol.FileMatch{}
+	limitHit, err = concurrentFindStream(ctx, rg, zf, fileMatchLimit, patternMatchesContent, patternMatchesPaths, func(fm protocol.FileMatch) {
+		matches = append(matches, fm)
+	})
+	return matches, limitHit, err
+}
+
+// concurrentFindStream searches files in zr looking for matches using rg. It
+// calls send for each FileMatch as soon as it is found. Calls to send are
+// serialized, but may happen from different goroutines. send is never called
+// after concurrentFindStream returns.
+func concurrentFindStream(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMatchLimit int, patternMatchesContent, patternMatchesPaths bool, send func(protocol.FileMatch)) (limitHit bool, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "ConcurrentFind")
 	ext.Component.Set(span, "matcher")
 	if rg.re != nil {
 		span.SetTag("re", rg.re.String())
 	}
+	if rg.structural != nil {
+		span.SetTag("structural", rg.structural.String())
+	}
 	span.SetTag("path", rg.matchPath.String())
 	defer func() {
 		if err != nil {
@@ -322,35 +635,45 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *store.ZipFile, file
 	defer cancel()
 
 	var (
-		filesmu   sync.Mutex // protects files
-		files     = zf.Files
-		matchesmu sync.Mutex // protects matches, limitHit
-		matches   = []protocol.FileMatch{}
+		filesmu    sync.Mutex // protects next
+		files      = zf.Files
+		next       int        // index of the next file in files to search
+		matchesmu  sync.Mutex // protects matchCount, limitHit and calls to send
+		matchCount int
 	)
 
-	if patternMatchesPaths && (!patternMatchesContent || rg.re == nil) {
+	if patternMatchesPaths && (!patternMatchesContent || (rg.re == nil && rg.structural == nil)) {
 		// Fast path for only matching file paths (or with a nil pattern, which matches all files,
 		// so is effectively matching only on file paths).
 		for _, f := range files {
 			if rg.matchPath.MatchPath(f.Name) && rg.matchString(f.Name) {
-				if len(matches) < fileMatchLimit {
-					matches = append(matches, protocol.FileMatch{Path: f.Name})
+				if matchCount < fileMatchLimit {
+					matchCount++
+					send(protocol.FileMatch{Path: f.Name})
 				} else {
 					limitHit = true
 					break
 				}
 			}
 		}
-		return matches, limitHit, nil
+		return limitHit, nil
+	}
+
+	// Use the archive's trigram index to skip files which cannot contain a
+	// match. The index is only consulted for literals of at least a trigram.
+	var index *store.TrigramIndex
+	if len(rg.indexLiteral) >= 3 {
+		index = zf.TrigramIndex()
 	}
 
 	var (
-		done          = ctx.Done()
-		wg            sync.WaitGroup
-		wgErrOnce     sync.Once
-		wgErr         error
-		filesSkipped  uint32 // accessed atomically
-		filesSearched uint32 // accessed atomically
+		done              = ctx.Done()
+		wg                sync.WaitGroup
+		wgErrOnce         sync.Once
+		wgErr             error
+		filesSkipped      uint32 // accessed atomically
+		filesSkippedIndex uint32 // accessed atomically
+		filesSearched     uint32 // accessed atomically
 	)
 
 	// Start workers. They read from files and write to matches.
@@ -369,12 +692,13 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *store.ZipFile, file
 
 				// grab a file to work on
 				filesmu.Lock()
-				if len(files) == 0 {
+				if next == len(files) {
 					filesmu.Unlock()
 					return
 				}
-				f := &files[0]
-				files = files[1:]
+				i := next
+				f := &files[i]
+				next++
 				filesmu.Unlock()
 
 				// decide whether to process, record that decision
@@ -386,15 +710,20 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *store.ZipFile, file
 
 				// process
 				var fm protocol.FileMatch
-				fm, err := rg.FindZip(zf, f)
-				if err != nil {
-					wgErrOnce.Do(func() {
-						wgErr = err
-						cancel()
-					})
-					return
+				if index != nil && !index.MayContain(i, rg.indexLiteral) {
+					atomic.AddUint32(&filesSkippedIndex, 1)
+				} else {
+					var err error
+					fm, err = rg.FindZip(zf, f)
+					if err != nil {
+						wgErrOnce.Do(func() {
+							wgErr = err
+							cancel()
+						})
+						return
+					}
 				}
-				match := len(fm.LineMatches) > 0
+				match := len(fm.LineMatches) > 0 || len(fm.MultilineMatches) > 0
 				if !match && patternMatchesPaths {
 					// Try matching against the file path.
 					match = rg.matchString(f.Name)
@@ -404,8 +733,9 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *store.ZipFile, file
 				}
 				if match {
 					matchesmu.Lock()
-					if len(matches) < fileMatchLimit {
-						matches = append(matches, fm)
+					if matchCount < fileMatchLimit {
+						matchCount++
+						send(fm)
 					} else {
 						limitHit = true
 						cancel()
@@ -426,10 +756,11 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *store.ZipFile, file
 
 	span.LogFields(
 		otlog.Int("filesSkipped", int(atomic.LoadUint32(&filesSkipped))),
+		otlog.Int("filesSkippedIndex", int(atomic.LoadUint32(&filesSkippedIndex))),
 		otlog.Int("filesSearched", int(atomic.LoadUint32(&filesSearched))),
 	)
 
-	return matches, limitHit, err
+	return limitHit, err
 }
 
 // lowerRegexpASCII lowers rune literals and expands char classes to include
@@ -466,6 +466,52 @@ func TestLineLimit(t *testing.T) {
 	}
 }
 
+func TestFindMultiline(t *testing.T) {
+	rg, err := compile(&protocol.PatternInfo{
+		Pattern:         `func \w+\([^)]*\)`,
+		IsRegExp:        true,
+		IsCaseSensitive: true,
+		IsMultiline:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("func foo(\n\ta int,\n) error {\n}\nfunc bar() {}\n")
+	fakeZipFile := store.ZipFile{MaxLen: len(data), Data: data}
+	fakeSrcFile := store.SrcFile{Len: int32(len(data))}
+	lineMatches, multilineMatches, limitHit := rg.FindMultiline(&fakeZipFile, &fakeSrcFile)
+	if limitHit {
+		t.Fatal("expected limit to not hit")
+	}
+
+	wantMultiline := []protocol.MultilineMatch{
+		{
+			Preview: "func foo(\n\ta int,\n) error {",
+			Start:   protocol.Location{Offset: 0, Line: 0, Column: 0},
+			End:     protocol.Location{Offset: 19, Line: 2, Column: 1},
+		},
+		{
+			Preview: "func bar() {}",
+			Start:   protocol.Location{Offset: 30, Line: 4, Column: 0},
+			End:     protocol.Location{Offset: 40, Line: 4, Column: 10},
+		},
+	}
+	if !reflect.DeepEqual(multilineMatches, wantMultiline) {
+		t.Errorf("got multiline matches %+v, want %+v", multilineMatches, wantMultiline)
+	}
+
+	wantLine := []protocol.LineMatch{
+		{Preview: "func foo(", LineNumber: 0, OffsetAndLengths: [][2]int{{0, 9}}},
+		{Preview: "\ta int,", LineNumber: 1, OffsetAndLengths: [][2]int{{0, 7}}},
+		{Preview: ") error {", LineNumber: 2, OffsetAndLengths: [][2]int{{0, 1}}},
+		{Preview: "func bar() {}", LineNumber: 4, OffsetAndLengths: [][2]int{{0, 10}}},
+	}
+	if !reflect.DeepEqual(lineMatches, wantLine) {
+		t.Errorf("got line matches %+v, want %+v", lineMatches, wantLine)
+	}
+}
+
 func TestMaxMatches(t *testing.T) {
 	pattern := "foo"
 
@@ -85,17 +85,17 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	matches, limitHit, deadlineHit, err := s.search(ctx, &p)
+	if p.Stream {
+		s.serveStream(ctx, w, &p)
+		return
+	}
+
+	var matches []protocol.FileMatch
+	limitHit, deadlineHit, err := s.search(ctx, &p, func(fm protocol.FileMatch) {
+		matches = append(matches, fm)
+	})
 	if err != nil {
-		code := http.StatusInternalServerError
-		if isBadRequest(err) || ctx.Err() == context.Canceled {
-			code = http.StatusBadRequest
-		} else if isTemporary(err) {
-			code = http.StatusServiceUnavailable
-		} else {
-			log.Printf("internal error serving %#+v: %s", p, err)
-		}
-		http.Error(w, err.Error(), code)
+		writeSearchError(ctx, w, &p, err)
 		return
 	}
 	if matches == nil {
@@ -116,7 +116,87 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(&resp)
 }
 
-func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []protocol.FileMatch, limitHit, deadlineHit bool, err error) {
+// serveStream writes the results of searching p to w as newline-delimited
+// JSON protocol.StreamMessages. Each FileMatch is flushed to the client as
+// soon as it is found.
+func (s *Service) serveStream(ctx context.Context, w http.ResponseWriter, p *protocol.Request) {
+	var (
+		enc        = json.NewEncoder(w)
+		flusher, _ = w.(http.Flusher)
+		started    bool
+	)
+	// start writes the response header. It is delayed until we have the
+	// first message so that errors which occur before any match is found
+	// (bad patterns, fetch failures) still get an appropriate status code.
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		w.Header().Set("Content-Type", protocol.StreamContentType)
+		w.WriteHeader(http.StatusOK)
+	}
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	// As in ServeHTTP, the only reasonable encoding error is the client
+	// going away, which will also cancel ctx. So we ignore encoding errors.
+	limitHit, deadlineHit, err := s.search(ctx, p, func(fm protocol.FileMatch) {
+		start()
+		_ = enc.Encode(&protocol.StreamMessage{FileMatch: &fm})
+		flush()
+	})
+	if err != nil && (errors.Cause(err) == context.DeadlineExceeded || ctx.Err() == context.DeadlineExceeded) {
+		// The matches streamed so far are still valid, so report the deadline
+		// in the Done message instead of failing the whole stream.
+		deadlineHit = true
+		err = nil
+	}
+	if err != nil && !started {
+		writeSearchError(ctx, w, p, err)
+		return
+	}
+
+	start()
+	done := protocol.StreamDone{
+		LimitHit:    limitHit,
+		DeadlineHit: deadlineHit,
+	}
+	if err != nil {
+		done.Error = err.Error()
+	}
+	_ = enc.Encode(&protocol.StreamMessage{Done: &done})
+	flush()
+}
+
+// writeSearchError writes err, returned by (*Service).search for p, to w with
+// an appropriate HTTP status code.
+func writeSearchError(ctx context.Context, w http.ResponseWriter, p *protocol.Request, err error) {
+	code := http.StatusInternalServerError
+	if isBadRequest(err) || ctx.Err() == context.Canceled {
+		code = http.StatusBadRequest
+	} else if isTemporary(err) {
+		code = http.StatusServiceUnavailable
+	} else {
+		log.Printf("internal error serving %#+v: %s", p, err)
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// search searches p and calls send for each FileMatch found. Calls to send
+// are serialized and do not happen after search returns.
+func (s *Service) search(ctx context.Context, p *protocol.Request, send func(protocol.FileMatch)) (limitHit, deadlineHit bool, err error) {
+	// matchCount is only used for logging. It is protected by the
+	// serialization guarantee of concurrentFindStream.
+	matchCount := 0
+	countingSend := func(fm protocol.FileMatch) {
+		matchCount++
+		send(fm)
+	}
+
 	tr := trace.New("search", fmt.Sprintf("%s@%s", p.Repo, p.Commit))
 	tr.LazyPrintf("%s", p.Pattern)
 
@@ -127,7 +207,9 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	span.SetTag("commit", p.Commit)
 	span.SetTag("pattern", p.Pattern)
 	span.SetTag("isRegExp", strconv.FormatBool(p.IsRegExp))
+	span.SetTag("isStructuralPat", strconv.FormatBool(p.IsStructuralPat))
 	span.SetTag("isWordMatch", strconv.FormatBool(p.IsWordMatch))
+	span.SetTag("isMultiline", strconv.FormatBool(p.IsMultiline))
 	span.SetTag("isCaseSensitive", strconv.FormatBool(p.IsCaseSensitive))
 	span.SetTag("pathPatternsAreRegExps", strconv.FormatBool(p.PathPatternsAreRegExps))
 	span.SetTag("pathPatternsAreCaseSensitive", strconv.FormatBool(p.PathPatternsAreCaseSensitive))
@@ -135,6 +217,7 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	span.SetTag("patternMatchesContent", p.PatternMatchesContent)
 	span.SetTag("patternMatchesPath", p.PatternMatchesPath)
 	span.SetTag("deadline", p.Deadline)
+	span.SetTag("stream", p.Stream)
 	defer func(start time.Time) {
 		code := "200"
 		// We often have canceled and timed out requests. We do not want to
@@ -160,21 +243,21 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 				code = "500"
 			}
 		}
-		tr.LazyPrintf("code=%s matches=%d limitHit=%v deadlineHit=%v", code, len(matches), limitHit, deadlineHit)
+		tr.LazyPrintf("code=%s matches=%d limitHit=%v deadlineHit=%v", code, matchCount, limitHit, deadlineHit)
 		tr.Finish()
 		requestTotal.WithLabelValues(code).Inc()
-		span.LogFields(otlog.Int("matches.len", len(matches)))
+		span.LogFields(otlog.Int("matches.len", matchCount))
 		span.SetTag("limitHit", limitHit)
 		span.SetTag("deadlineHit", deadlineHit)
 		span.Finish()
 		if s.Log != nil {
-			s.Log.Debug("search request", "repo", p.Repo, "commit", p.Commit, "pattern", p.Pattern, "isRegExp", p.IsRegExp, "isWordMatch", p.IsWordMatch, "isCaseSensitive", p.IsCaseSensitive, "patternMatchesContent", p.PatternMatchesContent, "patternMatchesPath", p.PatternMatchesPath, "matches", len(matches), "code", code, "duration", time.Since(start), "err", err)
+			s.Log.Debug("search request", "repo", p.Repo, "commit", p.Commit, "pattern", p.Pattern, "isRegExp", p.IsRegExp, "isStructuralPat", p.IsStructuralPat, "isWordMatch", p.IsWordMatch, "isMultiline", p.IsMultiline, "isCaseSensitive", p.IsCaseSensitive, "patternMatchesContent", p.PatternMatchesContent, "patternMatchesPath", p.PatternMatchesPath, "stream", p.Stream, "matches", matchCount, "code", code, "duration", time.Since(start), "err", err)
 		}
 	}(time.Now())
 
 	rg, err := compile(&p.PatternInfo)
 	if err != nil {
-		return nil, false, false, badRequestError{err.Error()}
+		return false, false, badRequestError{err.Error()}
 	}
 
 	if p.FetchTimeout == "" {
@@ -182,7 +265,7 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	}
 	fetchTimeout, err := time.ParseDuration(p.FetchTimeout)
 	if err != nil {
-		return nil, false, false, err
+		return false, false, err
 	}
 	prepareCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
 	defer cancel()
@@ -198,7 +281,7 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 
 	_, zf, err := store.GetZipFileWithRetry(getZf)
 	if err != nil {
-		return nil, false, false, err
+		return false, false, err
 	}
 	defer zf.Close()
 
@@ -211,8 +294,8 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	archiveFiles.Observe(float64(nFiles))
 	archiveSize.Observe(float64(bytes))
 
-	matches, limitHit, err = concurrentFind(ctx, rg, zf, p.FileMatchLimit, p.PatternMatchesContent, p.PatternMatchesPath)
-	return matches, limitHit, false, err
+	limitHit, err = concurrentFindStream(ctx, rg, zf, p.FileMatchLimit, p.PatternMatchesContent, p.PatternMatchesPath, countingSend)
+	return limitHit, false, err
 }
 
 func validateParams(p *protocol.Request) error {
@@ -130,6 +130,18 @@ main.go:6:	fmt.Println("Hello world")
 `},
 
 		{protocol.PatternInfo{Pattern: "doesnotmatch"}, ""},
+		{protocol.PatternInfo{Pattern: "fmt.Println(:[args])", IsStructuralPat: true}, `
+main.go:6:	fmt.Println("Hello world")
+`},
+		{protocol.PatternInfo{Pattern: "world", IsRegExp: true, AndPatterns: []string{"package"}}, `
+main.go:1:package main
+main.go:6:	fmt.Println("Hello world")
+`},
+		{protocol.PatternInfo{Pattern: "world", IsRegExp: true, NotPatterns: []string{"fmt"}}, `
+README.md:1:# Hello World
+README.md:3:Hello world example in go
+`},
+		{protocol.PatternInfo{Pattern: "world", IsRegExp: true, AndPatterns: []string{"package"}, NotPatterns: []string{"fmt"}}, ""},
 		{protocol.PatternInfo{Pattern: "", IsRegExp: false, IncludePatterns: []string{"\\.png"}, PathPatternsAreRegExps: true, PatternMatchesPath: true}, `
 milton.png
 `},
@@ -183,7 +195,7 @@ milton.png
 		if !test.arg.PathPatternsAreRegExps && (len(test.arg.IncludePatterns) > 0 || test.arg.IncludePattern != "" || test.arg.ExcludePattern != "") {
 			continue
 		}
-		if test.arg.IsWordMatch {
+		if test.arg.IsWordMatch || test.arg.IsStructuralPat || len(test.arg.AndPatterns) > 0 || len(test.arg.NotPatterns) > 0 {
 			continue
 		}
 
@@ -214,6 +226,52 @@ milton.png
 	}
 }
 
+func TestSearch_stream(t *testing.T) {
+	files := map[string]string{
+		"README.md": "# Hello World\n\nHello world example in go",
+		"main.go":   "package main\n\nfunc main() {\n\tfmt.Println(\"Hello world\")\n}\n",
+		"abc.txt":   "w",
+	}
+
+	store, cleanup, err := newStore(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	ts := httptest.NewServer(&search.Service{Store: store})
+	defer ts.Close()
+
+	cases := []protocol.PatternInfo{
+		{Pattern: "world"},
+		{Pattern: "doesnotmatch"},
+		{Pattern: "", IncludePatterns: []string{"\\.go$"}, PathPatternsAreRegExps: true, PatternMatchesPath: true},
+	}
+	for _, arg := range cases {
+		arg.PatternMatchesContent = true
+		req := protocol.Request{
+			Repo:         "foo",
+			URL:          "u",
+			Commit:       "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+			PatternInfo:  arg,
+			FetchTimeout: "500ms",
+		}
+		want, err := doSearch(ts.URL, &req)
+		if err != nil {
+			t.Fatalf("%v failed: %s", arg, err)
+		}
+		req.Stream = true
+		got, err := doSearch(ts.URL, &req)
+		if err != nil {
+			t.Fatalf("%v stream failed: %s", arg, err)
+		}
+		sort.Sort(sortByPath(want))
+		sort.Sort(sortByPath(got))
+		if toString(got) != toString(want) {
+			t.Errorf("%v stream returned different matches:\ngot:\n%s\nwant:\n%s", arg, toString(got), toString(want))
+		}
+	}
+}
+
 func TestSearch_badrequest(t *testing.T) {
 	cases := []protocol.Request{
 		// Bad regexp
@@ -323,12 +381,15 @@ func TestSearch_badrequest(t *testing.T) {
 
 	for _, p := range cases {
 		p.PatternInfo.PatternMatchesContent = true
-		_, err := doSearch(ts.URL, &p)
-		if err == nil {
-			t.Fatalf("%v expected to fail", p)
-		}
-		if !strings.HasPrefix(err.Error(), "non-200 response: code=400 ") {
-			t.Fatalf("%v expected to have HTTP 400 response. Got %s", p, err)
+		for _, stream := range []bool{false, true} {
+			p.Stream = stream
+			_, err := doSearch(ts.URL, &p)
+			if err == nil {
+				t.Fatalf("%v expected to fail", p)
+			}
+			if !strings.HasPrefix(err.Error(), "non-200 response: code=400 ") {
+				t.Fatalf("%v expected to have HTTP 400 response. Got %s", p, err)
+			}
 		}
 	}
 }
@@ -342,10 +403,15 @@ func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 		"IncludePatterns": p.IncludePatterns,
 		"IncludePattern":  []string{p.IncludePattern},
 		"ExcludePattern":  []string{p.ExcludePattern},
+		"AndPatterns":     p.AndPatterns,
+		"NotPatterns":     p.NotPatterns,
 	}
 	if p.IsRegExp {
 		form.Set("IsRegExp", "true")
 	}
+	if p.IsStructuralPat {
+		form.Set("IsStructuralPat", "true")
+	}
 	if p.IsWordMatch {
 		form.Set("IsWordMatch", "true")
 	}
@@ -364,10 +430,18 @@ func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 	if p.PatternMatchesPath {
 		form.Set("PatternMatchesPath", "true")
 	}
+	if p.Stream {
+		form.Set("Stream", "true")
+	}
 	resp, err := http.PostForm(u, form)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
+
+	if p.Stream && resp.StatusCode == 200 {
+		return decodeStream(resp.Body)
+	}
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -385,6 +459,30 @@ func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 	return r.Matches, err
 }
 
+func decodeStream(r io.Reader) ([]protocol.FileMatch, error) {
+	var matches []protocol.FileMatch
+	dec := json.NewDecoder(r)
+	for {
+		var msg protocol.StreamMessage
+		if err := dec.Decode(&msg); err != nil {
+			return nil, fmt.Errorf("stream ended without Done message: %s", err)
+		}
+		if msg.Done != nil {
+			if msg.Done.Error != "" {
+				return nil, errors.New(msg.Done.Error)
+			}
+			if dec.More() {
+				return nil, errors.New("unexpected message after Done")
+			}
+			return matches, nil
+		}
+		if msg.FileMatch == nil {
+			return nil, errors.New("empty stream message")
+		}
+		matches = append(matches, *msg.FileMatch)
+	}
+}
+
 func newStore(files map[string]string) (*store.Store, func(), error) {
 	buf := new(bytes.Buffer)
 	w := tar.NewWriter(buf)
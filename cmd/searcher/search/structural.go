@@ -0,0 +1,415 @@
+package search
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// maxStructuralSteps bounds the amount of backtracking done when matching a
+// structural pattern against a single file. Templates with many adjacent
+// holes can otherwise take exponential time on adversarial input.
+const maxStructuralSteps = 1 << 20
+
+type structuralTokenKind int
+
+const (
+	// structuralLiteral matches its text exactly.
+	structuralLiteral structuralTokenKind = iota
+
+	// structuralSpace matches a run of whitespace.
+	structuralSpace
+
+	// structuralHole matches any text with balanced delimiters. It is
+	// written :[name] in a template.
+	structuralHole
+
+	// structuralWordHole matches one or more word characters. It is
+	// written :[[name]] in a template.
+	structuralWordHole
+)
+
+type structuralToken struct {
+	kind structuralTokenKind
+
+	// text is the text to match for structuralLiteral tokens.
+	text []byte
+}
+
+// structuralPattern is a compiled structural match template. It uses the
+// same template syntax as the comby match templates used by cmd/replacer.
+//
+// A hole written :[name] matches any text in which parentheses, brackets and
+// braces are balanced. String literals and comments are matched as a whole,
+// so delimiters inside them are not counted. A hole at the very start or end
+// of a template does not match newlines. A hole written :[[name]] matches
+// one or more word characters.
+//
+// A run of whitespace in the template matches any run of whitespace. It may
+// match nothing unless it separates two word characters. Everything else is
+// matched literally and case sensitively.
+//
+// The names of holes are currently only used for readability since searcher
+// does not do rewriting.
+type structuralPattern struct {
+	tokens []structuralToken
+
+	// steps counts calls to match and the bytes scanned to skip groups,
+	// string literals and comments while matching a single file. It is
+	// compared against maxStructuralSteps.
+	steps int
+}
+
+// compileStructural parses template into a structuralPattern.
+func compileStructural(template string) (*structuralPattern, error) {
+	var (
+		tokens  []structuralToken
+		literal []byte
+	)
+	flushLiteral := func() {
+		if len(literal) > 0 {
+			tokens = append(tokens, structuralToken{kind: structuralLiteral, text: literal})
+			literal = nil
+		}
+	}
+
+	s := bytes.TrimSpace([]byte(template))
+	for len(s) > 0 {
+		if isSpace(s[0]) {
+			flushLiteral()
+			for len(s) > 0 && isSpace(s[0]) {
+				s = s[1:]
+			}
+			tokens = append(tokens, structuralToken{kind: structuralSpace})
+			continue
+		}
+		if kind, n, ok := parseHole(s); ok {
+			flushLiteral()
+			tokens = append(tokens, structuralToken{kind: kind})
+			s = s[n:]
+			continue
+		}
+		literal = append(literal, s[0])
+		s = s[1:]
+	}
+	flushLiteral()
+
+	if len(tokens) == 0 {
+		return nil, errors.New("structural pattern is empty")
+	}
+	return &structuralPattern{tokens: tokens}, nil
+}
+
+// parseHole reports whether s starts with a hole, and if so its kind and
+// length in bytes.
+func parseHole(s []byte) (kind structuralTokenKind, n int, ok bool) {
+	if !bytes.HasPrefix(s, []byte(":[")) {
+		return 0, 0, false
+	}
+	kind, open, close := structuralHole, ":[", "]"
+	if bytes.HasPrefix(s, []byte(":[[")) {
+		kind, open, close = structuralWordHole, ":[[", "]]"
+	}
+	end := bytes.Index(s[len(open):], []byte(close))
+	if end < 0 {
+		return 0, 0, false
+	}
+	name := s[len(open) : len(open)+end]
+	if len(name) == 0 {
+		return 0, 0, false
+	}
+	for _, c := range name {
+		if !isWordByte(c) {
+			return 0, 0, false
+		}
+	}
+	return kind, len(open) + end + len(close), true
+}
+
+// longestLiteral returns the longest literal in the pattern. It is
+// guaranteed to appear in any match.
+func (sp *structuralPattern) longestLiteral() []byte {
+	var longest []byte
+	for _, t := range sp.tokens {
+		if t.kind == structuralLiteral && len(t.text) > len(longest) {
+			longest = t.text
+		}
+	}
+	return longest
+}
+
+// String returns a canonical form of the template.
+func (sp *structuralPattern) String() string {
+	var buf bytes.Buffer
+	for _, t := range sp.tokens {
+		switch t.kind {
+		case structuralLiteral:
+			buf.Write(t.text)
+		case structuralSpace:
+			buf.WriteByte(' ')
+		case structuralHole:
+			buf.WriteString(":[_]")
+		case structuralWordHole:
+			buf.WriteString(":[[_]]")
+		}
+	}
+	return buf.String()
+}
+
+// Copy returns a copy of sp that is safe to use from another goroutine.
+func (sp *structuralPattern) Copy() *structuralPattern {
+	return &structuralPattern{tokens: sp.tokens}
+}
+
+// FindAll returns the byte ranges of up to n non-overlapping matches of sp
+// in buf. If matching gives up because it took more than maxStructuralSteps,
+// the matches found so far are returned and stepLimitHit is true.
+func (sp *structuralPattern) FindAll(buf []byte, n int) (matches [][2]int, stepLimitHit bool) {
+	sp.steps = 0
+
+	// If the template starts with a literal we only need to try starting
+	// positions where that literal occurs.
+	var prefix []byte
+	if first := sp.tokens[0]; first.kind == structuralLiteral {
+		prefix = first.text
+	}
+
+	for start := 0; start < len(buf) && len(matches) < n; {
+		if prefix != nil {
+			i := bytes.Index(buf[start:], prefix)
+			if i < 0 {
+				break
+			}
+			start += i
+		}
+		end, ok := sp.match(buf, start, 0)
+		if sp.steps > maxStructuralSteps {
+			return matches, true
+		}
+		if ok && end > start {
+			matches = append(matches, [2]int{start, end})
+			start = end
+			continue
+		}
+		_, size := utf8.DecodeRune(buf[start:])
+		start += size
+	}
+	return matches, false
+}
+
+// match reports whether sp.tokens[i:] matches buf starting at pos, and if
+// so where the match ends. Holes match as little as possible, except at the
+// end of the template.
+func (sp *structuralPattern) match(buf []byte, pos, i int) (end int, ok bool) {
+	sp.steps++
+	if sp.steps > maxStructuralSteps {
+		return 0, false
+	}
+	if i == len(sp.tokens) {
+		return pos, true
+	}
+
+	t := sp.tokens[i]
+	switch t.kind {
+	case structuralLiteral:
+		if !bytes.HasPrefix(buf[pos:], t.text) {
+			return 0, false
+		}
+		return sp.match(buf, pos+len(t.text), i+1)
+
+	case structuralSpace:
+		next := pos
+		for next < len(buf) && isSpace(buf[next]) {
+			next++
+		}
+		if next == pos && pos > 0 && pos < len(buf) && isWordByte(buf[pos-1]) && isWordByte(buf[pos]) {
+			return 0, false
+		}
+		return sp.match(buf, next, i+1)
+
+	case structuralWordHole:
+		if i == len(sp.tokens)-1 {
+			// As for a trailing structuralHole, a trailing word hole matches
+			// as much as possible.
+			next := pos
+			for next < len(buf) && isWordByte(buf[next]) {
+				next++
+			}
+			return next, next > pos
+		}
+		next := pos
+		for next < len(buf) && isWordByte(buf[next]) {
+			next++
+			if end, ok := sp.match(buf, next, i+1); ok {
+				return end, true
+			}
+		}
+		return 0, false
+
+	case structuralHole:
+		// Holes at the edges of the template stop at newlines, otherwise a
+		// leading hole would match everything from the start of the file.
+		stopAtNewline := i == 0 || i == len(sp.tokens)-1
+		if i == len(sp.tokens)-1 {
+			// A trailing hole would always match nothing if it were lazy,
+			// so it matches as much as possible instead.
+			return sp.scanBalanced(buf, pos, stopAtNewline), true
+		}
+		for next := pos; ; {
+			if end, ok := sp.match(buf, next, i+1); ok {
+				return end, true
+			}
+			n, ok := sp.nextBalancedUnit(buf, next, stopAtNewline)
+			if !ok {
+				return 0, false
+			}
+			next += n
+		}
+	}
+	panic("unreachable")
+}
+
+// scanBalanced returns the end of the longest balanced text in buf starting
+// at pos.
+func (sp *structuralPattern) scanBalanced(buf []byte, pos int, stopAtNewline bool) int {
+	for {
+		n, ok := sp.nextBalancedUnit(buf, pos, stopAtNewline)
+		if !ok {
+			return pos
+		}
+		pos += n
+	}
+}
+
+// nextBalancedUnit returns the length of the next unit of text in buf at
+// pos that a hole may consume. A unit is a single rune, a string literal, a
+// comment, or a delimited group including its nested content. ok is false if
+// the hole cannot be extended: at EOF, at an unbalanced closing delimiter,
+// at a newline if stopAtNewline, or once the step limit is exceeded.
+func (sp *structuralPattern) nextBalancedUnit(buf []byte, pos int, stopAtNewline bool) (n int, ok bool) {
+	if pos >= len(buf) || sp.steps > maxStructuralSteps {
+		return 0, false
+	}
+	switch c := buf[pos]; {
+	case c == '\n' && stopAtNewline:
+		return 0, false
+	case c == ')' || c == ']' || c == '}':
+		return 0, false
+	case c == '(' || c == '[' || c == '{':
+		end, ok := sp.skipGroup(buf, pos)
+		if !ok {
+			return 0, false
+		}
+		return end - pos, true
+	}
+	if end, ok := sp.skipStringOrComment(buf, pos); ok {
+		if stopAtNewline && bytes.IndexByte(buf[pos:end], '\n') >= 0 {
+			return 0, false
+		}
+		return end - pos, true
+	}
+	_, size := utf8.DecodeRune(buf[pos:])
+	return size, true
+}
+
+// skipGroup returns the position after the delimiter closing the group
+// opened at buf[pos]. Each byte scanned counts as a step, so that repeatedly
+// scanning a long unbalanced group (eg "((((...") is bounded by
+// maxStructuralSteps rather than taking quadratic time.
+func (sp *structuralPattern) skipGroup(buf []byte, pos int) (end int, ok bool) {
+	var stack []byte
+	for pos < len(buf) {
+		if sp.steps++; sp.steps > maxStructuralSteps {
+			return 0, false
+		}
+		c := buf[pos]
+		switch c {
+		case '(':
+			stack = append(stack, ')')
+		case '[':
+			stack = append(stack, ']')
+		case '{':
+			stack = append(stack, '}')
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				return 0, false
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return pos + 1, true
+			}
+		default:
+			if end, ok := sp.skipStringOrComment(buf, pos); ok {
+				pos = end
+				continue
+			}
+		}
+		pos++
+	}
+	return 0, false
+}
+
+// skipStringOrComment reports whether a string literal or comment starts at
+// buf[pos], and if so returns the position after it. Unterminated string
+// literals and block comments are not treated as such. The bytes scanned
+// count as steps.
+func (sp *structuralPattern) skipStringOrComment(buf []byte, pos int) (end int, ok bool) {
+	switch c := buf[pos]; c {
+	case '"', '\'', '`':
+		for i := pos + 1; i < len(buf); i++ {
+			switch buf[i] {
+			case '\\':
+				if c != '`' {
+					i++
+				}
+			case '\n':
+				if c != '`' {
+					sp.steps += i - pos
+					return 0, false
+				}
+			case c:
+				sp.steps += i - pos
+				return i + 1, true
+			}
+		}
+		sp.steps += len(buf) - pos
+		return 0, false
+	case '/':
+		rest := buf[pos:]
+		if bytes.HasPrefix(rest, []byte("//")) {
+			if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+				sp.steps += i
+				return pos + i, true
+			}
+			sp.steps += len(rest)
+			return len(buf), true
+		}
+		if bytes.HasPrefix(rest, []byte("/*")) {
+			if i := bytes.Index(rest[2:], []byte("*/")); i >= 0 {
+				sp.steps += i
+				return pos + 2 + i + 2, true
+			}
+			sp.steps += len(rest)
+		}
+	}
+	return 0, false
+}
+
+// findStructural returns the byte ranges of matches of rg.structural in
+// fileBuf. limitHit is true if there may be more matches than returned.
+func (rg *readerGrep) findStructural(fileBuf []byte) (ranges [][2]int, limitHit bool) {
+	if !bytes.Contains(fileBuf, rg.literalSubstring) {
+		return nil, false
+	}
+	ranges, stepLimitHit := rg.structural.FindAll(fileBuf, maxLineMatches)
+	return ranges, stepLimitHit || len(ranges) == maxLineMatches
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,128 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+)
+
+func TestStructuralFindAll(t *testing.T) {
+	cases := []struct {
+		template string
+		input    string
+		want     []string
+	}{
+		// Holes match balanced delimiters.
+		{"foo(:[args])", "foo(a, (b), [c]) foo()", []string{"foo(a, (b), [c])", "foo()"}},
+		{"foo(:[args])", "foo(a, b", nil},
+		{"foo(:[a], :[b])", "foo(x, bar(y, z))", []string{"foo(x, bar(y, z))"}},
+
+		// Delimiters inside strings and comments are ignored.
+		{"foo(:[args])", `foo(")", ')', /* ) */ x)`, []string{`foo(")", ')', /* ) */ x)`}},
+		{"foo(:[args])", "foo(x // )\n)", []string{"foo(x // )\n)"}},
+
+		// Whitespace in the template matches any whitespace.
+		{"if :[cond] {", "if  x == y\t{", []string{"if  x == y\t{"}},
+		{"a = b", "a=b", []string{"a=b"}},
+		{"func main", "funcmain func main", []string{"func main"}},
+
+		// Word holes only match identifiers.
+		{"foo.:[[name]](", "foo.Bar( foo.(", []string{"foo.Bar("}},
+		{"foo(:[[x]]", "foo(abc foo(", []string{"foo(abc"}},
+
+		// Holes at the edges of the template do not match newlines.
+		{":[x].Close()", "a\nf.Close()", []string{"f.Close()"}},
+		{"return :[x]", "return a, b\nfoo", []string{"return a, b"}},
+		{"return :[x]", "{ return a }", []string{"return a "}},
+	}
+	for _, c := range cases {
+		sp, err := compileStructural(c.template)
+		if err != nil {
+			t.Fatalf("%q: %s", c.template, err)
+		}
+		var got []string
+		matches, stepLimitHit := sp.FindAll([]byte(c.input), 10)
+		if stepLimitHit {
+			t.Errorf("%q on %q: unexpected step limit hit", c.template, c.input)
+		}
+		for _, r := range matches {
+			got = append(got, c.input[r[0]:r[1]])
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q on %q: got %q, want %q", c.template, c.input, got, c.want)
+		}
+	}
+}
+
+func TestStructuralFindAll_stepLimit(t *testing.T) {
+	sp, err := compileStructural("(:[a]:[b]:[c]:[d]x)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "(" + strings.Repeat("a", 200) + ")"
+	matches, stepLimitHit := sp.FindAll([]byte(input), 10)
+	if !stepLimitHit {
+		t.Error("got stepLimitHit false, want true")
+	}
+	if len(matches) != 0 {
+		t.Errorf("got matches %v, want none", matches)
+	}
+}
+
+func TestStructuralFindAll_unbalanced(t *testing.T) {
+	// Each starting position scans to the end of the input looking for the
+	// end of a group or string literal. The bytes scanned count towards the
+	// step limit, so this does not take quadratic time.
+	for _, input := range []string{
+		strings.Repeat("(", 1<<20),
+		strings.Repeat("[{", 1<<19),
+		strings.Repeat(`"\`, 1<<19),
+		strings.Repeat("/* ", 1<<18),
+	} {
+		sp, err := compileStructural(":[a];")
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches, stepLimitHit := sp.FindAll([]byte(input), 10)
+		if !stepLimitHit {
+			t.Errorf("%q...: got stepLimitHit false, want true", input[:4])
+		}
+		if len(matches) != 0 {
+			t.Errorf("%q...: got matches %v, want none", input[:4], matches)
+		}
+	}
+}
+
+func TestStructuralLongestLiteral(t *testing.T) {
+	sp, err := compileStructural("fmt.Sprintf(:[format], :[args])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(sp.longestLiteral()), "fmt.Sprintf("; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFindStructural(t *testing.T) {
+	sp, err := compileStructural("foo(:[args])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rg := &readerGrep{structural: sp, literalSubstring: sp.longestLiteral()}
+	fileBuf := []byte("x := foo(a,\n\tb)\ny := 1\nfoo() + foo(c)\n")
+	ranges, limitHit := rg.findStructural(fileBuf)
+	if limitHit {
+		t.Error("got limitHit true, want false")
+	}
+	matches := lineMatchesForRanges(fileBuf, ranges)
+	want := []protocol.LineMatch{
+		{Preview: "x := foo(a,", LineNumber: 0, OffsetAndLengths: [][2]int{{5, 6}}},
+		{Preview: "\tb)", LineNumber: 1, OffsetAndLengths: [][2]int{{0, 3}}},
+		{Preview: "foo() + foo(c)", LineNumber: 3, OffsetAndLengths: [][2]int{{0, 5}, {8, 6}}},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("got %+v, want %+v", matches, want)
+	}
+}
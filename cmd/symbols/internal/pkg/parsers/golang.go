@@ -0,0 +1,144 @@
+package parsers
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+)
+
+// goParser extracts symbols from Go files using go/parser. Unlike ctags it
+// reports the receiver type of methods, the fields of structs and the
+// methods of interfaces. It uses the same kinds as ctags.
+type goParser struct{}
+
+func (goParser) Parse(path string, content []byte) ([]ctags.Entry, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, content, 0)
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.Split(content, []byte("\n"))
+
+	var entries []ctags.Entry
+	add := func(ident *ast.Ident, kind, parent, parentKind, signature string) {
+		if ident == nil || ident.Name == "_" {
+			return
+		}
+		line := fset.Position(ident.Pos()).Line
+		entries = append(entries, ctags.Entry{
+			Name:       ident.Name,
+			Path:       path,
+			Line:       line,
+			Kind:       kind,
+			Language:   "Go",
+			Parent:     parent,
+			ParentKind: parentKind,
+			Pattern:    ctagsPattern(lines[line-1]),
+			Signature:  signature,
+		})
+	}
+
+	// Methods may be declared before their receiver type, so find the kinds
+	// of all types first.
+	typeKinds := map[string]string{}
+	for _, decl := range f.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.TYPE {
+			for _, spec := range d.Specs {
+				s := spec.(*ast.TypeSpec)
+				typeKinds[s.Name.Name] = goTypeKind(s.Type)
+			}
+		}
+	}
+
+	add(f.Name, "package", "", "", "")
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			signature := string(content[fset.Position(d.Type.Params.Pos()).Offset:fset.Position(d.Type.End()).Offset])
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				add(d.Name, "func", "", "", signature)
+				continue
+			}
+			recv := goReceiverType(d.Recv.List[0].Type)
+			recvKind := typeKinds[recv]
+			if recvKind == "" {
+				recvKind = "type"
+			}
+			add(d.Name, "method", recv, recvKind, signature)
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					kind := typeKinds[s.Name.Name]
+					add(s.Name, kind, "", "", "")
+					switch t := s.Type.(type) {
+					case *ast.StructType:
+						for _, field := range t.Fields.List {
+							for _, name := range field.Names {
+								add(name, "member", s.Name.Name, kind, "")
+							}
+						}
+					case *ast.InterfaceType:
+						for _, method := range t.Methods.List {
+							for _, name := range method.Names {
+								add(name, "methodSpec", s.Name.Name, kind, "")
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, name := range s.Names {
+						add(name, kind, "", "", "")
+					}
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+// goTypeKind returns the ctags kind of a type declared as t.
+func goTypeKind(t ast.Expr) string {
+	switch t.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	}
+	return "type"
+}
+
+// goReceiverType returns the name of the type of a method receiver, eg "T"
+// for a receiver of type *T.
+func goReceiverType(t ast.Expr) string {
+	for {
+		switch e := t.(type) {
+		case *ast.StarExpr:
+			t = e.X
+		case *ast.ParenExpr:
+			t = e.X
+		case *ast.IndexExpr:
+			t = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// ctagsPattern returns a pattern matching line in the format used by ctags,
+// eg "/^func f() {$/".
+func ctagsPattern(line []byte) string {
+	s := strings.TrimSuffix(string(line), "\r")
+	s = strings.NewReplacer(`\`, `\\`, `/`, `\/`).Replace(s)
+	return "/^" + s + "$/"
+}
@@ -0,0 +1,75 @@
+package parsers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+)
+
+func TestGoParser(t *testing.T) {
+	src := `package p
+
+func (t *T) Method(a int) (err error) { return nil }
+
+type T struct {
+	A, B int
+	io.Reader
+}
+
+type I interface {
+	M()
+}
+
+type S string
+
+const (
+	C = 1
+	_ = 2
+)
+
+var v = "a/b"
+
+func f() {}
+`
+	got, err := goParser{}.Parse("p/a.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := func(name string, line int, kind, parent, parentKind, pattern, signature string) ctags.Entry {
+		return ctags.Entry{
+			Name:       name,
+			Path:       "p/a.go",
+			Line:       line,
+			Kind:       kind,
+			Language:   "Go",
+			Parent:     parent,
+			ParentKind: parentKind,
+			Pattern:    pattern,
+			Signature:  signature,
+		}
+	}
+	want := []ctags.Entry{
+		entry("p", 1, "package", "", "", "/^package p$/", ""),
+		entry("Method", 3, "method", "T", "struct", "/^func (t *T) Method(a int) (err error) { return nil }$/", "(a int) (err error)"),
+		entry("T", 5, "struct", "", "", "/^type T struct {$/", ""),
+		entry("A", 6, "member", "T", "struct", "/^\tA, B int$/", ""),
+		entry("B", 6, "member", "T", "struct", "/^\tA, B int$/", ""),
+		entry("I", 10, "interface", "", "", "/^type I interface {$/", ""),
+		entry("M", 11, "methodSpec", "I", "interface", "/^\tM()$/", ""),
+		entry("S", 14, "type", "", "", "/^type S string$/", ""),
+		entry("C", 17, "const", "", "", "/^\tC = 1$/", ""),
+		entry("v", 21, "var", "", "", `/^var v = "a\/b"$/`, ""),
+		entry("f", 23, "func", "", "", "/^func f() {}$/", "()"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestGoParser_syntaxError(t *testing.T) {
+	if _, err := (goParser{}).Parse("a.go", []byte("package p\nfunc {")); err == nil {
+		t.Error("got nil error, want syntax error")
+	}
+}
@@ -0,0 +1,229 @@
+// Package parsers lets native symbol parsers for specific languages replace or
+// add to the symbols found by universal-ctags.
+package parsers
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// Parser extracts the symbols from a single file. Unlike ctags.Parser it is
+// not backed by a process, so it must be safe for concurrent use.
+type Parser interface {
+	Parse(path string, content []byte) ([]ctags.Entry, error)
+}
+
+// Mode determines how the entries of a registered parser are combined with
+// the entries of ctags.
+type Mode int
+
+const (
+	// Merge adds the entries of the parser to the entries of ctags. A ctags
+	// entry with the same name and line as an entry of the parser is dropped.
+	Merge Mode = iota
+
+	// Override uses the entries of the parser instead of running ctags. If
+	// the parser fails, the entries of ctags are used.
+	Override
+)
+
+type registration struct {
+	name   string
+	mode   Mode
+	parser Parser
+}
+
+// Registry maps file extensions to the parsers registered for them.
+type Registry struct {
+	mu        sync.RWMutex
+	names     map[string]bool
+	overrides map[string]*registration
+	merges    map[string][]*registration
+}
+
+// Default is the registry used by the symbols service.
+var Default = &Registry{}
+
+func init() {
+	Default.Register("go", Override, goParser{}, ".go")
+}
+
+// Register registers parser for files with the given extensions (eg ".go").
+// The name identifies the parser in logs and metrics. Register panics if the
+// name is already registered, if no extensions are given, or if mode is
+// Override and another parser already overrides ctags for one of them.
+func (r *Registry) Register(name string, mode Mode, parser Parser, extensions ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("parsers: Register called twice for parser %q", name))
+	}
+	if len(extensions) == 0 {
+		panic(fmt.Sprintf("parsers: no extensions given for parser %q", name))
+	}
+	if r.names == nil {
+		r.names = map[string]bool{}
+		r.overrides = map[string]*registration{}
+		r.merges = map[string][]*registration{}
+	}
+
+	reg := &registration{name: name, mode: mode, parser: parser}
+	for _, ext := range extensions {
+		ext = strings.ToLower(ext)
+		switch mode {
+		case Override:
+			if other, ok := r.overrides[ext]; ok {
+				panic(fmt.Sprintf("parsers: parser %q and %q both override ctags for %s files", other.name, name, ext))
+			}
+			r.overrides[ext] = reg
+		case Merge:
+			r.merges[ext] = append(r.merges[ext], reg)
+		default:
+			panic(fmt.Sprintf("parsers: invalid mode %d for parser %q", mode, name))
+		}
+	}
+	r.names[name] = true
+}
+
+// lookup returns the parsers registered for the extension of path.
+func (r *Registry) lookup(p string) (override *registration, merges []*registration) {
+	ext := strings.ToLower(path.Ext(p))
+	if ext == "" {
+		return nil, nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.overrides[ext], r.merges[ext]
+}
+
+// Wrap returns a ctags.Parser which parses each file with the parsers
+// registered for its extension, and with p unless a registered parser
+// overrides ctags. Closing the returned parser closes p.
+func (r *Registry) Wrap(p ctags.Parser) ctags.Parser {
+	return &registryParser{registry: r, ctags: p}
+}
+
+type registryParser struct {
+	registry *Registry
+	ctags    ctags.Parser
+}
+
+func (p *registryParser) Close() {
+	p.ctags.Close()
+}
+
+func (p *registryParser) Parse(path string, content []byte) ([]ctags.Entry, error) {
+	override, merges := p.registry.lookup(path)
+
+	var (
+		entries []ctags.Entry
+		err     error
+	)
+	if override != nil {
+		entries, err = override.parse(path, content)
+	}
+	if override == nil || err != nil {
+		// Errors from ctags are returned so the service replaces the
+		// ctags process, which may be in a bad state.
+		entries, err = observe("ctags", func() ([]ctags.Entry, error) {
+			return p.ctags.Parse(path, content)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, reg := range merges {
+		more, err := reg.parse(path, content)
+		if err != nil {
+			continue
+		}
+		entries = mergeEntries(entries, more)
+	}
+	return entries, nil
+}
+
+// parse runs the registered parser. Errors and panics are logged, and the
+// caller falls back to the entries of ctags.
+func (reg *registration) parse(path string, content []byte) ([]ctags.Entry, error) {
+	entries, err := observe(reg.name, func() (entries []ctags.Entry, err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = fmt.Errorf("panic: %s", e)
+			}
+		}()
+		return reg.parser.Parse(path, content)
+	})
+	if err != nil {
+		log15.Debug("Symbol parser failed.", "parser", reg.name, "path", path, "error", err)
+	}
+	return entries, err
+}
+
+// observe calls parse and records metrics for the parser with the given name.
+func observe(name string, parse func() ([]ctags.Entry, error)) ([]ctags.Entry, error) {
+	start := time.Now()
+	entries, err := parse()
+	parserDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	parserFiles.WithLabelValues(name).Inc()
+	if err != nil {
+		parserErrors.WithLabelValues(name).Inc()
+	}
+	return entries, err
+}
+
+// mergeEntries returns the entries of base which do not have the same name
+// and line as an entry of extra, followed by the entries of extra.
+func mergeEntries(base, extra []ctags.Entry) []ctags.Entry {
+	type key struct {
+		name string
+		line int
+	}
+	seen := make(map[key]bool, len(extra))
+	for _, e := range extra {
+		seen[key{e.Name, e.Line}] = true
+	}
+	merged := make([]ctags.Entry, 0, len(base)+len(extra))
+	for _, e := range base {
+		if !seen[key{e.Name, e.Line}] {
+			merged = append(merged, e)
+		}
+	}
+	return append(merged, extra...)
+}
+
+var (
+	parserFiles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "symbols",
+		Subsystem: "parse",
+		Name:      "parser_files",
+		Help:      "The total number of files parsed, by parser.",
+	}, []string{"parser"})
+	parserErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "symbols",
+		Subsystem: "parse",
+		Name:      "parser_errors",
+		Help:      "The total number of files a parser failed to parse, by parser.",
+	}, []string{"parser"})
+	parserDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "symbols",
+		Subsystem: "parse",
+		Name:      "parser_duration_seconds",
+		Help:      "Time spent parsing a single file, by parser.",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+	}, []string{"parser"})
+)
+
+func init() {
+	prometheus.MustRegister(parserFiles)
+	prometheus.MustRegister(parserErrors)
+	prometheus.MustRegister(parserDuration)
+}
@@ -0,0 +1,148 @@
+package parsers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+)
+
+type fakeParser struct {
+	entries []ctags.Entry
+	err     error
+	calls   int
+}
+
+func (p *fakeParser) Parse(path string, content []byte) ([]ctags.Entry, error) {
+	p.calls++
+	return p.entries, p.err
+}
+
+func (p *fakeParser) Close() {}
+
+type panicParser struct{}
+
+func (panicParser) Parse(path string, content []byte) ([]ctags.Entry, error) {
+	panic("oops")
+}
+
+func TestRegistry(t *testing.T) {
+	var (
+		ctagsFoo  = ctags.Entry{Name: "foo", Line: 1, Kind: "func"}
+		ctagsBar  = ctags.Entry{Name: "bar", Line: 2, Kind: "func"}
+		nativeFoo = ctags.Entry{Name: "foo", Line: 1, Kind: "method", Parent: "T"}
+		nativeBaz = ctags.Entry{Name: "baz", Line: 3, Kind: "type"}
+	)
+
+	tests := map[string]struct {
+		override  Parser
+		merge     Parser
+		path      string
+		want      []ctags.Entry
+		wantCtags bool
+	}{
+		"no parsers registered for extension": {
+			override:  &fakeParser{entries: []ctags.Entry{nativeBaz}},
+			path:      "a.txt",
+			want:      []ctags.Entry{ctagsFoo, ctagsBar},
+			wantCtags: true,
+		},
+		"override": {
+			override: &fakeParser{entries: []ctags.Entry{nativeBaz}},
+			path:     "a.x",
+			want:     []ctags.Entry{nativeBaz},
+		},
+		"extension is case insensitive": {
+			override: &fakeParser{entries: []ctags.Entry{nativeBaz}},
+			path:     "a.X",
+			want:     []ctags.Entry{nativeBaz},
+		},
+		"override error falls back to ctags": {
+			override:  &fakeParser{err: errors.New("bad syntax")},
+			path:      "a.x",
+			want:      []ctags.Entry{ctagsFoo, ctagsBar},
+			wantCtags: true,
+		},
+		"override panic falls back to ctags": {
+			override:  panicParser{},
+			path:      "a.x",
+			want:      []ctags.Entry{ctagsFoo, ctagsBar},
+			wantCtags: true,
+		},
+		"merge": {
+			merge:     &fakeParser{entries: []ctags.Entry{nativeFoo, nativeBaz}},
+			path:      "a.x",
+			want:      []ctags.Entry{ctagsBar, nativeFoo, nativeBaz},
+			wantCtags: true,
+		},
+		"merge error is ignored": {
+			merge:     &fakeParser{err: errors.New("bad syntax")},
+			path:      "a.x",
+			want:      []ctags.Entry{ctagsFoo, ctagsBar},
+			wantCtags: true,
+		},
+		"override and merge": {
+			override: &fakeParser{entries: []ctags.Entry{ctagsFoo}},
+			merge:    &fakeParser{entries: []ctags.Entry{nativeFoo}},
+			path:     "a.x",
+			want:     []ctags.Entry{nativeFoo},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := &Registry{}
+			if test.override != nil {
+				r.Register("override", Override, test.override, ".x")
+			}
+			if test.merge != nil {
+				r.Register("merge", Merge, test.merge, ".x")
+			}
+			ctagsParser := &fakeParser{entries: []ctags.Entry{ctagsFoo, ctagsBar}}
+
+			got, err := r.Wrap(ctagsParser).Parse(test.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+			if gotCtags := ctagsParser.calls > 0; gotCtags != test.wantCtags {
+				t.Errorf("ctags called: got %v, want %v", gotCtags, test.wantCtags)
+			}
+		})
+	}
+}
+
+func TestRegistry_ctagsError(t *testing.T) {
+	r := &Registry{}
+	r.Register("merge", Merge, &fakeParser{}, ".x")
+	wantErr := errors.New("ctags died")
+	if _, err := r.Wrap(&fakeParser{err: wantErr}).Parse("a.x", nil); err != wantErr {
+		t.Errorf("got err %v, want %v", err, wantErr)
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	mustPanic := func(name string, register func(r *Registry)) {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Register did not panic")
+				}
+			}()
+			register(&Registry{})
+		})
+	}
+	mustPanic("duplicate name", func(r *Registry) {
+		r.Register("a", Merge, &fakeParser{}, ".x")
+		r.Register("a", Merge, &fakeParser{}, ".y")
+	})
+	mustPanic("no extensions", func(r *Registry) {
+		r.Register("a", Merge, &fakeParser{})
+	})
+	mustPanic("two overrides", func(r *Registry) {
+		r.Register("a", Override, &fakeParser{}, ".x")
+		r.Register("b", Override, &fakeParser{}, ".X")
+	})
+}
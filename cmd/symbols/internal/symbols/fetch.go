@@ -19,7 +19,10 @@ type parseRequest struct {
 	data []byte
 }
 
-func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName, commitID api.CommitID) (<-chan parseRequest, <-chan error, error) {
+// fetchRepositoryArchive returns a parseRequest for each file of repo@commitID
+// which should be parsed. If paths is non-nil, only those paths are
+// returned.
+func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string) (<-chan parseRequest, <-chan error, error) {
 	fetchQueueSize.Inc()
 	s.fetchSem <- 1 // acquire concurrent fetches semaphore
 	fetchQueueSize.Dec()
@@ -59,8 +62,28 @@ func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName,
 		span.Finish()
 	}
 
-	r, err := s.FetchTar(ctx, gitserver.Repo{Name: repo}, commitID)
+	// include is the set of paths to return, or nil for all paths. We check
+	// it even if FetchTarPaths is used, since git interprets the paths as
+	// pathspecs which may match other files.
+	var include map[string]bool
+	if paths != nil {
+		include = make(map[string]bool, len(paths))
+		for _, p := range paths {
+			include[p] = true
+		}
+	}
+
+	var (
+		r   io.ReadCloser
+		err error
+	)
+	if paths != nil && s.FetchTarPaths != nil {
+		r, err = s.FetchTarPaths(ctx, gitserver.Repo{Name: repo}, commitID, paths)
+	} else {
+		r, err = s.FetchTar(ctx, gitserver.Repo{Name: repo}, commitID)
+	}
 	if err != nil {
+		done(err)
 		return nil, nil, err
 	}
 
@@ -88,6 +111,10 @@ func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName,
 				return
 			}
 
+			if include != nil && !include[hdr.Name] {
+				continue
+			}
+
 			if path.Ext(hdr.Name) == ".json" {
 				continue
 			}
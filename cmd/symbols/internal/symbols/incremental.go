@@ -0,0 +1,167 @@
+package symbols
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+const (
+	// maxAncestors is the number of ancestors of a commit which are checked
+	// for a cached symbols database.
+	maxAncestors = 20
+
+	// maxIncrementalChangedFiles is the largest number of changed files for
+	// which we derive a database from an ancestor. Beyond this it is
+	// simpler and not much slower to parse every file.
+	maxIncrementalChangedFiles = 1000
+)
+
+// dbCacheKey is the key of the symbols database for repo@commitID in the
+// disk cache.
+func dbCacheKey(repo api.RepoName, commitID api.CommitID) string {
+	return fmt.Sprintf("%d-%s@%s", symbolsDBVersion, repo, commitID)
+}
+
+// writeSymbolsFromAncestor writes the symbols of repo@commitID to the blank
+// database file dbFile by copying the cached database of the nearest
+// ancestor and reparsing only the files which changed since. It returns
+// false if there is no cached ancestor, or if too many files changed. The
+// database is derived in a separate file which replaces dbFile only on
+// success, so dbFile is still blank if it returns false or an error.
+//
+// Reference counts are approximate: the counts of an identifier in unchanged
+// files are only known if it was the name of a symbol in the ancestor.
+func (s *Service) writeSymbolsFromAncestor(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID) (bool, error) {
+	if s.ListAncestors == nil || s.DiffPaths == nil {
+		return false, nil
+	}
+	repo := gitserver.Repo{Name: repoName}
+
+	ancestors, err := s.ListAncestors(ctx, repo, commitID, maxAncestors)
+	if err != nil {
+		return false, err
+	}
+	var (
+		ancestor   api.CommitID
+		ancestorDB *os.File
+	)
+	for _, a := range ancestors {
+		f, err := s.cache.OpenCached(dbCacheKey(repoName, a))
+		if err == nil {
+			ancestor, ancestorDB = a, f.File
+			break
+		}
+		if !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+	if ancestorDB == nil {
+		return false, nil
+	}
+	defer ancestorDB.Close()
+
+	changed, deleted, err := s.DiffPaths(ctx, repo, ancestor, commitID)
+	if err != nil {
+		return false, err
+	}
+	if len(changed)+len(deleted) > maxIncrementalChangedFiles {
+		return false, nil
+	}
+
+	// Cached databases are never modified, so we can copy it while other
+	// requests read it.
+	derivedFile := dbFile + ".ancestor"
+	defer removeDBFile(derivedFile)
+	if err := copyToFile(derivedFile, ancestorDB); err != nil {
+		return false, err
+	}
+	if err := s.updateSymbols(ctx, derivedFile, repoName, commitID, changed, deleted); err != nil {
+		return false, err
+	}
+	if err := os.Rename(derivedFile, dbFile); err != nil {
+		return false, err
+	}
+
+	incrementalParses.Inc()
+	log15.Debug("Derived symbols from an ancestor commit", "repo", repoName, "commit", commitID, "ancestor", ancestor, "changed", len(changed), "deleted", len(deleted))
+	return true, nil
+}
+
+// updateSymbols reparses the changed files and removes the deleted files in
+// the symbols database dbFile. The database is closed when it returns.
+func (s *Service) updateSymbols(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID, changed, deleted []string) error {
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	// Rollback is a no-op after a successful Commit.
+	defer tx.Rollback()
+
+	for _, path := range append(append([]string(nil), changed...), deleted...) {
+		if _, err := tx.Exec(`DELETE FROM symbols WHERE path = $1;`, path); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM refs WHERE path = $1;`, path); err != nil {
+			return err
+		}
+	}
+	if len(changed) > 0 {
+		err = s.writeSymbols(ctx, tx, repoName, commitID, changed)
+	} else {
+		// Only deletions, so there is nothing to parse but reference counts
+		// may have changed.
+		_, err = tx.Exec(`UPDATE symbols SET refcount = (SELECT COALESCE(SUM(count), 0) FROM refs WHERE refs.name = symbols.name);`)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// removeDBFile removes the sqlite3 database at path and its rollback
+// journal, if they exist.
+func removeDBFile(path string) {
+	for _, p := range []string{path, path + "-journal"} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log15.Warn("Unable to remove symbols database file", "path", p, "error", err)
+		}
+	}
+}
+
+// copyToFile creates or overwrites the file at path with the contents of r.
+func copyToFile(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var incrementalParses = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "symbols",
+	Subsystem: "parse",
+	Name:      "incremental_parses",
+	Help:      "The total number of symbols databases derived from the database of an ancestor commit.",
+})
+
+func init() {
+	prometheus.MustRegister(incrementalParses)
+}
@@ -37,7 +37,10 @@ func (s *Service) startParsers() error {
 	return nil
 }
 
-func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, callback func(symbol protocol.Symbol) error) (err error) {
+// parseUncached parses every file in repo@commitID, or only paths if it is
+// non-nil. It calls callback for each symbol and refsCallback with the number
+// of occurrences of each identifier in each file. Calls are serialized.
+func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string, callback func(symbol protocol.Symbol) error, refsCallback func(path string, counts map[string]int) error) (err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "parseUncached")
 	defer func() {
 		if err != nil {
@@ -63,7 +66,7 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	}()
 
 	tr.LazyPrintf("fetch")
-	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID)
+	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID, paths)
 	tr.LazyPrintf("fetch (returned chans)")
 	if err != nil {
 		return err
@@ -100,9 +103,17 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 			if parseErr != nil && parseErr != context.Canceled && parseErr != context.DeadlineExceeded {
 				log15.Error("Error parsing symbols.", "repo", repo, "commitID", commitID, "path", req.path, "dataSize", len(req.data), "error", parseErr)
 			}
+			counts := countIdentifiers(req.data)
+			mu.Lock()
+			defer mu.Unlock()
+			if len(counts) > 0 {
+				err = refsCallback(req.path, counts)
+				if err != nil {
+					log15.Error("Failed to add identifier counts", "path", req.path, "error", err)
+					return
+				}
+			}
 			if len(entries) > 0 {
-				mu.Lock()
-				defer mu.Unlock()
 				for _, e := range entries {
 					if e.Name == "" || strings.HasPrefix(e.Name, "__anon") || strings.HasPrefix(e.Parent, "__anon") || strings.HasPrefix(e.Name, "AnonymousFunction") || strings.HasPrefix(e.Parent, "AnonymousFunction") {
 						continue
@@ -0,0 +1,145 @@
+package symbols
+
+import (
+	"math"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+// maxIdentifierLength is the length of the longest identifier whose
+// occurrences are counted. It matches the size of the name column.
+const maxIdentifierLength = 256
+
+// countIdentifiers returns the number of times each identifier occurs in
+// data. An identifier is an ASCII letter or underscore followed by ASCII
+// letters, digits and underscores. This is a rough approximation of the
+// references to a symbol that works for most languages, including text in
+// comments and strings.
+func countIdentifiers(data []byte) map[string]int {
+	counts := map[string]int{}
+	for i := 0; i < len(data); {
+		if !isIdentifierStart(data[i]) {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(data) && (isIdentifierStart(data[j]) || ('0' <= data[j] && data[j] <= '9')) {
+			j++
+		}
+		if j-i <= maxIdentifierLength {
+			counts[string(data[i:j])]++
+		}
+		i = j
+	}
+	return counts
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// Kinds of symbols as reported by ctags, grouped by how likely they are to be
+// the definition a user is searching for.
+var (
+	typeKinds = map[string]bool{
+		"class": true, "struct": true, "interface": true, "type": true, "typedef": true,
+		"enum": true, "trait": true, "union": true, "protocol": true, "alias": true,
+	}
+	funcKinds = map[string]bool{
+		"func": true, "function": true, "method": true, "constructor": true,
+		"procedure": true, "subroutine": true, "macro": true,
+	}
+	memberKinds = map[string]bool{
+		"const": true, "constant": true, "field": true, "member": true,
+		"property": true, "enumerator": true,
+	}
+)
+
+// kindScore returns the part of a symbol's score which depends on its kind.
+// Types rank above functions, which rank above constants and fields, which
+// rank above variables and everything else.
+func kindScore(kind string) float64 {
+	kind = strings.ToLower(kind)
+	switch {
+	case typeKinds[kind]:
+		return 20
+	case funcKinds[kind]:
+		return 15
+	case memberKinds[kind]:
+		return 5
+	}
+	return 0
+}
+
+// queryLiteral returns the symbol name a query is looking for, if the query
+// is a literal (eg "foo") or an exact match (eg "^foo$").
+func queryLiteral(query string) (string, bool) {
+	if ok, lit, err := isLiteralEquality(query); ok && err == nil {
+		return lit, true
+	}
+	re, err := syntax.Parse(query, syntax.Perl)
+	if err != nil || re.Op != syntax.OpLiteral {
+		return "", false
+	}
+	return string(re.Rune), true
+}
+
+// symbolScore returns how likely symbol is to be the definition a user
+// searching for args.Query wants. Higher is better. A symbol whose name is
+// exactly the query always ranks above one which is not. Otherwise types and
+// functions rank above variables, symbols with many references rank above
+// those with few, and symbols in shallow paths rank above deeply nested ones.
+func symbolScore(symbol protocol.Symbol, lit string, hasLit, isCaseSensitive bool) float64 {
+	var score float64
+	if hasLit && (symbol.Name == lit || (!isCaseSensitive && strings.EqualFold(symbol.Name, lit))) {
+		score += 100
+	}
+	score += kindScore(symbol.Kind)
+	score += 10 * math.Log10(1+float64(symbol.RefCount))
+	depth := strings.Count(symbol.Path, "/")
+	if depth > 10 {
+		depth = 10
+	}
+	score -= float64(depth)
+	return score
+}
+
+// rankSymbols sorts symbols by symbolScore, best first. Symbols with the same
+// score are ordered by name, path and line so results are stable.
+func rankSymbols(symbols []protocol.Symbol, args protocol.SearchArgs) {
+	lit, hasLit := queryLiteral(args.Query)
+	scores := make([]float64, len(symbols))
+	for i, symbol := range symbols {
+		scores[i] = symbolScore(symbol, lit, hasLit, args.IsCaseSensitive)
+	}
+	sort.Sort(symbolsByScore{symbols: symbols, scores: scores})
+}
+
+type symbolsByScore struct {
+	symbols []protocol.Symbol
+	scores  []float64
+}
+
+func (s symbolsByScore) Len() int { return len(s.symbols) }
+
+func (s symbolsByScore) Swap(i, j int) {
+	s.symbols[i], s.symbols[j] = s.symbols[j], s.symbols[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
+
+func (s symbolsByScore) Less(i, j int) bool {
+	if s.scores[i] != s.scores[j] {
+		return s.scores[i] > s.scores[j]
+	}
+	a, b := s.symbols[i], s.symbols[j]
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	if a.Path != b.Path {
+		return a.Path < b.Path
+	}
+	return a.Line < b.Line
+}
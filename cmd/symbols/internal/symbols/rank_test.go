@@ -0,0 +1,80 @@
+package symbols
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+func TestCountIdentifiers(t *testing.T) {
+	got := countIdentifiers([]byte("func foo() {\n\treturn foo_bar(foo, 1x2, _y)\n}"))
+	want := map[string]int{"func": 1, "foo": 2, "return": 1, "foo_bar": 1, "x2": 1, "_y": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRankSymbols(t *testing.T) {
+	var (
+		typeFoo     = protocol.Symbol{Name: "Foo", Kind: "type", Path: "foo.go", RefCount: 2}
+		funcFoo     = protocol.Symbol{Name: "Foo", Kind: "func", Path: "foo.go", RefCount: 2}
+		varFoo      = protocol.Symbol{Name: "foo", Kind: "variable", Path: "foo.go", RefCount: 2}
+		deepFoo     = protocol.Symbol{Name: "Foo", Kind: "type", Path: "a/b/c/foo.go", RefCount: 2}
+		popularFoo  = protocol.Symbol{Name: "Foo", Kind: "variable", Path: "foo.go", RefCount: 10000}
+		fooBar      = protocol.Symbol{Name: "FooBar", Kind: "type", Path: "foo.go", RefCount: 100000}
+		otherFooBar = protocol.Symbol{Name: "FooBar", Kind: "type", Path: "bar.go", RefCount: 100000}
+	)
+
+	tests := []struct {
+		name    string
+		args    protocol.SearchArgs
+		symbols []protocol.Symbol
+		want    []protocol.Symbol
+	}{
+		{
+			name:    "kind",
+			args:    protocol.SearchArgs{Query: "Foo", IsCaseSensitive: true},
+			symbols: []protocol.Symbol{funcFoo, typeFoo},
+			want:    []protocol.Symbol{typeFoo, funcFoo},
+		},
+		{
+			name:    "exact match beats references",
+			args:    protocol.SearchArgs{Query: "^foo$"},
+			symbols: []protocol.Symbol{fooBar, typeFoo},
+			want:    []protocol.Symbol{typeFoo, fooBar},
+		},
+		{
+			name:    "case sensitive exact match",
+			args:    protocol.SearchArgs{Query: "foo", IsCaseSensitive: true},
+			symbols: []protocol.Symbol{typeFoo, varFoo},
+			want:    []protocol.Symbol{varFoo, typeFoo},
+		},
+		{
+			name:    "path depth",
+			args:    protocol.SearchArgs{Query: "Foo"},
+			symbols: []protocol.Symbol{deepFoo, typeFoo},
+			want:    []protocol.Symbol{typeFoo, deepFoo},
+		},
+		{
+			name:    "references",
+			args:    protocol.SearchArgs{Query: "Foo"},
+			symbols: []protocol.Symbol{varFoo, popularFoo},
+			want:    []protocol.Symbol{popularFoo, varFoo},
+		},
+		{
+			name:    "ties are ordered by path",
+			args:    protocol.SearchArgs{Query: "Foo."},
+			symbols: []protocol.Symbol{fooBar, otherFooBar},
+			want:    []protocol.Symbol{otherFooBar, fooBar},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rankSymbols(test.symbols, test.args)
+			if !reflect.DeepEqual(test.symbols, test.want) {
+				t.Errorf("got %+v, want %+v", test.symbols, test.want)
+			}
+		})
+	}
+}
@@ -116,7 +116,7 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (result
 // specified in `args`. If the database doesn't already exist in the disk cache,
 // it will create a new one and write all the symbols into it.
 func (s *Service) getDBFile(ctx context.Context, args protocol.SearchArgs) (string, error) {
-	diskcacheFile, err := s.cache.OpenWithPath(ctx, fmt.Sprintf("%d-%s@%s", symbolsDBVersion, args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
+	diskcacheFile, err := s.cache.OpenWithPath(ctx, dbCacheKey(args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
 		err := s.writeAllSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
 		if err != nil {
 			if err == context.Canceled {
@@ -162,7 +162,13 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 		span.Finish()
 	}()
 
-	const maxFirst = 500
+	const (
+		maxFirst = 500
+
+		// rankCandidatesFactor is how many more symbols than requested are
+		// read from the database and ranked.
+		rankCandidatesFactor = 10
+	)
 	if args.First < 0 || args.First > maxFirst {
 		args.First = maxFirst
 	}
@@ -209,11 +215,24 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 	}
 	conditions = append(conditions, negateAll(makeCondition("path", args.ExcludePattern))...)
 
+	// We rank more candidates than we return. Symbols named exactly like
+	// the query and symbols with many references are most likely to rank
+	// highly, so they are chosen as candidates first.
+	order := sqlf.Sprintf("refcount DESC")
+	if lit, ok := queryLiteral(args.Query); ok {
+		if args.IsCaseSensitive {
+			order = sqlf.Sprintf("name = %s DESC, %s", lit, order)
+		} else {
+			order = sqlf.Sprintf("namelowercase = %s DESC, %s", strings.ToLower(lit), order)
+		}
+	}
+	limit := args.First * rankCandidatesFactor
+
 	var sqlQuery *sqlf.Query
 	if len(conditions) == 0 {
-		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols LIMIT %s", args.First)
+		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols ORDER BY %s LIMIT %s", order, limit)
 	} else {
-		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols WHERE %s LIMIT %s", sqlf.Join(conditions, "AND"), args.First)
+		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols WHERE %s ORDER BY %s LIMIT %s", sqlf.Join(conditions, "AND"), order, limit)
 	}
 
 	var symbolsInDB []symbolInDB
@@ -225,6 +244,10 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 	for _, symbolInDB := range symbolsInDB {
 		res = append(res, symbolInDBToSymbol(symbolInDB))
 	}
+	rankSymbols(res, args)
+	if len(res) > args.First {
+		res = res[:args.First]
+	}
 
 	span.SetTag("hits", len(res))
 	return res, nil
@@ -233,12 +256,14 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 // The version of the symbols database schema. This is included in the database
 // filenames to prevent a newer version of the symbols service from attempting
 // to read from a database created by an older (and likely incompatible) symbols
-// service. Increment this when you change the database schema.
-const symbolsDBVersion = 2
+// service. Increment this when you change the database schema or the symbols
+// parsers produce different symbols for the same files.
+const symbolsDBVersion = 5
 
 // symbolInDB is the same as `protocol.Symbol`, but with two additional columns:
 // namelowercase and pathlowercase, which enable indexed case insensitive
-// queries.
+// queries. RefCount is computed from the refs table once all files have been
+// parsed.
 type symbolInDB struct {
 	Name          string
 	NameLowercase string // derived from `Name`
@@ -253,6 +278,16 @@ type symbolInDB struct {
 	Pattern       string
 
 	FileLimited bool
+	RefCount    int
+}
+
+// refInDB is a row of the refs table, which records how many times an
+// identifier occurs in a file. Only identifiers which are the name of a
+// symbol are kept.
+type refInDB struct {
+	Name  string
+	Path  string
+	Count int
 }
 
 func symbolToSymbolInDB(symbol protocol.Symbol) symbolInDB {
@@ -270,6 +305,7 @@ func symbolToSymbolInDB(symbol protocol.Symbol) symbolInDB {
 		Pattern:       symbol.Pattern,
 
 		FileLimited: symbol.FileLimited,
+		RefCount:    symbol.RefCount,
 	}
 }
 
@@ -286,12 +322,27 @@ func symbolInDBToSymbol(symbolInDB symbolInDB) protocol.Symbol {
 		Pattern:    symbolInDB.Pattern,
 
 		FileLimited: symbolInDB.FileLimited,
+		RefCount:    symbolInDB.RefCount,
 	}
 }
 
 // writeAllSymbolsToNewDB fetches the repo@commit from gitserver, parses all the
-// symbols, and writes them to the blank database file `dbFile`.
+// symbols, and writes them to the blank database file `dbFile`. If the
+// database of an ancestor commit is cached, only the files which changed
+// since the ancestor are parsed.
 func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID) error {
+	ok, err := s.writeSymbolsFromAncestor(ctx, dbFile, repoName, commitID)
+	if ok {
+		return nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		// dbFile is still blank, so we can start again from scratch.
+		log15.Warn("Unable to derive symbols from an ancestor commit, parsing all files", "repo", repoName, "commit", commitID, "error", err)
+	}
+
 	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
 	if err != nil {
 		return err
@@ -320,7 +371,18 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 			parentkind VARCHAR(255) NOT NULL,
 			signature VARCHAR(255) NOT NULL,
 			pattern VARCHAR(255) NOT NULL,
-			filelimited BOOLEAN NOT NULL
+			filelimited BOOLEAN NOT NULL,
+			refcount INT NOT NULL
+		)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`CREATE TABLE IF NOT EXISTS refs (
+			name VARCHAR(256) NOT NULL,
+			path VARCHAR(4096) NOT NULL,
+			count INT NOT NULL
 		)`)
 	if err != nil {
 		return err
@@ -347,28 +409,74 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
+	err = s.writeSymbols(ctx, tx, repoName, commitID, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// writeSymbols parses the files of repo@commit, or only paths if it is
+// non-nil, and inserts their symbols and identifier counts into the tables
+// created by writeAllSymbolsToNewDB. It then updates the reference counts
+// of all symbols.
+func (s *Service) writeSymbols(ctx context.Context, tx *sqlx.Tx, repoName api.RepoName, commitID api.CommitID, paths []string) error {
 	insertStatement, err := tx.PrepareNamed(
 		fmt.Sprintf(
 			"INSERT INTO symbols %s VALUES %s",
-			"( name,  namelowercase,  path,  pathlowercase,  line,  kind,  language,  parent,  parentkind,  signature,  pattern,  filelimited)",
-			"(:name, :namelowercase, :path, :pathlowercase, :line, :kind, :language, :parent, :parentkind, :signature, :pattern, :filelimited)"))
+			"( name,  namelowercase,  path,  pathlowercase,  line,  kind,  language,  parent,  parentkind,  signature,  pattern,  filelimited,  refcount)",
+			"(:name, :namelowercase, :path, :pathlowercase, :line, :kind, :language, :parent, :parentkind, :signature, :pattern, :filelimited, :refcount)"))
+	if err != nil {
+		return err
+	}
+
+	insertRefStatement, err := tx.PrepareNamed("INSERT INTO refs (name, path, count) VALUES (:name, :path, :count)")
 	if err != nil {
 		return err
 	}
 
-	err = s.parseUncached(ctx, repoName, commitID, func(symbol protocol.Symbol) error {
+	err = s.parseUncached(ctx, repoName, commitID, paths, func(symbol protocol.Symbol) error {
 		symbolInDBValue := symbolToSymbolInDB(symbol)
 		_, err := insertStatement.Exec(&symbolInDBValue)
 		return err
+	}, func(path string, counts map[string]int) error {
+		for name, count := range counts {
+			refInDBValue := refInDB{Name: name, Path: path, Count: count}
+			if _, err := insertRefStatement.Exec(&refInDBValue); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return err
 	}
 
-	err = tx.Commit()
+	// Most identifiers are not the name of a symbol (eg keywords and local
+	// variables), so we only keep the counts of those which are.
+	_, err = tx.Exec(`DELETE FROM refs WHERE name NOT IN (SELECT name FROM symbols);`)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS refs_name_index ON refs(name);`)
+	if err != nil {
+		return err
+	}
+
+	// `refs_path_index` speeds up removing the counts of changed files when
+	// deriving the database of a descendant commit.
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS refs_path_index ON refs(path);`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE symbols SET refcount = (SELECT COALESCE(SUM(count), 0) FROM refs WHERE refs.name = symbols.name);`)
+	return err
 }
@@ -22,6 +22,21 @@ type Service struct {
 	// determine if the error is a bad request (eg invalid repo).
 	FetchTar func(context.Context, gitserver.Repo, api.CommitID) (io.ReadCloser, error)
 
+	// FetchTarPaths is like FetchTar, but the archive only needs to contain
+	// the given paths. It is optional and is used to fetch only the changed
+	// files when deriving symbols from an ancestor commit.
+	FetchTarPaths func(context.Context, gitserver.Repo, api.CommitID, []string) (io.ReadCloser, error)
+
+	// ListAncestors returns up to n ancestors of a commit, nearest first. It
+	// is optional. When it and DiffPaths are set, the symbols of a commit are
+	// derived from the cached symbols of an ancestor by parsing only the
+	// files which changed between the two commits.
+	ListAncestors func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error)
+
+	// DiffPaths returns the paths of the files which were added or modified
+	// (changed) and deleted between two commits.
+	DiffPaths func(ctx context.Context, repo gitserver.Repo, a, b api.CommitID) (changed, deleted []string, err error)
+
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
 	// to FetchTar. It defaults to 15.
 	MaxConcurrentFetchTar int
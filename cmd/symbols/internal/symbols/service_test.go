@@ -13,7 +13,9 @@ import (
 	"path"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
@@ -40,6 +42,14 @@ func init() {
 	}
 }
 
+var registerSqlite3Once sync.Once
+
+// registerSqlite3 registers the sqlite3 driver once for all tests and
+// benchmarks in this package.
+func registerSqlite3() {
+	registerSqlite3Once.Do(MustRegisterSqlite3WithPcre)
+}
+
 func TestIsLiteralEquality(t *testing.T) {
 	type TestCase struct {
 		Regex       string
@@ -73,7 +83,7 @@ func TestIsLiteralEquality(t *testing.T) {
 }
 
 func TestService(t *testing.T) {
-	MustRegisterSqlite3WithPcre()
+	registerSqlite3()
 
 	tmpDir, err := ioutil.TempDir("", "")
 	if err != nil {
@@ -98,7 +108,7 @@ func TestService(t *testing.T) {
 	server := httptest.NewServer(service.Handler())
 	defer server.Close()
 	client := symbolsclient.Client{URL: server.URL}
-	x := protocol.Symbol{Name: "x", Path: "a.js"}
+	x := protocol.Symbol{Name: "x", Path: "a.js", RefCount: 1}
 	y := protocol.Symbol{Name: "y", Path: "a.js"}
 
 	tests := map[string]struct {
@@ -159,6 +169,79 @@ func TestService(t *testing.T) {
 	}
 }
 
+func TestService_incremental(t *testing.T) {
+	registerSqlite3()
+
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	commits := map[api.CommitID]map[string]string{
+		"parent": {"a.go": "foo\nbar", "b.go": "baz"},
+		"child":  {"a.go": "foo\nqux", "c.go": "quux qux"},
+	}
+	var fetchedAll []api.CommitID
+	var fetchedPaths []string
+	service := Service{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+			fetchedAll = append(fetchedAll, commit)
+			return createTar(commits[commit])
+		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			fetchedPaths = append(fetchedPaths, paths...)
+			return createTar(commits[commit])
+		},
+		ListAncestors: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error) {
+			if commit == "child" {
+				return []api.CommitID{"parent"}, nil
+			}
+			return nil, nil
+		},
+		DiffPaths: func(ctx context.Context, repo gitserver.Repo, a, b api.CommitID) ([]string, []string, error) {
+			if a != "parent" || b != "child" {
+				t.Fatalf("unexpected diff %s..%s", a, b)
+			}
+			return []string{"a.go", "c.go"}, []string{"b.go"}, nil
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return linesParser{}, nil
+		},
+		Path: tmpDir,
+	}
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(commit api.CommitID) []string {
+		result, err := service.search(context.Background(), protocol.SearchArgs{Repo: "r", CommitID: commit, First: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var symbols []string
+		for _, s := range result.Symbols {
+			symbols = append(symbols, fmt.Sprintf("%s:%d:%s:%d", s.Path, s.Line, s.Name, s.RefCount))
+		}
+		sort.Strings(symbols)
+		return symbols
+	}
+
+	if got, want := search("parent"), []string{"a.go:0:foo:1", "a.go:1:bar:1", "b.go:0:baz:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parent: got %q, want %q", got, want)
+	}
+	if got, want := search("child"), []string{"a.go:0:foo:1", "a.go:1:qux:2", "c.go:0:quux:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("child: got %q, want %q", got, want)
+	}
+
+	if want := []api.CommitID{"parent"}; !reflect.DeepEqual(fetchedAll, want) {
+		t.Errorf("fetched whole archives of %q, want %q", fetchedAll, want)
+	}
+	if want := []string{"a.go", "c.go"}; !reflect.DeepEqual(fetchedPaths, want) {
+		t.Errorf("fetched paths %q, want %q", fetchedPaths, want)
+	}
+}
+
 func createTar(files map[string]string) (io.ReadCloser, error) {
 	buf := new(bytes.Buffer)
 	w := tar.NewWriter(buf)
@@ -194,3 +277,18 @@ func (m mockParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
 }
 
 func (mockParser) Close() {}
+
+// linesParser returns a symbol named after the first word of each line.
+type linesParser struct{}
+
+func (linesParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
+	var entries []ctags.Entry
+	for i, line := range strings.Split(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			entries = append(entries, ctags.Entry{Name: fields[0], Path: name, Line: i})
+		}
+	}
+	return entries, nil
+}
+
+func (linesParser) Close() {}
@@ -21,6 +21,7 @@ import (
 	log15 "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/parsers"
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/symbols"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/debugserver"
@@ -52,12 +53,27 @@ func main() {
 		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
 			return git.Archive(ctx, repo, git.ArchiveOptions{Treeish: string(commit), Format: "tar"})
 		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return git.Archive(ctx, repo, git.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: paths})
+		},
+		ListAncestors: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error) {
+			commits, err := git.Commits(ctx, repo, git.CommitsOptions{Range: string(commit), N: uint(n), Skip: 1})
+			if err != nil {
+				return nil, err
+			}
+			ids := make([]api.CommitID, len(commits))
+			for i, c := range commits {
+				ids[i] = c.ID
+			}
+			return ids, nil
+		},
+		DiffPaths: git.DiffPaths,
 		NewParser: func() (ctags.Parser, error) {
 			parser, err := ctags.NewParser(ctags.GetCommand())
 			if err != nil {
 				return nil, errors.Wrap(err, fmt.Sprintf("command: %s", ctags.GetCommand()))
 			}
-			return parser, nil
+			return parsers.Default.Wrap(parser), nil
 		},
 		Path: cacheDir,
 	}
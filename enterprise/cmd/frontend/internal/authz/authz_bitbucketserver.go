@@ -0,0 +1,81 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	permbbs "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func bitbucketServerProviders(ctx context.Context, bitbuckets []*schema.BitbucketServerConnection) (
+	authzProviders []authz.Provider,
+	seriousProblems []string,
+	warnings []string,
+) {
+	for _, c := range bitbuckets {
+		p, err := bitbucketServerProvider(c.Authorization, c.Url)
+		if err != nil {
+			seriousProblems = append(seriousProblems, err.Error())
+			continue
+		}
+		if p != nil {
+			authzProviders = append(authzProviders, p)
+		}
+	}
+	for _, provider := range authzProviders {
+		for _, problem := range provider.Validate() {
+			warnings = append(warnings, fmt.Sprintf("Bitbucket Server config for %s was invalid: %s", provider.ServiceID(), problem))
+		}
+	}
+	return authzProviders, seriousProblems, warnings
+}
+
+func bitbucketServerProvider(a *schema.BitbucketServerAuthorization, instanceURL string) (authz.Provider, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	bbsURL, err := url.Parse(instanceURL)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse URL for Bitbucket Server instance %q: %s", instanceURL, err)
+	}
+
+	ttl, err := parseTTL(a.Ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	var useNativeUsername bool
+	switch idp := a.IdentityProvider; {
+	case idp.Oauth != nil:
+		useNativeUsername = false
+	case idp.Username != nil:
+		useNativeUsername = true
+	default:
+		return nil, fmt.Errorf("No identityProvider was specified")
+	}
+
+	cli := bitbucketserver.NewClient(bbsURL, nil)
+	if err := cli.SetOAuth(a.Oauth.ConsumerKey, a.Oauth.SigningKey); err != nil {
+		return nil, fmt.Errorf("Invalid authorization.oauth.signingKey for Bitbucket Server instance %q: %s", instanceURL, err)
+	}
+
+	return NewBitbucketServerProvider(cli, useNativeUsername, ttl), nil
+}
+
+// NewBitbucketServerProvider is a mockable constructor for new bitbucketserver.Provider instances.
+var NewBitbucketServerProvider = func(cli *bitbucketserver.Client, useNativeUsername bool, ttl time.Duration) authz.Provider {
+	return permbbs.NewProvider(cli, useNativeUsername, ttl, nil)
+}
+
+// ValidateBitbucketServerAuthz validates the authorization fields of the given Bitbucket Server
+// external service config.
+func ValidateBitbucketServerAuthz(cfg *schema.BitbucketServerConnection) error {
+	_, err := bitbucketServerProvider(cfg.Authorization, cfg.Url)
+	return err
+}
@@ -10,7 +10,9 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/auth/providers"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/explicit"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/gitlab"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
@@ -324,6 +326,18 @@ func Test_providersFromConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "explicit permissions",
+			cfg: conf.Unified{
+				SiteConfiguration: schema.SiteConfiguration{
+					PermissionsExplicit: &schema.PermissionsExplicit{ServiceTypes: []string{"gitolite", "other"}},
+				},
+			},
+			expAuthzAllowAccessByDefault: true,
+			expAuthzProviders: []authz.Provider{
+				explicit.NewProvider([]string{"gitolite", "other"}, db.ExplicitPermissions),
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -360,8 +374,9 @@ func asJSON(t *testing.T, v interface{}) string {
 }
 
 type fakeStore struct {
-	gitlabs []*schema.GitLabConnection
-	githubs []*schema.GitHubConnection
+	gitlabs    []*schema.GitLabConnection
+	githubs    []*schema.GitHubConnection
+	bitbuckets []*schema.BitbucketServerConnection
 }
 
 func (s fakeStore) ListGitHubConnections(context.Context) ([]*schema.GitHubConnection, error) {
@@ -371,3 +386,7 @@ func (s fakeStore) ListGitHubConnections(context.Context) ([]*schema.GitHubConne
 func (s fakeStore) ListGitLabConnections(context.Context) ([]*schema.GitLabConnection, error) {
 	return s.gitlabs, nil
 }
+
+func (s fakeStore) ListBitbucketServerConnections(context.Context) ([]*schema.BitbucketServerConnection, error) {
+	return s.bitbuckets, nil
+}
@@ -0,0 +1,55 @@
+package bitbucketserver
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// cache describes the shape of the repo permissions cache that Provider uses internally.
+type cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, b []byte)
+	Delete(key string)
+}
+
+// publicReposCacheKey is the key for caching the set of public repositories. It must not collide
+// with any key returned by userReposCacheKey.
+const publicReposCacheKey = "public"
+
+// userReposCacheKey returns the key for caching the set of repositories that the Bitbucket Server
+// user with the given username can read.
+func userReposCacheKey(username string) string {
+	return "u:" + username
+}
+
+type reposCacheVal struct {
+	// IDs are the Bitbucket Server IDs of the repositories.
+	IDs []int
+	TTL time.Duration
+}
+
+func cacheGetRepos(c cache, key string, ttl time.Duration) (v reposCacheVal, exists bool) {
+	b, exists := c.Get(key)
+	if !exists {
+		return reposCacheVal{}, false
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		c.Delete(key)
+		return reposCacheVal{}, false
+	}
+	if v.TTL != ttl {
+		// if the cache TTL changed, invalidate the entry
+		c.Delete(key)
+		return reposCacheVal{}, false
+	}
+	return v, true
+}
+
+func cacheSetRepos(c cache, key string, v reposCacheVal) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.Set(key, b)
+	return nil
+}
@@ -0,0 +1,225 @@
+// Package bitbucketserver contains an authorization provider for Bitbucket Server.
+package bitbucketserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+)
+
+// Provider implements authz.Provider for Bitbucket Server repository permissions.
+type Provider struct {
+	client            *bitbucketserver.Client
+	codeHost          *bitbucketserver.CodeHost
+	useNativeUsername bool
+	cacheTTL          time.Duration
+	cache             cache
+}
+
+var _ authz.Provider = ((*Provider)(nil))
+
+// NewProvider returns a new Bitbucket Server authorization provider that uses the given client,
+// which must be configured with OAuth (see bitbucketserver.Client.SetOAuth) so that it can list the
+// repositories a user can read on behalf of that user.
+//
+// If useNativeUsername is true, Sourcegraph users are mapped to the Bitbucket Server users with the
+// same username. This is insecure if Sourcegraph users can change their usernames at will.
+// Otherwise, the Bitbucket Server account must already be linked to the Sourcegraph user (e.g., by
+// signing in via OAuth).
+func NewProvider(cli *bitbucketserver.Client, useNativeUsername bool, cacheTTL time.Duration, mockCache cache) *Provider {
+	p := &Provider{
+		client:            cli,
+		codeHost:          bitbucketserver.NewCodeHost(cli.URL),
+		useNativeUsername: useNativeUsername,
+		cacheTTL:          cacheTTL,
+		cache:             mockCache,
+	}
+	// Note: like the GitHub provider, every instance of Provider for the same Bitbucket Server
+	// instance shares the same Redis key namespace, even across processes.
+	if p.cache == nil {
+		p.cache = rcache.NewWithTTL(fmt.Sprintf("bitbucketServerAuthz:%s", p.codeHost.ServiceID()), int(math.Ceil(cacheTTL.Seconds())))
+	}
+	return p
+}
+
+// Validate implements the authz.Provider interface. It checks that the Bitbucket Server API
+// accepts the OAuth credentials of the provider.
+func (p *Provider) Validate() (problems []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, _, err := p.client.Users(ctx, &bitbucketserver.PageToken{Limit: 1}, ""); err != nil {
+		if err == ctx.Err() {
+			problems = append(problems, fmt.Sprintf("Bitbucket Server API did not respond within 5s (%s)", err.Error()))
+		} else {
+			problems = append(problems, fmt.Sprintf("Bitbucket Server API rejected the OAuth credentials (%s)", err.Error()))
+		}
+	}
+	return problems
+}
+
+func (p *Provider) ServiceID() string {
+	return p.codeHost.ServiceID()
+}
+
+func (p *Provider) ServiceType() string {
+	return p.codeHost.ServiceType()
+}
+
+// Repos implements the authz.Provider interface.
+func (p *Provider) Repos(ctx context.Context, repos map[authz.Repo]struct{}) (mine map[authz.Repo]struct{}, others map[authz.Repo]struct{}) {
+	return authz.GetCodeHostRepos(p.codeHost, repos)
+}
+
+// RepoPerms implements the authz.Provider interface.
+//
+// It lists every repository the user's Bitbucket Server account can read (or every public
+// repository if there is no such account) and grants read access to the repos in that list. The
+// list is cached in Redis for the cache TTL of the provider.
+func (p *Provider) RepoPerms(ctx context.Context, account *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
+	remaining, _ := p.Repos(ctx, repos)
+	if len(remaining) == 0 {
+		return nil, nil
+	}
+
+	var username string // empty means public / unauthenticated to the code host
+	if account != nil && account.ServiceID == p.codeHost.ServiceID() && account.ServiceType == p.codeHost.ServiceType() {
+		usr, err := bitbucketserver.GetExternalAccountData(&account.ExternalAccountData)
+		if err != nil {
+			return nil, err
+		}
+		if usr != nil {
+			username = usr.Name
+		}
+	}
+
+	readable, err := p.readableRepos(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make(map[api.RepoName]map[authz.Perm]bool, len(remaining))
+	for repo := range remaining {
+		id, err := strconv.Atoi(repo.ExternalRepoSpec.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "Bitbucket Server repo external ID did not parse to int")
+		}
+		perms[repo.RepoName] = map[authz.Perm]bool{authz.Read: readable[id]}
+	}
+	return perms, nil
+}
+
+// readableRepos returns the set of IDs of the repositories that the Bitbucket Server user with the
+// given username can read. If username is empty, it returns the set of IDs of public repositories.
+// It consults and updates the cache.
+func (p *Provider) readableRepos(ctx context.Context, username string) (map[int]bool, error) {
+	key := publicReposCacheKey
+	if username != "" {
+		key = userReposCacheKey(username)
+	}
+
+	val, exists := cacheGetRepos(p.cache, key, p.cacheTTL)
+	if !exists {
+		ids, err := p.fetchReadableRepos(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		val = reposCacheVal{IDs: ids, TTL: p.cacheTTL}
+		if err := cacheSetRepos(p.cache, key, val); err != nil {
+			return nil, errors.Wrap(err, "could not set cached repos")
+		}
+	}
+
+	readable := make(map[int]bool, len(val.IDs))
+	for _, id := range val.IDs {
+		readable[id] = true
+	}
+	return readable, nil
+}
+
+// fetchReadableRepos fetches the IDs of the repositories that the Bitbucket Server user with the
+// given username can read from the Bitbucket Server API, impersonating that user. If username is
+// empty, it fetches the IDs of public repositories instead.
+func (p *Provider) fetchReadableRepos(ctx context.Context, username string) ([]int, error) {
+	cli, query := p.client, "visibility=public"
+	if username != "" {
+		var err error
+		if cli, err = p.client.Sudo(username); err != nil {
+			return nil, err
+		}
+		query = "permission=REPO_READ"
+	}
+
+	var ids []int
+	page := &bitbucketserver.PageToken{Limit: 1000}
+	for page.HasMore() {
+		var err error
+		var repos []*bitbucketserver.Repo
+		if repos, page, err = cli.Repos(ctx, page, query); err != nil {
+			return nil, err
+		}
+		for _, r := range repos {
+			ids = append(ids, r.ID)
+		}
+	}
+	return ids, nil
+}
+
+// FetchAccount implements the authz.Provider interface. If the provider maps users by username, it
+// fetches the Bitbucket Server user with the same username as the given user. Otherwise, it always
+// returns nil, because the Bitbucket Server account is linked when the user signs in via OAuth.
+func (p *Provider) FetchAccount(ctx context.Context, user *types.User, current []*extsvc.ExternalAccount) (mine *extsvc.ExternalAccount, err error) {
+	if user == nil || !p.useNativeUsername {
+		return nil, nil
+	}
+
+	bbUser, err := p.fetchAccountByUsername(ctx, user.Username)
+	if err != nil {
+		return nil, err
+	}
+	if bbUser == nil {
+		return nil, nil
+	}
+
+	var accountData extsvc.ExternalAccountData
+	bitbucketserver.SetExternalAccountData(&accountData, bbUser)
+
+	return &extsvc.ExternalAccount{
+		UserID: user.ID,
+		ExternalAccountSpec: extsvc.ExternalAccountSpec{
+			ServiceType: p.codeHost.ServiceType(),
+			ServiceID:   p.codeHost.ServiceID(),
+			AccountID:   strconv.Itoa(bbUser.ID),
+		},
+		ExternalAccountData: accountData,
+	}, nil
+}
+
+// fetchAccountByUsername returns the Bitbucket Server user whose username is exactly the given
+// username, or nil if there is no such user.
+func (p *Provider) fetchAccountByUsername(ctx context.Context, username string) (*bitbucketserver.User, error) {
+	page := &bitbucketserver.PageToken{Limit: 1000}
+	for page.HasMore() {
+		var err error
+		var users []*bitbucketserver.User
+		// The filter also matches display names and email addresses, and matches substrings.
+		if users, page, err = p.client.Users(ctx, page, username); err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if u.Name == username {
+				return u, nil
+			}
+		}
+	}
+	return nil, nil
+}
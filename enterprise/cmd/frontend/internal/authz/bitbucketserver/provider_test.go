@@ -0,0 +1,249 @@
+package bitbucketserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+)
+
+// mockBitbucketServer serves the subset of the Bitbucket Server REST API used by Provider.
+type mockBitbucketServer struct {
+	users []*bitbucketserver.User
+
+	// repos maps a username to the repositories the user can read. The empty username maps to the
+	// public repositories.
+	repos map[string][]*bitbucketserver.Repo
+
+	reposRequests int
+}
+
+func (s *mockBitbucketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "OAuth ") {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var values interface{}
+	switch r.URL.Path {
+	case "/rest/api/1.0/users":
+		var users []*bitbucketserver.User
+		for _, u := range s.users {
+			if strings.Contains(u.Name, r.URL.Query().Get("filter")) {
+				users = append(users, u)
+			}
+		}
+		values = users
+	case "/rest/api/1.0/repos":
+		s.reposRequests++
+		username := r.URL.Query().Get("xoauth_requestor_id")
+		if username == "" && r.URL.Query().Get("visibility") != "public" {
+			http.Error(w, "expected visibility=public", http.StatusBadRequest)
+			return
+		}
+		if username != "" && r.URL.Query().Get("permission") != "REPO_READ" {
+			http.Error(w, "expected permission=REPO_READ", http.StatusBadRequest)
+			return
+		}
+		values = s.repos[username]
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"isLastPage": true,
+		"values":     values,
+	})
+}
+
+func newTestProvider(t *testing.T, srv *httptest.Server, useNativeUsername bool, ttl time.Duration, c cache) *Provider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKey := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := bitbucketserver.NewClient(u, nil)
+	if err := cli.SetOAuth("sourcegraph", signingKey); err != nil {
+		t.Fatal(err)
+	}
+	return NewProvider(cli, useNativeUsername, ttl, c)
+}
+
+func TestProvider_FetchAccount(t *testing.T) {
+	bbs := &mockBitbucketServer{
+		users: []*bitbucketserver.User{
+			{ID: 1, Name: "alice"},
+			{ID: 2, Name: "alice2"},
+		},
+	}
+	srv := httptest.NewServer(bbs)
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	t.Run("username", func(t *testing.T) {
+		p := newTestProvider(t, srv, true, time.Hour, make(authz.MockCache))
+
+		acct, err := p.FetchAccount(ctx, &types.User{ID: 42, Username: "alice"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acct == nil {
+			t.Fatal("expected an account")
+		}
+		if want := (extsvc.ExternalAccountSpec{ServiceType: "bitbucketServer", ServiceID: srv.URL + "/", AccountID: "1"}); acct.ExternalAccountSpec != want {
+			t.Errorf("got account spec %+v, want %+v", acct.ExternalAccountSpec, want)
+		}
+		if acct.UserID != 42 {
+			t.Errorf("got user ID %d, want 42", acct.UserID)
+		}
+
+		acct, err = p.FetchAccount(ctx, &types.User{ID: 43, Username: "bob"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acct != nil {
+			t.Errorf("expected no account for unknown user, got %+v", acct)
+		}
+	})
+
+	t.Run("oauth", func(t *testing.T) {
+		p := newTestProvider(t, srv, false, time.Hour, make(authz.MockCache))
+
+		acct, err := p.FetchAccount(ctx, &types.User{ID: 42, Username: "alice"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acct != nil {
+			t.Errorf("expected no account, got %+v", acct)
+		}
+	})
+}
+
+func TestProvider_RepoPerms(t *testing.T) {
+	bbs := &mockBitbucketServer{
+		users: []*bitbucketserver.User{{ID: 1, Name: "alice"}},
+		repos: map[string][]*bitbucketserver.Repo{
+			"":      {{ID: 3}},
+			"alice": {{ID: 1}, {ID: 3}},
+		},
+	}
+	srv := httptest.NewServer(bbs)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := make(authz.MockCache)
+	p := newTestProvider(t, srv, true, time.Hour, c)
+
+	alice, err := p.FetchAccount(ctx, &types.User{ID: 42, Username: "alice"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := func(name, id string) authz.Repo {
+		return authz.Repo{
+			RepoName: api.RepoName(name),
+			ExternalRepoSpec: api.ExternalRepoSpec{
+				ID:          id,
+				ServiceType: "bitbucketServer",
+				ServiceID:   srv.URL + "/",
+			},
+		}
+	}
+	repos := map[authz.Repo]struct{}{
+		repo("r1", "1"): {},
+		repo("r2", "2"): {},
+		repo("r3", "3"): {},
+		{RepoName: "gh", ExternalRepoSpec: api.ExternalRepoSpec{ID: "1", ServiceType: "github", ServiceID: "https://github.com/"}}: {},
+	}
+
+	readPerms := map[authz.Perm]bool{authz.Read: true}
+	noPerms := map[authz.Perm]bool{authz.Read: false}
+
+	tests := []struct {
+		description string
+		account     *extsvc.ExternalAccount
+		cacheTTL    time.Duration
+		wantPerms   map[api.RepoName]map[authz.Perm]bool
+		wantFetches int
+	}{
+		{
+			description: "user",
+			account:     alice,
+			cacheTTL:    time.Hour,
+			wantPerms:   map[api.RepoName]map[authz.Perm]bool{"r1": readPerms, "r2": noPerms, "r3": readPerms},
+			wantFetches: 1,
+		},
+		{
+			description: "user, cached",
+			account:     alice,
+			cacheTTL:    time.Hour,
+			wantPerms:   map[api.RepoName]map[authz.Perm]bool{"r1": readPerms, "r2": noPerms, "r3": readPerms},
+			wantFetches: 0,
+		},
+		{
+			description: "user, cache TTL changed",
+			account:     alice,
+			cacheTTL:    2 * time.Hour,
+			wantPerms:   map[api.RepoName]map[authz.Perm]bool{"r1": readPerms, "r2": noPerms, "r3": readPerms},
+			wantFetches: 1,
+		},
+		{
+			description: "anonymous",
+			cacheTTL:    2 * time.Hour,
+			wantPerms:   map[api.RepoName]map[authz.Perm]bool{"r1": noPerms, "r2": noPerms, "r3": readPerms},
+			wantFetches: 1,
+		},
+		{
+			description: "account of another code host",
+			account:     &extsvc.ExternalAccount{ExternalAccountSpec: extsvc.ExternalAccountSpec{ServiceType: "github", ServiceID: "https://github.com/", AccountID: "1"}},
+			cacheTTL:    2 * time.Hour,
+			wantPerms:   map[api.RepoName]map[authz.Perm]bool{"r1": noPerms, "r2": noPerms, "r3": readPerms},
+			wantFetches: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			p.cacheTTL = test.cacheTTL
+			bbs.reposRequests = 0
+
+			perms, err := p.RepoPerms(ctx, test.account, repos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(perms, test.wantPerms) {
+				t.Errorf("got perms %v, want %v", perms, test.wantPerms)
+			}
+			if bbs.reposRequests != test.wantFetches {
+				t.Errorf("got %d repos requests, want %d", bbs.reposRequests, test.wantFetches)
+			}
+		})
+	}
+}
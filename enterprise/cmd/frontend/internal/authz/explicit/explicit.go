@@ -0,0 +1,137 @@
+// Package explicit implements an authz.Provider for repository permissions which are granted
+// explicitly by site admins (and stored in the database), for repositories from code hosts which do
+// not provide permissions themselves.
+package explicit
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+const (
+	// ServiceType and ServiceID identify the explicit permissions provider. It has no external
+	// accounts: permissions are granted to Sourcegraph users, so the user is taken from the actor.
+	ServiceType = "explicit"
+	ServiceID   = "explicit"
+)
+
+// Store is the subset of db.ExplicitPermissions used by the provider.
+type Store interface {
+	ListByUser(ctx context.Context, userID int32) ([]*db.ExplicitRepoPermission, error)
+}
+
+// Provider implements authz.Provider for explicit repository permissions. It is the source of
+// permissions for all repositories from code hosts of the configured service types.
+type Provider struct {
+	serviceTypes map[string]bool
+	store        Store
+}
+
+// NewProvider returns a provider of permissions for the repositories of the given service types
+// (e.g., "gitolite" and "other"), which reads explicit permissions from store.
+func NewProvider(serviceTypes []string, store Store) *Provider {
+	p := &Provider{serviceTypes: make(map[string]bool, len(serviceTypes)), store: store}
+	for _, t := range serviceTypes {
+		p.serviceTypes[t] = true
+	}
+	return p
+}
+
+var _ authz.Provider = ((*Provider)(nil))
+
+// Repos implements the authz.Provider interface.
+func (p *Provider) Repos(ctx context.Context, repos map[authz.Repo]struct{}) (mine map[authz.Repo]struct{}, others map[authz.Repo]struct{}) {
+	mine, others = make(map[authz.Repo]struct{}), make(map[authz.Repo]struct{})
+	for repo := range repos {
+		if p.serviceTypes[repo.ExternalRepoSpec.ServiceType] {
+			mine[repo] = struct{}{}
+		} else {
+			others[repo] = struct{}{}
+		}
+	}
+	return mine, others
+}
+
+// RepoPerms implements the authz.Provider interface.
+//
+// A user can read a repository if it was granted to the user or to an organization the user is a
+// member of, either by name or by a pattern matching its name. Names and patterns are
+// case-insensitive.
+//
+// 🚨 SECURITY: The user is the actor of ctx, not the owner of userAccount (which is always nil,
+// because the provider has no external accounts). Unauthenticated users cannot read any
+// repository.
+func (p *Provider) RepoPerms(ctx context.Context, userAccount *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() {
+		return nil, nil
+	}
+
+	grants, err := p.store.ListByUser(ctx, a.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make(map[api.RepoName]map[authz.Perm]bool)
+	names := make(map[string]bool) // lowercase, because repository names are case-insensitive
+	var patterns []func(string) bool
+	for _, g := range grants {
+		if g.RepoPattern == "" {
+			names[strings.ToLower(string(g.RepoName))] = true
+			continue
+		}
+		re, err := db.CompileExplicitRepoPattern(g.RepoPattern)
+		if err != nil {
+			// Patterns are validated when they are granted, so this should never happen.
+			log15.Warn("Ignoring invalid explicit repository permission pattern.", "id", g.ID, "pattern", g.RepoPattern, "error", err)
+			continue
+		}
+		patterns = append(patterns, re.MatchString)
+	}
+
+	mine, _ := p.Repos(ctx, repos)
+	for repo := range mine {
+		if canRead(repo.RepoName, names, patterns) {
+			perms[repo.RepoName] = map[authz.Perm]bool{authz.Read: true}
+		}
+	}
+	return perms, nil
+}
+
+func canRead(repo api.RepoName, names map[string]bool, patterns []func(string) bool) bool {
+	if names[strings.ToLower(string(repo))] {
+		return true
+	}
+	for _, match := range patterns {
+		if match(string(repo)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchAccount implements the authz.Provider interface. It always returns nil, because
+// permissions are granted to Sourcegraph users and not to external accounts.
+func (p *Provider) FetchAccount(ctx context.Context, user *types.User, current []*extsvc.ExternalAccount) (mine *extsvc.ExternalAccount, err error) {
+	return nil, nil
+}
+
+func (p *Provider) ServiceID() string {
+	return ServiceID
+}
+
+func (p *Provider) ServiceType() string {
+	return ServiceType
+}
+
+func (p *Provider) Validate() (problems []string) {
+	return nil
+}
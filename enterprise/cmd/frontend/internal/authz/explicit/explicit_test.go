@@ -0,0 +1,112 @@
+package explicit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+type mockStore map[int32][]*db.ExplicitRepoPermission
+
+func (s mockStore) ListByUser(ctx context.Context, userID int32) ([]*db.ExplicitRepoPermission, error) {
+	return s[userID], nil
+}
+
+func repo(name, serviceType string) authz.Repo {
+	return authz.Repo{
+		RepoName: api.RepoName(name),
+		ExternalRepoSpec: api.ExternalRepoSpec{
+			ID:          name,
+			ServiceType: serviceType,
+			ServiceID:   "https://" + serviceType + ".example.com/",
+		},
+	}
+}
+
+func TestProvider_Repos(t *testing.T) {
+	p := NewProvider([]string{"gitolite", "other"}, mockStore{})
+	repos := map[authz.Repo]struct{}{
+		repo("a", "gitolite"): {},
+		repo("b", "other"):    {},
+		repo("c", "github"):   {},
+	}
+	mine, others := p.Repos(context.Background(), repos)
+	if want := (map[authz.Repo]struct{}{repo("a", "gitolite"): {}, repo("b", "other"): {}}); !reflect.DeepEqual(mine, want) {
+		t.Errorf("got mine %v, want %v", mine, want)
+	}
+	if want := (map[authz.Repo]struct{}{repo("c", "github"): {}}); !reflect.DeepEqual(others, want) {
+		t.Errorf("got others %v, want %v", others, want)
+	}
+}
+
+func TestProvider_RepoPerms(t *testing.T) {
+	p := NewProvider([]string{"other"}, mockStore{
+		1: {
+			{RepoName: "example.com/Exact", UserID: 1},
+			{RepoPattern: "example.com/Team/.*", OrgID: 3},
+			{RepoPattern: "(", UserID: 1}, // invalid, ignored
+		},
+		2: {
+			{RepoPattern: "example.com/team", OrgID: 4}, // must match the entire name
+		},
+	})
+	repos := map[authz.Repo]struct{}{
+		repo("example.com/exact", "other"):     {},
+		repo("example.com/team/a", "other"):    {},
+		repo("example.com/other", "other"):     {},
+		repo("example.com/team/b", "gitolite"): {}, // not ours
+	}
+	read := map[authz.Perm]bool{authz.Read: true}
+
+	tests := []struct {
+		description string
+		uid         int32 // the user ID of the actor (0 if unauthenticated)
+		wantPerms   map[api.RepoName]map[authz.Perm]bool
+	}{
+		{
+			description: "unauthenticated user",
+		},
+		{
+			description: "user with grants by name and pattern",
+			uid:         1,
+			wantPerms: map[api.RepoName]map[authz.Perm]bool{
+				"example.com/exact":  read,
+				"example.com/team/a": read,
+			},
+		},
+		{
+			description: "pattern matching a prefix only",
+			uid:         2,
+			wantPerms:   map[api.RepoName]map[authz.Perm]bool{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ctx := actor.WithActor(context.Background(), &actor.Actor{UID: test.uid})
+			perms, err := p.RepoPerms(ctx, nil, repos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(perms) != 0 || len(test.wantPerms) != 0 {
+				if !reflect.DeepEqual(perms, test.wantPerms) {
+					t.Errorf("got perms %v, want %v", perms, test.wantPerms)
+				}
+			}
+		})
+	}
+}
+
+func TestProvider_FetchAccount(t *testing.T) {
+	// No account is fetched, so that authzFilter doesn't save one for every user.
+	p := NewProvider([]string{"other"}, mockStore{})
+	acct, err := p.FetchAccount(context.Background(), &types.User{ID: 1}, nil)
+	if acct != nil || err != nil {
+		t.Errorf("got account %+v and error %v, want neither", acct, err)
+	}
+}
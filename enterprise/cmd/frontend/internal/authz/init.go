@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/explicit"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/schema"
 	log15 "gopkg.in/inconshreveable/log15.v2"
@@ -13,6 +15,7 @@ import (
 type ExternalServicesStore interface {
 	ListGitLabConnections(context.Context) ([]*schema.GitLabConnection, error)
 	ListGitHubConnections(context.Context) ([]*schema.GitHubConnection, error)
+	ListBitbucketServerConnections(context.Context) ([]*schema.BitbucketServerConnection, error)
 }
 
 // ProvidersFromConfig returns the set of permission-related providers derived from the site config.
@@ -55,5 +58,20 @@ func ProvidersFromConfig(
 		warnings = append(warnings, ghwarnings...)
 	}
 
+	if bitbuckets, err := s.ListBitbucketServerConnections(ctx); err != nil {
+		seriousProblems = append(seriousProblems, fmt.Sprintf("Could not load Bitbucket Server external service configs: %s", err))
+	} else {
+		bbsp, bbsproblems, bbswarnings := bitbucketServerProviders(ctx, bitbuckets)
+		authzProviders = append(authzProviders, bbsp...)
+		seriousProblems = append(seriousProblems, bbsproblems...)
+		warnings = append(warnings, bbswarnings...)
+	}
+
+	// Explicit permissions come last, so that they never take precedence over the permissions of a
+	// code host.
+	if e := cfg.PermissionsExplicit; e != nil {
+		authzProviders = append(authzProviders, explicit.NewProvider(e.ServiceTypes, db.ExplicitPermissions))
+	}
+
 	return allowAccessByDefault, authzProviders, seriousProblems, warnings
 }
@@ -0,0 +1,174 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/explicit"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// SyncPermissionsPeriodically syncs the repository permissions of all users every interval, while
+// background syncing is enabled by the permissions.backgroundSync site configuration property. It
+// never returns.
+func SyncPermissionsPeriodically(ctx context.Context) {
+	for {
+		c := conf.Get().PermissionsBackgroundSync
+		if c == nil || !c.Enabled {
+			time.Sleep(time.Minute)
+			continue
+		}
+
+		interval, err := parseSyncInterval(c.Interval)
+		if err != nil {
+			log15.Error("Invalid repository permissions sync interval, using the default.", "error", err)
+		}
+
+		start := time.Now()
+		if err := SyncPermissions(ctx); err != nil {
+			log15.Error("Failed to sync repository permissions.", "error", err)
+		} else {
+			log15.Debug("Synced repository permissions.", "duration", time.Since(start))
+		}
+		time.Sleep(interval)
+	}
+}
+
+func parseSyncInterval(interval string) (time.Duration, error) {
+	defaultValue := time.Hour
+	if interval == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return defaultValue, fmt.Errorf("permissions.backgroundSync.interval: %s", err)
+	}
+	if d <= 0 {
+		return defaultValue, fmt.Errorf("permissions.backgroundSync.interval: must be positive")
+	}
+	return d, nil
+}
+
+// SyncPermissions asks the authz providers which repositories every user can read, and stores the
+// result in db.UserRepoPermissions, one entry per user and provider. Site admins are not synced,
+// because they can read all repositories.
+//
+// Providers whose permissions are already stored in the database (e.g. explicit permissions) are
+// not synced.
+func SyncPermissions(ctx context.Context) error {
+	var providers []authz.Provider
+	var services [][2]string
+	_, allProviders := authz.GetProviders()
+	for _, p := range allProviders {
+		if p.ServiceType() == explicit.ServiceType {
+			continue
+		}
+		providers = append(providers, p)
+		services = append(services, [2]string{p.ServiceType(), p.ServiceID()})
+	}
+
+	// Remove the permissions synced from providers which no longer exist, so that they aren't used
+	// if a provider with the same code host is added again later.
+	if err := db.UserRepoPermissions.DeleteStale(ctx, services); err != nil {
+		return err
+	}
+	if len(providers) == 0 {
+		return nil
+	}
+
+	// 🚨 SECURITY: Listing repositories must not be subject to the permissions being synced.
+	ctx = actor.WithActor(ctx, &actor.Actor{Internal: true})
+
+	repos, err := db.Repos.List(ctx, db.ReposListOptions{Enabled: true, Disabled: true})
+	if err != nil {
+		return err
+	}
+
+	// Like authzFilter, the first provider which claims a repository is the source of its
+	// permissions.
+	unclaimed := authz.ToRepos(repos)
+	providerRepos := make([]map[authz.Repo]struct{}, len(providers))
+	for i, p := range providers {
+		providerRepos[i], unclaimed = p.Repos(ctx, unclaimed)
+	}
+
+	users, err := db.Users.List(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.SiteAdmin {
+			continue
+		}
+		syncUserPermissions(ctx, user, providers, providerRepos)
+	}
+	return nil
+}
+
+// syncUserPermissions syncs the permissions of the user for the repositories claimed by each
+// provider (providerRepos[i] are the repositories of providers[i]). Errors are logged, so that a
+// failure to sync one provider doesn't prevent syncing the others.
+func syncUserPermissions(ctx context.Context, user *types.User, providers []authz.Provider, providerRepos []map[authz.Repo]struct{}) {
+	accts, err := db.ExternalAccounts.List(ctx, db.ExternalAccountsListOptions{UserID: user.ID})
+	if err != nil {
+		log15.Warn("Could not list external accounts of user to sync repository permissions", "username", user.Username, "error", err)
+		return
+	}
+
+	for i, p := range providers {
+		if err := syncUserProviderPermissions(ctx, user, accts, p, providerRepos[i]); err != nil {
+			log15.Warn("Could not sync repository permissions of user", "username", user.Username, "authzProvider", p.ServiceID(), "error", err)
+		}
+	}
+}
+
+func syncUserProviderPermissions(ctx context.Context, user *types.User, accts []*extsvc.ExternalAccount, p authz.Provider, repos map[authz.Repo]struct{}) error {
+	// Determine the external account to use, in the same way as authzFilter.
+	var providerAcct *extsvc.ExternalAccount
+	for _, acct := range accts {
+		if acct.ServiceID == p.ServiceID() && acct.ServiceType == p.ServiceType() {
+			providerAcct = acct
+			break
+		}
+	}
+	if providerAcct == nil {
+		acct, err := p.FetchAccount(ctx, user, accts)
+		if err != nil {
+			return err
+		}
+		if acct != nil {
+			if err := db.ExternalAccounts.AssociateUserAndSave(ctx, user.ID, acct.ExternalAccountSpec, acct.ExternalAccountData); err != nil {
+				return err
+			}
+			providerAcct = acct
+		}
+	}
+
+	perms, err := p.RepoPerms(ctx, providerAcct, repos)
+	if err != nil {
+		return err
+	}
+
+	externalRepoIDs := []string{}
+	for repo := range repos {
+		if perms[repo.RepoName][authz.Read] {
+			externalRepoIDs = append(externalRepoIDs, repo.ExternalRepoSpec.ID)
+		}
+	}
+	sort.Strings(externalRepoIDs)
+
+	return db.UserRepoPermissions.Upsert(ctx, &db.UserRepoPermissions{
+		UserID:          user.ID,
+		ServiceType:     p.ServiceType(),
+		ServiceID:       p.ServiceID(),
+		ExternalRepoIDs: externalRepoIDs,
+	})
+}
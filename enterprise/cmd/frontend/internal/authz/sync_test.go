@@ -0,0 +1,165 @@
+package authz
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/explicit"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+)
+
+// syncTestProvider grants each user (identified by the account ID of their external account) read
+// access to the repositories of its code host listed in readable.
+type syncTestProvider struct {
+	serviceType, serviceID string
+	readable               map[string][]api.RepoName // account ID ("" for no account) -> repos
+}
+
+func (p *syncTestProvider) Repos(ctx context.Context, repos map[authz.Repo]struct{}) (mine map[authz.Repo]struct{}, others map[authz.Repo]struct{}) {
+	mine, others = make(map[authz.Repo]struct{}), make(map[authz.Repo]struct{})
+	for repo := range repos {
+		if repo.ServiceType == p.serviceType && repo.ServiceID == p.serviceID {
+			mine[repo] = struct{}{}
+		} else {
+			others[repo] = struct{}{}
+		}
+	}
+	return mine, others
+}
+
+func (p *syncTestProvider) RepoPerms(ctx context.Context, account *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
+	var accountID string
+	if account != nil {
+		accountID = account.AccountID
+	}
+	perms := map[api.RepoName]map[authz.Perm]bool{}
+	for _, name := range p.readable[accountID] {
+		perms[name] = map[authz.Perm]bool{authz.Read: true}
+	}
+	return perms, nil
+}
+
+func (p *syncTestProvider) FetchAccount(ctx context.Context, user *types.User, current []*extsvc.ExternalAccount) (*extsvc.ExternalAccount, error) {
+	return nil, nil
+}
+func (p *syncTestProvider) ServiceType() string { return p.serviceType }
+func (p *syncTestProvider) ServiceID() string   { return p.serviceID }
+func (p *syncTestProvider) Validate() []string  { return nil }
+
+func TestSyncPermissions(t *testing.T) {
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	repo := func(name, serviceType, serviceID string) *types.Repo {
+		return &types.Repo{
+			Name:         api.RepoName(name),
+			ExternalRepo: &api.ExternalRepoSpec{ID: "id-" + name, ServiceType: serviceType, ServiceID: serviceID},
+		}
+	}
+	db.Mocks.Repos.List = func(ctx context.Context, opt db.ReposListOptions) ([]*types.Repo, error) {
+		if !actor.FromContext(ctx).Internal {
+			t.Error("repos must be listed by an internal actor (SECURITY)")
+		}
+		return []*types.Repo{
+			repo("gh/public", "github", "https://github.com/"),
+			repo("gh/private", "github", "https://github.com/"),
+			repo("gl/private", "gitlab", "https://gitlab.com/"),
+			repo("other/r", "other", "https://other.com/"),
+		}, nil
+	}
+	db.Mocks.Users.List = func(ctx context.Context, opt *db.UsersListOptions) ([]*types.User, error) {
+		return []*types.User{
+			{ID: 1, Username: "alice"},
+			{ID: 2, Username: "bob"},
+			{ID: 3, Username: "admin", SiteAdmin: true},
+		}, nil
+	}
+	db.Mocks.ExternalAccounts.List = func(opt db.ExternalAccountsListOptions) ([]*extsvc.ExternalAccount, error) {
+		if opt.UserID == 1 {
+			return []*extsvc.ExternalAccount{
+				{UserID: 1, ExternalAccountSpec: extsvc.ExternalAccountSpec{ServiceType: "github", ServiceID: "https://github.com/", AccountID: "alice-gh"}},
+				{UserID: 1, ExternalAccountSpec: extsvc.ExternalAccountSpec{ServiceType: "gitlab", ServiceID: "https://gitlab.com/", AccountID: "alice-gl"}},
+			}, nil
+		}
+		return nil, nil
+	}
+
+	var deletedStale [][2]string
+	db.Mocks.UserRepoPermissions.DeleteStale = func(services [][2]string) error {
+		deletedStale = services
+		return nil
+	}
+	synced := map[int32][]db.UserRepoPermissions{}
+	db.Mocks.UserRepoPermissions.Upsert = func(p *db.UserRepoPermissions) error {
+		synced[p.UserID] = append(synced[p.UserID], *p)
+		return nil
+	}
+
+	authz.SetProviders(false, []authz.Provider{
+		&syncTestProvider{
+			serviceType: "github",
+			serviceID:   "https://github.com/",
+			readable: map[string][]api.RepoName{
+				"":         {"gh/public"},
+				"alice-gh": {"gh/public", "gh/private"},
+			},
+		},
+		&syncTestProvider{
+			serviceType: "gitlab",
+			serviceID:   "https://gitlab.com/",
+			readable: map[string][]api.RepoName{
+				"alice-gl": {"gl/private"},
+			},
+		},
+		explicit.NewProvider([]string{"other"}, nil),
+	})
+	defer authz.SetProviders(true, nil)
+
+	if err := SyncPermissions(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := [][2]string{{"github", "https://github.com/"}, {"gitlab", "https://gitlab.com/"}}; !reflect.DeepEqual(deletedStale, want) {
+		t.Errorf("got stale services %v, want %v", deletedStale, want)
+	}
+
+	want := map[int32][]db.UserRepoPermissions{
+		1: {
+			{UserID: 1, ServiceType: "github", ServiceID: "https://github.com/", ExternalRepoIDs: []string{"id-gh/private", "id-gh/public"}},
+			{UserID: 1, ServiceType: "gitlab", ServiceID: "https://gitlab.com/", ExternalRepoIDs: []string{"id-gl/private"}},
+		},
+		2: {
+			{UserID: 2, ServiceType: "github", ServiceID: "https://github.com/", ExternalRepoIDs: []string{"id-gh/public"}},
+			{UserID: 2, ServiceType: "gitlab", ServiceID: "https://gitlab.com/", ExternalRepoIDs: []string{}},
+		},
+	}
+	if !reflect.DeepEqual(synced, want) {
+		t.Errorf("got synced permissions\n%s\nwant\n%s", asJSON(t, synced), asJSON(t, want))
+	}
+}
+
+func TestParseSyncInterval(t *testing.T) {
+	for _, test := range []struct {
+		interval string
+		want     string
+		wantErr  bool
+	}{
+		{interval: "", want: "1h0m0s"},
+		{interval: "30m", want: "30m0s"},
+		{interval: "0s", want: "1h0m0s", wantErr: true},
+		{interval: "soon", want: "1h0m0s", wantErr: true},
+	} {
+		got, err := parseSyncInterval(test.interval)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v, want error: %v", test.interval, err, test.wantErr)
+		}
+		if got.String() != test.want {
+			t.Errorf("%q: got %s, want %s", test.interval, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	frontendregistry "github.com/sourcegraph/sourcegraph/cmd/frontend/registry"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	"github.com/sourcegraph/sourcegraph/pkg/registry"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// An extensions archive is a gzipped tar file that contains extensions (and their releases'
+// manifests, bundles, and source maps) exported from an extension registry. It is used to make
+// extensions available on instances that can't access the remote registry (such as air-gapped
+// instances).
+//
+// The archive contains an index file (archiveIndexFilename) that describes the extensions and
+// refers to the bundle and source map files, which are also in the archive.
+
+// archiveIndexFilename is the name of the index file in an extensions archive.
+const archiveIndexFilename = "index.json"
+
+// archiveFormatVersion is the version of the extensions archive format.
+const archiveFormatVersion = 1
+
+// maxArchiveFileSize and maxArchiveSize are the maximum (uncompressed) sizes of each file in an
+// extensions archive and of all of its files, respectively. They prevent a small archive that
+// decompresses to a huge size from exhausting memory.
+var (
+	maxArchiveFileSize int64 = 50 << 20
+	maxArchiveSize     int64 = 500 << 20
+)
+
+// archiveIndex is the contents of the index file in an extensions archive.
+type archiveIndex struct {
+	Version    int                 `json:"version"`
+	ExportedAt time.Time           `json:"exportedAt"`
+	Extensions []*archiveExtension `json:"extensions"`
+}
+
+// archiveExtension is an extension in an extensions archive.
+type archiveExtension struct {
+	// ExtensionID is the extension ID in the extension registry that the extension was exported
+	// from. It is preserved when the extension is imported.
+	ExtensionID string            `json:"extensionID"`
+	Releases    []*archiveRelease `json:"releases"` // oldest first
+}
+
+// archiveRelease is a release of an extension in an extensions archive.
+type archiveRelease struct {
+	Version     *string   `json:"version,omitempty"`
+	Manifest    string    `json:"manifest"`
+	PublishedAt time.Time `json:"publishedAt"`
+
+	// BundlePath and SourceMapPath are the paths of the release's bundle and source map files in
+	// the archive, or empty if the release has none.
+	BundlePath    string `json:"bundlePath,omitempty"`
+	SourceMapPath string `json:"sourceMapPath,omitempty"`
+
+	bundle, sourceMap []byte
+}
+
+// writeExtensionsArchive writes an extensions archive with the extensions in the index to w.
+func writeExtensionsArchive(w io.Writer, index *archiveIndex) error {
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+	writeFile := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: index.ExportedAt,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	for i, x := range index.Extensions {
+		for j, release := range x.Releases {
+			release.BundlePath, release.SourceMapPath = "", ""
+			dir := path.Join("extensions", fmt.Sprint(i), "releases", fmt.Sprint(j))
+			if release.bundle != nil {
+				release.BundlePath = path.Join(dir, "bundle.js")
+			}
+			if release.sourceMap != nil {
+				release.SourceMapPath = path.Join(dir, "bundle.js.map")
+			}
+		}
+	}
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(archiveIndexFilename, indexData); err != nil {
+		return err
+	}
+	for _, x := range index.Extensions {
+		for _, release := range x.Releases {
+			if release.BundlePath != "" {
+				if err := writeFile(release.BundlePath, release.bundle); err != nil {
+					return err
+				}
+			}
+			if release.SourceMapPath != "" {
+				if err := writeFile(release.SourceMapPath, release.sourceMap); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+// readExtensionsArchive reads the extensions archive from r.
+func readExtensionsArchive(r io.Reader) (*archiveIndex, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extensions archive: %s", err)
+	}
+	tr := tar.NewReader(gzr)
+	files := map[string][]byte{}
+	var totalSize int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid extensions archive: %s", err)
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		if hdr.Size > maxArchiveFileSize {
+			return nil, fmt.Errorf("invalid extensions archive: file %q is larger than the maximum of %d bytes", hdr.Name, maxArchiveFileSize)
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(tr, maxArchiveFileSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > maxArchiveFileSize {
+			return nil, fmt.Errorf("invalid extensions archive: file %q is larger than the maximum of %d bytes", hdr.Name, maxArchiveFileSize)
+		}
+		totalSize += int64(len(data))
+		if totalSize > maxArchiveSize {
+			return nil, fmt.Errorf("invalid extensions archive: files are larger than the maximum total of %d bytes", maxArchiveSize)
+		}
+		files[path.Clean(hdr.Name)] = data
+	}
+
+	indexData, ok := files[archiveIndexFilename]
+	if !ok {
+		return nil, fmt.Errorf("invalid extensions archive: no %s file", archiveIndexFilename)
+	}
+	var index archiveIndex
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return nil, fmt.Errorf("invalid extensions archive: %s: %s", archiveIndexFilename, err)
+	}
+	if index.Version != archiveFormatVersion {
+		return nil, fmt.Errorf("unsupported extensions archive version %d (expected %d)", index.Version, archiveFormatVersion)
+	}
+	getFile := func(name string) ([]byte, error) {
+		if name == "" {
+			return nil, nil
+		}
+		data, ok := files[path.Clean(name)]
+		if !ok {
+			return nil, fmt.Errorf("invalid extensions archive: file %q not found", name)
+		}
+		return data, nil
+	}
+	for _, x := range index.Extensions {
+		if _, _, _, err := frontendregistry.SplitExtensionID(x.ExtensionID); err != nil {
+			return nil, err
+		}
+		for _, release := range x.Releases {
+			if release.bundle, err = getFile(release.BundlePath); err != nil {
+				return nil, err
+			}
+			if release.sourceMap, err = getFile(release.SourceMapPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &index, nil
+}
+
+// exportExtensions returns the index of an extensions archive with the extensions (local or
+// remote) with the given extension IDs.
+//
+// For local extensions, the archive contains the latest release and all other releases with a
+// version (except yanked releases). For remote extensions, it contains only the latest release.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to export the extensions.
+func exportExtensions(ctx context.Context, extensionIDs []string) (*archiveIndex, error) {
+	index := archiveIndex{
+		Version:    archiveFormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Extensions: make([]*archiveExtension, 0, len(extensionIDs)),
+	}
+	for _, extensionID := range extensionIDs {
+		local, remote, err := frontendregistry.GetExtensionByExtensionID(ctx, extensionID)
+		if err != nil {
+			return nil, err
+		}
+		var x *archiveExtension
+		switch {
+		case local != nil:
+			id, err := frontendregistry.UnmarshalRegistryExtensionID(local.ID())
+			if err != nil {
+				return nil, err
+			}
+			x, err = exportLocalExtension(ctx, id.LocalID)
+			if err != nil {
+				return nil, err
+			}
+		case remote != nil:
+			x, err = exportRemoteExtension(ctx, remote)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("extension %q not found", extensionID)
+		}
+		if len(x.Releases) == 0 {
+			return nil, fmt.Errorf("extension %q has no releases to export", extensionID)
+		}
+		index.Extensions = append(index.Extensions, x)
+	}
+	return &index, nil
+}
+
+func exportLocalExtension(ctx context.Context, registryExtensionID int32) (*archiveExtension, error) {
+	v, err := dbExtensions{}.GetByID(ctx, registryExtensionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := prefixLocalExtensionID(v); err != nil {
+		return nil, err
+	}
+	releases, err := dbReleases{}.List(ctx, v.ID, "release")
+	if err != nil {
+		return nil, err
+	}
+
+	// Export the latest release and all other releases with a version (which settings can pin the
+	// extension to).
+	latest := -1
+	for i, release := range releases {
+		if release.YankedAt == nil {
+			latest = i
+			break
+		}
+	}
+	x := &archiveExtension{ExtensionID: v.NonCanonicalExtensionID}
+	for i := len(releases) - 1; i >= 0; i-- { // oldest first
+		release := releases[i]
+		if release.YankedAt != nil || (release.ReleaseVersion == nil && i != latest) {
+			continue
+		}
+		bundle, sourceMap, err := dbReleases{}.GetArtifacts(ctx, release.ID)
+		if err != nil && !errcode.IsNotFound(err) {
+			return nil, err
+		}
+		x.Releases = append(x.Releases, &archiveRelease{
+			Version:     release.ReleaseVersion,
+			Manifest:    release.Manifest,
+			PublishedAt: release.CreatedAt,
+			bundle:      bundle,
+			sourceMap:   sourceMap,
+		})
+	}
+	return x, nil
+}
+
+func exportRemoteExtension(ctx context.Context, remote *registry.Extension) (*archiveExtension, error) {
+	if remote.Manifest == nil {
+		return &archiveExtension{ExtensionID: remote.ExtensionID}, nil
+	}
+
+	// The manifest's "url" refers to the bundle on the remote registry, which the importing site
+	// may not be able to access. Include the bundle in the archive instead.
+	var manifest map[string]interface{}
+	if err := jsonc.Unmarshal(*remote.Manifest, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing extension manifest for extension %q: %s", remote.ExtensionID, err)
+	}
+	var bundle []byte
+	if bundleURL, _ := manifest["url"].(string); bundleURL != "" {
+		resp, err := ctxhttp.Get(ctx, nil, bundleURL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching bundle for extension %q: HTTP status %d", remote.ExtensionID, resp.StatusCode)
+		}
+		if bundle, err = ioutil.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+		delete(manifest, "url")
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &archiveExtension{
+		ExtensionID: remote.ExtensionID,
+		Releases: []*archiveRelease{{
+			Version:     remote.Version,
+			Manifest:    string(manifestData),
+			PublishedAt: remote.PublishedAt,
+			bundle:      bundle,
+		}},
+	}, nil
+}
+
+// importExtensions imports the extensions in the extensions archive into the local extension
+// registry and returns their IDs.
+//
+// Each extension keeps the extension ID it had in the extension registry that it was exported from,
+// so that settings that refer to it keep working. Extensions that were already imported get the
+// releases that they don't already have (so importing the same archive again is a no-op). New
+// extensions are created with the given publisher (see importedExtensionName for their names), and
+// releases are created by the given user.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to import the extensions.
+func importExtensions(ctx context.Context, index *archiveIndex, publisher registryPublisherID, creatorUserID int32) ([]int32, error) {
+	ids := make([]int32, 0, len(index.Extensions))
+	for _, x := range index.Extensions {
+		id, err := importExtension(ctx, x, publisher, creatorUserID)
+		if err != nil {
+			return nil, fmt.Errorf("importing extension %q: %s", x.ExtensionID, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func importExtension(ctx context.Context, x *archiveExtension, publisher registryPublisherID, creatorUserID int32) (int32, error) {
+	var id int32
+	existing, err := dbExtensions{}.GetByImportedExtensionID(ctx, x.ExtensionID)
+	switch {
+	case err == nil:
+		id = existing.ID
+	case errcode.IsNotFound(err):
+		name, err := importedExtensionName(x.ExtensionID)
+		if err != nil {
+			return 0, err
+		}
+		id, err = dbExtensions{}.CreateImported(ctx, publisher.userID, publisher.orgID, name, x.ExtensionID)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, err
+	}
+
+	releases, err := dbReleases{}.List(ctx, id, "release")
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range x.Releases {
+		if hasArchiveRelease(releases, r) {
+			continue
+		}
+		if err := validateExtensionManifest(r.Manifest); err != nil {
+			return 0, fmt.Errorf("invalid extension manifest: %s", err)
+		}
+		release := dbRelease{
+			RegistryExtensionID: id,
+			CreatorUserID:       creatorUserID,
+			ReleaseVersion:      r.Version,
+			ReleaseTag:          "release",
+			Manifest:            r.Manifest,
+		}
+		if r.bundle != nil {
+			release.Bundle = strptr(string(r.bundle))
+		}
+		if r.sourceMap != nil {
+			release.SourceMap = strptr(string(r.sourceMap))
+		}
+		if _, err := (dbReleases{}).Create(ctx, &release); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// importedExtensionName returns the name of the local extension for an extension imported from an
+// extensions archive. It includes the original publisher (as in "alice-myextension" for
+// "alice/myextension") because extensions with the same name from different publishers are
+// imported under the same local publisher.
+func importedExtensionName(extensionID string) (string, error) {
+	_, publisher, name, err := frontendregistry.SplitExtensionID(extensionID)
+	if err != nil {
+		return "", err
+	}
+	return publisher + "-" + name, nil
+}
+
+// hasArchiveRelease reports whether the release from an extensions archive already exists in the
+// list of releases: a release with the same version, or (for releases with no version) the latest
+// release if it has the same manifest.
+func hasArchiveRelease(releases []*dbRelease, r *archiveRelease) bool {
+	if r.Version == nil {
+		return len(releases) > 0 && releases[0].ReleaseVersion == nil && releases[0].Manifest == r.Manifest
+	}
+	v, err := parseReleaseVersion(*r.Version)
+	if err != nil {
+		return false // let (dbReleases).Create report the error
+	}
+	for _, release := range releases {
+		if release.ReleaseVersion != nil && *release.ReleaseVersion == v.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// marshalExtensionsArchive is a helper that returns the extensions archive as bytes.
+func marshalExtensionsArchive(index *archiveIndex) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeExtensionsArchive(&buf, index); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/hooks"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+)
+
+func init() {
+	hooks.Commands["registry-export"] = registryExportCommand
+	hooks.Commands["registry-import"] = registryImportCommand
+}
+
+// registryExportCommand implements the `frontend registry-export` command, which exports
+// extensions into an extensions archive file.
+func registryExportCommand(args []string) error {
+	flags := flag.NewFlagSet("registry-export", flag.ExitOnError)
+	output := flags.String("o", "extensions.tar.gz", "write the extensions archive to this file")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: frontend registry-export [-o file] extension-id...")
+		fmt.Fprintln(flags.Output(), "\nExports the extensions (from the local or remote registry) into an extensions archive.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no extension IDs specified")
+	}
+
+	index, err := exportExtensions(context.Background(), flags.Args())
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeExtensionsArchive(f, index); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d extensions to %s.\n", len(index.Extensions), *output)
+	return nil
+}
+
+// registryImportCommand implements the `frontend registry-import` command, which imports the
+// extensions in an extensions archive file into the local extension registry.
+func registryImportCommand(args []string) error {
+	flags := flag.NewFlagSet("registry-import", flag.ExitOnError)
+	username := flags.String("user", "", "the username of the site admin who imports the extensions (required)")
+	orgName := flags.String("org", "", "the organization that publishes newly imported extensions (default: the user)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: frontend registry-import -user username [-org name] file")
+		fmt.Fprintln(flags.Output(), "\nImports the extensions in an extensions archive into the local extension registry.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 || *username == "" {
+		flags.Usage()
+		return errors.New("a username and exactly 1 extensions archive file must be specified")
+	}
+
+	ctx := context.Background()
+	user, err := db.Users.GetByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+	if !user.SiteAdmin {
+		return fmt.Errorf("user %q is not a site admin", user.Username)
+	}
+	ctx = actor.WithActor(ctx, &actor.Actor{UID: user.ID})
+	publisher := registryPublisherID{userID: user.ID}
+	if *orgName != "" {
+		org, err := db.Orgs.GetByName(ctx, *orgName)
+		if err != nil {
+			return err
+		}
+		publisher = registryPublisherID{orgID: org.ID}
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	index, err := readExtensionsArchive(f)
+	if err != nil {
+		return err
+	}
+	ids, err := importExtensions(ctx, index, publisher, user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d extensions.\n", len(ids))
+	return nil
+}
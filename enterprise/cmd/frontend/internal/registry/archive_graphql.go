@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	frontendregistry "github.com/sourcegraph/sourcegraph/cmd/frontend/registry"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/licensing"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+)
+
+func init() {
+	frontendregistry.ExtensionRegistry.ExportExtensionsFunc = extensionRegistryExportExtensions
+	frontendregistry.ExtensionRegistry.ImportExtensionsFunc = extensionRegistryImportExtensions
+}
+
+func extensionRegistryExportExtensions(ctx context.Context, args *graphqlbackend.ExtensionRegistryExportExtensionsArgs) (string, error) {
+	// 🚨 SECURITY: Only site admins may export extensions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return "", err
+	}
+
+	index, err := exportExtensions(ctx, args.ExtensionIDs)
+	if err != nil {
+		return "", err
+	}
+	data, err := marshalExtensionsArchive(index)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func extensionRegistryImportExtensions(ctx context.Context, args *graphqlbackend.ExtensionRegistryImportExtensionsArgs) ([]graphqlbackend.RegistryExtension, error) {
+	if err := licensing.CheckFeature(licensing.FeatureExtensionRegistry); err != nil {
+		return nil, err
+	}
+
+	// 🚨 SECURITY: Only site admins may import extensions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	creatorUserID := actor.FromContext(ctx).UID
+	publisher := &registryPublisherID{userID: creatorUserID}
+	if args.Publisher != nil {
+		var err error
+		if publisher, err = unmarshalRegistryPublisherID(*args.Publisher); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := base64.StdEncoding.DecodeString(args.Archive)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extensions archive (must be base64-encoded): %s", err)
+	}
+	index, err := readExtensionsArchive(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	ids, err := importExtensions(ctx, index, *publisher, creatorUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := make([]graphqlbackend.RegistryExtension, len(ids))
+	for i, id := range ids {
+		if extensions[i], err = registryExtensionByIDInt32(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return extensions, nil
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtensionsArchive(t *testing.T) {
+	index := &archiveIndex{
+		Version:    archiveFormatVersion,
+		ExportedAt: time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC),
+		Extensions: []*archiveExtension{
+			{
+				ExtensionID: "sourcegraph/a",
+				Releases: []*archiveRelease{
+					{Version: strptr("1.0.0"), Manifest: `{"a":1}`, bundle: []byte("b1"), sourceMap: []byte("sm1")},
+					{Manifest: `{"a":2}`, bundle: []byte("b2")},
+				},
+			},
+			{
+				ExtensionID: "example.com/alice/b",
+				Releases:    []*archiveRelease{{Manifest: `{}`}},
+			},
+		},
+	}
+	data, err := marshalExtensionsArchive(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readExtensionsArchive(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, index) {
+		t.Errorf("got %+v, want %+v", got, index)
+	}
+	for i, x := range got.Extensions {
+		for j, release := range x.Releases {
+			want := index.Extensions[i].Releases[j]
+			if !bytes.Equal(release.bundle, want.bundle) || !bytes.Equal(release.sourceMap, want.sourceMap) {
+				t.Errorf("extension %d release %d: got bundle %q source map %q, want %q and %q", i, j, release.bundle, release.sourceMap, want.bundle, want.sourceMap)
+			}
+		}
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := readExtensionsArchive(bytes.NewReader([]byte("not an archive"))); err == nil {
+			t.Error("got nil error, want error")
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		defer func(fileSize, size int64) { maxArchiveFileSize, maxArchiveSize = fileSize, size }(maxArchiveFileSize, maxArchiveSize)
+
+		maxArchiveFileSize, maxArchiveSize = 2, 1000
+		if _, err := readExtensionsArchive(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "larger than the maximum") {
+			t.Errorf("got error %v, want file too large", err)
+		}
+
+		maxArchiveFileSize, maxArchiveSize = 1000, int64(len(data))/2
+		if _, err := readExtensionsArchive(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "maximum total") {
+			t.Errorf("got error %v, want archive too large", err)
+		}
+	})
+}
+
+func TestImportedExtensionName(t *testing.T) {
+	tests := map[string]string{
+		"alice/x":             "alice-x",
+		"bob/x":               "bob-x",
+		"example.com/alice/x": "alice-x",
+	}
+	for extensionID, want := range tests {
+		got, err := importedExtensionName(extensionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", extensionID, got, want)
+		}
+	}
+}
+
+func TestHasArchiveRelease(t *testing.T) {
+	releases := []*dbRelease{
+		{ReleaseVersion: nil, Manifest: `{"latest":true}`},
+		{ReleaseVersion: strptr("1.2.0"), Manifest: `{}`},
+	}
+	tests := map[string]struct {
+		release *archiveRelease
+		want    bool
+	}{
+		"same version":                 {release: &archiveRelease{Version: strptr("1.2.0")}, want: true},
+		"same version with v prefix":   {release: &archiveRelease{Version: strptr("v1.2.0")}, want: true},
+		"other version":                {release: &archiveRelease{Version: strptr("1.3.0")}, want: false},
+		"no version and same manifest": {release: &archiveRelease{Manifest: `{"latest":true}`}, want: true},
+		"no version and new manifest":  {release: &archiveRelease{Manifest: `{"latest":false}`}, want: false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := hasArchiveRelease(releases, test.release); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -15,7 +15,11 @@ func init() {
 }
 
 func listLocalRegistryExtensions(ctx context.Context, args graphqlbackend.RegistryExtensionConnectionArgs) ([]graphqlbackend.RegistryExtension, error) {
+	var prioritizeImportedExtensionIDs []string
 	if args.PrioritizeExtensionIDs != nil {
+		// Imported extensions keep their original extension IDs, which may look like local or
+		// remote extension IDs.
+		prioritizeImportedExtensionIDs = *args.PrioritizeExtensionIDs
 		ids := filterStripLocalExtensionIDs(*args.PrioritizeExtensionIDs)
 		args.PrioritizeExtensionIDs = &ids
 	}
@@ -23,6 +27,7 @@ func listLocalRegistryExtensions(ctx context.Context, args graphqlbackend.Regist
 	if err != nil {
 		return nil, err
 	}
+	opt.PrioritizeImportedExtensionIDs = prioritizeImportedExtensionIDs
 	xs, err := dbExtensions{}.List(ctx, opt)
 	if err != nil {
 		return nil, err
@@ -30,9 +35,14 @@ func listLocalRegistryExtensions(ctx context.Context, args graphqlbackend.Regist
 	if err := prefixLocalExtensionID(xs...); err != nil {
 		return nil, err
 	}
+	versionRanges, err := graphqlbackend.ViewerExtensionVersionRanges(ctx)
+	if err != nil {
+		return nil, err
+	}
 	xs2 := make([]graphqlbackend.RegistryExtension, len(xs))
 	for i, x := range xs {
-		xs2[i] = &extensionDBResolver{v: x}
+		versionRange := versionRanges[x.NonCanonicalExtensionID]
+		xs2[i] = &extensionDBResolver{v: x, versionRange: &versionRange}
 	}
 	return xs2, nil
 }
@@ -3,6 +3,7 @@ package registry
 import (
 	"context"
 	"strings"
+	"sync"
 	"time"
 
 	graphql "github.com/graph-gophers/graphql-go"
@@ -14,6 +15,28 @@ import (
 // extensionDBResolver implements the GraphQL type RegistryExtension.
 type extensionDBResolver struct {
 	v *dbExtension
+
+	// versionRange is the version range (such as "^1.2.0") of the release to resolve the manifest
+	// from, or "" for the latest release. If nil, the version range that the viewer's settings pin
+	// the extension to (if any) is used.
+	versionRange *string
+
+	settingsOnce         sync.Once
+	settingsVersionRange string
+	settingsErr          error
+}
+
+// getVersionRange returns the version range of the release to resolve the manifest from.
+func (r *extensionDBResolver) getVersionRange(ctx context.Context) (string, error) {
+	if r.versionRange != nil {
+		return *r.versionRange, nil
+	}
+	r.settingsOnce.Do(func() {
+		var versionRanges map[string]string
+		versionRanges, r.settingsErr = graphqlbackend.ViewerExtensionVersionRanges(ctx)
+		r.settingsVersionRange = versionRanges[r.v.NonCanonicalExtensionID]
+	})
+	return r.settingsVersionRange, r.settingsErr
 }
 
 func (r *extensionDBResolver) ID() graphql.ID {
@@ -35,13 +58,41 @@ func (r *extensionDBResolver) Publisher(ctx context.Context) (graphqlbackend.Reg
 
 func (r *extensionDBResolver) Name() string { return r.v.Name }
 func (r *extensionDBResolver) Manifest(ctx context.Context) (graphqlbackend.ExtensionManifest, error) {
-	manifest, _, err := getExtensionManifestWithBundleURL(ctx, r.v.NonCanonicalExtensionID, r.v.ID, "release")
+	versionRange, err := r.getVersionRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifest, _, err := getExtensionManifestWithBundleURL(ctx, r.v.NonCanonicalExtensionID, r.v.ID, "release", versionRange)
 	if err != nil {
 		return nil, err
 	}
 	return registry.NewExtensionManifest(manifest), nil
 }
 
+func (r *extensionDBResolver) Version(ctx context.Context) (*string, error) {
+	versionRange, err := r.getVersionRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	release, err := getExtensionRelease(ctx, r.v.ID, "release", versionRange)
+	if release == nil || err != nil {
+		return nil, err
+	}
+	return release.ReleaseVersion, nil
+}
+
+func (r *extensionDBResolver) Releases(ctx context.Context) (*[]graphqlbackend.RegistryExtensionRelease, error) {
+	releases, err := dbReleases{}.List(ctx, r.v.ID, "release")
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]graphqlbackend.RegistryExtensionRelease, len(releases))
+	for i, release := range releases {
+		resolvers[i] = &releaseDBResolver{v: release}
+	}
+	return &resolvers, nil
+}
+
 func (r *extensionDBResolver) CreatedAt() *string {
 	return strptr(r.v.CreatedAt.Format(time.RFC3339))
 }
@@ -51,7 +102,11 @@ func (r *extensionDBResolver) UpdatedAt() *string {
 }
 
 func (r *extensionDBResolver) PublishedAt(ctx context.Context) (*string, error) {
-	_, publishedAt, err := getExtensionManifestWithBundleURL(ctx, r.v.NonCanonicalExtensionID, r.v.ID, "release")
+	versionRange, err := r.getVersionRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, publishedAt, err := getExtensionManifestWithBundleURL(ctx, r.v.NonCanonicalExtensionID, r.v.ID, "release", versionRange)
 	if err != nil {
 		return nil, err
 	}
@@ -84,4 +139,17 @@ func (r *extensionDBResolver) ViewerCanAdminister(ctx context.Context) (bool, er
 	return err == nil, err
 }
 
+// releaseDBResolver implements the GraphQL type RegistryExtensionRelease.
+type releaseDBResolver struct {
+	v *dbRelease
+}
+
+func (r *releaseDBResolver) Version() *string { return r.v.ReleaseVersion }
+
+func (r *releaseDBResolver) PublishedAt() string {
+	return r.v.CreatedAt.Format(time.RFC3339)
+}
+
+func (r *releaseDBResolver) Yanked() bool { return r.v.YankedAt != nil }
+
 func strptr(s string) *string { return &s }
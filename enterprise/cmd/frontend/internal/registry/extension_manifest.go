@@ -23,44 +23,59 @@ func validateExtensionManifest(text string) error {
 	return jsonc.Unmarshal(text, &o)
 }
 
-// getExtensionManifestWithBundleURL returns the extension manifest as JSON. If there are no
-// releases, it returns a nil manifest. If the manifest has no "url" field itself, a "url" field
-// pointing to the extension's bundle is inserted. It also returns the date that the release was
-// published.
-func getExtensionManifestWithBundleURL(ctx context.Context, extensionID string, registryExtensionID int32, releaseTag string) (manifest *string, publishedAt time.Time, err error) {
-	release, err := dbReleases{}.GetLatest(ctx, registryExtensionID, releaseTag, false)
+// getExtensionRelease returns the release of the extension with the given release tag that has
+// the greatest version in the version range, or the latest release if versionRange is empty. If
+// there is no such release, it returns nil.
+func getExtensionRelease(ctx context.Context, registryExtensionID int32, releaseTag, versionRange string) (*dbRelease, error) {
+	release, err := dbReleases{}.GetLatestInVersionRange(ctx, registryExtensionID, releaseTag, versionRange, false)
 	if err != nil && !errcode.IsNotFound(err) {
+		return nil, err
+	}
+	return release, nil
+}
+
+// getExtensionManifestWithBundleURL returns the extension manifest as JSON. If there are no
+// releases (in the version range, if versionRange is not empty), it returns a nil manifest. If the
+// manifest has no "url" field itself, a "url" field pointing to the extension's bundle is inserted.
+// It also returns the date that the release was published.
+func getExtensionManifestWithBundleURL(ctx context.Context, extensionID string, registryExtensionID int32, releaseTag, versionRange string) (manifest *string, publishedAt time.Time, err error) {
+	release, err := getExtensionRelease(ctx, registryExtensionID, releaseTag, versionRange)
+	if err != nil || release == nil {
 		return nil, time.Time{}, err
 	}
-	if release != nil {
-		// Add URL to bundle if necessary.
-		var o map[string]interface{}
-		if err := jsonc.Unmarshal(release.Manifest, &o); err != nil {
-			return nil, time.Time{}, fmt.Errorf("parsing extension manifest for extension with ID %d (release tag %q): %s", registryExtensionID, releaseTag, err)
-		}
-		if o == nil {
-			o = map[string]interface{}{}
+	manifest, err = getReleaseManifestWithBundleURL(extensionID, release)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return manifest, release.CreatedAt, nil
+}
+
+// getReleaseManifestWithBundleURL returns the release's extension manifest as JSON. If the manifest
+// has no "url" field itself, a "url" field pointing to the extension's bundle is inserted.
+func getReleaseManifestWithBundleURL(extensionID string, release *dbRelease) (*string, error) {
+	// Add URL to bundle if necessary.
+	var o map[string]interface{}
+	if err := jsonc.Unmarshal(release.Manifest, &o); err != nil {
+		return nil, fmt.Errorf("parsing extension manifest for extension with ID %d (release tag %q): %s", release.RegistryExtensionID, release.ReleaseTag, err)
+	}
+	if o == nil {
+		o = map[string]interface{}{}
+	}
+	urlStr, _ := o["url"].(string)
+	if urlStr == "" {
+		// Insert "url" field with link to bundle file on this site.
+		bundleURL, err := makeExtensionBundleURL(release.ID, release.CreatedAt.UnixNano(), extensionID)
+		if err != nil {
+			return nil, err
 		}
-		urlStr, _ := o["url"].(string)
-		if urlStr == "" {
-			// Insert "url" field with link to bundle file on this site.
-			bundleURL, err := makeExtensionBundleURL(release.ID, release.CreatedAt.UnixNano(), extensionID)
-			if err != nil {
-				return nil, time.Time{}, err
-			}
-			o["url"] = bundleURL
-			b, err := json.MarshalIndent(o, "", "  ")
-			if err != nil {
-				return nil, time.Time{}, err
-			}
-			release.Manifest = string(b)
+		o["url"] = bundleURL
+		b, err := json.MarshalIndent(o, "", "  ")
+		if err != nil {
+			return nil, err
 		}
-
-		manifest = &release.Manifest
-		publishedAt = release.CreatedAt
+		release.Manifest = string(b)
 	}
-
-	return manifest, publishedAt, nil
+	return &release.Manifest, nil
 }
 
 var nonLettersDigits = regexp.MustCompile(`[^a-zA-Z0-9-]`)
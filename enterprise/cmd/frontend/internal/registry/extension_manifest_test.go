@@ -28,7 +28,7 @@ func TestGetExtensionManifestWithBundleURL(t *testing.T) {
 			}, nil
 		}
 		defer func() { mocks.releases.GetLatest = nil }()
-		manifest, publishedAt, err := getExtensionManifestWithBundleURL(ctx, "x", 1, "t")
+		manifest, publishedAt, err := getExtensionManifestWithBundleURL(ctx, "x", 1, "t", "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -48,7 +48,7 @@ func TestGetExtensionManifestWithBundleURL(t *testing.T) {
 			}, nil
 		}
 		defer func() { mocks.releases.GetLatest = nil }()
-		manifest, publishedAt, err := getExtensionManifestWithBundleURL(ctx, "x", 1, "t")
+		manifest, publishedAt, err := getExtensionManifestWithBundleURL(ctx, "x", 1, "t", "")
 		if err != nil {
 			t.Fatal(err)
 		}
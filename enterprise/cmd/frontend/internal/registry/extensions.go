@@ -10,7 +10,7 @@ import (
 
 func init() {
 	conf.DefaultRemoteRegistry = "https://sourcegraph.com/.api/registry"
-	registry.GetLocalExtensionByExtensionID = func(ctx context.Context, extensionIDWithoutPrefix string) (graphqlbackend.RegistryExtension, error) {
+	registry.GetLocalExtensionByExtensionID = func(ctx context.Context, extensionIDWithoutPrefix, versionRange string) (graphqlbackend.RegistryExtension, error) {
 		x, err := dbExtensions{}.GetByExtensionID(ctx, extensionIDWithoutPrefix)
 		if err != nil {
 			return nil, err
@@ -18,18 +18,43 @@ func init() {
 		if err := prefixLocalExtensionID(x); err != nil {
 			return nil, err
 		}
-		return &extensionDBResolver{v: x}, nil
+		return &extensionDBResolver{v: x, versionRange: &versionRange}, nil
+	}
+	registry.GetImportedExtensionByExtensionID = func(ctx context.Context, extensionID, versionRange string) (graphqlbackend.RegistryExtension, error) {
+		x, err := dbExtensions{}.GetByImportedExtensionID(ctx, extensionID)
+		if err != nil {
+			return nil, err
+		}
+		if err := prefixLocalExtensionID(x); err != nil {
+			return nil, err
+		}
+		return &extensionDBResolver{v: x, versionRange: &versionRange}, nil
 	}
 }
 
 // prefixLocalExtensionID adds the local registry's extension ID prefix (from
-// GetLocalRegistryExtensionIDPrefix) to all extensions' extension IDs in the list.
+// GetLocalRegistryExtensionIDPrefix) to all extensions' extension IDs in the list. Extensions that
+// were imported from an extension registry archive get their original extension ID instead.
 func prefixLocalExtensionID(xs ...*dbExtension) error {
+	for _, x := range xs {
+		if x.ImportedExtensionID != nil {
+			prefix, _, _, err := registry.SplitExtensionID(*x.ImportedExtensionID)
+			if err != nil {
+				return err
+			}
+			x.NonCanonicalExtensionID = *x.ImportedExtensionID
+			x.NonCanonicalRegistry = prefix
+		}
+	}
+
 	prefix := registry.GetLocalRegistryExtensionIDPrefix()
 	if prefix == nil {
 		return nil
 	}
 	for _, x := range xs {
+		if x.ImportedExtensionID != nil {
+			continue
+		}
 		x.NonCanonicalExtensionID = *prefix + "/" + x.NonCanonicalExtensionID
 		x.NonCanonicalRegistry = *prefix
 	}
@@ -28,6 +28,12 @@ type dbExtension struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
+	// ImportedExtensionID is the extension ID that the extension had in the extension registry it
+	// was exported from, if it was imported from an extension registry archive (see
+	// importExtensionsArchive). It is used instead of the local extension ID so that settings that
+	// refer to the original extension ID keep working.
+	ImportedExtensionID *string
+
 	// NonCanonicalExtensionID is the denormalized fully qualified extension ID
 	// ("[registry/]publisher/name" format), using the username/name of the extension's publisher
 	// (joined from another table) as of when the query executed. Do not persist this, because the
@@ -71,7 +77,16 @@ func (s dbExtensions) Create(ctx context.Context, publisherUserID, publisherOrgI
 	if mocks.extensions.Create != nil {
 		return mocks.extensions.Create(publisherUserID, publisherOrgID, name)
 	}
+	return s.create(ctx, publisherUserID, publisherOrgID, name, nil)
+}
 
+// CreateImported is like Create, except that it also records that the new extension was imported
+// from an extensions archive, where it had the given extension ID.
+func (s dbExtensions) CreateImported(ctx context.Context, publisherUserID, publisherOrgID int32, name, importedExtensionID string) (id int32, err error) {
+	return s.create(ctx, publisherUserID, publisherOrgID, name, &importedExtensionID)
+}
+
+func (dbExtensions) create(ctx context.Context, publisherUserID, publisherOrgID int32, name string, importedExtensionID *string) (id int32, err error) {
 	if publisherUserID != 0 && publisherOrgID != 0 {
 		return 0, errors.New("at most 1 of the publisher user/org may be set")
 	}
@@ -85,16 +100,17 @@ func (s dbExtensions) Create(ctx context.Context, publisherUserID, publisherOrgI
 		// Include users/orgs table query (with "FOR UPDATE") to ensure that the publisher user/org
 		// not been deleted. If it was deleted, the query will return an error.
 		`
-INSERT INTO registry_extensions(uuid, publisher_user_id, publisher_org_id, name)
+INSERT INTO registry_extensions(uuid, publisher_user_id, publisher_org_id, name, imported_extension_id)
 VALUES(
   $1,
   (SELECT id FROM users WHERE id=$2 AND deleted_at IS NULL FOR UPDATE),
   (SELECT id FROM orgs WHERE id=$3 AND deleted_at IS NULL FOR UPDATE),
-  $4
+  $4,
+  $5
 )
 RETURNING id
 `,
-		uuid, publisherUserID, publisherOrgID, name,
+		uuid, publisherUserID, publisherOrgID, name, importedExtensionID,
 	).Scan(&id); err != nil {
 		return 0, err
 	}
@@ -179,13 +195,33 @@ func (s dbExtensions) GetByExtensionID(ctx context.Context, extensionID string)
 	return results[0], nil
 }
 
+// GetByImportedExtensionID retrieves the registry extension (if any) that was imported from an
+// extension registry archive with the given (original) extension ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view this registry extension.
+func (s dbExtensions) GetByImportedExtensionID(ctx context.Context, extensionID string) (*dbExtension, error) {
+	if mocks.extensions.GetByImportedExtensionID != nil {
+		return mocks.extensions.GetByImportedExtensionID(extensionID)
+	}
+
+	results, err := s.list(ctx, []*sqlf.Query{sqlf.Sprintf("x.imported_extension_id=%s", extensionID)}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, extensionNotFoundError{[]interface{}{fmt.Sprintf("imported extensionID %q", extensionID)}}
+	}
+	return results[0], nil
+}
+
 // dbExtensionsListOptions contains options for listing registry extensions.
 type dbExtensionsListOptions struct {
-	Publisher              dbPublisher
-	Query                  string // matches the extension ID and latest release's manifest's title
-	Category               string // matches the latest release's manifest's categories array
-	Tag                    string // matches the latest release's manifest's tags array
-	PrioritizeExtensionIDs []string
+	Publisher                      dbPublisher
+	Query                          string // matches the extension ID and latest release's manifest's title
+	Category                       string // matches the latest release's manifest's categories array
+	Tag                            string // matches the latest release's manifest's tags array
+	PrioritizeExtensionIDs         []string
+	PrioritizeImportedExtensionIDs []string // matches the imported extension ID (not the local extension ID)
 	*db.LimitOffset
 }
 
@@ -236,12 +272,15 @@ func (o dbExtensionsListOptions) sqlConditions() []*sqlf.Query {
 }
 
 func (o dbExtensionsListOptions) sqlOrder() []*sqlf.Query {
-	ids := make([]*sqlf.Query, len(o.PrioritizeExtensionIDs)+1)
-	for i, id := range o.PrioritizeExtensionIDs {
-		ids[i] = sqlf.Sprintf("%v", string(id))
+	toList := func(extensionIDs []string) *sqlf.Query {
+		ids := make([]*sqlf.Query, len(extensionIDs)+1)
+		for i, id := range extensionIDs {
+			ids[i] = sqlf.Sprintf("%v", string(id))
+		}
+		ids[len(extensionIDs)] = sqlf.Sprintf("NULL")
+		return sqlf.Join(ids, ",")
 	}
-	ids[len(o.PrioritizeExtensionIDs)] = sqlf.Sprintf("NULL")
-	return []*sqlf.Query{sqlf.Sprintf(extensionIDExpr+` IN (%v) ASC`, sqlf.Join(ids, ","))}
+	return []*sqlf.Query{sqlf.Sprintf(`(CASE WHEN x.imported_extension_id IS NULL THEN `+extensionIDExpr+` IN (%v) ELSE x.imported_extension_id IN (%v) END) ASC`, toList(o.PrioritizeExtensionIDs), toList(o.PrioritizeImportedExtensionIDs))}
 }
 
 // List lists all registry extensions that satisfy the options.
@@ -272,7 +311,7 @@ WHERE (%s)
 func (s dbExtensions) list(ctx context.Context, conds, order []*sqlf.Query, limitOffset *db.LimitOffset) ([]*dbExtension, error) {
 	order = append(order, sqlf.Sprintf("TRUE"))
 	q := sqlf.Sprintf(`
-SELECT x.id, x.uuid, x.publisher_user_id, x.publisher_org_id, x.name, x.created_at, x.updated_at,
+SELECT x.id, x.uuid, x.publisher_user_id, x.publisher_org_id, x.name, x.created_at, x.updated_at, x.imported_extension_id,
   `+extensionIDExpr+` AS non_canonical_extension_id, `+extensionPublisherNameExpr+` AS non_canonical_publisher_name,
   (%s) AS non_canonical_is_work_in_progress
 %s
@@ -298,11 +337,15 @@ ORDER BY %s,
 	for rows.Next() {
 		var t dbExtension
 		var publisherUserID, publisherOrgID sql.NullInt64
-		if err := rows.Scan(&t.ID, &t.UUID, &publisherUserID, &publisherOrgID, &t.Name, &t.CreatedAt, &t.UpdatedAt, &t.NonCanonicalExtensionID, &t.Publisher.NonCanonicalName, &t.NonCanonicalIsWorkInProgress); err != nil {
+		var importedExtensionID sql.NullString
+		if err := rows.Scan(&t.ID, &t.UUID, &publisherUserID, &publisherOrgID, &t.Name, &t.CreatedAt, &t.UpdatedAt, &importedExtensionID, &t.NonCanonicalExtensionID, &t.Publisher.NonCanonicalName, &t.NonCanonicalIsWorkInProgress); err != nil {
 			return nil, err
 		}
 		t.Publisher.UserID = int32(publisherUserID.Int64)
 		t.Publisher.OrgID = int32(publisherOrgID.Int64)
+		if importedExtensionID.Valid {
+			t.ImportedExtensionID = &importedExtensionID.String
+		}
 		results = append(results, &t)
 	}
 	return results, nil
@@ -365,10 +408,11 @@ func (dbExtensions) Delete(ctx context.Context, id int32) error {
 
 // mockExtensions mocks the registry extensions store.
 type mockExtensions struct {
-	Create           func(publisherUserID, publisherOrgID int32, name string) (int32, error)
-	GetByID          func(id int32) (*dbExtension, error)
-	GetByUUID        func(uuid string) (*dbExtension, error)
-	GetByExtensionID func(extensionID string) (*dbExtension, error)
-	Update           func(id int32, name *string) error
-	Delete           func(id int32) error
+	Create                   func(publisherUserID, publisherOrgID int32, name string) (int32, error)
+	GetByID                  func(id int32) (*dbExtension, error)
+	GetByUUID                func(uuid string) (*dbExtension, error)
+	GetByExtensionID         func(extensionID string) (*dbExtension, error)
+	GetByImportedExtensionID func(extensionID string) (*dbExtension, error)
+	Update                   func(id int32, name *string) error
+	Delete                   func(id int32) error
 }
@@ -425,3 +425,58 @@ func asJSON(t *testing.T, v interface{}) string {
 	}
 	return string(b)
 }
+
+func TestRegistryExtensions_Imported(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	user, err := db.Users.Create(ctx, db.NewUser{Username: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	localID, err := dbExtensions{}.Create(ctx, user.ID, 0, "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	importedID, err := dbExtensions{}.CreateImported(ctx, user.ID, 0, "imported", "sourcegraph/imported")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := dbExtensions{}.GetByImportedExtensionID(ctx, "sourcegraph/imported")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.ID != importedID {
+		t.Errorf("got ID %d, want %d", x.ID, importedID)
+	}
+	if x.ImportedExtensionID == nil || *x.ImportedExtensionID != "sourcegraph/imported" {
+		t.Errorf("got imported extension ID %v, want %q", x.ImportedExtensionID, "sourcegraph/imported")
+	}
+	if _, err := (dbExtensions{}).GetByImportedExtensionID(ctx, "u/local"); !errcode.IsNotFound(err) {
+		t.Errorf("got err %v, want errcode.IsNotFound", err)
+	}
+
+	t.Run("PrioritizeImportedExtensionIDs", func(t *testing.T) {
+		for _, test := range []struct {
+			opt  dbExtensionsListOptions
+			want int32
+		}{
+			{opt: dbExtensionsListOptions{PrioritizeImportedExtensionIDs: []string{"sourcegraph/imported"}}, want: importedID},
+			{opt: dbExtensionsListOptions{PrioritizeExtensionIDs: []string{"u/local"}}, want: localID},
+			// The local extension ID of an imported extension is not used.
+			{opt: dbExtensionsListOptions{PrioritizeExtensionIDs: []string{"u/imported"}}, want: localID},
+		} {
+			test.opt.LimitOffset = &db.LimitOffset{Limit: 1}
+			xs, err := dbExtensions{}.List(ctx, test.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(xs) != 1 || xs[0].ID != test.want {
+				t.Errorf("%+v: got %s, want only extension %d", test.opt, asJSON(t, xs), test.want)
+			}
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	frontendregistry "github.com/sourcegraph/sourcegraph/cmd/frontend/registry"
@@ -33,7 +34,7 @@ var (
 		}
 		xs := make([]*registry.Extension, 0, len(vs))
 		for _, v := range vs {
-			x, err := toRegistryAPIExtension(ctx, v)
+			x, err := toRegistryAPIExtension(ctx, v, "")
 			if err != nil {
 				continue
 			}
@@ -52,28 +53,63 @@ var (
 		return xs, nil
 	}
 
-	registryGetByUUID = func(ctx context.Context, uuid string) (*registry.Extension, error) {
+	registryGetByUUID = func(ctx context.Context, uuid, versionRange string) (*registry.Extension, error) {
 		x, err := dbExtensions{}.GetByUUID(ctx, uuid)
 		if err != nil {
 			return nil, err
 		}
-		return toRegistryAPIExtension(ctx, x)
+		return toRegistryAPIExtension(ctx, x, versionRange)
 	}
 
-	registryGetByExtensionID = func(ctx context.Context, extensionID string) (*registry.Extension, error) {
+	registryGetByExtensionID = func(ctx context.Context, extensionID, versionRange string) (*registry.Extension, error) {
 		x, err := dbExtensions{}.GetByExtensionID(ctx, extensionID)
 		if err != nil {
 			return nil, err
 		}
-		return toRegistryAPIExtension(ctx, x)
+		return toRegistryAPIExtension(ctx, x, versionRange)
+	}
+
+	registryListReleases = func(ctx context.Context, extensionID string) ([]*registry.Release, error) {
+		x, err := dbExtensions{}.GetByExtensionID(ctx, extensionID)
+		if err != nil {
+			return nil, err
+		}
+		releases, err := dbReleases{}.List(ctx, x.ID, "release")
+		if err != nil {
+			return nil, err
+		}
+		xs := make([]*registry.Release, len(releases))
+		for i, release := range releases {
+			xs[i] = &registry.Release{
+				Version:     release.ReleaseVersion,
+				PublishedAt: release.CreatedAt,
+				Yanked:      release.YankedAt != nil,
+			}
+		}
+		return xs, nil
 	}
 )
 
-func toRegistryAPIExtension(ctx context.Context, v *dbExtension) (*registry.Extension, error) {
-	manifest, publishedAt, err := getExtensionManifestWithBundleURL(ctx, v.NonCanonicalExtensionID, v.ID, "release")
+// toRegistryAPIExtension converts the extension to its HTTP API representation. If versionRange is
+// not empty, the manifest is from the release with the greatest version in the range.
+func toRegistryAPIExtension(ctx context.Context, v *dbExtension, versionRange string) (*registry.Extension, error) {
+	release, err := getExtensionRelease(ctx, v.ID, "release", versionRange)
 	if err != nil {
 		return nil, err
 	}
+	var (
+		manifest    *string
+		publishedAt time.Time
+		version     *string
+	)
+	if release != nil {
+		manifest, err = getReleaseManifestWithBundleURL(v.NonCanonicalExtensionID, release)
+		if err != nil {
+			return nil, err
+		}
+		publishedAt = release.CreatedAt
+		version = release.ReleaseVersion
+	}
 
 	baseURL := strings.TrimSuffix(conf.Get().Critical.ExternalURL, "/")
 	return &registry.Extension{
@@ -89,6 +125,7 @@ func toRegistryAPIExtension(ctx context.Context, v *dbExtension) (*registry.Exte
 		UpdatedAt:   v.UpdatedAt,
 		PublishedAt: publishedAt,
 		URL:         baseURL + frontendregistry.ExtensionURL(v.NonCanonicalExtensionID),
+		Version:     version,
 	}, nil
 }
 
@@ -148,17 +185,39 @@ func handleRegistry(w http.ResponseWriter, r *http.Request) (err error) {
 		ev.AddField("results_count", len(xs))
 		result = xs
 
+	case strings.HasPrefix(urlPath, extensionsPath+"/extension-id/") && isReleasesPath(strings.TrimPrefix(urlPath, extensionsPath+"/extension-id/")):
+		extensionID := strings.TrimSuffix(strings.TrimPrefix(urlPath, extensionsPath+"/extension-id/"), "/releases")
+		releases, err := registryListReleases(r.Context(), extensionID)
+		if err != nil {
+			if errcode.IsNotFound(err) {
+				w.Header().Set("Cache-Control", "max-age=5, private")
+				http.Error(w, "extension not found", http.StatusNotFound)
+				return nil
+			}
+			return err
+		}
+		ev.AddField("extension-id", extensionID)
+		result = releases
+
 	case strings.HasPrefix(urlPath, extensionsPath+"/"):
 		var (
-			spec = strings.TrimPrefix(urlPath, extensionsPath+"/")
-			x    *registry.Extension
-			err  error
+			spec         = strings.TrimPrefix(urlPath, extensionsPath+"/")
+			versionRange = r.URL.Query().Get("version")
+			x            *registry.Extension
+			err          error
 		)
+		if versionRange != "" {
+			if _, err := parseVersionRange(versionRange); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return nil
+			}
+			ev.AddField("version", versionRange)
+		}
 		switch {
 		case strings.HasPrefix(spec, "uuid/"):
-			x, err = registryGetByUUID(r.Context(), strings.TrimPrefix(spec, "uuid/"))
+			x, err = registryGetByUUID(r.Context(), strings.TrimPrefix(spec, "uuid/"), versionRange)
 		case strings.HasPrefix(spec, "extension-id/"):
-			x, err = registryGetByExtensionID(r.Context(), strings.TrimPrefix(spec, "extension-id/"))
+			x, err = registryGetByExtensionID(r.Context(), strings.TrimPrefix(spec, "extension-id/"), versionRange)
 		default:
 			w.WriteHeader(http.StatusNotFound)
 			return nil
@@ -188,6 +247,13 @@ func handleRegistry(w http.ResponseWriter, r *http.Request) (err error) {
 	return nil
 }
 
+// isReleasesPath reports whether the path (after "extension-id/") refers to the list of an
+// extension's releases. Because extension IDs contain slashes, an extension ID with only a name
+// component (such as the extension ID "alice/releases") is not treated as a releases path.
+func isReleasesPath(spec string) bool {
+	return strings.HasSuffix(spec, "/releases") && strings.Contains(strings.TrimSuffix(spec, "/releases"), "/")
+}
+
 var (
 	registryRequestsSuccessCounter = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "src",
@@ -237,18 +303,21 @@ func init() {
 		}
 		return frontendregistry.FilterRegistryExtensions(xs, opt.Query), nil
 	}
-	registryGetByUUID = func(ctx context.Context, uuid string) (*registry.Extension, error) {
+	registryGetByUUID = func(ctx context.Context, uuid, versionRange string) (*registry.Extension, error) {
 		xs, err := readFakeExtensions()
 		if err != nil {
 			return nil, err
 		}
 		return frontendregistry.FindRegistryExtension(xs, "uuid", uuid), nil
 	}
-	registryGetByExtensionID = func(ctx context.Context, extensionID string) (*registry.Extension, error) {
+	registryGetByExtensionID = func(ctx context.Context, extensionID, versionRange string) (*registry.Extension, error) {
 		xs, err := readFakeExtensions()
 		if err != nil {
 			return nil, err
 		}
 		return frontendregistry.FindRegistryExtension(xs, "extensionID", extensionID), nil
 	}
+	registryListReleases = func(ctx context.Context, extensionID string) ([]*registry.Release, error) {
+		return []*registry.Release{}, nil // fake registry data has no releases
+	}
 }
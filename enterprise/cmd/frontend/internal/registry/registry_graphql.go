@@ -19,6 +19,7 @@ func init() {
 	frontendregistry.ExtensionRegistry.UpdateExtensionFunc = extensionRegistryUpdateExtension
 	frontendregistry.ExtensionRegistry.DeleteExtensionFunc = extensionRegistryDeleteExtension
 	frontendregistry.ExtensionRegistry.PublishExtensionFunc = extensionRegistryPublishExtension
+	frontendregistry.ExtensionRegistry.SetReleaseYankedFunc = extensionRegistrySetReleaseYanked
 }
 
 func registryExtensionByIDInt32(ctx context.Context, id int32) (graphqlbackend.RegistryExtension, error) {
@@ -177,6 +178,7 @@ func extensionRegistryPublishExtension(ctx context.Context, args *graphqlbackend
 	release := dbRelease{
 		RegistryExtensionID: id.LocalID,
 		CreatorUserID:       actor.FromContext(ctx).UID,
+		ReleaseVersion:      args.Version,
 		ReleaseTag:          "release",
 		Manifest:            args.Manifest,
 		Bundle:              args.Bundle,
@@ -187,3 +189,21 @@ func extensionRegistryPublishExtension(ctx context.Context, args *graphqlbackend
 	}
 	return &frontendregistry.ExtensionRegistryMutationResult{ID: id.LocalID}, nil
 }
+
+func extensionRegistrySetReleaseYanked(ctx context.Context, args *graphqlbackend.ExtensionRegistrySetReleaseYankedArgs) (*graphqlbackend.EmptyResponse, error) {
+	id, err := frontendregistry.UnmarshalRegistryExtensionID(args.Extension)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🚨 SECURITY: Check that the current user is authorized to yank (or unyank) the extension's
+	// releases.
+	if err := viewerCanAdministerExtension(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := (dbReleases{}).SetYanked(ctx, id.LocalID, args.Version, args.Yanked); err != nil {
+		return nil, err
+	}
+	return &graphqlbackend.EmptyResponse{}, nil
+}
@@ -0,0 +1,256 @@
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+)
+
+// parseReleaseVersion parses a release version, which must be a valid semantic version (such as
+// "1.2.3" or "2.0.0-beta.1"). A leading "v" is allowed and removed.
+func parseReleaseVersion(s string) (*semver.Version, error) {
+	v, err := semver.NewVersion(strings.TrimPrefix(strings.TrimSpace(s), "v"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid release version %q (must be a semantic version, such as \"1.2.3\")", s)
+	}
+	return v, nil
+}
+
+// compareVersions returns -1, 0, or 1 if a is less than, equal to, or greater than b.
+func compareVersions(a, b semver.Version) int {
+	switch {
+	case a.LessThan(b):
+		return -1
+	case b.LessThan(a):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// versionRange is a range of release versions that settings can pin an extension to. The syntax
+// is the subset of npm's that is commonly used:
+//
+//   - "1.2.3" or "=1.2.3" matches only 1.2.3.
+//   - "1.2", "1.2.x", and "1.2.*" match 1.2.0 and later versions before 1.3.0 ("1" and "1.x" are
+//     similar).
+//   - "^1.2.3" matches versions that do not change the leftmost non-zero component (1.2.3 and later
+//     versions before 2.0.0).
+//   - "~1.2.3" matches patch-level changes (1.2.3 and later versions before 1.3.0).
+//   - ">1.2.3", ">=1.2.3", "<1.2.3", and "<=1.2.3" compare against the version.
+//   - "*", "x", and "" match any version.
+//
+// Space-separated comparators must all match, and "||" separates alternative ranges (e.g.,
+// ">=1.2.0 <1.5.0 || ^2.0.0").
+//
+// As in npm, a prerelease version (such as 1.2.3-beta.1) only matches a range if one of the
+// comparators that must match has a prerelease on the same major, minor, and patch version.
+type versionRange [][]versionComparator
+
+type versionComparator struct {
+	op      string // "=", "<", "<=", ">", or ">="
+	version semver.Version
+}
+
+func (c versionComparator) matches(v semver.Version) bool {
+	cmp := compareVersions(v, c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		panic("unexpected version comparator operator: " + c.op)
+	}
+}
+
+// parseVersionRange parses a version range. See the versionRange documentation for the syntax.
+func parseVersionRange(s string) (versionRange, error) {
+	var r versionRange
+	for _, alt := range strings.Split(s, "||") {
+		comparators := []versionComparator{}
+		for _, tok := range strings.Fields(alt) {
+			cs, err := parseVersionComparator(tok)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version range %q: %s", s, err)
+			}
+			comparators = append(comparators, cs...)
+		}
+		r = append(r, comparators)
+	}
+	return r, nil
+}
+
+// parseVersionComparator parses a single token of a version range (such as "^1.2" or ">=1.0.0")
+// into the equivalent comparators, all of which must match.
+func parseVersionComparator(tok string) ([]versionComparator, error) {
+	var op string
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(tok, prefix) {
+			op = prefix
+			tok = strings.TrimPrefix(tok, prefix)
+			break
+		}
+	}
+	if op != "" && tok == "" {
+		return nil, fmt.Errorf("missing version after %q", op)
+	}
+	v, n, err := parsePartialVersion(strings.TrimPrefix(tok, "v"))
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil // matches any version
+	}
+
+	// Returns the version that is greater than v in the given component (0 for major, 1 for
+	// minor, 2 for patch).
+	next := func(component int) semver.Version {
+		switch component {
+		case 0:
+			return semver.Version{Major: v.Major + 1}
+		case 1:
+			return semver.Version{Major: v.Major, Minor: v.Minor + 1}
+		default:
+			return semver.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+		}
+	}
+	between := func(upper semver.Version) []versionComparator {
+		return []versionComparator{{op: ">=", version: v}, {op: "<", version: upper}}
+	}
+
+	switch op {
+	case "", "=":
+		if n == 3 {
+			return []versionComparator{{op: "=", version: v}}, nil
+		}
+		return between(next(n - 1)), nil
+	case "^":
+		switch {
+		case v.Major > 0 || n == 1:
+			return between(next(0)), nil
+		case v.Minor > 0 || n == 2:
+			return between(next(1)), nil
+		default:
+			return between(next(2)), nil
+		}
+	case "~":
+		if n == 1 {
+			return between(next(0)), nil
+		}
+		return between(next(1)), nil
+	case ">":
+		if n == 3 {
+			return []versionComparator{{op: ">", version: v}}, nil
+		}
+		return []versionComparator{{op: ">=", version: next(n - 1)}}, nil
+	case "<=":
+		if n == 3 {
+			return []versionComparator{{op: "<=", version: v}}, nil
+		}
+		return []versionComparator{{op: "<", version: next(n - 1)}}, nil
+	default: // ">=" or "<"
+		return []versionComparator{{op: op, version: v}}, nil
+	}
+}
+
+// parsePartialVersion parses a version that may omit components or use "x", "X", or "*" for
+// them (such as "1", "1.2", "1.x", or "*"). It returns the version (with omitted components set
+// to 0) and the number of components that were specified.
+func parsePartialVersion(s string) (v semver.Version, n int, err error) {
+	if i := strings.Index(s, "+"); i != -1 {
+		s = s[:i] // ignore build metadata
+	}
+	if i := strings.Index(s, "-"); i == 0 {
+		return v, 0, fmt.Errorf("invalid version %q", s)
+	} else if i != -1 {
+		s, v.PreRelease = s[:i], semver.PreRelease(s[i+1:])
+	}
+
+	if s == "" {
+		return v, 0, nil
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, 0, fmt.Errorf("invalid version %q", s)
+	}
+	components := []*int64{&v.Major, &v.Minor, &v.Patch}
+	wildcard := false
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			wildcard = true
+			continue
+		}
+		if wildcard {
+			return v, 0, fmt.Errorf("invalid version %q (wildcard followed by a number)", s)
+		}
+		c, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || c < 0 {
+			return v, 0, fmt.Errorf("invalid version %q", s)
+		}
+		*components[i] = c
+		n++
+	}
+	if v.PreRelease != "" && n != 3 {
+		return v, 0, fmt.Errorf("invalid version %q (a prerelease requires major, minor, and patch versions)", s)
+	}
+	return v, n, nil
+}
+
+// contains reports whether the version is in the range.
+func (r versionRange) contains(v semver.Version) bool {
+	for _, comparators := range r {
+		matches := true
+		for _, c := range comparators {
+			if !c.matches(v) {
+				matches = false
+				break
+			}
+		}
+		if matches && (v.PreRelease == "" || allowsPreReleaseOf(comparators, v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsPreReleaseOf reports whether any of the comparators has a prerelease version with the
+// same major, minor, and patch version as v.
+func allowsPreReleaseOf(comparators []versionComparator, v semver.Version) bool {
+	for _, c := range comparators {
+		if c.version.PreRelease != "" && c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// latestReleaseInVersionRange returns the release with the greatest version in the range, or nil
+// if there is none. Releases that are yanked or that have no (valid) version are ignored.
+func latestReleaseInVersionRange(releases []*dbRelease, r versionRange) *dbRelease {
+	var (
+		latest        *dbRelease
+		latestVersion semver.Version
+	)
+	for _, release := range releases {
+		if release.YankedAt != nil || release.ReleaseVersion == nil {
+			continue
+		}
+		v, err := parseReleaseVersion(*release.ReleaseVersion)
+		if err != nil || !r.contains(*v) {
+			continue
+		}
+		if latest == nil || compareVersions(*v, latestVersion) > 0 {
+			latest, latestVersion = release, *v
+		}
+	}
+	return latest
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseReleaseVersion(t *testing.T) {
+	for _, s := range []string{"1.2.3", "v1.2.3", "0.0.1-beta.2", "1.0.0+build"} {
+		if _, err := parseReleaseVersion(s); err != nil {
+			t.Errorf("%q: %s", s, err)
+		}
+	}
+	for _, s := range []string{"", "1", "1.2", "1.2.x", "^1.2.3", "latest"} {
+		if _, err := parseReleaseVersion(s); err == nil {
+			t.Errorf("%q: got nil error, want error", s)
+		}
+	}
+}
+
+func TestVersionRange(t *testing.T) {
+	tests := map[string]struct {
+		match, noMatch []string
+	}{
+		"":                 {match: []string{"0.0.1", "1.2.3", "99.0.0"}, noMatch: []string{"1.0.0-beta"}},
+		"*":                {match: []string{"0.0.1", "1.2.3"}},
+		"1.2.3":            {match: []string{"1.2.3"}, noMatch: []string{"1.2.4", "1.2.2", "1.2.3-beta"}},
+		"=1.2.3":           {match: []string{"1.2.3"}, noMatch: []string{"1.2.4"}},
+		"v1.2.3":           {match: []string{"1.2.3"}, noMatch: []string{"1.2.4"}},
+		"1.2":              {match: []string{"1.2.0", "1.2.99"}, noMatch: []string{"1.1.9", "1.3.0"}},
+		"1.2.x":            {match: []string{"1.2.0", "1.2.99"}, noMatch: []string{"1.3.0"}},
+		"1":                {match: []string{"1.0.0", "1.99.0"}, noMatch: []string{"0.9.0", "2.0.0"}},
+		"1.x":              {match: []string{"1.0.0", "1.99.0"}, noMatch: []string{"2.0.0"}},
+		"^1.2.3":           {match: []string{"1.2.3", "1.9.0"}, noMatch: []string{"1.2.2", "2.0.0", "2.0.0-beta"}},
+		"^0.2.3":           {match: []string{"0.2.3", "0.2.9"}, noMatch: []string{"0.3.0", "0.2.2"}},
+		"^0.0.3":           {match: []string{"0.0.3"}, noMatch: []string{"0.0.4"}},
+		"^0.2":             {match: []string{"0.2.0", "0.2.9"}, noMatch: []string{"0.3.0"}},
+		"^1":               {match: []string{"1.0.0", "1.9.9"}, noMatch: []string{"2.0.0"}},
+		"~1.2.3":           {match: []string{"1.2.3", "1.2.9"}, noMatch: []string{"1.3.0", "1.2.2"}},
+		"~1":               {match: []string{"1.0.0", "1.9.0"}, noMatch: []string{"2.0.0"}},
+		">1.2.3":           {match: []string{"1.2.4", "2.0.0"}, noMatch: []string{"1.2.3"}},
+		">1.2":             {match: []string{"1.3.0"}, noMatch: []string{"1.2.9"}},
+		">=1.2":            {match: []string{"1.2.0", "2.0.0"}, noMatch: []string{"1.1.9"}},
+		"<1.2":             {match: []string{"1.1.9"}, noMatch: []string{"1.2.0"}},
+		"<=1.2":            {match: []string{"1.2.9"}, noMatch: []string{"1.3.0"}},
+		"<=1.2.3":          {match: []string{"1.2.3"}, noMatch: []string{"1.2.4"}},
+		">=1.2.0 <1.5.0":   {match: []string{"1.2.0", "1.4.9"}, noMatch: []string{"1.1.0", "1.5.0"}},
+		"<1.0.0 || ^2.1.0": {match: []string{"0.9.0", "2.1.0", "2.9.9"}, noMatch: []string{"1.0.0", "2.0.0", "3.0.0"}},
+		">=1.2.3-beta.2":   {match: []string{"1.2.3-beta.2", "1.2.3-beta.10", "1.2.3", "1.3.0"}, noMatch: []string{"1.2.3-beta.1", "1.3.0-beta"}},
+	}
+	for rangeStr, test := range tests {
+		r, err := parseVersionRange(rangeStr)
+		if err != nil {
+			t.Errorf("%q: %s", rangeStr, err)
+			continue
+		}
+		for _, s := range test.match {
+			if v, _ := parseReleaseVersion(s); !r.contains(*v) {
+				t.Errorf("%q: want %s to match", rangeStr, s)
+			}
+		}
+		for _, s := range test.noMatch {
+			if v, _ := parseReleaseVersion(s); r.contains(*v) {
+				t.Errorf("%q: want %s to not match", rangeStr, s)
+			}
+		}
+	}
+
+	for _, rangeStr := range []string{"1.2.3.4", "a.b", "1.x.3", ">=", "1.2.3 - 2.0.0", "^1.2-beta"} {
+		if _, err := parseVersionRange(rangeStr); err == nil {
+			t.Errorf("%q: got nil error, want error", rangeStr)
+		}
+	}
+}
+
+func TestLatestReleaseInVersionRange(t *testing.T) {
+	yankedAt := time.Now()
+	releases := []*dbRelease{
+		{ID: 1, ReleaseVersion: strptr("1.0.0")},
+		{ID: 2, ReleaseVersion: strptr("1.2.0")},
+		{ID: 3, ReleaseVersion: strptr("1.3.0"), YankedAt: &yankedAt},
+		{ID: 4, ReleaseVersion: strptr("2.0.0-beta")},
+		{ID: 5}, // no version
+		{ID: 6, ReleaseVersion: strptr("1.1.0")},
+	}
+	tests := map[string]*dbRelease{
+		"^1.0.0":       releases[1], // not the yanked 1.3.0
+		"~1.1.0":       releases[5],
+		"1.3.0":        nil,
+		"*":            releases[1],
+		"2.0.0-beta":   releases[3],
+		">=3.0.0":      nil,
+		"1.0.0 || 2.x": releases[0],
+	}
+	for rangeStr, want := range tests {
+		r, err := parseVersionRange(rangeStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := latestReleaseInVersionRange(releases, r); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %+v, want %+v", rangeStr, got, want)
+		}
+	}
+}
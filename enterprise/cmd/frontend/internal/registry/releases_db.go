@@ -10,6 +10,7 @@ import (
 	"github.com/keegancsmith/sqlf"
 	"github.com/lib/pq"
 	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 )
 
 // dbRelease describes a release of an extension in the extension registry.
@@ -23,6 +24,7 @@ type dbRelease struct {
 	Bundle              *string
 	SourceMap           *string
 	CreatedAt           time.Time
+	YankedAt            *time.Time // when the release was yanked (nil if it is not yanked)
 }
 
 type dbReleases struct{}
@@ -42,33 +44,59 @@ func (err releaseNotFoundError) Error() string {
 
 var errInvalidJSONInManifest = errors.New("invalid syntax in extension manifest JSON")
 
-// Create creates a new release of an extension in the extension registry. The release.ID and
-// release.CreatedAt fields are ignored (they are populated automatically by the database).
+// Create creates a new release of an extension in the extension registry. The release.ID,
+// release.CreatedAt, and release.YankedAt fields are ignored (they are populated automatically by
+// the database).
+//
+// If release.ReleaseVersion is set, it must be a valid semantic version that no other release of
+// the extension has. It is stored in normalized form (e.g., "v1.2.3" is stored as "1.2.3").
 func (dbReleases) Create(ctx context.Context, release *dbRelease) (id int64, err error) {
 	if mocks.releases.Create != nil {
 		return mocks.releases.Create(release)
 	}
 
+	var version *string
+	if release.ReleaseVersion != nil {
+		v, err := parseReleaseVersion(*release.ReleaseVersion)
+		if err != nil {
+			return 0, err
+		}
+		version = strptr(v.String())
+	}
+
 	if err := dbconn.Global.QueryRowContext(ctx,
 		`
 INSERT INTO registry_extension_releases(registry_extension_id, creator_user_id, release_version, release_tag, manifest, bundle, source_map)
 VALUES($1, $2, $3, $4, $5, $6, $7)
 RETURNING id
 `,
-		release.RegistryExtensionID, release.CreatorUserID, release.ReleaseVersion, release.ReleaseTag, release.Manifest, release.Bundle, release.SourceMap,
+		release.RegistryExtensionID, release.CreatorUserID, version, release.ReleaseTag, release.Manifest, release.Bundle, release.SourceMap,
 	).Scan(&id); err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Message == "invalid input syntax for type json" {
 				return 0, errInvalidJSONInManifest
 			}
+			if pqErr.Constraint == "registry_extension_releases_version" {
+				return 0, fmt.Errorf("a release with version %q already exists for this extension", *version)
+			}
 		}
 		return 0, err
 	}
 	return id, nil
 }
 
+const releaseColumns = "id, registry_extension_id, creator_user_id, release_version, release_tag, manifest, CASE WHEN %v::boolean THEN bundle ELSE null END AS bundle, CASE WHEN %v::boolean THEN source_map ELSE null END AS source_map, created_at, yanked_at"
+
+func scanRelease(scanner interface{ Scan(...interface{}) error }) (*dbRelease, error) {
+	var r dbRelease
+	if err := scanner.Scan(&r.ID, &r.RegistryExtensionID, &r.CreatorUserID, &r.ReleaseVersion, &r.ReleaseTag, &r.Manifest, &r.Bundle, &r.SourceMap, &r.CreatedAt, &r.YankedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
 // GetLatest gets the latest release for the extension with the given release tag (e.g.,
-// "release"). If includeArtifacts is true, it populates the
+// "release"). Yanked releases are ignored. If includeArtifacts is true, it populates the
 // (*dbRelease).{Bundle,SourceMap} fields, which may be large.
 func (dbReleases) GetLatest(ctx context.Context, registryExtensionID int32, releaseTag string, includeArtifacts bool) (*dbRelease, error) {
 	if mocks.releases.GetLatest != nil {
@@ -76,20 +104,110 @@ func (dbReleases) GetLatest(ctx context.Context, registryExtensionID int32, rele
 	}
 
 	q := sqlf.Sprintf(`
-SELECT id, registry_extension_id, creator_user_id, release_version, release_tag, manifest, CASE WHEN %v::boolean THEN bundle ELSE null END AS bundle, CASE WHEN %v::boolean THEN source_map ELSE null END AS source_map, created_at
+SELECT `+releaseColumns+`
 FROM registry_extension_releases
-WHERE registry_extension_id=%d AND release_tag=%s AND deleted_at IS NULL
+WHERE registry_extension_id=%d AND release_tag=%s AND deleted_at IS NULL AND yanked_at IS NULL
 ORDER BY created_at DESC
 LIMIT 1`, includeArtifacts, includeArtifacts, registryExtensionID, releaseTag)
-	var r dbRelease
-	err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&r.ID, &r.RegistryExtensionID, &r.CreatorUserID, &r.ReleaseVersion, &r.ReleaseTag, &r.Manifest, &r.Bundle, &r.SourceMap, &r.CreatedAt)
+	r, err := scanRelease(dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, releaseNotFoundError{[]interface{}{fmt.Sprintf("latest for registry extension ID %d tag %q", registryExtensionID, releaseTag)}}
 		}
 		return nil, err
 	}
-	return &r, nil
+	return r, nil
+}
+
+// GetLatestInVersionRange gets the release with the greatest version in the version range (e.g.,
+// "^1.2.0") for the extension with the given release tag. Yanked releases and releases without a
+// version are ignored. If versionRange is empty, it is equivalent to GetLatest.
+func (s dbReleases) GetLatestInVersionRange(ctx context.Context, registryExtensionID int32, releaseTag, versionRange string, includeArtifacts bool) (*dbRelease, error) {
+	if versionRange == "" {
+		return s.GetLatest(ctx, registryExtensionID, releaseTag, includeArtifacts)
+	}
+
+	r, err := parseVersionRange(versionRange)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := s.List(ctx, registryExtensionID, releaseTag)
+	if err != nil {
+		return nil, err
+	}
+	release := latestReleaseInVersionRange(releases, r)
+	if release == nil {
+		return nil, releaseNotFoundError{[]interface{}{fmt.Sprintf("version range %q for registry extension ID %d tag %q", versionRange, registryExtensionID, releaseTag)}}
+	}
+	if includeArtifacts {
+		bundle, sourceMap, err := s.GetArtifacts(ctx, release.ID)
+		if err != nil && !errcode.IsNotFound(err) {
+			return nil, err
+		}
+		if bundle != nil {
+			release.Bundle = strptr(string(bundle))
+		}
+		if sourceMap != nil {
+			release.SourceMap = strptr(string(sourceMap))
+		}
+	}
+	return release, nil
+}
+
+// List lists all releases (including yanked releases) for the extension with the given release
+// tag, newest first. The (*dbRelease).{Bundle,SourceMap} fields are not populated.
+func (dbReleases) List(ctx context.Context, registryExtensionID int32, releaseTag string) ([]*dbRelease, error) {
+	if mocks.releases.List != nil {
+		return mocks.releases.List(registryExtensionID, releaseTag)
+	}
+
+	q := sqlf.Sprintf(`
+SELECT `+releaseColumns+`
+FROM registry_extension_releases
+WHERE registry_extension_id=%d AND release_tag=%s AND deleted_at IS NULL
+ORDER BY created_at DESC, id DESC`, false, false, registryExtensionID, releaseTag)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []*dbRelease
+	for rows.Next() {
+		r, err := scanRelease(rows)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	return releases, rows.Err()
+}
+
+// SetYanked yanks (or, if yanked is false, un-yanks) the release of the extension with the given
+// version. A yanked release is still served to clients that refer to it directly, but it is no
+// longer used as the latest release or as the release for a version range.
+func (dbReleases) SetYanked(ctx context.Context, registryExtensionID int32, version string, yanked bool) error {
+	v, err := parseReleaseVersion(version)
+	if err != nil {
+		return err
+	}
+
+	q := sqlf.Sprintf(`
+UPDATE registry_extension_releases
+SET yanked_at=(CASE WHEN %v::boolean THEN COALESCE(yanked_at, now()) ELSE null END)
+WHERE registry_extension_id=%d AND release_version=%s AND deleted_at IS NULL`, yanked, registryExtensionID, v.String())
+	res, err := dbconn.Global.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return releaseNotFoundError{[]interface{}{fmt.Sprintf("registry extension ID %d version %q", registryExtensionID, version)}}
+	}
+	return nil
 }
 
 // GetArtifacts gets the bundled JavaScript source file contents and the source map for a release
@@ -115,4 +233,5 @@ WHERE id=%d AND deleted_at IS NULL`, id)
 type mockReleases struct {
 	Create    func(release *dbRelease) (int64, error)
 	GetLatest func(registryExtensionID int32, releaseTag string, includeArtifacts bool) (*dbRelease, error)
+	List      func(registryExtensionID int32, releaseTag string) ([]*dbRelease, error)
 }
@@ -161,4 +161,79 @@ func TestRegistryExtensionReleases(t *testing.T) {
 			t.Error("sourcemap != nil")
 		}
 	})
+
+	t.Run("Versions and yanking", func(t *testing.T) {
+		extensionID, err := (dbExtensions{}).Create(ctx, user.ID, 0, "versioned")
+		if err != nil {
+			t.Fatal(err)
+		}
+		create := func(version string) error {
+			_, err := dbReleases{}.Create(ctx, &dbRelease{
+				RegistryExtensionID: extensionID,
+				CreatorUserID:       user.ID,
+				ReleaseVersion:      &version,
+				ReleaseTag:          "release",
+				Manifest:            `{}`,
+			})
+			return err
+		}
+		for _, version := range []string{"1.0.0", "v1.1.0", "2.0.0"} {
+			if err := create(version); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := create("1.1.0"); err == nil {
+			t.Error("got nil error for duplicate version, want error")
+		}
+		if err := create("1.2"); err == nil {
+			t.Error("got nil error for invalid version, want error")
+		}
+
+		wantVersion := func(t *testing.T, versionRange, want string) {
+			t.Helper()
+			r, err := dbReleases{}.GetLatestInVersionRange(ctx, extensionID, "release", versionRange, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.ReleaseVersion == nil || *r.ReleaseVersion != want {
+				t.Errorf("%q: got version %v, want %q", versionRange, r.ReleaseVersion, want)
+			}
+		}
+		wantVersion(t, "", "2.0.0")
+		wantVersion(t, "^1.0.0", "1.1.0")
+
+		if err := (dbReleases{}).SetYanked(ctx, extensionID, "1.1.0", true); err != nil {
+			t.Fatal(err)
+		}
+		wantVersion(t, "^1.0.0", "1.0.0")
+		if err := (dbReleases{}).SetYanked(ctx, extensionID, "2.0.0", true); err != nil {
+			t.Fatal(err)
+		}
+		wantVersion(t, "", "1.0.0")
+		if _, err := (dbReleases{}).GetLatestInVersionRange(ctx, extensionID, "release", "^2.0.0", false); !errcode.IsNotFound(err) {
+			t.Errorf("got err %v, want errcode.IsNotFound", err)
+		}
+
+		releases, err := dbReleases{}.List(ctx, extensionID, "release")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var yanked []string
+		for _, r := range releases {
+			if r.YankedAt != nil {
+				yanked = append(yanked, *r.ReleaseVersion)
+			}
+		}
+		if want := []string{"2.0.0", "1.1.0"}; len(releases) != 3 || !reflect.DeepEqual(yanked, want) {
+			t.Errorf("got %d releases with yanked %v, want 3 releases with yanked %v", len(releases), yanked, want)
+		}
+
+		if err := (dbReleases{}).SetYanked(ctx, extensionID, "2.0.0", false); err != nil {
+			t.Fatal(err)
+		}
+		wantVersion(t, "", "2.0.0")
+		if err := (dbReleases{}).SetYanked(ctx, extensionID, "9.9.9", true); !errcode.IsNotFound(err) {
+			t.Errorf("got err %v, want errcode.IsNotFound", err)
+		}
+	})
 }
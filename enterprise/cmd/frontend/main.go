@@ -46,6 +46,7 @@ func main() {
 			}
 		}()
 		go licensing.StartMaxUserCount(&usersStore{})
+		go iauthz.SyncPermissionsPeriodically(ctx)
 	}
 
 	debug, _ := strconv.ParseBool(os.Getenv("DEBUG"))
@@ -136,6 +137,20 @@ func initAuthz() {
 			}
 		}
 
+		bitbuckets, err := db.ExternalServices.ListBitbucketServerConnections(ctx)
+		if err != nil {
+			return []*graphqlbackend.Alert{{
+				TypeValue:    graphqlbackend.AlertTypeError,
+				MessageValue: fmt.Sprintf("Unable to fetch Bitbucket Server external services: %s", err),
+			}}
+		}
+		for _, b := range bitbuckets {
+			if b.Authorization != nil {
+				authzTypes = append(authzTypes, "Bitbucket Server")
+				break
+			}
+		}
+
 		if len(authzTypes) > 0 {
 			return []*graphqlbackend.Alert{{
 				TypeValue:    graphqlbackend.AlertTypeError,
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "explicit_repo_permissions" (
+    "id" serial NOT NULL PRIMARY KEY,
+    "repo_name" citext,
+    "repo_pattern" text,
+    "user_id" integer REFERENCES users (id) ON DELETE CASCADE,
+    "org_id" integer REFERENCES orgs (id) ON DELETE CASCADE,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT "explicit_repo_permissions_repo_check" CHECK ((repo_name IS NULL) <> (repo_pattern IS NULL)),
+    CONSTRAINT "explicit_repo_permissions_subject_check" CHECK ((user_id IS NULL) <> (org_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS "explicit_repo_permissions_user_id" ON explicit_repo_permissions(user_id);
+CREATE INDEX IF NOT EXISTS "explicit_repo_permissions_org_id" ON explicit_repo_permissions(org_id);
+
+COMMIT;
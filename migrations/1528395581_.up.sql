@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "user_repo_permissions" (
+    "user_id" integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    "service_type" text NOT NULL,
+    "service_id" text NOT NULL,
+    "external_repo_ids" text[] DEFAULT '{}' NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT now() NOT NULL,
+    PRIMARY KEY (user_id, service_type, service_id)
+);
+
+COMMIT;
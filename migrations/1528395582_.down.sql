@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS webhook_secret;
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS webhook_url;
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS notify_webhook;
+
+COMMIT;
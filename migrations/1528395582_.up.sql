@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE saved_searches ADD COLUMN notify_webhook boolean NOT NULL DEFAULT false;
+ALTER TABLE saved_searches ADD COLUMN webhook_url text;
+ALTER TABLE saved_searches ADD COLUMN webhook_secret text;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS discussion_comments_external;
+DROP TABLE IF EXISTS discussion_threads_external;
+
+COMMIT;
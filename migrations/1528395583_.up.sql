@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE discussion_threads_external (
+    thread_id bigint PRIMARY KEY REFERENCES discussion_threads(id) ON DELETE CASCADE,
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    service_type text NOT NULL,
+    service_id text NOT NULL,
+    pull_request_id text NOT NULL,
+    external_id text NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE discussion_comments_external (
+    comment_id bigint PRIMARY KEY REFERENCES discussion_comments(id) ON DELETE CASCADE,
+    thread_id bigint NOT NULL REFERENCES discussion_threads_external(thread_id) ON DELETE CASCADE,
+    external_id text NOT NULL,
+    imported boolean NOT NULL DEFAULT false,
+    synced_at timestamp with time zone NOT NULL,
+    external_updated_at timestamp with time zone NOT NULL
+);
+CREATE UNIQUE INDEX discussion_comments_external_thread_id_external_id_idx ON discussion_comments_external(thread_id, external_id);
+
+COMMIT;
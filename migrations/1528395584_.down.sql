@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE registry_extension_releases DROP COLUMN IF EXISTS yanked_at;
+
+COMMIT;
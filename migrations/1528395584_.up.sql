@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE registry_extension_releases ADD COLUMN yanked_at timestamp with time zone;
+
+COMMIT;
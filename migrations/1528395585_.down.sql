@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS registry_extensions_imported_extension_id;
+ALTER TABLE registry_extensions DROP COLUMN IF EXISTS imported_extension_id;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE registry_extensions ADD COLUMN imported_extension_id citext;
+CREATE UNIQUE INDEX registry_extensions_imported_extension_id ON registry_extensions(imported_extension_id) WHERE deleted_at IS NULL;
+
+COMMIT;
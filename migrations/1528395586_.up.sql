@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE TABLE site_config_changes (
+    id serial PRIMARY KEY,
+    author_user_id integer REFERENCES users(id) ON DELETE SET NULL,
+    previous_critical text NOT NULL,
+    previous_site text NOT NULL,
+    critical text NOT NULL,
+    site text NOT NULL,
+    changed_fields text[] NOT NULL DEFAULT '{}',
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+COMMIT;
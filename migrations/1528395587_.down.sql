@@ -0,0 +1,8 @@
+BEGIN;
+
+DELETE FROM discussion_comments WHERE author_user_id IS NULL;
+ALTER TABLE discussion_comments DROP CONSTRAINT discussion_comments_author_check;
+ALTER TABLE discussion_comments DROP COLUMN external_author;
+ALTER TABLE discussion_comments ALTER COLUMN author_user_id SET NOT NULL;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE discussion_comments ALTER COLUMN author_user_id DROP NOT NULL;
+ALTER TABLE discussion_comments ADD COLUMN external_author text;
+ALTER TABLE discussion_comments ADD CONSTRAINT discussion_comments_author_check CHECK ((author_user_id IS NULL) <> (external_author IS NULL));
+
+COMMIT;
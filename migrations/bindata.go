@@ -221,6 +221,22 @@
 // 1528395578_.up.sql (714B)
 // 1528395579_.down.sql (35B)
 // 1528395579_.up.sql (175B)
+// 1528395580_.down.sql (65B)
+// 1528395580_.up.sql (769B)
+// 1528395581_.down.sql (61B)
+// 1528395581_.up.sql (383B)
+// 1528395582_.down.sql (209B)
+// 1528395582_.up.sql (217B)
+// 1528395583_.down.sql (118B)
+// 1528395583_.up.sql (958B)
+// 1528395584_.down.sql (90B)
+// 1528395584_.up.sql (104B)
+// 1528395585_.down.sql (158B)
+// 1528395585_.up.sql (224B)
+// 1528395586_.down.sql (59B)
+// 1528395586_.up.sql (384B)
+// 1528395587_.down.sql (296B)
+// 1528395587_.up.sql (300B)
 
 package migrations
 
@@ -4709,6 +4725,326 @@ func _1528395579_UpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1528395580_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x41\x00\xbe\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x78\x70\x6c\x69\x63\x69\x74\x5f\x72\x65\x70\x6f\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x3f\x46\x96\x67\x41\x00\x00\x00")
+
+func _1528395580_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395580_DownSql,
+		"1528395580_.down.sql",
+	)
+}
+
+func _1528395580_DownSql() (*asset, error) {
+	bytes, err := _1528395580_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395580_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xea, 0x8e, 0x80, 0xdb, 0x37, 0x15, 0x3b, 0x42, 0xa, 0xab, 0x69, 0x20, 0x52, 0x73, 0x9, 0xec, 0x13, 0xfd, 0x7b, 0x65, 0x75, 0xa, 0x28, 0x39, 0x78, 0x56, 0x9d, 0x45, 0xdf, 0xf8, 0x98, 0xac}}
+	return a, nil
+}
+
+var __1528395580_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x92\xdd\x8a\xdb\x30\x10\x85\xef\xfd\x14\x07\x5d\xd9\xd0\x37\x48\x29\x78\xed\x49\x2b\xd6\x91\x8b\xad\x85\xdd\x2b\xe3\x3a\x43\xa2\x36\xfe\x41\x52\x48\xe8\xd3\x97\xd8\x89\x93\x16\x92\xb6\x7b\xa9\x99\x39\xf3\x69\xce\xcc\x13\x7d\x96\x6a\x11\x04\x49\x41\xb1\x26\xe8\xf8\x29\x23\xc8\x25\x54\xae\x41\xaf\xb2\xd4\x25\x04\x1f\x87\x9d\x69\x8c\xaf\x2c\x0f\x7d\x35\xb0\x6d\x8d\x73\xa6\xef\x9c\x40\x18\x00\x80\x30\x6b\x01\xc7\xd6\xd4\xbb\x51\xa8\x5e\xb2\x0c\x5f\x0b\xb9\x8a\x8b\x37\x3c\xd3\xdb\x87\xa9\x6a\x94\x77\x75\xcb\x02\x8d\xf1\x7c\xf4\xb7\xf1\xa1\xf6\x9e\x6d\x27\x70\x93\xd8\x3b\xb6\xd5\xa9\xb7\xe9\x3c\x6f\xd8\xa2\xa0\x25\x15\xa4\x12\x2a\x71\xca\x39\x84\x66\x1d\x21\x57\x48\x29\x23\x4d\x48\xe2\x32\x89\x53\x3a\xcb\x7b\xbb\xb9\xa7\xee\xed\xe6\x2f\xe2\xc6\x72\xed\x79\x5d\xd5\x5e\xc0\x9b\x96\x9d\xaf\xdb\x01\x07\xe3\xb7\xe3\x13\x3f\xfb\x8e\x91\xd2\x32\x7e\xc9\x34\xba\xfe\x10\x46\xf3\xe8\x53\x87\x24\x57\xa5\x2e\x62\xa9\xf4\x03\x07\xa7\x40\xb3\xe5\xe6\x87\x40\xf2\x85\x92\x67\x84\xe1\xec\x13\x64\x39\x36\x8c\xf0\xf1\x13\xc2\x5b\x9b\xe6\x4c\xf4\x3f\x30\xb7\xff\xf6\x9d\x1b\xff\x27\xef\x6c\xf3\xef\xb4\xc9\xbc\x2b\x27\x88\xae\x57\x22\x55\x4a\xaf\xff\x7c\x25\xd5\xbc\xc6\x5c\xe1\x6e\xd5\xe5\x17\xd1\xe2\x9d\x94\xcb\xb6\x1f\x42\xa6\xa2\x71\x94\x7c\xb5\x92\x7a\x11\xfc\x1a\x00\xe6\x82\x38\x8c\x01\x03\x00\x00")
+
+func _1528395580_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395580_UpSql,
+		"1528395580_.up.sql",
+	)
+}
+
+func _1528395580_UpSql() (*asset, error) {
+	bytes, err := _1528395580_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395580_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x91, 0xf4, 0x28, 0x93, 0x3c, 0x8d, 0xb5, 0xb5, 0x67, 0x93, 0x98, 0x34, 0x6c, 0x4c, 0x9, 0xed, 0xe3, 0xdc, 0x8f, 0x78, 0xa2, 0x1e, 0xcd, 0x8f, 0x58, 0x28, 0xdb, 0x4b, 0xa7, 0x4, 0x13, 0x7c}}
+	return a, nil
+}
+
+var __1528395581_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x73\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x2d\x4e\x2d\x8a\x2f\x4a\x2d\xc8\x8f\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x06\x2a\x76\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x00\xf3\x29\x91\x47\x3d\x00\x00\x00")
+
+func _1528395581_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395581_DownSql,
+		"1528395581_.down.sql",
+	)
+}
+
+func _1528395581_DownSql() (*asset, error) {
+	bytes, err := _1528395581_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395581_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x3f, 0x44, 0x5, 0x21, 0x91, 0x40, 0xbc, 0x50, 0xb1, 0xdc, 0x84, 0x30, 0x6d, 0x1, 0xc8, 0xb7, 0x6d, 0xfe, 0xda, 0x65, 0x9b, 0x94, 0xd4, 0xb5, 0x42, 0xb0, 0x5d, 0x40, 0x70, 0xf0, 0xd7, 0xa2}}
+	return a, nil
+}
+
+var __1528395581_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6d\x90\xdd\x6a\xc3\x30\x0c\x85\xef\xfd\x14\x22\x37\x4d\xa0\x6f\xd0\x2b\x37\x55\x86\x59\xe2\x0c\xc7\x85\x95\x31\x42\x58\xc4\x66\x58\x12\x13\xbb\xeb\x7e\xd8\xbb\xcf\x69\x46\x37\x42\x75\x27\xe9\xd3\x39\xf6\xd9\xe2\x8d\x90\x1b\xc6\x52\x85\x5c\x23\x68\xbe\xcd\x11\x44\x06\xb2\xd4\x80\xf7\xa2\xd2\x15\x44\x47\x47\x63\x3d\x92\x1d\x6a\x4b\x63\x67\x9c\x33\x43\xef\x22\x88\x19\x84\x9a\xb7\xa6\x8d\xc0\xf4\x9e\x9e\x69\x3c\x9f\xca\x7d\x9e\x83\xc2\x0c\x15\xca\x14\x2b\x98\x20\x07\xb1\x69\x13\x28\x25\xec\x30\xc7\x60\x96\xf2\x2a\xe5\x3b\x5c\xcf\x3a\x81\x78\x33\x4f\x54\xfb\x0f\x4b\x11\x78\x7a\xf7\x17\xa5\x05\x31\x99\x5d\xdb\x87\x11\x8d\x7d\xf3\x3a\xbf\xd5\xb4\x6e\xc6\x1e\x1e\x83\x61\xc6\xf7\xb9\x86\xd5\xd7\xf7\x6a\x79\x75\xb4\x6d\xe3\xa9\xad\x1b\x1f\x70\xd3\x91\xf3\x4d\x67\xe1\x64\xfc\xcb\xb9\x85\xcf\xa1\xa7\x8b\x40\x3f\x9c\xe2\x64\xa1\x70\xa7\x44\xc1\xd5\x01\x6e\xf1\x00\xf1\x6f\x1a\x6b\xf8\xff\x9d\xbf\x2e\x04\xc0\x92\x29\xee\xb2\x28\x84\xde\xb0\x1f\xd9\x96\xbd\x74\x7f\x01\x00\x00")
+
+func _1528395581_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395581_UpSql,
+		"1528395581_.up.sql",
+	)
+}
+
+func _1528395581_UpSql() (*asset, error) {
+	bytes, err := _1528395581_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395581_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x53, 0x60, 0x60, 0x45, 0x31, 0x2c, 0xc7, 0xe9, 0x41, 0xc5, 0x24, 0x1b, 0xed, 0xcc, 0x3, 0xf3, 0x41, 0x75, 0x74, 0xba, 0xe3, 0xe9, 0xa6, 0x71, 0x4, 0x63, 0x1f, 0xb4, 0x7f, 0xee, 0x8d, 0x3d}}
+	return a, nil
+}
+
+var __1528395582_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x73\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2c\x4b\x4d\x89\x2f\x4e\x4d\x2c\x4a\xce\x48\x2d\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4f\x4d\xca\xc8\xcf\xcf\x06\x2a\x4b\x2e\x4a\x2d\xb1\x26\xdf\x80\xd2\xa2\x1c\x32\x74\xe7\xe5\x97\x64\xa6\x55\xc6\x43\x0d\x01\x7a\xc0\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x00\x7b\x63\xc0\xd1\xd1\x00\x00\x00")
+
+func _1528395582_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395582_DownSql,
+		"1528395582_.down.sql",
+	)
+}
+
+func _1528395582_DownSql() (*asset, error) {
+	bytes, err := _1528395582_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395582_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf6, 0x29, 0x3e, 0xda, 0xfc, 0x65, 0x70, 0xd1, 0xfc, 0x4, 0x74, 0xcd, 0x5a, 0xd0, 0x76, 0x4c, 0x8b, 0xbb, 0x98, 0xa7, 0x37, 0xea, 0x3b, 0x3d, 0xc, 0x38, 0x1a, 0x2d, 0x87, 0x8e, 0x53, 0xe5}}
+	return a, nil
+}
+
+var __1528395582_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x95\xcc\x4b\x0a\xc3\x20\x10\x00\xd0\xbd\xa7\x98\x7b\xb8\x32\xd1\x96\xc0\xa8\x50\x74\x2d\x26\x9d\x90\x52\xc9\x80\xda\xdf\xed\xbb\xc9\x01\xda\x03\xbc\x37\x98\xf3\xe4\xa4\x10\x0a\x83\xb9\x40\x50\x03\x1a\x68\xf9\x49\xd7\xd4\x28\xd7\x65\xa3\x06\x4a\x6b\x18\x3d\x46\xeb\x60\xe7\x7e\x5b\x3f\xe9\x45\xf3\xc6\x7c\x87\x99\xb9\x50\xde\xc1\xf9\x00\x2e\x22\x82\x36\x27\x15\x31\xc0\x9a\x4b\x23\xf9\x63\x7a\x6c\xe9\x51\x0b\x74\x7a\xf7\x7f\x5d\xa3\xa5\x52\x3f\xa8\x18\xbd\xb5\x53\x90\xe2\x0b\xd1\xe9\x63\x92\xd9\x00\x00\x00")
+
+func _1528395582_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395582_UpSql,
+		"1528395582_.up.sql",
+	)
+}
+
+func _1528395582_UpSql() (*asset, error) {
+	bytes, err := _1528395582_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395582_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8c, 0x21, 0xf0, 0x53, 0x2c, 0xa2, 0x1c, 0x58, 0x25, 0x27, 0x12, 0x71, 0xfa, 0xd8, 0xe9, 0x82, 0xa7, 0x32, 0x65, 0x4d, 0xc7, 0x3a, 0x2, 0x1c, 0x40, 0xea, 0xf, 0x4, 0x94, 0xd8, 0x97, 0x6a}}
+	return a, nil
+}
+
+var __1528395583_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x73\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xc9\x2c\x4e\x2e\x2d\x2e\xce\xcc\xcf\x8b\x4f\xce\xcf\xcd\x4d\xcd\x2b\x29\x8e\x4f\xad\x28\x49\x2d\xca\x4b\xcc\xb1\x26\xa8\xa5\x24\xa3\x28\x35\x31\x05\x59\x07\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x00\xb8\xf3\x74\x3e\x76\x00\x00\x00")
+
+func _1528395583_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395583_DownSql,
+		"1528395583_.down.sql",
+	)
+}
+
+func _1528395583_DownSql() (*asset, error) {
+	bytes, err := _1528395583_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395583_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8a, 0xd2, 0xbb, 0xb6, 0xe0, 0x1f, 0x4a, 0x86, 0xe4, 0x7b, 0xce, 0x18, 0x80, 0xa3, 0x6b, 0x8c, 0xc, 0x5d, 0xf8, 0x44, 0x3f, 0x92, 0x23, 0xf, 0xe2, 0x1b, 0xa2, 0x9f, 0x76, 0x9d, 0xe1, 0xb6}}
+	return a, nil
+}
+
+var __1528395583_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x95\x92\xcf\x72\x82\x30\x10\x87\xef\x3c\xc5\x1e\x71\xc6\x37\xf0\x84\xb0\x76\x98\x62\x6c\x11\x66\xea\x29\x43\x61\xab\x99\x81\x84\x86\x50\xb5\x4f\xdf\x60\xfd\xd7\xaa\xd4\x32\x5c\xc8\x86\xdf\x66\xbf\x2f\x63\x7c\x08\xd9\xc8\x71\xfc\x18\xbd\x04\x21\xf1\xc6\x11\x42\x21\x9a\xbc\x6d\x1a\xa1\x24\x37\x2b\x4d\x59\xd1\x70\xda\x18\xd2\x32\x2b\xc1\x75\xc0\x3e\xdf\xcb\x5c\x14\xf0\x2a\x96\x42\x1a\x78\x8a\xc3\xa9\x17\x2f\xe0\x11\x17\x10\xe3\x04\x63\x64\x3e\xce\xaf\x24\xb9\xa2\x18\xc0\x8c\x41\x80\x11\xda\x86\xbe\x37\xf7\xbd\x00\x87\xbb\x54\x4d\xb5\xea\x32\x6d\x20\x2d\x49\x03\x9b\x25\xc0\xd2\x28\x3a\x4f\xec\xf6\xf4\x65\x34\xa4\x3f\x44\x4e\xdc\x6c\x6b\x02\x63\x8f\x7d\x4c\xf9\x59\xb7\x6d\xae\x54\xeb\xb6\x2c\xb9\xa6\xf7\x96\x1a\x73\x63\xcb\x81\xc4\x8d\x72\x6e\xa7\x34\x54\xf0\xcc\x80\x11\x95\x8d\xc9\xaa\x1a\xd6\xc2\xac\x76\x9f\xf0\xa9\x24\x9d\xe6\x0a\x70\xe2\xa5\x51\x02\x52\xad\xdd\x81\x33\xe8\xf1\x90\xab\xaa\x22\x69\x2e\x44\xec\xd7\xff\x65\xe2\x90\xd5\x87\xf1\x42\xf0\x35\x17\x3d\xf7\xc4\x3d\x06\xdc\x6c\xf1\x07\x48\x51\xd5\x4a\x5b\x92\xf0\xaa\x54\x49\x99\xbc\xa4\xf6\x96\x95\x0d\xed\xad\x6e\x65\x7e\x27\xf4\x5f\xcd\xdb\xba\xb8\xdb\x57\x67\x68\x2f\x28\x65\xe1\x73\x8a\x10\xb2\x00\x5f\x7a\x3d\xf1\x23\x08\x7e\x36\xb0\x7d\x37\x1d\x97\xbe\x3f\x4f\x08\x87\xe7\xac\x76\xb7\x64\x36\x9d\x86\xc9\xc8\xf9\x02\x64\xca\x93\x71\xbe\x03\x00\x00")
+
+func _1528395583_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395583_UpSql,
+		"1528395583_.up.sql",
+	)
+}
+
+func _1528395583_UpSql() (*asset, error) {
+	bytes, err := _1528395583_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395583_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2b, 0x7d, 0x87, 0xf8, 0x49, 0x6a, 0xea, 0x7a, 0xb4, 0xe0, 0xbd, 0xf2, 0x66, 0x20, 0x34, 0x57, 0x30, 0xaa, 0x3a, 0x2d, 0xf5, 0xf, 0x64, 0x97, 0xe9, 0x78, 0x59, 0x79, 0x95, 0x5b, 0x80, 0x97}}
+	return a, nil
+}
+
+var __1528395584_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x0d\xcc\x4b\x0a\x80\x20\x14\x00\xc0\xbd\xa7\x78\xf7\x68\xa5\x66\x21\xf8\x09\x35\x68\x27\x42\x8f\x90\xc2\x40\x5d\xd4\xed\x6b\x0e\x30\x4c\xcc\xd2\x0c\x84\x50\x15\x84\x83\x40\x99\x12\x50\xf1\xc8\xad\xd7\x37\xe2\xd3\xb1\xb4\x7c\x97\x58\xf1\xc2\xd4\xb0\xc1\xe8\xec\x02\xdc\xaa\x55\x1b\x90\x13\x88\x4d\xfa\xe0\xe1\x4d\xe5\xc4\x3d\xa6\xfe\x4f\xdc\x6a\x2d\xc3\x40\x3e\x4d\x03\xf6\xf7\x5a\x00\x00\x00")
+
+func _1528395584_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395584_DownSql,
+		"1528395584_.down.sql",
+	)
+}
+
+func _1528395584_DownSql() (*asset, error) {
+	bytes, err := _1528395584_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395584_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa5, 0x71, 0xae, 0x50, 0xeb, 0xcb, 0xad, 0xcd, 0x44, 0x40, 0x91, 0x64, 0xaf, 0xb, 0xa2, 0xdd, 0x56, 0xaa, 0x3b, 0xe, 0x43, 0xfe, 0x21, 0xac, 0xcd, 0x61, 0xcf, 0xef, 0x5c, 0x96, 0xb5, 0xa3}}
+	return a, nil
+}
+
+var __1528395584_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x1d\xcc\x41\x0a\x83\x30\x10\x05\xd0\x7d\x4e\x31\xf7\x70\x15\x35\x88\x90\x28\x48\xba\x0e\x81\x7e\xda\xd0\x1a\x25\x33\x60\xe3\xe9\x95\x2e\xdf\xe6\xb5\x66\x18\xa7\x46\x29\x6d\xbd\x59\xc8\xeb\xd6\x1a\x2a\x78\x25\x96\x52\x03\x7e\x82\xcc\x69\xcb\xa1\xe0\x8b\xc8\x60\xd2\x7d\x4f\xdd\x6c\x1f\x6e\xa2\x1a\xf3\x07\xcf\x10\x85\x24\xad\x60\x89\xeb\x4e\x47\x92\xf7\x9f\x74\x6e\x19\xf7\xdb\xcd\xce\x8d\xbe\x51\x17\x0d\x80\x7a\xd0\x68\x00\x00\x00")
+
+func _1528395584_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395584_UpSql,
+		"1528395584_.up.sql",
+	)
+}
+
+func _1528395584_UpSql() (*asset, error) {
+	bytes, err := _1528395584_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395584_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x86, 0xf2, 0xfd, 0x6d, 0x6f, 0xcd, 0xd3, 0x8d, 0x3b, 0xd4, 0x0, 0x52, 0x9f, 0x56, 0xe2, 0x8, 0xa9, 0x54, 0x7e, 0xfa, 0xa1, 0x8a, 0x70, 0xc6, 0x90, 0xe, 0x52, 0xab, 0xe4, 0x26, 0x35, 0xf}}
+	return a, nil
+}
+
+var __1528395585_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x73\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x4d\xcf\x2c\x2e\x29\xaa\x8c\x4f\xad\x28\x49\xcd\x2b\xce\xcc\xcf\x2b\x8e\xcf\xcc\x2d\xc8\x2f\x2a\x49\x4d\x41\x88\xc5\x67\xa6\x58\x73\x39\xfa\x84\xb8\x06\x29\x84\x38\x3a\xf9\xb8\x62\xd3\xa6\x00\x36\xdf\xd9\xdf\x27\xd4\xd7\x0f\xc9\x02\x1c\x86\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x00\xdf\x01\xa4\x89\x9e\x00\x00\x00")
+
+func _1528395585_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395585_DownSql,
+		"1528395585_.down.sql",
+	)
+}
+
+func _1528395585_DownSql() (*asset, error) {
+	bytes, err := _1528395585_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395585_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xdb, 0xcd, 0xe2, 0x75, 0xb5, 0x8f, 0xd7, 0x38, 0xaf, 0xcf, 0x56, 0x1f, 0xbc, 0x87, 0xe0, 0xba, 0x60, 0xa1, 0x8e, 0xdc, 0x6b, 0x96, 0xfc, 0x79, 0x6b, 0x9, 0x7e, 0xaa, 0xe1, 0xfb, 0xbf, 0x9c}}
+	return a, nil
+}
+
+var __1528395585_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6d\x8f\xcd\x0a\x02\x21\x14\x46\xf7\x3e\xc5\x5d\xd6\x33\xb8\x72\xf4\x52\x82\x3f\x64\x4a\xed\x24\x52\x42\x98\x66\x42\x5d\xd4\xdb\x37\xad\xda\xb8\xfc\x38\x87\x03\xdf\x84\x07\x69\x28\x21\x4c\x79\x74\xe0\xd9\xa4\x10\x6a\x7e\x94\xd6\xeb\x27\xe6\x77\xcf\x4b\x2b\xeb\xd2\x80\x09\x01\xdc\xaa\xa0\x0d\x94\xe7\x6b\xad\x3d\xa7\x3f\x8e\x25\xc1\xbd\xf4\x6d\x53\xc2\x1d\x32\x8f\x10\x8c\x3c\x05\x04\x69\x04\x5e\x47\xc1\x38\xae\x58\x33\x92\x77\x43\x79\x0f\x97\x23\x3a\x84\x94\xe7\xfc\x63\xb7\x0e\xf2\x0c\x26\x28\xb5\xfd\xe1\x56\x6b\xe9\x29\xf9\x02\x65\xd2\xf3\xc3\xe0\x00\x00\x00")
+
+func _1528395585_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395585_UpSql,
+		"1528395585_.up.sql",
+	)
+}
+
+func _1528395585_UpSql() (*asset, error) {
+	bytes, err := _1528395585_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395585_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb8, 0x44, 0x2f, 0xde, 0x69, 0x47, 0x23, 0x2e, 0x4d, 0x63, 0x98, 0x5b, 0x4f, 0x7a, 0x91, 0xfa, 0x67, 0xab, 0xe7, 0x5d, 0x54, 0xb1, 0x82, 0x4, 0xc8, 0xe5, 0x28, 0x64, 0x95, 0x3, 0xc3, 0x82}}
+	return a, nil
+}
+
+var __1528395586_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x73\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\xce\x2c\x49\x8d\x4f\xce\xcf\x4b\xcb\x4c\x8f\x4f\xce\x48\xcc\x4b\x4f\x2d\x06\x2a\x75\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x00\x47\x9f\x97\x39\x3b\x00\x00\x00")
+
+func _1528395586_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395586_DownSql,
+		"1528395586_.down.sql",
+	)
+}
+
+func _1528395586_DownSql() (*asset, error) {
+	bytes, err := _1528395586_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395586_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x34, 0x9f, 0xbd, 0x80, 0xf4, 0x8c, 0x7c, 0xfa, 0x81, 0x23, 0x4e, 0xd1, 0x1d, 0xf1, 0xb9, 0xc4, 0x25, 0xa6, 0xc7, 0x1e, 0x14, 0x70, 0xd9, 0x8f, 0x13, 0x45, 0xe4, 0x76, 0xc1, 0x66, 0xb0, 0xfc}}
+	return a, nil
+}
+
+var __1528395586_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\x90\x41\x4e\xc3\x40\x0c\x45\xf7\x39\x85\x77\x4d\x24\x6e\xd0\x55\x5a\x5c\x14\x35\x99\xa0\x34\x5d\x54\x08\x8d\x46\x89\x9b\x58\x6a\x67\xaa\x8c\x43\x11\x88\xbb\x33\x09\x82\x0d\x05\xef\xac\xff\xbe\xbf\xed\x15\x3e\x64\x6a\x19\x45\xeb\x0a\xd3\x1a\xa1\x4e\x57\x39\x82\x67\x21\xdd\x38\x7b\xe4\x4e\x37\xbd\xb1\x1d\x79\x88\x23\x08\xc5\x2d\x78\x1a\xd8\x9c\xe0\xb1\xca\x8a\xb4\x3a\xc0\x16\x0f\x77\xb3\x64\x46\xe9\xdd\xa0\xc7\xa0\xeb\x80\xb1\x15\xea\x68\x80\x0a\x37\x58\xa1\x5a\xe3\x0e\x26\xc9\xc7\xdc\x26\x50\x2a\xb8\xc7\x1c\x43\xe0\x0e\x6b\x50\xfb\x3c\xff\x9a\x71\x19\xe8\x85\xdd\xe8\x75\x33\xb0\x70\x13\x62\x84\x5e\x05\x54\x79\x13\x9a\xd6\xbc\x05\xfc\x67\xfe\xd3\x33\x9f\xd9\xea\x23\xd3\xa9\xf5\x33\xf1\xf4\xfc\xc3\x84\x6d\x37\xe9\x3e\xaf\x61\xf1\xfe\xb1\xf8\x0e\x21\x23\xc1\x60\x04\x84\xcf\xe4\xc5\x9c\x2f\x70\x65\xe9\xe7\x16\xde\x9c\xa5\xdf\x76\xeb\xae\x71\x12\x25\xd3\xbf\xcb\xa2\xc8\xea\x65\xf4\x09\x4b\x2c\x5e\x72\x80\x01\x00\x00")
+
+func _1528395586_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395586_UpSql,
+		"1528395586_.up.sql",
+	)
+}
+
+func _1528395586_UpSql() (*asset, error) {
+	bytes, err := _1528395586_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395586_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x11, 0xa9, 0x73, 0x6e, 0x81, 0xfe, 0xb1, 0xe5, 0x75, 0x73, 0xdc, 0x71, 0xc4, 0x16, 0x94, 0x7b, 0x23, 0x71, 0x93, 0x10, 0xd, 0x6a, 0xb8, 0x65, 0x6, 0x8b, 0x70, 0x36, 0x88, 0x37, 0xde, 0xd1}}
+	return a, nil
+}
+
+var __1528395587_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8d\x8f\xcb\x0a\x83\x30\x10\x45\xf7\xf9\x8a\xf9\x0f\x57\x3e\xa6\x6d\x20\x0f\x49\x46\xba\x0c\x12\x03\x4a\x6b\x04\x13\xa1\x9f\xdf\x45\xb3\x2a\x42\xbb\xbe\xf7\x1c\x38\x0d\x5e\xb9\xaa\x18\xeb\x50\x20\x21\x5c\x8c\x96\x30\x2d\xc9\x1f\x29\x2d\x5b\x74\x7e\x5b\xd7\x10\x73\x82\xfb\x0d\x0d\xc2\x78\xe4\x79\xdb\xdd\x91\xc2\xee\x96\x09\xb8\x05\x35\x08\x51\xb1\x5a\x10\x1a\xa0\xba\x11\x78\x4a\x77\x46\xf7\xd0\x6a\x65\xc9\xd4\x5c\xd1\xd9\xc7\x15\xb7\x9f\x83\x7f\xfc\x6d\x14\x83\x54\x10\x5e\x39\xec\x71\x7c\x16\xc5\x6f\xf8\xb3\x17\xfa\xab\xc9\x22\x81\xd2\x54\xc2\x58\xab\xa5\xe4\x54\xb1\x37\x6a\xd5\x2c\xef\x28\x01\x00\x00")
+
+func _1528395587_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395587_DownSql,
+		"1528395587_.down.sql",
+	)
+}
+
+func _1528395587_DownSql() (*asset, error) {
+	bytes, err := _1528395587_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395587_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf7, 0xb6, 0xd7, 0x37, 0x9d, 0x77, 0x50, 0xcd, 0x5e, 0xf8, 0xe9, 0x4e, 0xa, 0x14, 0xc2, 0x28, 0x92, 0xca, 0x94, 0x8b, 0xd6, 0xe9, 0xdd, 0xe7, 0x33, 0x7, 0x6f, 0xa3, 0x4a, 0x98, 0x52, 0x29}}
+	return a, nil
+}
+
+var __1528395587_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8d\x90\x4d\x0a\x83\x30\x18\x44\xf7\x9e\xe2\x5b\xc6\x33\xa4\x14\x34\x86\x36\x34\x3f\x45\xe3\x3a\x48\x0c\x28\xd6\x08\x26\x42\x8f\x5f\xa9\x75\x23\x85\x76\x3b\x33\x3c\x1e\x93\xd3\x0b\x93\x38\x49\x32\xae\x69\x09\x3a\xcb\x39\x85\xb6\x0f\x76\x09\xa1\x9f\xbc\xb1\xd3\x38\x3a\x1f\x03\x6c\x3d\x51\xbc\x16\x12\x9a\x25\x76\xd3\x6c\x96\xe0\x66\xd3\xb7\x50\x94\xea\x0e\x52\x69\x90\x35\xe7\xf8\x37\xaa\x28\x76\x90\x7b\x46\x37\xfb\xe6\x61\x36\x22\xc4\x35\xf8\x17\x20\x2b\x5d\x66\x4c\xea\x6f\x93\x0f\xcf\xd8\xce\xd9\x01\xc8\x95\x92\x1b\x20\x74\xd0\x66\xd5\x5b\x38\x85\xd3\x19\xd0\xd1\x64\x2f\xd3\xf5\x1b\xa2\x84\x60\x1a\x27\x2f\x85\x06\x7d\x1f\x2c\x01\x00\x00")
+
+func _1528395587_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395587_UpSql,
+		"1528395587_.up.sql",
+	)
+}
+
+func _1528395587_UpSql() (*asset, error) {
+	bytes, err := _1528395587_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395587_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x1c, 0x1f, 0xa5, 0x2f, 0xa4, 0x9c, 0xee, 0x69, 0x76, 0x44, 0x7b, 0x5a, 0x82, 0x8c, 0xae, 0x6b, 0xa2, 0x61, 0xc9, 0x6d, 0x41, 0x32, 0xb5, 0x32, 0x27, 0xcf, 0x3, 0xe, 0xde, 0xb7, 0x88, 0x10}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -5241,6 +5577,38 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395579_.down.sql": _1528395579_DownSql,
 
 	"1528395579_.up.sql": _1528395579_UpSql,
+
+	"1528395580_.down.sql": _1528395580_DownSql,
+
+	"1528395580_.up.sql": _1528395580_UpSql,
+
+	"1528395581_.down.sql": _1528395581_DownSql,
+
+	"1528395581_.up.sql": _1528395581_UpSql,
+
+	"1528395582_.down.sql": _1528395582_DownSql,
+
+	"1528395582_.up.sql": _1528395582_UpSql,
+
+	"1528395583_.down.sql": _1528395583_DownSql,
+
+	"1528395583_.up.sql": _1528395583_UpSql,
+
+	"1528395584_.down.sql": _1528395584_DownSql,
+
+	"1528395584_.up.sql": _1528395584_UpSql,
+
+	"1528395585_.down.sql": _1528395585_DownSql,
+
+	"1528395585_.up.sql": _1528395585_UpSql,
+
+	"1528395586_.down.sql": _1528395586_DownSql,
+
+	"1528395586_.up.sql": _1528395586_UpSql,
+
+	"1528395587_.down.sql": _1528395587_DownSql,
+
+	"1528395587_.up.sql": _1528395587_UpSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -5505,6 +5873,22 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395578_.up.sql":                                          {_1528395578_UpSql, map[string]*bintree{}},
 	"1528395579_.down.sql":                                        {_1528395579_DownSql, map[string]*bintree{}},
 	"1528395579_.up.sql":                                          {_1528395579_UpSql, map[string]*bintree{}},
+	"1528395580_.down.sql":                                        {_1528395580_DownSql, map[string]*bintree{}},
+	"1528395580_.up.sql":                                          {_1528395580_UpSql, map[string]*bintree{}},
+	"1528395581_.down.sql":                                        {_1528395581_DownSql, map[string]*bintree{}},
+	"1528395581_.up.sql":                                          {_1528395581_UpSql, map[string]*bintree{}},
+	"1528395582_.down.sql":                                        {_1528395582_DownSql, map[string]*bintree{}},
+	"1528395582_.up.sql":                                          {_1528395582_UpSql, map[string]*bintree{}},
+	"1528395583_.down.sql":                                        {_1528395583_DownSql, map[string]*bintree{}},
+	"1528395583_.up.sql":                                          {_1528395583_UpSql, map[string]*bintree{}},
+	"1528395584_.down.sql":                                        {_1528395584_DownSql, map[string]*bintree{}},
+	"1528395584_.up.sql":                                          {_1528395584_UpSql, map[string]*bintree{}},
+	"1528395585_.down.sql":                                        {_1528395585_DownSql, map[string]*bintree{}},
+	"1528395585_.up.sql":                                          {_1528395585_UpSql, map[string]*bintree{}},
+	"1528395586_.down.sql":                                        {_1528395586_DownSql, map[string]*bintree{}},
+	"1528395586_.up.sql":                                          {_1528395586_UpSql, map[string]*bintree{}},
+	"1528395587_.down.sql":                                        {_1528395587_DownSql, map[string]*bintree{}},
+	"1528395587_.up.sql":                                          {_1528395587_UpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -86,6 +86,9 @@ type ConfigSavedQuery struct {
 	UserID          *int32  `json:"userID"`
 	OrgID           *int32  `json:"orgID"`
 	SlackWebhookURL *string `json:"slackWebhookURL"`
+	NotifyWebhook   bool    `json:"notifyWebhook,omitempty"`
+	WebhookURL      *string `json:"webhookURL"`
+	WebhookSecret   *string `json:"webhookSecret"`
 }
 
 func (sq ConfigSavedQuery) Equals(other ConfigSavedQuery) bool {
@@ -3,6 +3,7 @@ package conf
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/sourcegraph/sourcegraph/schema"
@@ -21,6 +22,22 @@ func diff(before, after *Unified) (fields map[string]struct{}) {
 	return diff
 }
 
+// changedFields returns the sorted names of the fields that have different values
+// between the two configurations (as computed by diff). If before is nil, it returns
+// nil.
+func changedFields(before, after *Unified) []string {
+	if before == nil {
+		return nil
+	}
+	fieldSet := diff(before, after)
+	fields := make([]string, 0, len(fieldSet))
+	for fieldName := range fieldSet {
+		fields = append(fields, fieldName)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
 func diffStruct(before, after interface{}, prefix string) (fields map[string]struct{}) {
 	fields = make(map[string]struct{})
 	beforeFields := getJSONFields(before, prefix)
@@ -89,6 +89,26 @@ func TestDiff(t *testing.T) {
 	}
 }
 
+func TestChangedFields(t *testing.T) {
+	before := &Unified{
+		SiteConfiguration: schema.SiteConfiguration{MaxReposToSearch: 1},
+		Critical:          schema.CriticalConfiguration{ExternalURL: "a"},
+	}
+	after := &Unified{
+		SiteConfiguration: schema.SiteConfiguration{MaxReposToSearch: 2},
+		Critical:          schema.CriticalConfiguration{ExternalURL: "b"},
+	}
+	if got, want := changedFields(before, after), []string{"critical::externalURL", "maxReposToSearch"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v want %#v", got, want)
+	}
+	if got, want := changedFields(before, before), []string{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v want %#v", got, want)
+	}
+	if got := changedFields(nil, after); got != nil {
+		t.Errorf("got %#v want nil", got)
+	}
+}
+
 func toSlice(m map[string]struct{}) []string {
 	var s []string
 	for v := range m {
@@ -20,6 +20,17 @@ type ConfigurationSource interface {
 	Read(ctx context.Context) (conftypes.RawUnified, error)
 }
 
+// HistorySource is a ConfigurationSource that keeps a history of the accepted
+// configuration writes.
+type HistorySource interface {
+	ConfigurationSource
+
+	// WriteAndRecord updates the configuration like Write and records the write in
+	// the history. changedFields are the names of the fields whose values are changed
+	// by the write (nil if unknown).
+	WriteAndRecord(ctx context.Context, data conftypes.RawUnified, changedFields []string) error
+}
+
 // Server provides access and manages modifications to the site configuration.
 type Server struct {
 	Source ConfigurationSource
@@ -57,15 +68,22 @@ func (s *Server) Raw() conftypes.RawUnified {
 
 // Write writes the JSON config file to the config file's path. If the JSON configuration is
 // invalid, an error is returned.
+//
+// If the source is a HistorySource, the write is recorded in its history along with the
+// fields that it changed.
 func (s *Server) Write(ctx context.Context, input conftypes.RawUnified) error {
 	// Parse the configuration so that we can diff it (this also validates it
 	// is proper JSON).
-	_, err := ParseConfig(input)
+	newConfig, err := ParseConfig(input)
 	if err != nil {
 		return err
 	}
 
-	err = s.Source.Write(ctx, input)
+	if history, ok := s.Source.(HistorySource); ok {
+		err = history.WriteAndRecord(ctx, input, changedFields(s.store.LastValid(), newConfig))
+	} else {
+		err = s.Source.Write(ctx, input)
+	}
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,260 @@
+package confdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/pkg/conf/confdefaults"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+)
+
+// Change describes an accepted write to the critical and site config, including the
+// contents of both configs before and after the write.
+type Change struct {
+	ID               int32     // the unique ID of this change
+	AuthorUserID     *int32    // the user who made the change (nil if unknown or not made by a user)
+	PreviousCritical string    // the raw critical config before the change
+	PreviousSite     string    // the raw site config before the change
+	Critical         string    // the raw critical config after the change
+	Site             string    // the raw site config after the change
+	ChangedFields    []string  // the names of the configuration fields whose values changed
+	CreatedAt        time.Time // the date when the change was made
+}
+
+// ChangeNotFoundError occurs when a configuration change is not found.
+type ChangeNotFoundError struct {
+	ID int32
+}
+
+func (err ChangeNotFoundError) Error() string {
+	return fmt.Sprintf("site configuration change not found: %d", err.ID)
+}
+
+func (ChangeNotFoundError) NotFound() bool { return true }
+
+// ChangesCreate records a change to the critical and site config. The ID and CreatedAt
+// fields of change are ignored.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func ChangesCreate(ctx context.Context, change *Change) (*Change, error) {
+	return changesCreate(ctx, dbconn.Global, change)
+}
+
+// WriteAndRecordChange saves the critical and site config of change to the database and records
+// the change in a single transaction, so that every write is in the history. The ID,
+// PreviousCritical, PreviousSite, and CreatedAt fields of change are ignored.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func WriteAndRecordChange(ctx context.Context, change *Change) (created *Change, err error) {
+	tx, err := dbconn.Global.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			rollErr := tx.Rollback()
+			if rollErr != nil {
+				err = multierror.Append(err, rollErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	c := *change
+	if c.PreviousCritical, _, err = writeIfUpToDate(ctx, tx, typeCritical, confdefaults.Default.Critical, nil, c.Critical); err != nil {
+		return nil, err
+	}
+	if c.PreviousSite, _, err = writeIfUpToDate(ctx, tx, typeSite, confdefaults.Default.Site, nil, c.Site); err != nil {
+		return nil, err
+	}
+	return changesCreate(ctx, tx, &c)
+}
+
+// CriticalWriteAndRecordChange saves the given critical config "contents" like
+// CriticalCreateIfUpToDate and records the change in a single transaction. The change has no
+// author, and its changed fields are the top-level critical config properties whose values
+// changed.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func CriticalWriteAndRecordChange(ctx context.Context, lastID *int32, contents string) (latest *CriticalConfig, err error) {
+	tx, err := dbconn.Global.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			rollErr := tx.Rollback()
+			if rollErr != nil {
+				err = multierror.Append(err, rollErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	previousCritical, critical, err := writeIfUpToDate(ctx, tx, typeCritical, confdefaults.Default.Critical, lastID, contents)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := addDefault(ctx, tx, typeSite, confdefaults.Default.Site); err != nil {
+		return nil, err
+	}
+	site, err := getLatest(ctx, tx, typeSite)
+	if err != nil {
+		return nil, err
+	}
+	changedFields, err := changedTopLevelFields("critical::", previousCritical, contents)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := changesCreate(ctx, tx, &Change{
+		PreviousCritical: previousCritical,
+		PreviousSite:     site.Contents,
+		Critical:         contents,
+		Site:             site.Contents,
+		ChangedFields:    changedFields,
+	}); err != nil {
+		return nil, err
+	}
+	return (*CriticalConfig)(critical), nil
+}
+
+// writeIfUpToDate saves the config contents iff lastID (if non-nil) is the ID of the latest
+// config. It returns the previous contents of the config and the saved config.
+func writeIfUpToDate(ctx context.Context, tx queryable, configType configType, defaultContents string, lastID *int32, contents string) (previous string, latest *Config, err error) {
+	newLastID, err := addDefault(ctx, tx, configType, defaultContents)
+	if err != nil {
+		return "", nil, err
+	}
+	if newLastID != nil {
+		lastID = newLastID
+	}
+	previousConfig, err := getLatest(ctx, tx, configType)
+	if err != nil {
+		return "", nil, err
+	}
+	latest, err = createIfUpToDate(ctx, tx, configType, lastID, contents)
+	if err != nil {
+		return "", nil, err
+	}
+	return previousConfig.Contents, latest, nil
+}
+
+// changedTopLevelFields returns the sorted names (with the given prefix) of the top-level
+// properties whose values differ between the two JSON objects.
+func changedTopLevelFields(prefix, before, after string) ([]string, error) {
+	var b, a map[string]interface{}
+	if err := jsonc.Unmarshal(before, &b); err != nil {
+		return nil, err
+	}
+	if err := jsonc.Unmarshal(after, &a); err != nil {
+		return nil, err
+	}
+	fields := []string{}
+	for name, v := range b {
+		if w, ok := a[name]; !ok || !reflect.DeepEqual(v, w) {
+			fields = append(fields, prefix+name)
+		}
+	}
+	for name := range a {
+		if _, ok := b[name]; !ok {
+			fields = append(fields, prefix+name)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+func changesCreate(ctx context.Context, tx queryable, change *Change) (*Change, error) {
+	created := *change
+	if created.ChangedFields == nil {
+		created.ChangedFields = []string{}
+	}
+	if err := tx.QueryRowContext(ctx,
+		"INSERT INTO site_config_changes(author_user_id, previous_critical, previous_site, critical, site, changed_fields) VALUES($1, $2, $3, $4, $5, $6) RETURNING id, created_at",
+		created.AuthorUserID, created.PreviousCritical, created.PreviousSite, created.Critical, created.Site, pq.Array(created.ChangedFields),
+	).Scan(&created.ID, &created.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ChangesGetByID returns the configuration change with the given ID.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func ChangesGetByID(ctx context.Context, id int32) (*Change, error) {
+	changes, err := listChanges(ctx, sqlf.Sprintf("WHERE id=%d LIMIT 1", id))
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, ChangeNotFoundError{ID: id}
+	}
+	return changes[0], nil
+}
+
+// ChangesListOptions contains options for listing configuration changes.
+type ChangesListOptions struct {
+	Limit int // the maximum number of changes to return (0 means no limit)
+}
+
+// ChangesList returns the configuration changes, most recent first.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func ChangesList(ctx context.Context, opt ChangesListOptions) ([]*Change, error) {
+	cond := sqlf.Sprintf("ORDER BY id DESC")
+	if opt.Limit > 0 {
+		cond = sqlf.Sprintf("%s LIMIT %d", cond, opt.Limit)
+	}
+	return listChanges(ctx, cond)
+}
+
+// ChangesCount counts all configuration changes.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func ChangesCount(ctx context.Context) (int, error) {
+	var count int
+	err := dbconn.Global.QueryRowContext(ctx, "SELECT COUNT(*) FROM site_config_changes").Scan(&count)
+	return count, err
+}
+
+func listChanges(ctx context.Context, cond *sqlf.Query) ([]*Change, error) {
+	q := sqlf.Sprintf("SELECT id, author_user_id, previous_critical, previous_site, critical, site, changed_fields, created_at FROM site_config_changes %s", cond)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*Change
+	for rows.Next() {
+		var (
+			c            Change
+			authorUserID sql.NullInt64
+		)
+		if err := rows.Scan(&c.ID, &authorUserID, &c.PreviousCritical, &c.PreviousSite, &c.Critical, &c.Site, pq.Array(&c.ChangedFields), &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		if authorUserID.Valid {
+			id := int32(authorUserID.Int64)
+			c.AuthorUserID = &id
+		}
+		changes = append(changes, &c)
+	}
+	return changes, rows.Err()
+}
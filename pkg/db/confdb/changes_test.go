@@ -0,0 +1,171 @@
+package confdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/conf/confdefaults"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+)
+
+func TestChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+
+	change1, err := ChangesCreate(ctx, &Change{
+		PreviousCritical: `{}`,
+		PreviousSite:     `{}`,
+		Critical:         `{}`,
+		Site:             `{"maxReposToSearch": 1}`,
+		ChangedFields:    []string{"maxReposToSearch"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	change2, err := ChangesCreate(ctx, &Change{
+		PreviousCritical: `{}`,
+		PreviousSite:     `{"maxReposToSearch": 1}`,
+		Critical:         `{"externalURL": "https://example.com"}`,
+		Site:             `{"maxReposToSearch": 1}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change2.ChangedFields == nil || len(change2.ChangedFields) != 0 {
+		t.Errorf("got changed fields %#v, want empty", change2.ChangedFields)
+	}
+
+	got, err := ChangesGetByID(ctx, change1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, change1) {
+		t.Errorf("got %+v, want %+v", got, change1)
+	}
+
+	if _, err := ChangesGetByID(ctx, change2.ID+1); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want NotFound", err)
+	}
+
+	changes, err := ChangesList(ctx, ChangesListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*Change{change2, change1}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("got %+v, want %+v", changes, want)
+	}
+
+	changes, err = ChangesList(ctx, ChangesListOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*Change{change2}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("got %+v, want %+v", changes, want)
+	}
+
+	count, err := ChangesCount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2; count != want {
+		t.Errorf("got count %d, want %d", count, want)
+	}
+}
+
+func TestWriteAndRecordChange(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+
+	change, err := WriteAndRecordChange(ctx, &Change{
+		Critical:      `{"externalURL": "https://example.com"}`,
+		Site:          `{"maxReposToSearch": 1}`,
+		ChangedFields: []string{"maxReposToSearch"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.PreviousCritical != confdefaults.Default.Critical || change.PreviousSite != confdefaults.Default.Site {
+		t.Errorf("got previous contents %q and %q, want the defaults", change.PreviousCritical, change.PreviousSite)
+	}
+
+	critical, err := CriticalGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	site, err := SiteGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if critical.Contents != change.Critical || site.Contents != change.Site {
+		t.Errorf("got config %q and %q, want %q and %q", critical.Contents, site.Contents, change.Critical, change.Site)
+	}
+
+	// An invalid write is neither saved nor recorded.
+	if _, err := WriteAndRecordChange(ctx, &Change{Critical: `{}`, Site: `{`}); err == nil {
+		t.Fatal("got nil error, want error")
+	}
+	if critical, err := CriticalGetLatest(ctx); err != nil {
+		t.Fatal(err)
+	} else if critical.Contents != change.Critical {
+		t.Errorf("got critical config %q, want %q", critical.Contents, change.Critical)
+	}
+	if count, err := ChangesCount(ctx); err != nil {
+		t.Fatal(err)
+	} else if count != 1 {
+		t.Errorf("got count %d, want 1", count)
+	}
+}
+
+func TestCriticalWriteAndRecordChange(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+
+	latest, err := CriticalGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	critical, err := CriticalWriteAndRecordChange(ctx, &latest.ID, `{"externalURL": "https://example.com"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := ChangesList(ctx, ChangesListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("got %d changes, want 1", len(changes))
+	}
+	if c := changes[0]; c.PreviousCritical != latest.Contents || c.Critical != critical.Contents || c.Site != c.PreviousSite || c.AuthorUserID != nil {
+		t.Errorf("unexpected change %+v", c)
+	}
+
+	// An edit based on an old critical config is neither saved nor recorded.
+	if _, err := CriticalWriteAndRecordChange(ctx, &latest.ID, `{}`); err != ErrNewerEdit {
+		t.Errorf("got error %v, want %v", err, ErrNewerEdit)
+	}
+	if count, err := ChangesCount(ctx); err != nil {
+		t.Fatal(err)
+	} else if count != 1 {
+		t.Errorf("got count %d, want 1", count)
+	}
+}
+
+func TestChangedTopLevelFields(t *testing.T) {
+	got, err := changedTopLevelFields("critical::", `{"a": 1, "b": [1], "c": 3}`, `{"a": 1, "b": [2], "d": 4, /* comment */}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"critical::b", "critical::c", "critical::d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
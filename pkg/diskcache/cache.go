@@ -35,6 +35,12 @@ type Store struct {
 	// BeforeEvict, when non-nil, is a function to call before evicting a file.
 	// It is passed the path to the file to be evicted.
 	BeforeEvict func(string)
+
+	// CompanionSuffixes are the suffixes of files which are stored next to a
+	// cached file and derived from it, at the cached file's path followed by
+	// the suffix. Their sizes count toward the size of the cached file in
+	// Evict. BeforeEvict is responsible for removing them.
+	CompanionSuffixes []string
 }
 
 // File is an os.File, but includes the Path
@@ -138,6 +144,22 @@ func (s *Store) OpenWithPath(ctx context.Context, key string, fetcher FetcherWit
 	}
 }
 
+// OpenCached opens the file for key if it is already in the cache. Unlike
+// Open it never fetches. If key is not in the cache the returned error
+// satisfies os.IsNotExist.
+func (s *Store) OpenCached(key string) (*File, error) {
+	if s.Dir == "" {
+		return nil, errors.New("diskcache.Store.Dir must be set")
+	}
+	path := s.path(key)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	touch(path)
+	return &File{File: f, Path: path}, nil
+}
+
 // path returns the path for key.
 func (s *Store) path(key string) string {
 	// path uses a sha256 hash of the key since we want to use it for the
@@ -227,11 +249,24 @@ func (s *Store) Evict(maxCacheSizeBytes int64) (stats EvictStats, err error) {
 		return stats, errors.Wrapf(err, "failed to ReadDir %s", s.Dir)
 	}
 
+	// entrySize is the size of a zip including its companion files.
+	sizes := make(map[string]int64, len(list))
+	for _, fi := range list {
+		sizes[fi.Name()] = fi.Size()
+	}
+	entrySize := func(fi os.FileInfo) int64 {
+		size := fi.Size()
+		for _, suffix := range s.CompanionSuffixes {
+			size += sizes[fi.Name()+suffix]
+		}
+		return size
+	}
+
 	// Sum up the total size of all zips
 	var size int64
 	for _, fi := range list {
 		if isZip(fi) {
-			size += fi.Size()
+			size += entrySize(fi)
 		}
 	}
 	stats.CacheSize = size
@@ -263,7 +298,7 @@ func (s *Store) Evict(maxCacheSizeBytes int64) (stats EvictStats, err error) {
 			continue
 		}
 		stats.Evicted++
-		size -= fi.Size()
+		size -= entrySize(fi)
 	}
 
 	return stats, nil
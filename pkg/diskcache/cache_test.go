@@ -6,7 +6,10 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestOpen(t *testing.T) {
@@ -61,3 +64,90 @@ func TestOpen(t *testing.T) {
 		t.Fatal("Item was not properly evicted")
 	}
 }
+
+func TestOpenCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diskcache_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := &Store{
+		Dir:       dir,
+		Component: "test",
+	}
+
+	if _, err := store.OpenCached("key"); !os.IsNotExist(err) {
+		t.Fatalf("expected a not exist error for an empty cache, got %v", err)
+	}
+
+	f, err := store.Open(context.Background(), "key", func(ctx context.Context) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader([]byte("foobar"))), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	f, err = store.OpenCached("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ioutil.ReadAll(f.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "foobar" {
+		t.Errorf("got %q, want %q", got, "foobar")
+	}
+}
+
+func TestEvict_companionFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diskcache_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var evicted []string
+	store := &Store{
+		Dir:               dir,
+		Component:         "test",
+		CompanionSuffixes: []string{".idx"},
+		BeforeEvict: func(path string) {
+			evicted = append(evicted, filepath.Base(path))
+			os.Remove(path + ".idx")
+		},
+	}
+
+	write := func(name string, size int, mtime time.Time) {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write("a.zip", 10, now.Add(-2*time.Hour))
+	write("a.zip.idx", 100, now)
+	write("b.zip", 10, now.Add(-time.Hour))
+
+	stats, err := store.Evict(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.CacheSize != 120 || stats.Evicted != 0 {
+		t.Errorf("got %+v, want CacheSize 120 and no evictions", stats)
+	}
+
+	// Evicting a.zip and its companion file is enough to get under the limit.
+	if _, err := store.Evict(50); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.zip"}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("got evicted %v, want %v", evicted, want)
+	}
+}
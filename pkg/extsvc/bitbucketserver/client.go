@@ -56,6 +56,14 @@ type Client struct {
 	// version 5.4 and older). If both Token and Username/Password are specified, Token is used.
 	Username, Password string
 
+	// oauth, if set, is used to sign requests instead of the Token or Username/Password
+	// credentials. See SetOAuth.
+	oauth *oauthConsumer
+
+	// sudo is the username of the user the client impersonates. It is only honored when oauth is
+	// set. See Sudo.
+	sudo string
+
 	// RateLimit is the self-imposed rate limiter (since Bitbucket does not have a concept
 	// of rate limiting in HTTP response headers).
 	RateLimit *rate.Limiter
@@ -79,6 +87,42 @@ func NewClient(url *url.URL, httpClient httpcli.Doer) *Client {
 	}
 }
 
+// Sudo returns a copy of the Client authenticated as the Bitbucket Server user with the given
+// username. This only works when the Client uses OAuth (see SetOAuth) and the application link of
+// the consumer allows user impersonation.
+func (c *Client) Sudo(username string) (*Client, error) {
+	if c.oauth == nil {
+		return nil, errors.New("bitbucketserver.Client: OAuth not configured")
+	}
+	sc := *c
+	sc.sudo = username
+	return &sc, nil
+}
+
+// Users returns a page of users, optionally matching the given filter (which matches
+// against the username, display name and email address of a user).
+func (c *Client) Users(ctx context.Context, pageToken *PageToken, filter string) ([]*User, *PageToken, error) {
+	qry := pageToken.Values()
+	if filter != "" {
+		qry.Set("filter", filter)
+	}
+
+	u := fmt.Sprintf("rest/api/1.0/users?%s", qry.Encode())
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var resp struct {
+		*PageToken
+		Values []*User
+	}
+	err = c.do(ctx, req, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Values, resp.PageToken, nil
+}
+
 func (c *Client) Repo(ctx context.Context, projectKey, repoSlug string) (*Repo, error) {
 	u := fmt.Sprintf("rest/api/1.0/projects/%s/repos/%s", projectKey, repoSlug)
 	req, err := http.NewRequest("GET", u, nil)
@@ -145,8 +189,12 @@ func (c *Client) do(ctx context.Context, req *http.Request, result interface{})
 	req.URL = c.URL.ResolveReference(req.URL)
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
-	// Authenticate request, preferring token.
-	if c.Token != "" {
+	// Authenticate request, preferring OAuth, then token.
+	if c.oauth != nil {
+		if err := c.oauth.sign(req, c.sudo); err != nil {
+			return err
+		}
+	} else if c.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	} else if c.Username != "" || c.Password != "" {
 		req.SetBasicAuth(c.Username, c.Password)
@@ -286,6 +334,16 @@ type Project struct {
 	} `json:"links"`
 }
 
+type User struct {
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+	ID           int    `json:"id"`
+	DisplayName  string `json:"displayName"`
+	Active       bool   `json:"active"`
+	Slug         string `json:"slug"`
+	Type         string `json:"type"`
+}
+
 // IsNotFound reports whether err is a Bitbucket Server API not found error.
 func IsNotFound(err error) bool {
 	switch e := errors.Cause(err).(type) {
@@ -1,5 +1,37 @@
 package bitbucketserver
 
+import (
+	"net/url"
+
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+)
+
 // ServiceType is the (api.ExternalRepoSpec).ServiceType value for Bitbucket Server projects. The
 // ServiceID value is the base URL to the Bitbucket Server instance.
 const ServiceType = "bitbucketServer"
+
+type CodeHost struct {
+	id      string
+	baseURL *url.URL
+}
+
+var _ extsvc.CodeHost = ((*CodeHost)(nil))
+
+func NewCodeHost(baseURL *url.URL) *CodeHost {
+	return &CodeHost{
+		id:      extsvc.NormalizeBaseURL(baseURL).String(),
+		baseURL: baseURL,
+	}
+}
+
+func (h *CodeHost) ServiceID() string {
+	return h.id
+}
+
+func (h *CodeHost) ServiceType() string {
+	return ServiceType
+}
+
+func (h *CodeHost) BaseURL() *url.URL {
+	return h.baseURL
+}
@@ -0,0 +1,158 @@
+package bitbucketserver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SetOAuth enables OAuth authentication in a Client, using the given consumer key to identify
+// with the Bitbucket Server API and the given signing key to sign requests. The signing key must
+// be a base64 encoded PEM encoded RSA private key, whose public key is configured in the incoming
+// authentication settings of a Bitbucket Server application link.
+//
+// Requests are signed with the two-legged flavor of OAuth 1.0a, which Bitbucket Server supports
+// for application links. See
+// https://confluence.atlassian.com/bitbucketserver/linking-bitbucket-server-with-jira-776640408.html.
+func (c *Client) SetOAuth(consumerKey, signingKey string) error {
+	key, err := parseSigningKey(signingKey)
+	if err != nil {
+		return err
+	}
+	c.oauth = &oauthConsumer{consumerKey: consumerKey, signingKey: key}
+	return nil
+}
+
+func parseSigningKey(signingKey string) (*rsa.PrivateKey, error) {
+	pemKey, err := base64.StdEncoding.DecodeString(signingKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing key is not valid base64")
+	}
+
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse signing key")
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA private key")
+	}
+	return rsaKey, nil
+}
+
+// oauthConsumer signs requests with OAuth 1.0a using the RSA-SHA1 signature method.
+type oauthConsumer struct {
+	consumerKey string
+	signingKey  *rsa.PrivateKey
+}
+
+// sign sets the OAuth Authorization header of req. If sudo is non-empty, the request is made on
+// behalf of the Bitbucket Server user with that username.
+func (o *oauthConsumer) sign(req *http.Request, sudo string) error {
+	if sudo != "" {
+		q := req.URL.Query()
+		q.Set("xoauth_requestor_id", sudo)
+		req.URL.RawQuery = q.Encode()
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"oauth_consumer_key":     o.consumerKey,
+		"oauth_nonce":            hex.EncodeToString(nonce),
+		"oauth_signature_method": "RSA-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_version":          "1.0",
+	}
+
+	sum := sha1.Sum([]byte(signatureBase(req, params)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, o.signingKey, crypto.SHA1, sum[:])
+	if err != nil {
+		return err
+	}
+	params["oauth_signature"] = base64.StdEncoding.EncodeToString(sig)
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", oauthEscape(k), oauthEscape(params[k])))
+	}
+	req.Header.Set("Authorization", "OAuth "+strings.Join(pairs, ", "))
+	return nil
+}
+
+// signatureBase returns the OAuth 1.0a signature base string of req with the given OAuth
+// protocol parameters. See https://tools.ietf.org/html/rfc5849#section-3.4.1.
+func signatureBase(req *http.Request, oauthParams map[string]string) string {
+	var pairs [][2]string
+	for k, vs := range req.URL.Query() {
+		for _, v := range vs {
+			pairs = append(pairs, [2]string{oauthEscape(k), oauthEscape(v)})
+		}
+	}
+	for k, v := range oauthParams {
+		pairs = append(pairs, [2]string{oauthEscape(k), oauthEscape(v)})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	params := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		params = append(params, p[0]+"="+p[1])
+	}
+
+	baseURL := strings.ToLower(req.URL.Scheme) + "://" + strings.ToLower(req.URL.Host) + req.URL.EscapedPath()
+
+	return strings.Join([]string{
+		strings.ToUpper(req.Method),
+		oauthEscape(baseURL),
+		oauthEscape(strings.Join(params, "&")),
+	}, "&")
+}
+
+// oauthEscape percent-encodes s as specified in https://tools.ietf.org/html/rfc5849#section-3.6.
+func oauthEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
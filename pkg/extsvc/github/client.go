@@ -216,7 +216,7 @@ func (c *Client) do(ctx context.Context, token string, req *http.Request, result
 
 	defer resp.Body.Close()
 	c.RateLimit.Update(resp.Header)
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		var err APIError
 		if decErr := json.NewDecoder(resp.Body).Decode(&err); decErr != nil {
 			log15.Warn("Failed to decode error response from github API", "error", decErr)
@@ -225,6 +225,9 @@ func (c *Client) do(ctx context.Context, token string, req *http.Request, result
 		err.Code = resp.StatusCode
 		return &err
 	}
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
 	return json.NewDecoder(resp.Body).Decode(result)
 }
 
@@ -241,6 +244,24 @@ func (c *Client) requestGet(ctx context.Context, token, requestURI string, resul
 	return c.do(ctx, token, req, result)
 }
 
+// requestJSON sends a request with the JSON encoding of body (if non-nil) to
+// the REST API and decodes the response into result (if non-nil).
+func (c *Client) requestJSON(ctx context.Context, token, method, requestURI string, body, result interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, requestURI, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, token, req, result)
+}
+
 func (c *Client) requestGraphQL(ctx context.Context, token, query string, vars map[string]interface{}, result interface{}) (err error) {
 	reqBody, err := json.Marshal(struct {
 		Query     string                 `json:"query"`
@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// PullRequest is a GitHub pull request.
+type PullRequest struct {
+	Number  int    `json:"number"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+// PullRequestReviewComment is a comment on the diff of a GitHub pull request.
+type PullRequestReviewComment struct {
+	ID          int64  `json:"id"`
+	InReplyToID int64  `json:"in_reply_to_id,omitempty"`
+	Body        string `json:"body"`
+	Path        string `json:"path"`
+	HTMLURL     string `json:"html_url"`
+	User        struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+	} `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewPullRequestReviewComment describes a new comment on the diff of a pull
+// request. See https://developer.github.com/v3/pulls/comments/#create-a-comment.
+type NewPullRequestReviewComment struct {
+	Body     string `json:"body"`
+	CommitID string `json:"commit_id"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`           // line (one-based) of the file that the comment applies to
+	Side     string `json:"side,omitempty"` // "RIGHT" (the default) or "LEFT"
+}
+
+// ListOpenPullRequestsForBranch lists the open pull requests in the repository
+// owner/name whose head is the given branch of the same repository.
+func (c *Client) ListOpenPullRequestsForBranch(ctx context.Context, owner, name, branch string) ([]*PullRequest, error) {
+	q := url.Values{"state": {"open"}, "head": {owner + ":" + branch}, "per_page": {"100"}}
+	var prs []*PullRequest
+	if err := c.requestGet(ctx, "", fmt.Sprintf("/repos/%s/%s/pulls?%s", owner, name, q.Encode()), &prs); err != nil {
+		return nil, err
+	}
+	return prs, nil
+}
+
+// ListPullRequestReviewComments lists all review comments on the pull request.
+func (c *Client) ListPullRequestReviewComments(ctx context.Context, owner, name string, number int) ([]*PullRequestReviewComment, error) {
+	const perPage = 100
+	var all []*PullRequestReviewComment
+	for page := 1; ; page++ {
+		var comments []*PullRequestReviewComment
+		if err := c.requestGet(ctx, "", fmt.Sprintf("/repos/%s/%s/pulls/%d/comments?per_page=%d&page=%d", owner, name, number, perPage, page), &comments); err != nil {
+			return nil, err
+		}
+		all = append(all, comments...)
+		if len(comments) < perPage {
+			return all, nil
+		}
+	}
+}
+
+// CreatePullRequestReviewComment creates a new review comment on the pull
+// request.
+func (c *Client) CreatePullRequestReviewComment(ctx context.Context, owner, name string, number int, comment *NewPullRequestReviewComment) (*PullRequestReviewComment, error) {
+	var created PullRequestReviewComment
+	if err := c.requestJSON(ctx, "", "POST", fmt.Sprintf("/repos/%s/%s/pulls/%d/comments", owner, name, number), comment, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ReplyToPullRequestReviewComment creates a reply to the top-level review
+// comment with the given ID on the pull request.
+func (c *Client) ReplyToPullRequestReviewComment(ctx context.Context, owner, name string, number int, commentID int64, body string) (*PullRequestReviewComment, error) {
+	var created PullRequestReviewComment
+	reqBody := struct {
+		Body string `json:"body"`
+	}{Body: body}
+	if err := c.requestJSON(ctx, "", "POST", fmt.Sprintf("/repos/%s/%s/pulls/%d/comments/%d/replies", owner, name, number, commentID), reqBody, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdatePullRequestReviewComment updates the body of the review comment.
+func (c *Client) UpdatePullRequestReviewComment(ctx context.Context, owner, name string, commentID int64, body string) (*PullRequestReviewComment, error) {
+	var updated PullRequestReviewComment
+	reqBody := struct {
+		Body string `json:"body"`
+	}{Body: body}
+	if err := c.requestJSON(ctx, "", "PATCH", fmt.Sprintf("/repos/%s/%s/pulls/comments/%d", owner, name, commentID), reqBody, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeletePullRequestReviewComment deletes the review comment.
+func (c *Client) DeletePullRequestReviewComment(ctx context.Context, owner, name string, commentID int64) error {
+	return c.requestJSON(ctx, "", "DELETE", fmt.Sprintf("/repos/%s/%s/pulls/comments/%d", owner, name, commentID), nil, nil)
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type mockHTTPRequestRecorder struct {
+	mockHTTPResponseBody
+	method, path, query, body string
+}
+
+func (s *mockHTTPRequestRecorder) Do(req *http.Request) (*http.Response, error) {
+	s.method, s.path, s.query = req.Method, req.URL.Path, req.URL.RawQuery
+	if req.Body != nil {
+		b, _ := ioutil.ReadAll(req.Body)
+		s.body = string(b)
+	}
+	return s.mockHTTPResponseBody.Do(req)
+}
+
+func TestClient_ListOpenPullRequestsForBranch(t *testing.T) {
+	mock := mockHTTPRequestRecorder{mockHTTPResponseBody: mockHTTPResponseBody{
+		responseBody: `[{"number": 7, "state": "open", "head": {"ref": "b", "sha": "c0ffee"}}]`,
+	}}
+	c := newTestClient(t, &mock)
+
+	prs, err := c.ListOpenPullRequestsForBranch(context.Background(), "o", "r", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 1 || prs[0].Number != 7 || prs[0].Head.SHA != "c0ffee" {
+		t.Errorf("unexpected pull requests %+v", prs)
+	}
+	if want := "/repos/o/r/pulls"; mock.path != want {
+		t.Errorf("got path %q, want %q", mock.path, want)
+	}
+	if want := "head=o%3Ab&per_page=100&state=open"; mock.query != want {
+		t.Errorf("got query %q, want %q", mock.query, want)
+	}
+}
+
+func TestClient_CreatePullRequestReviewComment(t *testing.T) {
+	mock := mockHTTPRequestRecorder{mockHTTPResponseBody: mockHTTPResponseBody{
+		responseBody: `{"id": 3, "body": "hello", "user": {"login": "u"}}`,
+		status:       http.StatusCreated,
+	}}
+	c := newTestClient(t, &mock)
+
+	comment, err := c.CreatePullRequestReviewComment(context.Background(), "o", "r", 7, &NewPullRequestReviewComment{
+		Body:     "hello",
+		CommitID: "c0ffee",
+		Path:     "a.go",
+		Line:     4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.ID != 3 || comment.User.Login != "u" {
+		t.Errorf("unexpected comment %+v", comment)
+	}
+	if mock.method != "POST" || mock.path != "/repos/o/r/pulls/7/comments" {
+		t.Errorf("unexpected request %s %s", mock.method, mock.path)
+	}
+	if want := `{"body":"hello","commit_id":"c0ffee","path":"a.go","line":4}`; mock.body != want {
+		t.Errorf("got body %s, want %s", mock.body, want)
+	}
+}
+
+func TestClient_DeletePullRequestReviewComment(t *testing.T) {
+	mock := mockHTTPRequestRecorder{mockHTTPResponseBody: mockHTTPResponseBody{status: http.StatusNoContent}}
+	c := newTestClient(t, &mock)
+
+	if err := c.DeletePullRequestReviewComment(context.Background(), "o", "r", 3); err != nil {
+		t.Fatal(err)
+	}
+	if mock.method != "DELETE" || mock.path != "/repos/o/r/pulls/comments/3" {
+		t.Errorf("unexpected request %s %s", mock.method, mock.path)
+	}
+
+	mock.status = http.StatusNotFound
+	if err := c.DeletePullRequestReviewComment(context.Background(), "o", "r", 3); !IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
+
+func TestClient_ListPullRequestReviewComments_paginated(t *testing.T) {
+	page := "[" + strings.TrimSuffix(strings.Repeat(`{"id": 1},`, 100), ",") + "]"
+	mock := mockHTTPRequestRecorder{mockHTTPResponseBody: mockHTTPResponseBody{responseBody: page}}
+	c := newTestClient(t, &mock)
+
+	// The mock always returns a full page, so stop it after the second page.
+	pages := 0
+	c.httpClient = doerFunc(func(req *http.Request) (*http.Response, error) {
+		pages++
+		if pages == 2 {
+			mock.responseBody = `[{"id": 2}]`
+		}
+		return mock.Do(req)
+	})
+	comments, err := c.ListPullRequestReviewComments(context.Background(), "o", "r", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 101 || pages != 2 {
+		t.Errorf("got %d comments in %d pages, want 101 in 2", len(comments), pages)
+	}
+	if want := "per_page=100&page=2"; mock.query != want {
+		t.Errorf("got query %q, want %q", mock.query, want)
+	}
+}
+
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
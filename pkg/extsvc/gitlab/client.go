@@ -202,9 +202,12 @@ func (c *Client) do(ctx context.Context, req *http.Request, result interface{})
 	}
 	defer resp.Body.Close()
 	c.RateLimit.Update(resp.Header)
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, errors.Wrap(httpError(resp.StatusCode), fmt.Sprintf("unexpected response from GitLab API (%s)", req.URL))
 	}
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.Header, nil
+	}
 
 	return resp.Header, json.NewDecoder(resp.Body).Decode(result)
 }
@@ -0,0 +1,166 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MergeRequest is a GitLab merge request (equivalent to a GitHub pull request).
+type MergeRequest struct {
+	ID           int    `json:"id"`
+	IID          int    `json:"iid"` // ID of the merge request within its project
+	State        string `json:"state"`
+	SourceBranch string `json:"source_branch"`
+	WebURL       string `json:"web_url"`
+
+	// DiffRefs is only returned when getting a single merge request.
+	DiffRefs *DiffRefs `json:"diff_refs,omitempty"`
+}
+
+// DiffRefs are the commit IDs that a merge request's diff is between.
+type DiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// Discussion is a thread of notes on a GitLab merge request.
+type Discussion struct {
+	ID    string  `json:"id"`
+	Notes []*Note `json:"notes"`
+}
+
+// Note is a comment on a GitLab merge request.
+type Note struct {
+	ID     int    `json:"id"`
+	Body   string `json:"body"`
+	Author struct {
+		ID       int    `json:"id"`
+		Username string `json:"username"`
+	} `json:"author"`
+	System    bool      `json:"system"` // whether the note was created by GitLab (e.g., "changed the description")
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DiffPosition is the position of a new discussion on the diff of a merge
+// request. See https://docs.gitlab.com/ee/api/discussions.html#create-new-merge-request-thread.
+type DiffPosition struct {
+	DiffRefs
+	PositionType string `json:"position_type"` // "text"
+	NewPath      string `json:"new_path"`
+	OldPath      string `json:"old_path"`
+	NewLine      int    `json:"new_line"` // line (one-based) of the file at HeadSHA
+}
+
+// ListOpenMergeRequestsForBranch lists the open merge requests in the project
+// whose source branch is the given branch.
+func (c *Client) ListOpenMergeRequestsForBranch(ctx context.Context, projectID int, branch string) ([]*MergeRequest, error) {
+	q := url.Values{"state": {"opened"}, "source_branch": {branch}}
+	req, err := http.NewRequest("GET", fmt.Sprintf("projects/%d/merge_requests?%s", projectID, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	var mrs []*MergeRequest
+	_, err = c.do(ctx, req, &mrs)
+	return mrs, err
+}
+
+// GetMergeRequest gets the merge request with the given IID in the project.
+func (c *Client) GetMergeRequest(ctx context.Context, projectID, iid int) (*MergeRequest, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("projects/%d/merge_requests/%d", projectID, iid), nil)
+	if err != nil {
+		return nil, err
+	}
+	var mr MergeRequest
+	if _, err := c.do(ctx, req, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// GetMergeRequestDiscussion gets the discussion on the merge request.
+func (c *Client) GetMergeRequestDiscussion(ctx context.Context, projectID, iid int, discussionID string) (*Discussion, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("projects/%d/merge_requests/%d/discussions/%s", projectID, iid, url.PathEscape(discussionID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var d Discussion
+	if _, err := c.do(ctx, req, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// CreateMergeRequestDiscussion creates a new discussion on the merge request.
+// If position is non-nil, the discussion is on that position of the diff.
+func (c *Client) CreateMergeRequestDiscussion(ctx context.Context, projectID, iid int, body string, position *DiffPosition) (*Discussion, error) {
+	req, err := newJSONRequest("POST", fmt.Sprintf("projects/%d/merge_requests/%d/discussions", projectID, iid), struct {
+		Body     string        `json:"body"`
+		Position *DiffPosition `json:"position,omitempty"`
+	}{Body: body, Position: position})
+	if err != nil {
+		return nil, err
+	}
+	var d Discussion
+	if _, err := c.do(ctx, req, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// CreateMergeRequestDiscussionNote adds a note to the discussion on the merge
+// request.
+func (c *Client) CreateMergeRequestDiscussionNote(ctx context.Context, projectID, iid int, discussionID, body string) (*Note, error) {
+	req, err := newJSONRequest("POST", fmt.Sprintf("projects/%d/merge_requests/%d/discussions/%s/notes", projectID, iid, url.PathEscape(discussionID)), struct {
+		Body string `json:"body"`
+	}{Body: body})
+	if err != nil {
+		return nil, err
+	}
+	var note Note
+	if _, err := c.do(ctx, req, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// UpdateMergeRequestDiscussionNote updates the body of the note in the
+// discussion on the merge request.
+func (c *Client) UpdateMergeRequestDiscussionNote(ctx context.Context, projectID, iid int, discussionID string, noteID int, body string) (*Note, error) {
+	req, err := newJSONRequest("PUT", fmt.Sprintf("projects/%d/merge_requests/%d/discussions/%s/notes/%d", projectID, iid, url.PathEscape(discussionID), noteID), struct {
+		Body string `json:"body"`
+	}{Body: body})
+	if err != nil {
+		return nil, err
+	}
+	var note Note
+	if _, err := c.do(ctx, req, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// DeleteMergeRequestDiscussionNote deletes the note in the discussion on the
+// merge request.
+func (c *Client) DeleteMergeRequestDiscussionNote(ctx context.Context, projectID, iid int, discussionID string, noteID int) error {
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("projects/%d/merge_requests/%d/discussions/%s/notes/%d", projectID, iid, url.PathEscape(discussionID), noteID), nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, req, nil)
+	return err
+}
+
+func newJSONRequest(method, urlStr string, body interface{}) (*http.Request, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequest(method, urlStr, bytes.NewReader(b))
+}
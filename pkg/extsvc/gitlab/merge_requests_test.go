@@ -0,0 +1,97 @@
+package gitlab
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type mockHTTPRequestRecorder struct {
+	status       int
+	responseBody string
+
+	method, url, body string
+}
+
+func (s *mockHTTPRequestRecorder) Do(req *http.Request) (*http.Response, error) {
+	s.method, s.url = req.Method, req.URL.String()
+	if req.Body != nil {
+		b, _ := ioutil.ReadAll(req.Body)
+		s.body = string(b)
+	}
+	status := s.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		Request:    req,
+		StatusCode: status,
+		Body:       ioutil.NopCloser(strings.NewReader(s.responseBody)),
+	}, nil
+}
+
+func TestClient_ListOpenMergeRequestsForBranch(t *testing.T) {
+	mock := mockHTTPRequestRecorder{responseBody: `[{"id": 10, "iid": 2, "state": "opened", "source_branch": "b"}]`}
+	c := newTestClient(t)
+	c.httpClient = &mock
+
+	mrs, err := c.ListOpenMergeRequestsForBranch(context.Background(), 1, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mrs) != 1 || mrs[0].IID != 2 {
+		t.Errorf("unexpected merge requests %+v", mrs)
+	}
+	if want := "https://example.com/projects/1/merge_requests?source_branch=b&state=opened"; mock.url != want {
+		t.Errorf("got URL %q, want %q", mock.url, want)
+	}
+}
+
+func TestClient_CreateMergeRequestDiscussion(t *testing.T) {
+	mock := mockHTTPRequestRecorder{
+		status:       http.StatusCreated,
+		responseBody: `{"id": "abc", "notes": [{"id": 5, "body": "hello", "author": {"username": "u"}}]}`,
+	}
+	c := newTestClient(t)
+	c.httpClient = &mock
+
+	d, err := c.CreateMergeRequestDiscussion(context.Background(), 1, 2, "hello", &DiffPosition{
+		DiffRefs:     DiffRefs{BaseSHA: "a", HeadSHA: "c", StartSHA: "b"},
+		PositionType: "text",
+		NewPath:      "f.go",
+		OldPath:      "f.go",
+		NewLine:      4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ID != "abc" || len(d.Notes) != 1 || d.Notes[0].ID != 5 || d.Notes[0].Author.Username != "u" {
+		t.Errorf("unexpected discussion %+v", d)
+	}
+	if mock.method != "POST" || mock.url != "https://example.com/projects/1/merge_requests/2/discussions" {
+		t.Errorf("unexpected request %s %s", mock.method, mock.url)
+	}
+	if want := `{"body":"hello","position":{"base_sha":"a","head_sha":"c","start_sha":"b","position_type":"text","new_path":"f.go","old_path":"f.go","new_line":4}}`; mock.body != want {
+		t.Errorf("got body %s, want %s", mock.body, want)
+	}
+}
+
+func TestClient_DeleteMergeRequestDiscussionNote(t *testing.T) {
+	mock := mockHTTPRequestRecorder{status: http.StatusNoContent}
+	c := newTestClient(t)
+	c.httpClient = &mock
+
+	if err := c.DeleteMergeRequestDiscussionNote(context.Background(), 1, 2, "abc", 5); err != nil {
+		t.Fatal(err)
+	}
+	if mock.method != "DELETE" || mock.url != "https://example.com/projects/1/merge_requests/2/discussions/abc/notes/5" {
+		t.Errorf("unexpected request %s %s", mock.method, mock.url)
+	}
+
+	mock.status = http.StatusNotFound
+	if err := c.DeleteMergeRequestDiscussionNote(context.Background(), 1, 2, "abc", 5); HTTPErrorCode(err) != http.StatusNotFound {
+		t.Errorf("got error %v, want HTTP 404", err)
+	}
+}
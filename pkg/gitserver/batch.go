@@ -0,0 +1,255 @@
+package gitserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs"
+)
+
+// ErrBatchUnavailable is returned by (*Batch).Run when the gitserver does not
+// implement batch exec requests. The caller should run the commands
+// individually instead.
+var ErrBatchUnavailable = errors.New("gitserver: batch exec not available")
+
+// errBatchCmdNotRun is returned by the output methods of a BatchCmd whose
+// batch has not run, or which did not run because the batch was interrupted.
+var errBatchCmdNotRun = errors.New("gitserver: batch command was not run")
+
+// Batch is a list of read-only git commands which are executed remotely in
+// one repository with a single request.
+type Batch struct {
+	client *Client
+
+	Repo           // the repository to execute the commands in
+	EnsureRevision string
+
+	cmds []*BatchCmd
+}
+
+// BatchCmd is a command of a Batch. Its output is available once the batch
+// has run.
+type BatchCmd struct {
+	Args       []string
+	ExitStatus int
+
+	result *protocol.BatchExecResult
+}
+
+// Batch creates a new empty Batch for repo.
+func (c *Client) Batch(repo Repo) *Batch {
+	return &Batch{client: c, Repo: repo}
+}
+
+// Command adds a command to the batch. Command name must be 'git', otherwise
+// it panics.
+func (b *Batch) Command(name string, arg ...string) *BatchCmd {
+	if name != "git" {
+		panic("gitserver: command name must be 'git'")
+	}
+	cmd := &BatchCmd{Args: append([]string{"git"}, arg...)}
+	b.cmds = append(b.cmds, cmd)
+	return cmd
+}
+
+// Run executes the commands of the batch. It only returns an error if the
+// batch could not be run, eg because the repository does not exist. The
+// errors of individual commands are returned by their output methods.
+//
+// Batches with more than protocol.MaxBatchExecCmds commands are sent in
+// several requests.
+func (b *Batch) Run(ctx context.Context) (errRes error) {
+	repoName := protocol.NormalizeRepo(b.Repo.Name)
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Client.BatchExec")
+	defer func() {
+		if errRes != nil {
+			ext.Error.Set(span, true)
+			span.SetTag("err", errRes.Error())
+		}
+		span.Finish()
+	}()
+	span.SetTag("repo", b.Repo.Name)
+	span.SetTag("cmds", len(b.cmds))
+
+	for cmds := b.cmds; len(cmds) > 0; {
+		n := len(cmds)
+		if n > protocol.MaxBatchExecCmds {
+			n = protocol.MaxBatchExecCmds
+		}
+		if err := b.run(ctx, repoName, cmds[:n]); err != nil {
+			return err
+		}
+		cmds = cmds[n:]
+	}
+	return nil
+}
+
+// run executes cmds, which are at most protocol.MaxBatchExecCmds commands of
+// the batch, with a single request.
+func (b *Batch) run(ctx context.Context, repoName api.RepoName, cmds []*BatchCmd) error {
+	req := &protocol.BatchExecRequest{
+		Repo:           repoName,
+		URL:            b.Repo.URL,
+		EnsureRevision: b.EnsureRevision,
+		Cmds:           make([][]string, len(cmds)),
+	}
+	for i, cmd := range cmds {
+		req.Cmds[i] = cmd.Args[1:]
+	}
+	resp, err := b.client.httpPost(ctx, repoName, "batch-exec", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// A 404 without a payload comes from a gitserver which does not
+		// implement batch exec requests.
+		var payload protocol.NotFoundPayload
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			return ErrBatchUnavailable
+		}
+		return &vcs.RepoNotExistError{Repo: repoName, CloneInProgress: payload.CloneInProgress, CloneProgress: payload.CloneProgress}
+	default:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code: %d (%s)", resp.StatusCode, body)
+	}
+
+	// The results are streamed in the order of the commands. If the stream
+	// is cut short, the remaining commands report that they were not run.
+	dec := json.NewDecoder(resp.Body)
+	for _, cmd := range cmds {
+		var result protocol.BatchExecResult
+		if err := dec.Decode(&result); err != nil {
+			return errors.Wrap(err, "reading batch exec results")
+		}
+		cmd.result = &result
+		cmd.ExitStatus = result.ExitStatus
+	}
+	return nil
+}
+
+// DividedOutput returns the standard output and standard error of the
+// command.
+func (c *BatchCmd) DividedOutput() ([]byte, []byte, error) {
+	if c.result == nil {
+		return nil, nil, errBatchCmdNotRun
+	}
+	stderr := []byte(c.result.Stderr)
+	if c.result.Error != "" {
+		return c.result.Stdout, stderr, errors.New(c.result.Error)
+	}
+	return c.result.Stdout, stderr, nil
+}
+
+// Output returns the standard output of the command.
+func (c *BatchCmd) Output() ([]byte, error) {
+	stdout, _, err := c.DividedOutput()
+	return stdout, err
+}
+
+// CombinedOutput returns the combined standard output and standard error of
+// the command.
+func (c *BatchCmd) CombinedOutput() ([]byte, error) {
+	stdout, stderr, err := c.DividedOutput()
+	return append(stdout, stderr...), err
+}
+
+func (c *BatchCmd) String() string { return fmt.Sprintf("%q", c.Args) }
+
+// batchLoaderDelay is how long a BatchLoader collects commands before it
+// sends them to gitserver. It is a variable so tests can change it.
+var batchLoaderDelay = 2 * time.Millisecond
+
+type batchLoaderKey struct{}
+
+// BatchLoader collects the git commands which the goroutines of one request
+// run against the same repository at about the same time, and sends them to
+// gitserver as a single batch. It is used by requests which resolve many
+// fields at once, such as the GraphQL requests of the tree page.
+type BatchLoader struct {
+	client *Client
+	ctx    context.Context // the context batches are run with
+
+	mu      sync.Mutex
+	pending map[batchLoaderBatchKey]*loaderBatch
+}
+
+type batchLoaderBatchKey struct {
+	repo           api.RepoName
+	ensureRevision string
+}
+
+// loaderBatch is a batch of a BatchLoader which has not run yet.
+type loaderBatch struct {
+	batch *Batch
+	cmds  map[string]*BatchCmd // by arguments, to run repeated commands once
+	done  chan struct{}        // closed once the batch has run
+	err   error
+}
+
+// WithBatchLoader returns a copy of ctx with a new BatchLoader, which runs
+// its batches with ctx.
+func (c *Client) WithBatchLoader(ctx context.Context) context.Context {
+	l := &BatchLoader{client: c, ctx: ctx, pending: map[batchLoaderBatchKey]*loaderBatch{}}
+	return context.WithValue(ctx, batchLoaderKey{}, l)
+}
+
+// BatchLoaderFromContext returns the BatchLoader of ctx, or nil if it has
+// none.
+func BatchLoaderFromContext(ctx context.Context) *BatchLoader {
+	l, _ := ctx.Value(batchLoaderKey{}).(*BatchLoader)
+	return l
+}
+
+// Run adds the git command with args to the next batch for repo and
+// ensureRevision, and returns the command once the batch has run. Identical
+// commands in the same batch are only run once. The error is that of
+// (*Batch).Run.
+func (l *BatchLoader) Run(ctx context.Context, repo Repo, ensureRevision string, args ...string) (*BatchCmd, error) {
+	key := batchLoaderBatchKey{repo: protocol.NormalizeRepo(repo.Name), ensureRevision: ensureRevision}
+	cmdKey := strings.Join(args, "\x00")
+
+	l.mu.Lock()
+	b := l.pending[key]
+	if b == nil {
+		b = &loaderBatch{batch: l.client.Batch(repo), cmds: map[string]*BatchCmd{}, done: make(chan struct{})}
+		b.batch.EnsureRevision = ensureRevision
+		l.pending[key] = b
+		time.AfterFunc(batchLoaderDelay, func() {
+			l.mu.Lock()
+			delete(l.pending, key)
+			l.mu.Unlock()
+
+			b.err = b.batch.Run(l.ctx)
+			close(b.done)
+		})
+	}
+	cmd := b.cmds[cmdKey]
+	if cmd == nil {
+		cmd = b.batch.Command("git", args...)
+		b.cmds[cmdKey] = cmd
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return cmd, b.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
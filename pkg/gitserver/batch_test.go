@@ -0,0 +1,83 @@
+package gitserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+)
+
+func TestBatchLoader(t *testing.T) {
+	defer func(d time.Duration) { batchLoaderDelay = d }(batchLoaderDelay)
+	batchLoaderDelay = 100 * time.Millisecond
+
+	var requests, cmds int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req protocol.BatchExecRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		atomic.AddInt32(&cmds, int32(len(req.Cmds)))
+		enc := json.NewEncoder(w)
+		for _, args := range req.Cmds {
+			enc.Encode(protocol.BatchExecResult{Stdout: []byte(strings.Join(args, " "))})
+		}
+	}))
+	defer srv.Close()
+	client := &Client{
+		HTTPClient: http.DefaultClient,
+		Addrs: func(context.Context) []string {
+			return []string{strings.TrimPrefix(srv.URL, "http://")}
+		},
+	}
+
+	ctx := client.WithBatchLoader(context.Background())
+	l := BatchLoaderFromContext(ctx)
+	if l == nil {
+		t.Fatal("expected a batch loader")
+	}
+	if BatchLoaderFromContext(context.Background()) != nil {
+		t.Error("expected no batch loader")
+	}
+
+	argss := [][]string{
+		{"rev-parse", "HEAD"},
+		{"ls-tree", "HEAD"},
+		{"rev-parse", "HEAD"},
+		{"ls-tree", "HEAD"},
+		{"rev-parse", "HEAD"},
+	}
+	var wg sync.WaitGroup
+	for _, args := range argss {
+		wg.Add(1)
+		go func(args []string) {
+			defer wg.Done()
+			cmd, err := l.Run(ctx, Repo{Name: "r"}, "", args...)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			out, err := cmd.Output()
+			if err != nil {
+				t.Error(err)
+			}
+			if want := strings.Join(args, " "); string(out) != want {
+				t.Errorf("got output %q, want %q", out, want)
+			}
+		}(args)
+	}
+	wg.Wait()
+
+	if requests != 1 || cmds != 2 {
+		t.Errorf("got %d requests with %d commands, want 1 request with the 2 distinct commands", requests, cmds)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -145,9 +146,33 @@ func (c *Client) addrForKey(ctx context.Context, key string) string {
 	if len(addrs) == 0 {
 		panic("unexpected state: no gitserver addresses")
 	}
-	sum := md5.Sum([]byte(key))
-	serverIndex := binary.BigEndian.Uint64(sum[:]) % uint64(len(addrs))
-	return addrs[serverIndex]
+	return AddrForKey(key, addrs)
+}
+
+// AddrForRepo returns the address of the gitserver in addrs which owns the
+// given repo.
+func AddrForRepo(repo api.RepoName, addrs []string) string {
+	return AddrForKey(string(protocol.NormalizeRepo(repo)), addrs)
+}
+
+// AddrForKey returns the address in addrs which owns the given key. It uses
+// rendezvous hashing: each address is scored by hashing it together with the
+// key, and the highest score wins. When an address is added, only the keys
+// which the new address wins move, and when one is removed, only the keys it
+// owned move. addrs must not be empty.
+func AddrForKey(key string, addrs []string) string {
+	var (
+		best      string
+		bestScore uint64
+	)
+	for i, addr := range addrs {
+		sum := md5.Sum([]byte(addr + "\x00" + key))
+		score := binary.BigEndian.Uint64(sum[:])
+		if i == 0 || score > bestScore {
+			best, bestScore = addr, score
+		}
+	}
+	return best
 }
 
 func (c *Cmd) sendExec(ctx context.Context) (_ io.ReadCloser, _ http.Header, errRes error) {
@@ -428,6 +453,65 @@ func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 	return repos, err
 }
 
+// RepoSizes returns the cloned repositories of all gitservers and the disk
+// space used by each, largest first. If limit is positive, at most limit
+// repositories are returned.
+func (c *Client) RepoSizes(ctx context.Context, limit int) ([]protocol.RepoSize, error) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		err   error
+		repos []protocol.RepoSize
+	)
+	for _, addr := range c.Addrs(ctx) {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			r, e := c.doRepoSizesOne(ctx, addr, limit)
+			mu.Lock()
+			if e != nil {
+				err = e
+			}
+			repos = append(repos, r...)
+			mu.Unlock()
+		}(addr)
+	}
+	wg.Wait()
+
+	sort.SliceStable(repos, func(i, j int) bool {
+		return repos[i].Size > repos[j].Size
+	})
+	if limit > 0 && len(repos) > limit {
+		repos = repos[:limit]
+	}
+	return repos, err
+}
+
+func (c *Client) doRepoSizesOne(ctx context.Context, addr string, limit int) ([]protocol.RepoSize, error) {
+	body, err := json.Marshal(&protocol.RepoSizesRequest{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", "http://"+addr+"/repo-sizes", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("RepoSizes: http status %d from %s", resp.StatusCode, addr)
+	}
+
+	var sizes protocol.RepoSizesResponse
+	err = json.NewDecoder(resp.Body).Decode(&sizes)
+	return sizes.Repos, err
+}
+
 // GetGitolitePhabricatorMetadata returns Phabricator metadata for a Gitolite repository fetched via
 // a user-provided command.
 func (c *Client) GetGitolitePhabricatorMetadata(ctx context.Context, gitoliteHost string, repoName api.RepoName) (*protocol.GitolitePhabricatorMetadataResponse, error) {
@@ -736,3 +820,24 @@ func (c *Client) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 
 	return res.Rev, json.NewDecoder(resp.Body).Decode(&res)
 }
+
+// CreateCommit creates a commit in req.Repo as described by req, optionally
+// pushing it to the repository's remote.
+func (c *Client) CreateCommit(ctx context.Context, req protocol.CreateCommitRequest) (*protocol.CreateCommitResponse, error) {
+	resp, err := c.httpPost(ctx, req.Repo, "create-commit", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return nil, &url.Error{URL: resp.Request.URL.String(), Op: "CreateCommit", Err: fmt.Errorf("CreateCommit: http status %d %s", resp.StatusCode, string(b))}
+	}
+
+	var res protocol.CreateCommitResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,56 @@
+package gitserver
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAddrForKey(t *testing.T) {
+	addrs := []string{"gitserver-0:3178", "gitserver-1:3178", "gitserver-2:3178"}
+	keys := make([]string, 3000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("github.com/foo/repo%d", i)
+	}
+
+	counts := map[string]int{}
+	owners := map[string]string{}
+	for _, key := range keys {
+		owner := AddrForKey(key, addrs)
+		counts[owner]++
+		owners[key] = owner
+		if got := AddrForKey(key, []string{addrs[2], addrs[0], addrs[1]}); got != owner {
+			t.Fatalf("owner of %s depends on the order of addrs: got %s, want %s", key, got, owner)
+		}
+	}
+	for _, addr := range addrs {
+		if counts[addr] < 800 {
+			t.Errorf("%s owns %d of %d keys, want roughly a third", addr, counts[addr], len(keys))
+		}
+	}
+
+	// Adding an address only moves keys to the new address.
+	grown := append(append([]string(nil), addrs...), "gitserver-3:3178")
+	moved := 0
+	for _, key := range keys {
+		owner := AddrForKey(key, grown)
+		if owner == owners[key] {
+			continue
+		}
+		moved++
+		if owner != "gitserver-3:3178" {
+			t.Fatalf("%s moved from %s to %s, want it to move to the new address", key, owners[key], owner)
+		}
+	}
+	if moved < 500 || moved > 1000 {
+		t.Errorf("%d of %d keys moved to the new address, want roughly a quarter", moved, len(keys))
+	}
+
+	// Removing an address only moves the keys it owned.
+	shrunk := addrs[:2]
+	for _, key := range keys {
+		owner := AddrForKey(key, shrunk)
+		if owners[key] != addrs[2] && owner != owners[key] {
+			t.Fatalf("%s moved from %s to %s, but its owner was not removed", key, owners[key], owner)
+		}
+	}
+}
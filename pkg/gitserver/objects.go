@@ -0,0 +1,110 @@
+package gitserver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+)
+
+// ErrObjectUnavailable is returned by the object methods (ReadBlob, ListTree,
+// ResolveRevision and GetCommit) when gitserver could not serve the request by
+// reading objects directly. The caller should fall back to running the
+// equivalent git command, which also clones and fetches as needed.
+//
+// It is also returned by gitservers which do not implement the object
+// endpoints.
+var ErrObjectUnavailable = errors.New("gitserver: object not available without running git")
+
+// errObjectPathNotFound is returned by objectPost when the commit exists but
+// the requested path does not.
+var errObjectPathNotFound = errors.New("gitserver: path not found")
+
+// ReadBlob returns the contents of the file at path in commit. If the file
+// does not exist, an error satisfying os.IsNotExist is returned.
+func (c *Client) ReadBlob(ctx context.Context, repo api.RepoName, commit api.CommitID, path string) ([]byte, error) {
+	body, err := c.objectPost(ctx, repo, "read-blob", &protocol.ReadBlobRequest{
+		Repo:   repo,
+		Commit: commit,
+		Path:   path,
+	})
+	if err == errObjectPathNotFound {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	} else if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// ListTree returns the tree entries at path in commit. See
+// protocol.ListTreeRequest for the semantics of path and recurse.
+func (c *Client) ListTree(ctx context.Context, repo api.RepoName, commit api.CommitID, path string, recurse bool) ([]protocol.TreeEntry, error) {
+	var resp protocol.ListTreeResponse
+	err := c.objectPostJSON(ctx, repo, "list-tree", &protocol.ListTreeRequest{
+		Repo:    repo,
+		Commit:  commit,
+		Path:    path,
+		Recurse: recurse,
+	}, &resp)
+	return resp.Entries, err
+}
+
+// ResolveRevision returns the commit ID the ref name or commit ID spec refers
+// to. Revision expressions such as "HEAD~1" are not supported.
+func (c *Client) ResolveRevision(ctx context.Context, repo api.RepoName, spec string) (api.CommitID, error) {
+	var resp protocol.ResolveRevisionResponse
+	err := c.objectPostJSON(ctx, repo, "resolve-revision", &protocol.ResolveRevisionRequest{
+		Repo: repo,
+		Spec: spec,
+	}, &resp)
+	return resp.Commit, err
+}
+
+// GetCommit returns the metadata of the commit with the given ID.
+func (c *Client) GetCommit(ctx context.Context, repo api.RepoName, commit api.CommitID) (*protocol.GetCommitResponse, error) {
+	var resp protocol.GetCommitResponse
+	if err := c.objectPostJSON(ctx, repo, "get-commit", &protocol.GetCommitRequest{
+		Repo:   repo,
+		Commit: commit,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) objectPostJSON(ctx context.Context, repo api.RepoName, method string, payload, result interface{}) error {
+	body, err := c.objectPost(ctx, repo, method, payload)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(result)
+}
+
+// objectPost sends a request to one of the object endpoints and returns the
+// body of a successful response.
+func (c *Client) objectPost(ctx context.Context, repo api.RepoName, method string, payload interface{}) (io.ReadCloser, error) {
+	resp, err := c.httpPost(ctx, repo, method, payload)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+
+	// A 404 without a payload comes from a gitserver which does not
+	// implement the endpoint.
+	var e protocol.ObjectErrorPayload
+	if resp.StatusCode == http.StatusNotFound && json.NewDecoder(resp.Body).Decode(&e) == nil && e.NotFound {
+		return nil, errObjectPathNotFound
+	}
+	return nil, ErrObjectUnavailable
+}
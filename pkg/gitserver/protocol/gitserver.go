@@ -20,6 +20,35 @@ type ExecRequest struct {
 	Opt            *RemoteOpts `json:"opt"`
 }
 
+// BatchExecRequest is a request to execute several read-only git commands
+// inside a git repository. The response body is a stream of JSON-encoded
+// BatchExecResults, one for each command in the order of Cmds, each written as
+// soon as its command finishes.
+type BatchExecRequest struct {
+	Repo api.RepoName `json:"repo"`
+
+	// URL is the repository's Git remote URL. See ExecRequest.
+	URL string `json:"url,omitempty"`
+
+	EnsureRevision string `json:"ensureRevision"`
+
+	// Cmds are the arguments of each git command. The first argument of each
+	// must be one of the subcommands gitserver allows in a batch, such as
+	// rev-parse, ls-tree or log. There may be at most MaxBatchExecCmds.
+	Cmds [][]string `json:"cmds"`
+}
+
+// MaxBatchExecCmds is the maximum number of commands in a BatchExecRequest.
+const MaxBatchExecCmds = 100
+
+// BatchExecResult is the result of one command of a BatchExecRequest.
+type BatchExecResult struct {
+	Stdout     []byte `json:"stdout"`
+	Stderr     string `json:"stderr,omitempty"` // truncated to 1 KiB
+	ExitStatus int    `json:"exitStatus"`
+	Error      string `json:"error,omitempty"`
+}
+
 // RemoteOpts configures interactions with a remote repository.
 type RemoteOpts struct {
 	SSH   *SSHConfig   `json:"ssh"`   // SSH configuration for communication with the remote
@@ -143,6 +172,44 @@ type RepoInfo struct {
 	// recloned automatically, so this time is likely to move forward
 	// periodically.
 	CloneTime *time.Time
+
+	// MigratingFrom is the address of the gitserver this repository is
+	// being copied from while it is migrated to the gitserver which now
+	// owns it. It is empty if the repository is not being migrated.
+	MigratingFrom string `json:",omitempty"`
+
+	// Size is the disk space in bytes used by the clone, as of its last
+	// clone, fetch or maintenance. It is 0 if the repository is not cloned.
+	Size int64 `json:",omitempty"`
+
+	// Shallow is true if only the most recent commits of the repository were
+	// cloned because the full clone exceeded its size limit.
+	Shallow bool `json:",omitempty"`
+
+	// LastError is the error of the last attempt to clone the repository,
+	// such as the repository exceeding its size limit. It is empty if the
+	// last attempt succeeded or the error is not known.
+	LastError string `json:",omitempty"`
+}
+
+// RepoSizesRequest is a request for the repositories using the most disk
+// space.
+type RepoSizesRequest struct {
+	// Limit is the maximum number of repositories to return. 0 means no
+	// limit.
+	Limit int
+}
+
+// RepoSizesResponse is the response to a RepoSizesRequest.
+type RepoSizesResponse struct {
+	// Repos are the cloned repositories, largest first.
+	Repos []RepoSize
+}
+
+// RepoSize is the disk space used by the clone of a repository.
+type RepoSize struct {
+	Repo api.RepoName
+	Size int64 // in bytes, see RepoInfo.Size
 }
 
 // RepoInfoResponse is the response to a repository information request
@@ -181,3 +248,144 @@ type CreatePatchFromPatchResponse struct {
 	// Rev is the tag that the staging object can be found at
 	Rev string
 }
+
+// CreateCommitRequest is a request to create a commit on top of BaseCommit
+// which applies Patch and then Files, and to point TargetRef at it. The
+// commit is optionally pushed to the repository's remote.
+type CreateCommitRequest struct {
+	// Repo is the repository to create the commit in.
+	Repo api.RepoName
+	// BaseCommit is the parent of the new commit.
+	BaseCommit api.CommitID
+	// Patch is a diff applied to BaseCommit, as by `git apply`. It may be
+	// empty.
+	Patch string `json:",omitempty"`
+	// Files are written to (or removed from) the tree after Patch is
+	// applied.
+	Files []FileChange `json:",omitempty"`
+	// TargetRef is the ref created in the repository for the commit, such as
+	// refs/heads/my-branch. The request fails if it already exists.
+	//
+	// Refs under refs/heads/, refs/tags/ and refs/pull/ are mirrors of the
+	// remote and are reset or removed by the next fetch of the repository
+	// unless the commit is pushed there.
+	TargetRef string
+	// CommitInfo is the information that will be used when creating the
+	// commit.
+	CommitInfo PatchCommitInfo
+	// Push, if non-nil, pushes the commit to the repository's remote using
+	// the credentials gitserver clones the repository with.
+	Push *PushConfig `json:",omitempty"`
+}
+
+// FileChange is a change to a single file made by a CreateCommitRequest.
+type FileChange struct {
+	// Path is the path of the file in the repository.
+	Path string
+	// Content is the new content of the file.
+	Content []byte `json:",omitempty"`
+	// Executable sets the executable bit of the file.
+	Executable bool `json:",omitempty"`
+	// Delete removes the file instead of writing it. Content is ignored.
+	Delete bool `json:",omitempty"`
+}
+
+// PushConfig describes how the commit created by a CreateCommitRequest is
+// pushed.
+type PushConfig struct {
+	// RemoteRef is the branch updated on the remote, such as
+	// refs/heads/my-branch. It defaults to the TargetRef of the request.
+	RemoteRef string `json:",omitempty"`
+	// Force allows RemoteRef to be updated even if the commit is not a
+	// descendant of the commit it currently points to.
+	Force bool `json:",omitempty"`
+}
+
+// CreateCommitResponse is the response to a CreateCommitRequest.
+type CreateCommitResponse struct {
+	// Ref is the ref created in the repository for the commit.
+	Ref string
+	// Commit is the ID of the new commit.
+	Commit api.CommitID
+	// PushedRef is the ref updated on the remote. It is empty if the commit
+	// was not pushed.
+	PushedRef string `json:",omitempty"`
+}
+
+// ReadBlobRequest is a request to read the contents of the file at Path in
+// Commit. The response body is the file's contents.
+type ReadBlobRequest struct {
+	Repo   api.RepoName
+	Commit api.CommitID
+	Path   string
+}
+
+// ListTreeRequest is a request to list the tree entries at Path in Commit,
+// with the same semantics as `git ls-tree --full-name Commit -- Path`. A
+// Path with a trailing slash lists the contents of the directory, otherwise
+// the entry for Path itself is listed.
+type ListTreeRequest struct {
+	Repo    api.RepoName
+	Commit  api.CommitID
+	Path    string
+	Recurse bool // list subtrees recursively, including the subtrees themselves
+}
+
+// ListTreeResponse is the response to a ListTreeRequest.
+type ListTreeResponse struct {
+	Entries []TreeEntry
+}
+
+// TreeEntry is an entry in a git tree.
+type TreeEntry struct {
+	Path string // the full path of the entry relative to the repository root
+	Mode uint32 // the git file mode, such as 0100644 or 040000
+	Type string // "blob", "tree" or "commit" (a submodule)
+	OID  string
+	Size int64 // the size of a blob, or -1 for trees and submodules
+}
+
+// ResolveRevisionRequest is a request to resolve a ref name or commit ID to
+// a commit ID.
+type ResolveRevisionRequest struct {
+	Repo api.RepoName
+	Spec string
+}
+
+// ResolveRevisionResponse is the response to a ResolveRevisionRequest.
+type ResolveRevisionResponse struct {
+	Commit api.CommitID
+}
+
+// GetCommitRequest is a request for the metadata of a commit.
+type GetCommitRequest struct {
+	Repo   api.RepoName
+	Commit api.CommitID
+}
+
+// GetCommitResponse is the response to a GetCommitRequest.
+type GetCommitResponse struct {
+	ID        api.CommitID
+	Author    Signature
+	Committer Signature
+	Message   string
+	Parents   []api.CommitID
+}
+
+// Signature is the author or committer of a commit.
+type Signature struct {
+	Name  string
+	Email string
+	Date  time.Time
+}
+
+// ObjectErrorPayload is the body of an unsuccessful response from the object
+// endpoints (read-blob, list-tree, resolve-revision and get-commit).
+//
+// If NotFound is false the request could not be served by reading objects
+// directly, for example because the repository is not cloned or the commit
+// is missing, and the caller should fall back to running git via exec.
+type ObjectErrorPayload struct {
+	NotFound bool // the commit exists, but the path does not
+	Error    string
+}
@@ -55,19 +55,40 @@ func GetByUUID(ctx context.Context, registry *url.URL, uuidStr string) (*Extensi
 	if _, err := uuid.Parse(uuidStr); err != nil {
 		return nil, err
 	}
-	return getBy(ctx, registry, "registry.GetByUUID", "uuid", uuidStr)
+	return getBy(ctx, registry, "registry.GetByUUID", "uuid", uuidStr, nil)
 }
 
 // GetByExtensionID gets the extension from the remote registry with the given extension ID. If the
 // remote registry reports that the extension is not found, the returned error implements
 // errcode.NotFounder.
 func GetByExtensionID(ctx context.Context, registry *url.URL, extensionID string) (*Extension, error) {
-	return getBy(ctx, registry, "registry.GetByExtensionID", "extension-id", extensionID)
+	return getBy(ctx, registry, "registry.GetByExtensionID", "extension-id", extensionID, nil)
 }
 
-func getBy(ctx context.Context, registry *url.URL, op, field, value string) (*Extension, error) {
+// GetByExtensionIDInVersionRange is like GetByExtensionID, except that the extension's manifest is
+// from the release with the greatest version in the version range (such as "^1.2.0"). Remote
+// registries that do not support versioned releases return the latest release.
+func GetByExtensionIDInVersionRange(ctx context.Context, registry *url.URL, extensionID, versionRange string) (*Extension, error) {
+	return getBy(ctx, registry, "registry.GetByExtensionIDInVersionRange", "extension-id", extensionID, url.Values{"version": []string{versionRange}})
+}
+
+// ListReleases lists the releases (including yanked releases) of the extension with the given
+// extension ID on the remote registry, newest first. If the remote registry reports that the
+// extension is not found, the returned error implements errcode.NotFounder.
+func ListReleases(ctx context.Context, registry *url.URL, extensionID string) ([]*Release, error) {
+	var releases []*Release
+	if err := httpGet(ctx, "registry.ListReleases", toURL(registry, path.Join("extensions", "extension-id", extensionID, "releases"), nil), &releases); err != nil {
+		if e, ok := errors.Cause(err).(*url.Error); ok && e.Err == httpError(http.StatusNotFound) {
+			err = &notFoundError{field: "extension-id", value: extensionID}
+		}
+		return nil, err
+	}
+	return releases, nil
+}
+
+func getBy(ctx context.Context, registry *url.URL, op, field, value string, query url.Values) (*Extension, error) {
 	var x *Extension
-	if err := httpGet(ctx, op, toURL(registry, path.Join("extensions", field, value), nil), &x); err != nil {
+	if err := httpGet(ctx, op, toURL(registry, path.Join("extensions", field, value), query), &x); err != nil {
 		if e, ok := err.(*url.Error); ok && e.Err == httpError(http.StatusNotFound) {
 			err = &notFoundError{field: field, value: value}
 		}
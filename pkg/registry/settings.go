@@ -0,0 +1,15 @@
+package registry
+
+// ParseExtensionSetting parses the value of an extension in the "extensions" settings property,
+// which is either a boolean (whether the extension is enabled) or a string (the version range that
+// the enabled extension is pinned to, such as "^1.2.0"). Other values disable the extension.
+func ParseExtensionSetting(value interface{}) (enabled bool, versionRange string) {
+	switch v := value.(type) {
+	case bool:
+		return v, ""
+	case string:
+		return true, v
+	default:
+		return false, ""
+	}
+}
@@ -19,6 +19,10 @@ type Extension struct {
 	PublishedAt time.Time `json:"publishedAt"`
 	URL         string    `json:"url"`
 
+	// Version is the version of the release whose manifest is in Manifest, or nil if the release has
+	// no version (or the registry does not support versioned releases).
+	Version *string `json:"version,omitempty"`
+
 	// RegistryURL is the URL of the remote registry that this extension was retrieved from. It is
 	// not set by package registry.
 	RegistryURL string `json:"-"`
@@ -29,3 +33,13 @@ type Publisher struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
 }
+
+// Release describes a release of an extension in the extension registry.
+type Release struct {
+	Version     *string   `json:"version"` // nil if the release has no version
+	PublishedAt time.Time `json:"publishedAt"`
+
+	// Yanked is whether the publisher yanked the release. Yanked releases are not used as the
+	// latest release or as the release for a version range.
+	Yanked bool `json:"yanked"`
+}
@@ -580,6 +580,68 @@ func ExpandRepo(q Q, listFn func(include, exclude []string) (map[string]struct{}
 	return Simplify(q), retErr
 }
 
+// ConjunctiveNormalForm returns q as an And of Or queries whose children are
+// atoms or negated atoms. Not queries are moved to the atoms with De Morgan's
+// laws. ok is false if the result would have more than maxClauses children,
+// since the conversion can grow q exponentially, eg (or (and a b) (and c d)
+// ...).
+func ConjunctiveNormalForm(q Q, maxClauses int) (cnf *And, ok bool) {
+	clauses, ok := cnfClauses(q, false, maxClauses)
+	if !ok {
+		return nil, false
+	}
+	cnf = &And{}
+	for _, c := range clauses {
+		cnf.Children = append(cnf.Children, &Or{Children: c})
+	}
+	return cnf, true
+}
+
+// cnfClauses returns the clauses of q, negated if not, in conjunctive normal
+// form.
+func cnfClauses(q Q, not bool, maxClauses int) ([][]Q, bool) {
+	switch s := q.(type) {
+	case *Not:
+		return cnfClauses(s.Child, !not, maxClauses)
+	case *And, *Or:
+		// A negated or is the and of its negated children, and vice versa.
+		_, isAnd := q.(*And)
+		if isAnd != not {
+			var clauses [][]Q
+			for _, ch := range queryChildren(q) {
+				cs, ok := cnfClauses(ch, not, maxClauses)
+				if !ok || len(clauses)+len(cs) > maxClauses {
+					return nil, false
+				}
+				clauses = append(clauses, cs...)
+			}
+			return clauses, true
+		}
+
+		// An or of conjunctions is the conjunction of the ors of every
+		// combination of their clauses.
+		clauses := [][]Q{nil}
+		for _, ch := range queryChildren(q) {
+			cs, ok := cnfClauses(ch, not, maxClauses)
+			if !ok || len(clauses)*len(cs) > maxClauses {
+				return nil, false
+			}
+			var next [][]Q
+			for _, c := range clauses {
+				for _, chc := range cs {
+					next = append(next, append(append([]Q(nil), c...), chc...))
+				}
+			}
+			clauses = next
+		}
+		return clauses, true
+	}
+	if not {
+		return [][]Q{{&Not{Child: q}}}, true
+	}
+	return [][]Q{{q}}, true
+}
+
 // IsAtom returns true if q is an atom. An atom is a Q without children Q. For
 // example And is not an atom, but Repo is.
 func IsAtom(q Q) bool {
@@ -240,6 +240,37 @@ func TestVisitAtoms(t *testing.T) {
 	}
 }
 
+func TestConjunctiveNormalForm(t *testing.T) {
+	a, b, c, d := &Substring{Pattern: "a"}, &Substring{Pattern: "b"}, &Substring{Pattern: "c"}, &Substring{Pattern: "d"}
+	cases := []struct {
+		in   Q
+		want string
+	}{
+		{a, `(and (or substr:"a"))`},
+		{NewOr(a, b), `(and (or substr:"a" substr:"b"))`},
+		{NewAnd(NewOr(a, b), &Not{c}), `(and (or substr:"a" substr:"b") (or (not substr:"c")))`},
+		{NewOr(NewAnd(a, b), c), `(and (or substr:"a" substr:"c") (or substr:"b" substr:"c"))`},
+		{&Not{NewOr(a, &Not{b})}, `(and (or (not substr:"a")) (or substr:"b"))`},
+		{&Not{NewAnd(a, b)}, `(and (or (not substr:"a") (not substr:"b")))`},
+	}
+	for _, tc := range cases {
+		got, ok := ConjunctiveNormalForm(tc.in, 16)
+		if !ok {
+			t.Errorf("%s: got ok == false", tc.in)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.in, got, tc.want)
+		}
+	}
+
+	// Every combination of the ands is a clause.
+	in := NewOr(NewAnd(a, b), NewAnd(c, d), NewAnd(a, c), NewAnd(b, d), NewAnd(a, d))
+	if _, ok := ConjunctiveNormalForm(in, 16); ok {
+		t.Errorf("%s: got ok == true, want false", in)
+	}
+}
+
 type testEval struct {
 	value, ok bool
 }
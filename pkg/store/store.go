@@ -40,7 +40,8 @@ const (
 // do not want to search.
 //
 // We use an LRU to do cache eviction:
-// * When to evict is based on the total size of *.zip on disk.
+// * When to evict is based on the total size of *.zip and their trigram
+//   indexes on disk.
 // * What to evict uses the LRU algorithm.
 // * We touch files when opening them, so can do LRU based on file
 //   modification times.
@@ -102,8 +103,10 @@ func (s *Store) Start() {
 			Dir:               s.Path,
 			Component:         "store",
 			BackgroundTimeout: 2 * time.Minute,
-			BeforeEvict:       s.ZipCache.delete,
+			BeforeEvict:       s.beforeEvict,
+			CompanionSuffixes: []string{trigramIndexSuffix},
 		}
+		removeTrigramIndexTempFiles(s.Path)
 		go s.watchAndEvict()
 	})
 }
@@ -325,6 +328,13 @@ func copySearchable(tr *tar.Reader, zw *zip.Writer, largeFilePatterns []string)
 	}
 }
 
+// beforeEvict releases everything associated with the zip at path before it
+// is removed from disk.
+func (s *Store) beforeEvict(path string) {
+	s.ZipCache.delete(path)
+	removeTrigramIndex(path)
+}
+
 func (s *Store) String() string {
 	return "Store(" + s.Path + ")"
 }
@@ -0,0 +1,261 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// trigramIndexSuffix is appended to the path of a zip to get the path
+	// of its persisted TrigramIndex. It must not end in ".zip", otherwise
+	// diskcache would treat it as an archive.
+	trigramIndexSuffix = ".trigrams"
+
+	// trigramIndexTempSuffix is appended to the path of a persisted
+	// TrigramIndex to get the path it is written to before being renamed
+	// into place.
+	trigramIndexTempSuffix = ".part"
+
+	// trigramIndexMagic is the first bytes of a persisted TrigramIndex.
+	// Bump the version if the encoding or hashing changes.
+	trigramIndexMagic = "sgtrgm1\n"
+
+	// trigramBitsPerEntry is the number of bloom filter bits used for each
+	// distinct trigram in a file. Together with trigramHashes it gives a
+	// false positive rate of roughly 2%.
+	trigramBitsPerEntry = 10
+
+	// trigramHashes is the number of bits set in a file's bloom filter for
+	// each trigram.
+	trigramHashes = 3
+)
+
+// TrigramIndex records which trigrams appear in each file of a ZipFile. It
+// is used to skip files which cannot contain a literal without reading them.
+//
+// Each file gets a bloom filter of its ASCII lowercased trigrams, sized by
+// the number of distinct trigrams in the file. This is much smaller than
+// posting lists for every trigram, at the cost of occasional false
+// positives. Since trigrams are lowercased the index can be used for both
+// case sensitive and case insensitive searches.
+type TrigramIndex struct {
+	// offsets[i]:offsets[i+1] is the range of words holding the bloom
+	// filter for ZipFile.Files[i]. An empty range means the file is too
+	// short to contain any trigram.
+	offsets []uint32
+	words   []uint64
+}
+
+// MayContain reports whether the i'th file of the indexed ZipFile may
+// contain lit, ignoring ASCII case. It returns true if lit is shorter than
+// a trigram.
+func (ix *TrigramIndex) MayContain(i int, lit []byte) bool {
+	if len(lit) < 3 {
+		return true
+	}
+	filter := ix.words[ix.offsets[i]:ix.offsets[i+1]]
+	if len(filter) == 0 {
+		return false
+	}
+	nbits := uint32(len(filter) * 64)
+	for j := 0; j+3 <= len(lit); j++ {
+		h1, h2 := trigramHashes2(toLowerASCII(lit[j]), toLowerASCII(lit[j+1]), toLowerASCII(lit[j+2]))
+		for k := uint32(0); k < trigramHashes; k++ {
+			bit := (h1 + k*h2) % nbits
+			if filter[bit/64]&(1<<(bit%64)) == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// TrigramIndex returns the trigram index of f. The first call builds the
+// index, which reads every file in f, so it is only done for callers which
+// benefit from it. For a ZipFile read from disk the index is persisted next
+// to the zip so it only needs to be built once per archive.
+func (f *ZipFile) TrigramIndex() *TrigramIndex {
+	f.indexOnce.Do(func() {
+		if f.f == nil {
+			// Mock ZipFiles are not on disk.
+			f.index = buildTrigramIndex(f)
+			return
+		}
+		path := f.f.Name() + trigramIndexSuffix
+		if ix, err := readTrigramIndex(path, f); err == nil {
+			f.index = ix
+			return
+		} else if !os.IsNotExist(err) {
+			log.Printf("failed to read trigram index %q, rebuilding: %v", path, err)
+		}
+		f.index = buildTrigramIndex(f)
+		trigramIndexBuilds.Inc()
+		if err := writeTrigramIndex(path, f, f.index); err != nil {
+			// The index is only an optimization, so we continue with the
+			// in-memory copy.
+			log.Printf("failed to write trigram index %q: %v", path, err)
+		}
+	})
+	return f.index
+}
+
+// removeTrigramIndex removes the persisted trigram index of the zip at path,
+// if any.
+func removeTrigramIndex(path string) {
+	if err := os.Remove(path + trigramIndexSuffix); err != nil && !os.IsNotExist(err) {
+		log.Printf("failed to remove trigram index for %q: %v", path, err)
+	}
+}
+
+// removeTrigramIndexTempFiles removes the temporary files left in dir by
+// trigram index writes which did not complete, eg because the process was
+// killed.
+func removeTrigramIndexTempFiles(dir string) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+trigramIndexSuffix+trigramIndexTempSuffix))
+	if err != nil {
+		log.Printf("failed to list temporary trigram index files in %q: %v", dir, err)
+		return
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to remove temporary trigram index file %q: %v", path, err)
+		}
+	}
+}
+
+func buildTrigramIndex(f *ZipFile) *TrigramIndex {
+	ix := &TrigramIndex{offsets: make([]uint32, 1, len(f.Files)+1)}
+
+	// seen is a set of all possible trigrams, used to count the distinct
+	// trigrams of a file. Only the bits listed in trigrams are set between
+	// files, so clearing it is cheap.
+	seen := make([]uint64, (1<<24)/64)
+	var trigrams []uint32
+
+	for i := range f.Files {
+		data := f.DataFor(&f.Files[i])
+		trigrams = trigrams[:0]
+		for j := 0; j+3 <= len(data); j++ {
+			t := uint32(toLowerASCII(data[j]))<<16 | uint32(toLowerASCII(data[j+1]))<<8 | uint32(toLowerASCII(data[j+2]))
+			if seen[t/64]&(1<<(t%64)) == 0 {
+				seen[t/64] |= 1 << (t % 64)
+				trigrams = append(trigrams, t)
+			}
+		}
+
+		nwords := (len(trigrams)*trigramBitsPerEntry + 63) / 64
+		filter := make([]uint64, nwords)
+		nbits := uint32(nwords * 64)
+		for _, t := range trigrams {
+			seen[t/64] = 0
+			h1, h2 := trigramHashes2(byte(t>>16), byte(t>>8), byte(t))
+			for k := uint32(0); k < trigramHashes; k++ {
+				bit := (h1 + k*h2) % nbits
+				filter[bit/64] |= 1 << (bit % 64)
+			}
+		}
+		ix.words = append(ix.words, filter...)
+		ix.offsets = append(ix.offsets, uint32(len(ix.words)))
+	}
+	return ix
+}
+
+// trigramHashes2 returns the two hashes of the trigram abc used for double
+// hashing into a bloom filter.
+func trigramHashes2(a, b, c byte) (h1, h2 uint32) {
+	t := uint32(a)<<16 | uint32(b)<<8 | uint32(c)
+	h1 = t * 0x9e3779b1
+	h2 = (t*0x85ebca77)>>7 | 1
+	return h1 ^ h1>>15, h2
+}
+
+// A persisted TrigramIndex is encoded as trigramIndexMagic, followed by the
+// size of the zip and the number of files it indexes, followed by offsets
+// and words. All integers are little endian.
+
+func writeTrigramIndex(path string, f *ZipFile, ix *TrigramIndex) error {
+	var buf bytes.Buffer
+	buf.WriteString(trigramIndexMagic)
+	binary.Write(&buf, binary.LittleEndian, uint64(len(f.Data)))
+	binary.Write(&buf, binary.LittleEndian, uint32(len(f.Files)))
+	binary.Write(&buf, binary.LittleEndian, ix.offsets)
+	binary.Write(&buf, binary.LittleEndian, ix.words)
+
+	// Write to a temporary path so a concurrent reader never sees a partial
+	// index.
+	tmpPath := path + trigramIndexTempSuffix
+	if err := ioutil.WriteFile(tmpPath, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+func readTrigramIndex(path string, f *ZipFile) (*TrigramIndex, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(b, []byte(trigramIndexMagic)) {
+		return nil, errors.New("unknown trigram index format")
+	}
+	r := bytes.NewReader(b[len(trigramIndexMagic):])
+
+	var header struct {
+		ZipSize  uint64
+		NumFiles uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.ZipSize != uint64(len(f.Data)) || header.NumFiles != uint32(len(f.Files)) {
+		return nil, errors.New("trigram index does not match zip")
+	}
+
+	ix := &TrigramIndex{offsets: make([]uint32, header.NumFiles+1)}
+	if err := binary.Read(r, binary.LittleEndian, ix.offsets); err != nil {
+		return nil, err
+	}
+	nwords := ix.offsets[header.NumFiles]
+	if int64(nwords)*8 != int64(r.Len()) {
+		return nil, errors.New("trigram index is truncated")
+	}
+	ix.words = make([]uint64, nwords)
+	if err := binary.Read(r, binary.LittleEndian, ix.words); err != nil {
+		return nil, err
+	}
+	for i := 0; i < int(header.NumFiles); i++ {
+		if ix.offsets[i] > ix.offsets[i+1] {
+			return nil, errors.New("trigram index is corrupt")
+		}
+	}
+	return ix, nil
+}
+
+func toLowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+var trigramIndexBuilds = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "searcher",
+	Subsystem: "store",
+	Name:      "trigram_index_builds",
+	Help:      "The total number of archive trigram indexes built.",
+})
+
+func init() {
+	prometheus.MustRegister(trigramIndexBuilds)
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestTrigramIndex(t *testing.T) {
+	zf := mockZipFileWithFiles(t, map[string]string{
+		"a.go":     "package main\n\nfunc main() {\n\tfmt.Println(\"Hello World\")\n}\n",
+		"b.txt":    "the quick brown fox",
+		"short":    "ab",
+		"empty.md": "",
+	})
+	ix := zf.TrigramIndex()
+
+	cases := []struct {
+		lit  string
+		want []string
+	}{
+		// Lowercase trigrams are indexed, so case is ignored.
+		{"Println", []string{"a.go"}},
+		{"hello world", []string{"a.go"}},
+		{"QUICK", []string{"b.txt"}},
+
+		// Literals shorter than a trigram cannot be checked.
+		{"ab", []string{"a.go", "b.txt", "empty.md", "short"}},
+
+		{"zzz", nil},
+		{"brown fox jumps", nil},
+	}
+	for _, c := range cases {
+		var got []string
+		for i, f := range zf.Files {
+			if ix.MayContain(i, []byte(c.lit)) {
+				got = append(got, f.Name)
+			}
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("MayContain(%q): got %q, want %q", c.lit, got, c.want)
+		}
+	}
+}
+
+func TestTrigramIndex_persist(t *testing.T) {
+	d, err := ioutil.TempDir("", "trigram_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	path := filepath.Join(d, "repo.zip"+trigramIndexSuffix)
+
+	zf := mockZipFileWithFiles(t, map[string]string{
+		"a.go":  "func main() {}",
+		"b.txt": "the quick brown fox",
+	})
+	want := buildTrigramIndex(zf)
+	if err := writeTrigramIndex(path, zf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readTrigramIndex(path, zf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("read index does not match written index")
+	}
+
+	// An index for a different zip must not be used.
+	other := mockZipFileWithFiles(t, map[string]string{"a.go": "func main() {}"})
+	if _, err := readTrigramIndex(path, other); err == nil {
+		t.Error("expected error reading index for a different zip")
+	}
+}
+
+func mockZipFileWithFiles(t *testing.T, files map[string]string) *ZipFile {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range sortedKeys(files) {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zf, err := MockZipFile(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zf
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestRemoveTrigramIndexTempFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trigram_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a.zip", "a.zip" + trigramIndexSuffix, "b.zip" + trigramIndexSuffix + trigramIndexTempSuffix} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	removeTrigramIndexTempFiles(dir)
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fi := range infos {
+		names = append(names, fi.Name())
+	}
+	if want := []string{"a.zip", "a.zip" + trigramIndexSuffix}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
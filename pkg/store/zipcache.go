@@ -102,6 +102,9 @@ type ZipFile struct {
 	Data   []byte
 	f      *os.File
 	wg     sync.WaitGroup // ensures underlying file is not munmap'd or closed while in use
+
+	indexOnce sync.Once // protects index
+	index     *TrigramIndex
 }
 
 func readZipFile(path string) (*ZipFile, error) {
@@ -37,8 +37,14 @@ func TestZipCacheDelete(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	zf.TrigramIndex()
 	zf.Close() // don't block eviction of this zipFile
 
+	// Make sure the trigram index was persisted next to the zip.
+	if _, err := os.Stat(path + trigramIndexSuffix); err != nil {
+		t.Fatal(err)
+	}
+
 	// Make sure it's there.
 	if n := s.ZipCache.count(); n != 1 {
 		t.Fatalf("expected 1 item in cache, got %d", n)
@@ -60,4 +66,8 @@ func TestZipCacheDelete(t *testing.T) {
 	if !os.IsNotExist(err) {
 		t.Errorf("expected non-existence error, got %v", err)
 	}
+	_, err = os.Stat(path + trigramIndexSuffix)
+	if !os.IsNotExist(err) {
+		t.Errorf("expected non-existence error for trigram index, got %v", err)
+	}
 }
@@ -57,4 +57,9 @@ type Symbol struct {
 	Pattern    string
 
 	FileLimited bool
+
+	// RefCount is the number of times Name occurs as an identifier in the
+	// repository, including its definition. It is used to rank search
+	// results.
+	RefCount int
 }
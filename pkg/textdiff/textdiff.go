@@ -0,0 +1,111 @@
+// Package textdiff computes line-based diffs of text.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffContext is the number of unchanged lines around each change in a
+// unified diff.
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// Unified returns a unified diff from a to b, the old and new contents of the
+// file at path, in the format expected by git apply. It returns only the file
+// header if a and b are equal.
+func Unified(path, a, b string) string {
+	dmp := diffmatchpatch.New()
+	ca, cb, lineArray := dmp.DiffLinesToChars(a, b)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(ca, cb, false), lineArray)
+
+	var lines []diffLine
+	for _, d := range diffs {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, text := range strings.SplitAfter(d.Text, "\n") {
+			if text != "" {
+				lines = append(lines, diffLine{op: op, text: text})
+			}
+		}
+	}
+
+	// aLines[i] and bLines[i] are the number of lines of a and b before
+	// lines[i].
+	aLines := make([]int, len(lines)+1)
+	bLines := make([]int, len(lines)+1)
+	for i, l := range lines {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if l.op != '+' {
+			aLines[i+1]++
+		}
+		if l.op != '-' {
+			bLines[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", path, path)
+	for i := 0; i < len(lines); {
+		for i < len(lines) && lines[i].op == ' ' {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+
+		// A hunk extends until there are more than 2*diffContext
+		// unchanged lines after a change.
+		start, end := i-diffContext, i
+		if start < 0 {
+			start = 0
+		}
+		for j := i; j < len(lines); j++ {
+			if lines[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		stop := end + diffContext
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(aLines[start], aLines[stop]-aLines[start]),
+			hunkRange(bLines[start], bLines[stop]-bLines[start]))
+		for _, l := range lines[start:stop] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+	return out.String()
+}
+
+// hunkRange formats the range of count lines after the first before lines of
+// a file for a hunk header.
+func hunkRange(before, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
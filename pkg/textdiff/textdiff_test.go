@@ -0,0 +1,59 @@
+package textdiff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := map[string]struct {
+		a, b string
+		want string
+	}{
+		"single line": {
+			a:    "foo\n",
+			b:    "bar\n",
+			want: "@@ -1 +1 @@\n-foo\n+bar\n",
+		},
+		"context": {
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		"separate hunks": {
+			a:    "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			b:    "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want: "@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		"merged hunks": {
+			a:    "a\n1\n2\n3\n4\n5\n6\nb\n",
+			b:    "A\n1\n2\n3\n4\n5\n6\nB\n",
+			want: "@@ -1,8 +1,8 @@\n-a\n+A\n 1\n 2\n 3\n 4\n 5\n 6\n-b\n+B\n",
+		},
+		"insertion": {
+			a:    "a\nb\n",
+			b:    "a\nx\nb\n",
+			want: "@@ -1,2 +1,3 @@\n a\n+x\n b\n",
+		},
+		"new file": {
+			a:    "",
+			b:    "a\n",
+			want: "@@ -0,0 +1 @@\n+a\n",
+		},
+		"no newline at end of file": {
+			a:    "a\nb",
+			b:    "a\nc",
+			want: "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		"unchanged": {
+			a:    "a\n",
+			b:    "a\n",
+			want: "",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			want := "--- a/f\n+++ b/f\n" + test.want
+			if got := Unified("f", test.a, test.b); got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package git
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+)
+
+// runBatched runs the git command args in repo with the gitserver.BatchLoader
+// of ctx, so that it is sent to gitserver together with the other commands
+// the request runs at the same time. It returns nil if ctx has no batch
+// loader or the batch could not be run, in which case the caller runs the
+// command itself.
+func runBatched(ctx context.Context, repo gitserver.Repo, ensureRevision string, args ...string) *gitserver.BatchCmd {
+	l := gitserver.BatchLoaderFromContext(ctx)
+	if l == nil {
+		return nil
+	}
+	cmd, err := l.Run(ctx, repo, ensureRevision, args...)
+	if err != nil {
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,101 @@
+package git_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestBatchLoader_treePage(t *testing.T) {
+	t.Parallel()
+
+	repo := makeGitRepository(t,
+		"mkdir dir",
+		"echo -n hello > dir/file",
+		"echo -n readme > README",
+		"git add dir/file README",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m commit1 --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+	)
+
+	// Clone the repository without a batch loader.
+	head, err := git.ResolveRevision(context.Background(), repo, nil, "HEAD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run the calls of a tree page view concurrently, as the GraphQL
+	// resolvers do.
+	ctx := gitserver.DefaultClient.WithBatchLoader(context.Background())
+	var (
+		wg      sync.WaitGroup
+		resolve api.CommitID
+		commit  *git.Commit
+		readme  []byte
+		root    []string
+		dir     []string
+		errs    = make([]error, 5)
+	)
+	wg.Add(6)
+	go func() {
+		defer wg.Done()
+		resolve, errs[0] = git.ResolveRevision(ctx, repo, nil, "master", nil)
+	}()
+	go func() {
+		defer wg.Done()
+		commit, errs[1] = git.GetCommit(ctx, repo, nil, head)
+	}()
+	go func() {
+		defer wg.Done()
+		readme, errs[2] = git.ReadFile(ctx, repo, head, "README")
+	}()
+	go func() {
+		defer wg.Done()
+		fis, err := git.ReadDir(ctx, repo, head, "", false)
+		for _, fi := range fis {
+			root = append(root, fi.Name())
+		}
+		errs[3] = err
+	}()
+	go func() {
+		defer wg.Done()
+		fis, err := git.ReadDir(ctx, repo, head, "dir", false)
+		for _, fi := range fis {
+			dir = append(dir, fi.Name())
+		}
+		errs[4] = err
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := git.ReadFile(ctx, repo, head, "missing")
+		if err == nil {
+			t.Error("expected reading a missing file to fail")
+		}
+	}()
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("call %d: %s", i, err)
+		}
+	}
+
+	if resolve != head {
+		t.Errorf("got revision %s, want %s", resolve, head)
+	}
+	if commit == nil || commit.ID != head || commit.Message != "commit1" || commit.Author.Email != "a@a.com" {
+		t.Errorf("unexpected commit %+v", commit)
+	}
+	if string(readme) != "readme" {
+		t.Errorf("got README %q, want %q", readme, "readme")
+	}
+	if want := []string{"README", "dir"}; !reflect.DeepEqual(root, want) {
+		t.Errorf("got root entries %q, want %q", root, want)
+	}
+	if want := []string{"file"}; !reflect.DeepEqual(dir, want) {
+		t.Errorf("got dir entries %q, want %q", dir, want)
+	}
+}
@@ -34,9 +34,33 @@ func ReadFile(ctx context.Context, repo gitserver.Repo, commit api.CommitID, nam
 func readFileBytes(ctx context.Context, repo gitserver.Repo, commit api.CommitID, name string) ([]byte, error) {
 	ensureAbsCommit(commit)
 
+	// Within a request with a batch loader, read the file together with the
+	// other git commands of the request.
+	if cmd := runBatched(ctx, repo, "", "show", string(commit)+":"+name); cmd != nil {
+		out, stderr, err := cmd.DividedOutput()
+		if err != nil {
+			out = append(out, stderr...)
+		}
+		return parseShowFileResult(ctx, repo, commit, name, cmd.Args, out, err)
+	}
+
+	// Prefer reading the blob without running git. Anything gitserver can't
+	// serve that way (including submodules) is handled by `git show`.
+	if b, err := gitserver.DefaultClient.ReadBlob(ctx, repo.Name, commit, name); err == nil {
+		return b, nil
+	} else if os.IsNotExist(err) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
 	cmd := gitserver.DefaultClient.Command("git", "show", string(commit)+":"+name)
 	cmd.Repo = repo
 	out, err := cmd.CombinedOutput(ctx)
+	return parseShowFileResult(ctx, repo, commit, name, cmd.Args, out, err)
+}
+
+// parseShowFileResult interprets the result of the git show command args,
+// which reads the file name at commit.
+func parseShowFileResult(ctx context.Context, repo gitserver.Repo, commit api.CommitID, name string, args []string, out []byte, err error) ([]byte, error) {
 	if err != nil {
 		if bytes.Contains(out, []byte("exists on disk, but not in")) || bytes.Contains(out, []byte("does not exist")) {
 			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
@@ -52,7 +76,7 @@ func readFileBytes(ctx context.Context, repo gitserver.Repo, commit api.CommitID
 				return nil, nil
 			}
 		}
-		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", args, out))
 	}
 	return out, nil
 }
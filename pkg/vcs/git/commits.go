@@ -65,7 +65,41 @@ func getCommit(ctx context.Context, repo gitserver.Repo, remoteURLFunc func() (s
 		return nil, err
 	}
 
-	commits, err := commitLog(ctx, repo, CommitsOptions{Range: string(id), N: 1, RemoteURLFunc: remoteURLFunc})
+	// Within a request with a batch loader, read the commit together with
+	// the other git commands of the request.
+	opt := CommitsOptions{Range: string(id), N: 1, RemoteURLFunc: remoteURLFunc}
+	args, err := commitLogArgs([]string{"log", logFormatWithoutRefs}, opt)
+	if err != nil {
+		return nil, err
+	}
+	if cmd := runBatched(ctx, repo, string(id), args...); cmd != nil {
+		data, stderr, err := cmd.DividedOutput()
+		commits, err := parseCommitLogResult(repo.Name, cmd.Args, opt, data, stderr, err)
+		if err != nil {
+			return nil, err
+		}
+		if len(commits) != 1 {
+			return nil, fmt.Errorf("git log: expected 1 commit, got %d", len(commits))
+		}
+		return commits[0], nil
+	}
+
+	// Prefer reading the commit without running git. If gitserver doesn't
+	// have it, `git log` will fetch it.
+	if IsAbsoluteRevision(string(id)) {
+		if c, err := gitserver.DefaultClient.GetCommit(ctx, repo.Name, id); err == nil {
+			committer := Signature(c.Committer)
+			return &Commit{
+				ID:        c.ID,
+				Author:    Signature(c.Author),
+				Committer: &committer,
+				Message:   c.Message,
+				Parents:   c.Parents,
+			}, nil
+		}
+	}
+
+	commits, err := commitLog(ctx, repo, opt)
 	if err != nil {
 		return nil, err
 	}
@@ -140,14 +174,25 @@ func commitLog(ctx context.Context, repo gitserver.Repo, opt CommitsOptions) (co
 // revision responses and converts them into RevisionNotFoundError.
 func runCommitLog(ctx context.Context, cmd *gitserver.Cmd, opt CommitsOptions) ([]*Commit, error) {
 	data, stderr, err := cmd.DividedOutput(ctx)
+	return parseCommitLogResult(cmd.Repo.Name, cmd.Args, opt, data, stderr, err)
+}
+
+// parseCommitLogResult interprets the result of the git log command args run
+// with opt in repo.
+func parseCommitLogResult(repo api.RepoName, args []string, opt CommitsOptions, data, stderr []byte, err error) ([]*Commit, error) {
 	if err != nil {
 		data = bytes.TrimSpace(data)
 		if isBadObjectErr(string(stderr), string(opt.Range)) {
-			return nil, &RevisionNotFoundError{Repo: cmd.Repo.Name, Spec: string(opt.Range)}
+			return nil, &RevisionNotFoundError{Repo: repo, Spec: string(opt.Range)}
 		}
-		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, data))
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", args, data))
 	}
+	return parseCommitLog(data)
+}
 
+// parseCommitLog parses the output of `git log` formatted with
+// logFormatWithoutRefs.
+func parseCommitLog(data []byte) ([]*Commit, error) {
 	allParts := bytes.Split(data, []byte{'\x00'})
 	numCommits := len(allParts) / partsPerCommit
 	commits := make([]*Commit, 0, numCommits)
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+)
+
+// DiffPaths returns the paths of the files which differ between the
+// specified commits. Paths which exist in b (because they were added or
+// modified) are returned in changed, and paths which only exist in a are
+// returned in deleted. A rename is reported as a deletion and an addition.
+func DiffPaths(ctx context.Context, repo gitserver.Repo, a, b api.CommitID) (changed, deleted []string, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: DiffPaths")
+	span.SetTag("A", a)
+	span.SetTag("B", b)
+	defer span.Finish()
+
+	if err := checkSpecArgSafety(string(a)); err != nil {
+		return nil, nil, err
+	}
+	if err := checkSpecArgSafety(string(b)); err != nil {
+		return nil, nil, err
+	}
+
+	cmd := gitserver.DefaultClient.Command("git", "diff", "--name-status", "--no-renames", "-z", string(a), string(b), "--")
+	cmd.Repo = repo
+	out, stderr, err := cmd.DividedOutput(ctx)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, stderr))
+	}
+
+	if len(out) == 0 {
+		return nil, nil, nil
+	}
+	// The output is a NUL separated list of alternating statuses and paths.
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	if len(fields)%2 != 0 {
+		return nil, nil, fmt.Errorf("unexpected output from git command %v: %q", cmd.Args, out)
+	}
+	for i := 0; i < len(fields); i += 2 {
+		status, path := fields[i], string(fields[i+1])
+		if bytes.Equal(status, []byte("D")) {
+			deleted = append(deleted, path)
+		} else {
+			changed = append(changed, path)
+		}
+	}
+	return changed, deleted, nil
+}
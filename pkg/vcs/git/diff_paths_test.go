@@ -0,0 +1,52 @@
+package git_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestDiffPaths(t *testing.T) {
+	t.Parallel()
+
+	repo := makeGitRepository(t,
+		"echo line1 > f",
+		"echo line1 > g",
+		"mkdir dir && echo line1 > dir/h",
+		"git add f g dir/h",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m foo --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+		"git tag base",
+		"echo line2 >> f",
+		"git rm g",
+		"git mv dir/h dir/i",
+		"echo line1 > 'with space'",
+		"git add f 'with space'",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m bar --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+	)
+
+	base, err := git.ResolveRevision(ctx, repo, nil, "base", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := git.ResolveRevision(ctx, repo, nil, "HEAD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, deleted, err := git.DiffPaths(ctx, repo, base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dir/i", "f", "with space"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("got changed %q, want %q", changed, want)
+	}
+	if want := []string{"dir/h", "g"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("got deleted %q, want %q", deleted, want)
+	}
+
+	changed, deleted, err = git.DiffPaths(ctx, repo, head, head)
+	if err != nil || len(changed) != 0 || len(deleted) != 0 {
+		t.Errorf("got %q, %q, %v, want no paths", changed, deleted, err)
+	}
+}
@@ -109,6 +109,12 @@ func ListBranches(ctx context.Context, repo gitserver.Repo, opt BranchesOptions)
 	span.SetTag("Opt", opt)
 	defer span.Finish()
 
+	// Prefer listing the branches with two batch requests to gitserver: one
+	// for the branches, and one for the commit information of all branches.
+	if branches, err := listBranchesBatch(ctx, repo, opt); err != gitserver.ErrBatchUnavailable {
+		return branches, err
+	}
+
 	f := make(branchFilter)
 	if opt.MergedInto != "" {
 		b, err := branches(ctx, repo, "--merged", opt.MergedInto)
@@ -156,6 +162,99 @@ func ListBranches(ctx context.Context, repo gitserver.Repo, opt BranchesOptions)
 	return branches, nil
 }
 
+// listBranchesBatch is ListBranches using batch requests. It returns
+// gitserver.ErrBatchUnavailable if gitserver does not support them.
+func listBranchesBatch(ctx context.Context, repo gitserver.Repo, opt BranchesOptions) ([]*Branch, error) {
+	if opt.BehindAheadBranch != "" {
+		if err := checkSpecArgSafety(opt.BehindAheadBranch); err != nil {
+			return nil, err
+		}
+	}
+
+	batch := gitserver.DefaultClient.Batch(repo)
+	var mergedCmd, containsCmd *gitserver.BatchCmd
+	if opt.MergedInto != "" {
+		mergedCmd = batch.Command("git", "branch", "--merged", opt.MergedInto)
+	}
+	if opt.ContainsCommit != "" {
+		containsCmd = batch.Command("git", "branch", "--contains="+opt.ContainsCommit)
+	}
+	showRefCmd := batch.Command("git", "show-ref", "--heads")
+	if err := batch.Run(ctx); err != nil {
+		return nil, err
+	}
+
+	f := make(branchFilter)
+	for _, cmd := range []*gitserver.BatchCmd{mergedCmd, containsCmd} {
+		if cmd == nil {
+			continue
+		}
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("exec %v in %s failed: %v (output follows)\n\n%s", cmd.Args, repo, err, out)
+		}
+		f.add(parseBranches(out))
+	}
+	out, err := showRefCmd.CombinedOutput()
+	refs, err := parseShowRef(showRefCmd.Args, showRefCmd.ExitStatus, out, err)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []*Branch
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref[1], "refs/heads/")
+		if f.allows(name) {
+			branches = append(branches, &Branch{Name: name, Head: api.CommitID(ref[0])})
+		}
+	}
+	if len(branches) == 0 || (!opt.IncludeCommit && opt.BehindAheadBranch == "") {
+		return branches, nil
+	}
+
+	batch = gitserver.DefaultClient.Batch(repo)
+	commitCmds := make([]*gitserver.BatchCmd, len(branches))
+	countsCmds := make([]*gitserver.BatchCmd, len(branches))
+	for i, branch := range branches {
+		if opt.IncludeCommit {
+			commitCmds[i] = batch.Command("git", "log", logFormatWithoutRefs, "-n", "1", string(branch.Head))
+		}
+		if opt.BehindAheadBranch != "" {
+			countsCmds[i] = batch.Command("git", behindAheadArgs("refs/heads/"+opt.BehindAheadBranch, "refs/heads/"+branch.Name)...)
+		}
+	}
+	if err := batch.Run(ctx); err != nil {
+		return nil, err
+	}
+
+	for i, branch := range branches {
+		if cmd := commitCmds[i]; cmd != nil {
+			out, err := cmd.Output()
+			if err != nil {
+				return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+			}
+			commits, err := parseCommitLog(out)
+			if err != nil {
+				return nil, err
+			}
+			if len(commits) != 1 {
+				return nil, fmt.Errorf("git log: expected 1 commit, got %d", len(commits))
+			}
+			branch.Commit = commits[0]
+		}
+		if cmd := countsCmds[i]; cmd != nil {
+			out, err := cmd.Output()
+			if err != nil {
+				return nil, err
+			}
+			if branch.Counts, err = parseBehindAhead(out); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return branches, nil
+}
+
 // branches runs the `git branch` command followed by the given arguments and
 // returns the list of branches if successful.
 func branches(ctx context.Context, repo gitserver.Repo, args ...string) ([]string, error) {
@@ -165,13 +264,18 @@ func branches(ctx context.Context, repo gitserver.Repo, args ...string) ([]strin
 	if err != nil {
 		return nil, fmt.Errorf("exec %v in %s failed: %v (output follows)\n\n%s", cmd.Args, cmd.Repo, err, out)
 	}
+	return parseBranches(out), nil
+}
+
+// parseBranches parses the output of `git branch`.
+func parseBranches(out []byte) []string {
 	lines := strings.Split(string(out), "\n")
 	lines = lines[:len(lines)-1]
 	branches := make([]string, len(lines))
 	for i, line := range lines {
 		branches[i] = line[2:]
 	}
-	return branches, nil
+	return branches
 }
 
 // GetBehindAhead returns the behind/ahead commit counts information for right vs. left (both Git
@@ -187,12 +291,21 @@ func GetBehindAhead(ctx context.Context, repo gitserver.Repo, left, right string
 		return nil, err
 	}
 
-	cmd := gitserver.DefaultClient.Command("git", "rev-list", "--count", "--left-right", fmt.Sprintf("%s...%s", left, right))
+	cmd := gitserver.DefaultClient.Command("git", behindAheadArgs(left, right)...)
 	cmd.Repo = repo
 	out, err := cmd.Output(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return parseBehindAhead(out)
+}
+
+func behindAheadArgs(left, right string) []string {
+	return []string{"rev-list", "--count", "--left-right", fmt.Sprintf("%s...%s", left, right)}
+}
+
+// parseBehindAhead parses the output of `git rev-list --count --left-right`.
+func parseBehindAhead(out []byte) (*BehindAhead, error) {
 	behindAhead := strings.Split(strings.TrimSuffix(string(out), "\n"), "\t")
 	b, err := strconv.ParseUint(behindAhead[0], 10, 0)
 	if err != nil {
@@ -257,16 +370,22 @@ func showRef(ctx context.Context, repo gitserver.Repo, arg string) ([][2]string,
 	cmd := gitserver.DefaultClient.Command("git", "show-ref", arg)
 	cmd.Repo = repo
 	out, err := cmd.CombinedOutput(ctx)
+	return parseShowRef(cmd.Args, cmd.ExitStatus, out, err)
+}
+
+// parseShowRef parses the output of `git show-ref`, which was run with args
+// and exited with exitStatus and err.
+func parseShowRef(args []string, exitStatus int, out []byte, err error) ([][2]string, error) {
 	if err != nil {
 		if vcs.IsRepoNotExist(err) {
 			return nil, err
 		}
 		// Exit status of 1 and no output means there were no
 		// results. This is not a fatal error.
-		if cmd.ExitStatus == 1 && len(out) == 0 {
+		if exitStatus == 1 && len(out) == 0 {
 			return nil, nil
 		}
-		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", args, out))
 	}
 
 	out = bytes.TrimSuffix(out, []byte("\n")) // remove trailing newline
@@ -72,30 +72,51 @@ func ResolveRevision(ctx context.Context, repo gitserver.Repo, remoteURLFunc fun
 	if spec == "" {
 		spec = "HEAD"
 	}
+
+	revParseSpec := spec
 	if spec != "HEAD" {
 		// "git rev-parse HEAD^0" is slower than "git rev-parse HEAD"
 		// since it checks that the resolved git object exists. We can
 		// assume it exists for HEAD, but for other commits we should
 		// check.
-		spec = spec + "^0"
+		revParseSpec = spec + "^0"
+	}
+	noEnsureRevision := opt != nil && opt.NoEnsureRevision
+
+	// Within a request with a batch loader, resolve the revision together
+	// with the other git commands of the request.
+	ensureRevision := revParseSpec
+	if noEnsureRevision {
+		ensureRevision = ""
+	}
+	if cmd := runBatched(ctx, repo, ensureRevision, "rev-parse", revParseSpec); cmd != nil {
+		stdout, stderr, err := cmd.DividedOutput()
+		return parseRevParse(repo.Name, cmd.Args, revParseSpec, stdout, stderr, err)
+	}
+
+	// Prefer resolving the revision without running git. gitserver only
+	// does so for ref names and commit IDs which exist; everything else,
+	// including fetching missing revisions, is handled by `git rev-parse`.
+	if commit, err := gitserver.DefaultClient.ResolveRevision(ctx, repo.Name, spec); err == nil {
+		return commit, nil
 	}
 
 	var (
 		commit api.CommitID
 		err    error
 	)
-	cmd := gitserver.DefaultClient.Command("git", "rev-parse", spec)
+	cmd := gitserver.DefaultClient.Command("git", "rev-parse", revParseSpec)
 	cmd.Repo = repo
-	cmd.EnsureRevision = spec
+	cmd.EnsureRevision = revParseSpec
 	retryer := &commandRetryer{
 		cmd:           cmd,
 		remoteURLFunc: remoteURLFunc,
 		exec: func() error {
-			commit, err = runRevParse(ctx, cmd, spec)
+			commit, err = runRevParse(ctx, cmd, revParseSpec)
 			return err
 		},
 	}
-	if opt != nil && opt.NoEnsureRevision {
+	if noEnsureRevision {
 		// Make the commandRetryer no-op so that gitserver does not try to
 		// update the repository.
 		cmd.EnsureRevision = ""
@@ -109,14 +130,20 @@ func ResolveRevision(ctx context.Context, repo gitserver.Repo, remoteURLFunc fun
 // missing revision responses and converts them into RevisionNotFoundError.
 func runRevParse(ctx context.Context, cmd *gitserver.Cmd, spec string) (api.CommitID, error) {
 	stdout, stderr, err := cmd.DividedOutput(ctx)
+	if err != nil && vcs.IsRepoNotExist(err) {
+		return "", err
+	}
+	return parseRevParse(cmd.Name, cmd.Args, spec, stdout, stderr, err)
+}
+
+// parseRevParse interprets the result of the git rev-parse command args for
+// spec in repo.
+func parseRevParse(repo api.RepoName, args []string, spec string, stdout, stderr []byte, err error) (api.CommitID, error) {
 	if err != nil {
-		if vcs.IsRepoNotExist(err) {
-			return "", err
-		}
 		if bytes.Contains(stderr, []byte("unknown revision")) {
-			return "", &RevisionNotFoundError{Repo: cmd.Name, Spec: spec}
+			return "", &RevisionNotFoundError{Repo: repo, Spec: spec}
 		}
-		return "", errors.WithMessage(err, fmt.Sprintf("git command %v failed (stderr: %q)", cmd.Args, stderr))
+		return "", errors.WithMessage(err, fmt.Sprintf("git command %v failed (stderr: %q)", args, stderr))
 	}
 	commit := api.CommitID(bytes.TrimSpace(stdout))
 	if !IsAbsoluteRevision(string(commit)) {
@@ -125,9 +152,9 @@ func runRevParse(ctx context.Context, cmd *gitserver.Cmd, spec string) (api.Comm
 			// if HEAD doesn't point to anything git just returns `HEAD` as the
 			// output of rev-parse. An example where this occurs is an empty
 			// repository.
-			return "", &RevisionNotFoundError{Repo: cmd.Name, Spec: spec}
+			return "", &RevisionNotFoundError{Repo: repo, Spec: spec}
 		}
-		return "", fmt.Errorf("ResolveRevision: got bad commit %q for repo %q at revision %q", commit, cmd.Name, spec)
+		return "", fmt.Errorf("ResolveRevision: got bad commit %q for repo %q at revision %q", commit, repo, spec)
 	}
 	return commit, nil
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
 )
 
@@ -170,6 +171,111 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 		return nil, err
 	}
 
+	var entries []protocol.TreeEntry
+	if cmd := runBatched(ctx, repo, "", lsTreeArgs(commit, path, recurse)...); cmd != nil {
+		// Within a request with a batch loader, list the tree together with
+		// the other git commands of the request.
+		out, stderr, err := cmd.DividedOutput()
+		if err != nil {
+			out = append(out, stderr...)
+		}
+		entries, err = parseLsTreeResult(cmd.Args, path, out, err)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Prefer listing the tree without running git.
+		var err error
+		entries, err = gitserver.DefaultClient.ListTree(ctx, repo.Name, commit, path, recurse)
+		if err != nil {
+			entries, err = lsTreeExec(ctx, repo, commit, path, recurse)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(entries) == 0 {
+		// If we are listing the empty root tree, we will have no output.
+		if stdlibpath.Clean(path) == "." {
+			return []os.FileInfo{}, nil
+		}
+		return nil, &os.PathError{Op: "git ls-tree", Path: path, Err: os.ErrNotExist}
+	}
+
+	trimPath := strings.TrimPrefix(path, "./")
+	prefixLen := strings.LastIndexByte(trimPath, '/') + 1
+	var (
+		gitmodules     *config.Config // parsed .gitmodules, read at most once
+		gitmodulesRead bool
+	)
+	fis := make([]os.FileInfo, len(entries))
+	for i, entry := range entries {
+		name := entry.Path
+		if len(name) < len(trimPath) {
+			// This is in a submodule; return the original path to avoid a slice out of bounds panic
+			// when setting the FileInfo._Name below.
+			name = trimPath
+		}
+
+		var size int64
+		if entry.Size > 0 {
+			size = entry.Size
+		}
+
+		var sys interface{}
+		mode := os.FileMode(entry.Mode)
+		switch entry.Type {
+		case "blob":
+			const gitModeSymlink = 020000
+			if mode&gitModeSymlink != 0 {
+				mode = os.ModeSymlink
+			} else {
+				// Regular file.
+				mode = mode | 0644
+			}
+		case "commit":
+			mode = mode | ModeSubmodule
+			var submodule Submodule
+			if !gitmodulesRead {
+				gitmodulesRead = true
+				if out, err := readFileBytes(ctx, repo, commit, ".gitmodules"); err == nil {
+					var cfg config.Config
+					err := config.NewDecoder(bytes.NewBuffer(out)).Decode(&cfg)
+					if err != nil {
+						return nil, fmt.Errorf("error parsing .gitmodules: %s", err)
+					}
+					gitmodules = &cfg
+				}
+			}
+			if gitmodules != nil {
+				submodule.Path = gitmodules.Section("submodule").Subsection(name).Option("path")
+				submodule.URL = gitmodules.Section("submodule").Subsection(name).Option("url")
+			}
+			submodule.CommitID = api.CommitID(entry.OID)
+			sys = submodule
+		case "tree":
+			mode = mode | os.ModeDir
+		}
+
+		fis[i] = &util.FileInfo{
+			// This returns the full relative path (e.g. "path/to/file.go") when the path arg is "./"
+			// This behavior is necessary to construct the file tree.
+			// In all other cases, it returns the basename (e.g. "file.go").
+			Name_: name[prefixLen:],
+			Mode_: os.FileMode(mode),
+			Size_: size,
+			Sys_:  sys,
+		}
+	}
+	util.SortFileInfosByName(fis)
+
+	return fis, nil
+}
+
+// lsTreeArgs returns the arguments of the `git ls-tree` command which lists
+// the entries at path.
+func lsTreeArgs(commit api.CommitID, path string, recurse bool) []string {
 	args := []string{
 		"ls-tree",
 		"--long", // show size
@@ -183,28 +289,29 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 	if path != "" {
 		args = append(args, "--", filepath.ToSlash(path))
 	}
-	cmd := gitserver.DefaultClient.Command("git", args...)
+	return args
+}
+
+// lsTreeExec returns the entries at path by running `git ls-tree`.
+func lsTreeExec(ctx context.Context, repo gitserver.Repo, commit api.CommitID, path string, recurse bool) ([]protocol.TreeEntry, error) {
+	cmd := gitserver.DefaultClient.Command("git", lsTreeArgs(commit, path, recurse)...)
 	cmd.Repo = repo
 	out, err := cmd.CombinedOutput(ctx)
+	return parseLsTreeResult(cmd.Args, path, out, err)
+}
+
+// parseLsTreeResult parses the result of the `git ls-tree` command args
+// which lists the entries at path.
+func parseLsTreeResult(args []string, path string, out []byte, err error) ([]protocol.TreeEntry, error) {
 	if err != nil {
 		if bytes.Contains(out, []byte("exists on disk, but not in")) {
 			return nil, &os.PathError{Op: "ls-tree", Path: filepath.ToSlash(path), Err: os.ErrNotExist}
 		}
-		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
-	}
-
-	if len(out) == 0 {
-		// If we are listing the empty root tree, we will have no output.
-		if stdlibpath.Clean(path) == "." {
-			return []os.FileInfo{}, nil
-		}
-		return nil, &os.PathError{Op: "git ls-tree", Path: path, Err: os.ErrNotExist}
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", args, out))
 	}
 
-	trimPath := strings.TrimPrefix(path, "./")
-	prefixLen := strings.LastIndexByte(trimPath, '/') + 1
 	lines := strings.Split(string(out), "\x00")
-	fis := make([]os.FileInfo, len(lines)-1)
+	entries := make([]protocol.TreeEntry, 0, len(lines)-1)
 	for i, line := range lines {
 		if i == len(lines)-1 {
 			// last entry is empty
@@ -216,24 +323,16 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 			return nil, fmt.Errorf("invalid `git ls-tree` output: %q", out)
 		}
 		info := strings.SplitN(line[:tabPos], " ", 4)
-		name := line[tabPos+1:]
-		if len(name) < len(trimPath) {
-			// This is in a submodule; return the original path to avoid a slice out of bounds panic
-			// when setting the FileInfo._Name below.
-			name = trimPath
-		}
-
 		if len(info) != 4 {
 			return nil, fmt.Errorf("invalid `git ls-tree` output: %q", out)
 		}
-		typ := info[1]
 		oid := info[2]
 		if !IsAbsoluteRevision(oid) {
 			return nil, fmt.Errorf("invalid `git ls-tree` oid output: %q", oid)
 		}
 
 		sizeStr := strings.TrimSpace(info[3])
-		var size int64
+		size := int64(-1)
 		if sizeStr != "-" {
 			// Size of "-" indicates a dir or submodule.
 			size, err = strconv.ParseInt(sizeStr, 10, 64)
@@ -242,54 +341,18 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 			}
 		}
 
-		var sys interface{}
-		modeVal, err := strconv.ParseInt(info[0], 8, 32)
+		modeVal, err := strconv.ParseUint(info[0], 8, 32)
 		if err != nil {
 			return nil, err
 		}
-		mode := os.FileMode(modeVal)
-		switch typ {
-		case "blob":
-			const gitModeSymlink = 020000
-			if mode&gitModeSymlink != 0 {
-				mode = os.ModeSymlink
-			} else {
-				// Regular file.
-				mode = mode | 0644
-			}
-		case "commit":
-			mode = mode | ModeSubmodule
-			cmd := gitserver.DefaultClient.Command("git", "show", fmt.Sprintf("%s:.gitmodules", commit))
-			cmd.Repo = repo
-			var submodule Submodule
-			if out, err := cmd.Output(ctx); err == nil {
 
-				var cfg config.Config
-				err := config.NewDecoder(bytes.NewBuffer(out)).Decode(&cfg)
-				if err != nil {
-					return nil, fmt.Errorf("error parsing .gitmodules: %s", err)
-				}
-
-				submodule.Path = cfg.Section("submodule").Subsection(name).Option("path")
-				submodule.URL = cfg.Section("submodule").Subsection(name).Option("url")
-			}
-			submodule.CommitID = api.CommitID(oid)
-			sys = submodule
-		case "tree":
-			mode = mode | os.ModeDir
-		}
-
-		fis[i] = &util.FileInfo{
-			// This returns the full relative path (e.g. "path/to/file.go") when the path arg is "./"
-			// This behavior is necessary to construct the file tree.
-			// In all other cases, it returns the basename (e.g. "file.go").
-			Name_: name[prefixLen:],
-			Mode_: os.FileMode(mode),
-			Size_: size,
-			Sys_:  sys,
-		}
+		entries = append(entries, protocol.TreeEntry{
+			Path: line[tabPos+1:],
+			Mode: uint32(modeVal),
+			Type: info[1],
+			OID:  oid,
+			Size: size,
+		})
 	}
-	util.SortFileInfosByName(fis)
-
-	return fis, nil
+	return entries, nil
 }
@@ -131,6 +131,82 @@ const BitbucketServerSchemaJSON = `{
       "description": "Defines whether repositories from this Bitbucket Server instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable Bitbucket Server repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately (subject to rate limiting by Bitbucket Server); site admins can still disable them explicitly, and they'll remain disabled.",
       "type": "boolean",
       "default": false
+    },
+    "authorization": {
+      "title": "BitbucketServerAuthorization",
+      "description": "If non-null, enforces Bitbucket Server repository permissions. Sourcegraph queries the repositories a user can read on behalf of that user, which requires an application link on the Bitbucket Server instance with an incoming OAuth consumer that is allowed to impersonate users.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["identityProvider", "oauth"],
+      "properties": {
+        "identityProvider": {
+          "description": "The source of identity to use when computing permissions. This defines how to compute the Bitbucket Server identity to use for a given Sourcegraph user.",
+          "type": "object",
+          "title": "BitbucketServerIdentityProvider",
+          "required": ["type"],
+          "properties": {
+            "type": {
+              "type": "string",
+              "enum": ["oauth", "username"]
+            }
+          },
+          "oneOf": [
+            { "$ref": "#/definitions/BitbucketServerOAuthIdentity" },
+            { "$ref": "#/definitions/BitbucketServerUsernameIdentity" }
+          ],
+          "!go": {
+            "taggedUnionType": true
+          }
+        },
+        "oauth": {
+          "title": "BitbucketServerOAuth",
+          "description": "OAuth configuration specified when creating the Bitbucket Server Application Link with incoming authentication. Two Legged OAuth with 'ExecuteAs=admin' must be enabled as well as user impersonation.",
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["consumerKey", "signingKey"],
+          "properties": {
+            "consumerKey": {
+              "description": "The OAuth consumer key specified when creating the Application Link in Bitbucket Server.",
+              "type": "string",
+              "minLength": 1
+            },
+            "signingKey": {
+              "description": "Base64 encoding of the OAuth PEM encoded RSA private key used to generate the public key specified when creating the Application Link in Bitbucket Server.",
+              "type": "string",
+              "minLength": 1
+            }
+          }
+        },
+        "ttl": {
+          "description": "The TTL of how long to cache permissions data. This is 3 hours by default.\n\nDecreasing the TTL will increase the load on the code host API. If you have X repos on your instance, it will take ~X/1000 API requests to fetch the complete list for 1 user.  If you have Y users, you will incur X*Y/1000 API requests per cache refresh period.\n\nIf set to zero, Sourcegraph will sync a user's entire accessible repository list on every request (NOT recommended).",
+          "type": "string",
+          "default": "3h"
+        }
+      }
+    }
+  },
+  "definitions": {
+    "BitbucketServerOAuthIdentity": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["type"],
+      "properties": {
+        "type": {
+          "type": "string",
+          "const": "oauth"
+        }
+      }
+    },
+    "BitbucketServerUsernameIdentity": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["type"],
+      "properties": {
+        "type": {
+          "type": "string",
+          "const": "username"
+        }
+      }
     }
   }
 }
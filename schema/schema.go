@@ -91,8 +91,16 @@ func (v *AuthProviders) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"builtin", "saml", "openidconnect", "http-header", "github", "gitlab"})
 }
 
+// BitbucketServerAuthorization description: If non-null, enforces Bitbucket Server repository permissions. Sourcegraph queries the repositories a user can read on behalf of that user, which requires an application link on the Bitbucket Server instance with an incoming OAuth consumer that is allowed to impersonate users.
+type BitbucketServerAuthorization struct {
+	IdentityProvider BitbucketServerIdentityProvider `json:"identityProvider"`
+	Oauth            BitbucketServerOAuth            `json:"oauth"`
+	Ttl              string                          `json:"ttl,omitempty"`
+}
+
 // BitbucketServerConnection description: Configuration for a connection to Bitbucket Server.
 type BitbucketServerConnection struct {
+	Authorization               *BitbucketServerAuthorization  `json:"authorization,omitempty"`
 	Certificate                 string                         `json:"certificate,omitempty"`
 	Exclude                     []*ExcludedBitbucketServerRepo `json:"exclude,omitempty"`
 	ExcludePersonalRepositories bool                           `json:"excludePersonalRepositories,omitempty"`
@@ -106,6 +114,49 @@ type BitbucketServerConnection struct {
 	Url                         string                         `json:"url"`
 	Username                    string                         `json:"username"`
 }
+
+// BitbucketServerIdentityProvider description: The source of identity to use when computing permissions. This defines how to compute the Bitbucket Server identity to use for a given Sourcegraph user.
+type BitbucketServerIdentityProvider struct {
+	Oauth    *BitbucketServerOAuthIdentity
+	Username *BitbucketServerUsernameIdentity
+}
+
+func (v BitbucketServerIdentityProvider) MarshalJSON() ([]byte, error) {
+	if v.Oauth != nil {
+		return json.Marshal(v.Oauth)
+	}
+	if v.Username != nil {
+		return json.Marshal(v.Username)
+	}
+	return nil, errors.New("tagged union type must have exactly 1 non-nil field value")
+}
+func (v *BitbucketServerIdentityProvider) UnmarshalJSON(data []byte) error {
+	var d struct {
+		DiscriminantProperty string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+	switch d.DiscriminantProperty {
+	case "oauth":
+		return json.Unmarshal(data, &v.Oauth)
+	case "username":
+		return json.Unmarshal(data, &v.Username)
+	}
+	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"oauth", "username"})
+}
+
+// BitbucketServerOAuth description: OAuth configuration specified when creating the Bitbucket Server Application Link with incoming authentication. Two Legged OAuth with 'ExecuteAs=admin' must be enabled as well as user impersonation.
+type BitbucketServerOAuth struct {
+	ConsumerKey string `json:"consumerKey"`
+	SigningKey  string `json:"signingKey"`
+}
+type BitbucketServerOAuthIdentity struct {
+	Type string `json:"type"`
+}
+type BitbucketServerUsernameIdentity struct {
+	Type string `json:"type"`
+}
 type BrandAssets struct {
 	Logo   string `json:"logo,omitempty"`
 	Symbol string `json:"symbol,omitempty"`
@@ -155,8 +206,9 @@ type CriticalConfiguration struct {
 
 // Discussions description: Configures Sourcegraph code discussions.
 type Discussions struct {
-	AbuseEmails     []string `json:"abuseEmails,omitempty"`
-	AbuseProtection bool     `json:"abuseProtection,omitempty"`
+	AbuseEmails             []string `json:"abuseEmails,omitempty"`
+	AbuseProtection         bool     `json:"abuseProtection,omitempty"`
+	SyncPullRequestComments bool     `json:"syncPullRequestComments,omitempty"`
 }
 type ExcludedAWSCodeCommitRepo struct {
 	Id   string `json:"id,omitempty"`
@@ -196,6 +248,11 @@ type ExternalIdentity struct {
 	GitlabProvider   string `json:"gitlabProvider"`
 	Type             string `json:"type"`
 }
+type ExternalServiceRepoSizeLimit struct {
+	Action    string `json:"action,omitempty"`
+	MaxSizeMB int    `json:"maxSizeMB,omitempty"`
+	Url       string `json:"url"`
+}
 
 // GitHubAuthProvider description: Configures the GitHub (or GitHub Enterprise) OAuth authentication provider for SSO. In addition to specifying this configuration object, you must also create a OAuth App on your GitHub instance: https://developer.github.com/apps/building-oauth-apps/creating-an-oauth-app/. When a user signs into Sourcegraph or links their GitHub account to their existing Sourcegraph account, GitHub will prompt the user for the repo scope.
 type GitHubAuthProvider struct {
@@ -259,6 +316,13 @@ type GitLabProject struct {
 	Name string `json:"name,omitempty"`
 }
 
+// GitRepoSizeLimits description: Limits on the disk space used by the clone of each repository. A clone which grows larger than its limit is aborted, and the repository is either not cloned or cloned shallowly (with only the most recent commit of each branch and tag), depending on the action. The reason is shown in the repository's mirroring settings.
+type GitRepoSizeLimits struct {
+	Action           string                          `json:"action,omitempty"`
+	ExternalServices []*ExternalServiceRepoSizeLimit `json:"externalServices,omitempty"`
+	MaxSizeMB        int                             `json:"maxSizeMB,omitempty"`
+}
+
 // GitoliteConnection description: Configuration for a connection to Gitolite.
 type GitoliteConnection struct {
 	Blacklist                  string                  `json:"blacklist,omitempty"`
@@ -356,6 +420,21 @@ type ParentSourcegraph struct {
 	Url string `json:"url,omitempty"`
 }
 
+// PermissionsBackgroundSync description: Syncs the repositories each user can read from the repository permissions of code hosts (configured in the `authorization` field of external services) in the background, and stores them in the database. Repository permissions are then checked against the stored permissions instead of by querying the code host on each request. Permissions of users which were never synced are still checked by querying the code host.
+//
+// Only available in Sourcegraph Enterprise.
+type PermissionsBackgroundSync struct {
+	Enabled  bool   `json:"enabled"`
+	Interval string `json:"interval,omitempty"`
+}
+
+// PermissionsExplicit description: Enforces repository permissions which are granted explicitly by site admins (with the GraphQL API), for repositories from code hosts which do not provide permissions. A user can only access such a repository if it is granted to the user, or to an organization the user is a member of, by name or by a pattern matching its name. Site admins can access all repositories.
+//
+// Only available in Sourcegraph Enterprise.
+type PermissionsExplicit struct {
+	ServiceTypes []string `json:"serviceTypes"`
+}
+
 // Phabricator description: Phabricator instance that integrates with this Gitolite instance
 type Phabricator struct {
 	CallsignCommand string `json:"callsignCommand"`
@@ -368,6 +447,10 @@ type PhabricatorConnection struct {
 	Token string   `json:"token,omitempty"`
 	Url   string   `json:"url,omitempty"`
 }
+type ReplacerEngine struct {
+	Command []string `json:"command"`
+	Name    string   `json:"name"`
+}
 type Repos struct {
 	Callsign string `json:"callsign"`
 	Path     string `json:"path"`
@@ -422,7 +505,7 @@ type Sentry struct {
 // Settings description: Configuration settings for users and organizations on Sourcegraph.
 type Settings struct {
 	AlertsShowPatchUpdates bool                      `json:"alerts.showPatchUpdates,omitempty"`
-	Extensions             map[string]bool           `json:"extensions,omitempty"`
+	Extensions             map[string]interface{}    `json:"extensions,omitempty"`
 	Motd                   []string                  `json:"motd,omitempty"`
 	Notices                []*Notice                 `json:"notices,omitempty"`
 	NotificationsSlack     *SlackNotificationsConfig `json:"notifications.slack,omitempty"`
@@ -449,10 +532,14 @@ type SiteConfiguration struct {
 	Extensions                        *Extensions                 `json:"extensions,omitempty"`
 	GitCloneURLToRepositoryName       []*CloneURLToRepositoryName `json:"git.cloneURLToRepositoryName,omitempty"`
 	GitMaxConcurrentClones            int                         `json:"gitMaxConcurrentClones,omitempty"`
+	GitRepoSizeLimits                 *GitRepoSizeLimits          `json:"gitRepoSizeLimits,omitempty"`
 	GithubClientID                    string                      `json:"githubClientID,omitempty"`
 	GithubClientSecret                string                      `json:"githubClientSecret,omitempty"`
 	MaxReposToSearch                  int                         `json:"maxReposToSearch,omitempty"`
 	ParentSourcegraph                 *ParentSourcegraph          `json:"parentSourcegraph,omitempty"`
+	PermissionsBackgroundSync         *PermissionsBackgroundSync  `json:"permissions.backgroundSync,omitempty"`
+	PermissionsExplicit               *PermissionsExplicit        `json:"permissions.explicit,omitempty"`
+	ReplacerEngines                   []*ReplacerEngine           `json:"replacer.engines,omitempty"`
 	RepoListUpdateInterval            int                         `json:"repoListUpdateInterval,omitempty"`
 	SearchIndexEnabled                *bool                       `json:"search.index.enabled,omitempty"`
 	SearchLargeFiles                  []string                    `json:"search.largeFiles,omitempty"`
@@ -106,7 +106,7 @@ const SettingsSchemaJSON = `{
       "default": true
     },
     "extensions": {
-      "description": "The Sourcegraph extensions to use. Enable an extension by adding a property ` + "`" + `\"my/extension\": true` + "`" + ` (where ` + "`" + `my/extension` + "`" + ` is the extension ID). Override a previously enabled extension and disable it by setting its value to ` + "`" + `false` + "`" + `.",
+      "description": "The Sourcegraph extensions to use. Enable an extension by adding a property ` + "`" + `\"my/extension\": true` + "`" + ` (where ` + "`" + `my/extension` + "`" + ` is the extension ID). Override a previously enabled extension and disable it by setting its value to ` + "`" + `false` + "`" + `. To pin an enabled extension to a range of release versions, set its value to the version range (such as ` + "`" + `\"^1.2.0\"` + "`" + `) instead of ` + "`" + `true` + "`" + `; the release with the greatest version in the range is used.",
       "type": "object",
       "propertyNames": {
         "type": "string",
@@ -114,8 +114,17 @@ const SettingsSchemaJSON = `{
         "pattern": "^([^/]+/)?[^/]+/[^/]+$"
       },
       "additionalProperties": {
-        "type": "boolean",
-        "description": "` + "`" + `true` + "`" + ` to enable the extension, ` + "`" + `false` + "`" + ` to disable the extension (if it was previously enabled)"
+        "oneOf": [
+          {
+            "type": "boolean",
+            "description": "` + "`" + `true` + "`" + ` to enable the extension, ` + "`" + `false` + "`" + ` to disable the extension (if it was previously enabled)"
+          },
+          {
+            "type": "string",
+            "description": "A semantic version range (such as ` + "`" + `\"1.2.3\"` + "`" + `, ` + "`" + `\"^1.2.0\"` + "`" + `, ` + "`" + `\"~1.2.0\"` + "`" + `, or ` + "`" + `\">=1.2.0 <2.0.0\"` + "`" + `) to enable the extension and pin it to the release with the greatest version in the range",
+            "minLength": 1
+          }
+        ]
       }
     }
   },
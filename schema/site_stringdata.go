@@ -37,6 +37,31 @@ const SiteSchemaJSON = `{
       "group": "Search",
       "examples": [["go.sum", "package-lock.json", "*.thrift"]]
     },
+    "replacer.engines": {
+      "description": "Rewrite engines which run a command on each file, in addition to the built-in ` + "`" + `comby` + "`" + ` and ` + "`" + `regexp` + "`" + ` engines. A replace request selects an engine by name.",
+      "type": "array",
+      "items": {
+        "title": "ReplacerEngine",
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name", "command"],
+        "properties": {
+          "name": {
+            "description": "The name which replace requests use to select this engine. It must not be ` + "`" + `comby` + "`" + ` or ` + "`" + `regexp` + "`" + `.",
+            "type": "string",
+            "pattern": "^[a-zA-Z0-9_-]+$"
+          },
+          "command": {
+            "description": "The command and its arguments. It is run once for each file with the contents of the file on stdin, and must print the rewritten file on stdout. Each element is a Go template (https://golang.org/pkg/text/template/) which can reference ` + "`" + `{{.MatchTemplate}}` + "`" + `, ` + "`" + `{{.RewriteTemplate}}` + "`" + `, ` + "`" + `{{.FileExtension}}` + "`" + ` and ` + "`" + `{{.Path}}` + "`" + `. The command is not run by a shell.",
+            "type": "array",
+            "items": { "type": "string" },
+            "minItems": 1
+          }
+        }
+      },
+      "group": "Search",
+      "examples": [[{ "name": "sed", "command": ["sed", "-E", "s/{{.MatchTemplate}}/{{.RewriteTemplate}}/g"] }]]
+    },
     "experimentalFeatures": {
       "description": "Experimental features to enable or disable. Features that are now enabled by default are marked as deprecated.",
       "type": "object",
@@ -109,6 +134,36 @@ const SiteSchemaJSON = `{
       "default": 5,
       "group": "External services"
     },
+    "gitRepoSizeLimits": {
+      "description": "Limits on the disk space used by the clone of each repository. A clone which grows larger than its limit is aborted, and the repository is either not cloned or cloned shallowly (with only the most recent commit of each branch and tag), depending on the action. The reason is shown in the repository's mirroring settings.",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "maxSizeMB": {
+          "description": "The maximum size in megabytes of the clone of a repository. 0 means no limit.",
+          "type": "integer",
+          "minimum": 0,
+          "default": 0
+        },
+        "action": {
+          "$ref": "#/definitions/RepoSizeLimitAction"
+        },
+        "externalServices": {
+          "description": "Limits for repositories cloned from specific code hosts, overriding maxSizeMB and action.",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/ExternalServiceRepoSizeLimit"
+          }
+        }
+      },
+      "group": "External services",
+      "examples": [
+        {
+          "maxSizeMB": 5000,
+          "externalServices": [{ "url": "https://github.example.com", "maxSizeMB": 20000, "action": "shallow" }]
+        }
+      ]
+    },
     "repoListUpdateInterval": {
       "description": "Interval (in minutes) for checking code hosts (such as GitHub, Gitolite, etc.) for new repositories.",
       "type": "integer",
@@ -156,6 +211,52 @@ const SiteSchemaJSON = `{
       ],
       "group": "Security"
     },
+    "permissions.explicit": {
+      "description": "Enforces repository permissions which are granted explicitly by site admins (with the GraphQL API), for repositories from code hosts which do not provide permissions. A user can only access such a repository if it is granted to the user, or to an organization the user is a member of, by name or by a pattern matching its name. Site admins can access all repositories.\n\nOnly available in Sourcegraph Enterprise.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["serviceTypes"],
+      "properties": {
+        "serviceTypes": {
+          "description": "The types of code hosts (e.g. \"gitolite\" or \"other\") whose repositories are subject to explicit permissions.",
+          "type": "array",
+          "items": {
+            "type": "string"
+          },
+          "minItems": 1
+        }
+      },
+      "examples": [
+        {
+          "serviceTypes": ["gitolite", "other"]
+        }
+      ],
+      "group": "Security"
+    },
+    "permissions.backgroundSync": {
+      "description": "Syncs the repositories each user can read from the repository permissions of code hosts (configured in the ` + "`" + `authorization` + "`" + ` field of external services) in the background, and stores them in the database. Repository permissions are then checked against the stored permissions instead of by querying the code host on each request. Permissions of users which were never synced are still checked by querying the code host.\n\nOnly available in Sourcegraph Enterprise.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["enabled"],
+      "properties": {
+        "enabled": {
+          "description": "Whether to sync repository permissions in the background.",
+          "type": "boolean"
+        },
+        "interval": {
+          "description": "How often to sync the repository permissions of all users. Permissions are up to this old (plus the time it takes to sync all users, and the ` + "`" + `ttl` + "`" + ` of the code host's ` + "`" + `authorization` + "`" + ` cache).",
+          "type": "string",
+          "default": "1h"
+        }
+      },
+      "examples": [
+        {
+          "enabled": true,
+          "interval": "30m"
+        }
+      ],
+      "group": "Security"
+    },
     "branding": {
       "description": "Customize Sourcegraph homepage logo and search icon.\n\nOnly available in Sourcegraph Enterprise.",
       "type": "object",
@@ -322,6 +423,11 @@ const SiteSchemaJSON = `{
           "type": "array",
           "items": { "type": "string" },
           "default": []
+        },
+        "syncPullRequestComments": {
+          "description": "Mirror discussion threads on a branch with an open GitHub pull request or GitLab merge request to review comments on the pull request, and import replies from the code host. Edits and deletions are propagated in both directions. Uses the token of the GitHub or GitLab external service that the repository is from.",
+          "type": "boolean",
+          "default": false
         }
       },
       "group": "Experimental",
@@ -329,6 +435,32 @@ const SiteSchemaJSON = `{
     }
   },
   "definitions": {
+    "ExternalServiceRepoSizeLimit": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["url"],
+      "properties": {
+        "url": {
+          "description": "The URL of the code host, as in the url property of its external service configuration (e.g. https://github.com). A repository is cloned from the code host if its clone URL has the same host name and its path starts with the path of this URL. If several limits match, the one with the longest path is used, so code hosts sharing a host name (e.g. https://git.example.com/team-a and https://git.example.com/team-b) can have different limits.",
+          "type": "string",
+          "pattern": "^[a-z][a-z0-9+.-]*://"
+        },
+        "maxSizeMB": {
+          "description": "The maximum size in megabytes of the clone of a repository from this code host. 0 means no limit.",
+          "type": "integer",
+          "minimum": 0
+        },
+        "action": {
+          "$ref": "#/definitions/RepoSizeLimitAction"
+        }
+      }
+    },
+    "RepoSizeLimitAction": {
+      "description": "What to do with a repository whose clone exceeds its size limit. \"refuse\" does not clone it. \"shallow\" clones only the most recent commit of each branch and tag, and refuses the repository if that is too large as well.",
+      "type": "string",
+      "enum": ["refuse", "shallow"],
+      "default": "refuse"
+    },
     "BrandAssets": {
       "type": "object",
       "properties": {
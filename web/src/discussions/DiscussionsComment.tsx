@@ -73,16 +73,25 @@ export class DiscussionsComment extends React.PureComponent<Props> {
             >
                 <div className="discussions-comment__top-area">
                     <span className="discussions-comment__author">
-                        <Link to={`/users/${comment.author.username}`} data-tooltip={comment.author.displayName}>
-                            <UserAvatar user={comment.author} className="icon-inline icon-sm" />
-                        </Link>
-                        <Link
-                            to={`/users/${comment.author.username}`}
-                            data-tooltip={comment.author.displayName}
-                            className="ml-1 mr-1"
-                        >
-                            {comment.author.username}
-                        </Link>
+                        {comment.author ? (
+                            <>
+                                <Link
+                                    to={`/users/${comment.author.username}`}
+                                    data-tooltip={comment.author.displayName}
+                                >
+                                    <UserAvatar user={comment.author} className="icon-inline icon-sm" />
+                                </Link>
+                                <Link
+                                    to={`/users/${comment.author.username}`}
+                                    data-tooltip={comment.author.displayName}
+                                    className="ml-1 mr-1"
+                                >
+                                    {comment.author.username}
+                                </Link>
+                            </>
+                        ) : (
+                            <span className="mr-1">{comment.externalAuthor}</span>
+                        )}
                         <span className="mr-1">commented</span>
                         <Timestamp date={comment.createdAt} />
                     </span>
@@ -12,6 +12,7 @@ const discussionCommentFieldsFragment = gql`
         author {
             ...UserFields
         }
+        externalAuthor
         html
         inlineURL
         createdAt
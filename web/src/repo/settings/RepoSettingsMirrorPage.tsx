@@ -94,11 +94,26 @@ class UpdateMirrorRepositoryActionContainer extends React.PureComponent<UpdateMi
                 description =
                     'This repository is automatically updated from its remote repository periodically and when accessed by a user.'
             }
+            if (this.props.repo.mirrorInfo.shallow) {
+                info = (
+                    <div className="alert alert-info action-container__alert">
+                        Only the most recent commits of this repository were cloned, because it exceeds the configured
+                        repository size limit.
+                    </div>
+                )
+            }
             buttonLabel = 'Refresh now'
         } else {
             title = 'Clone this repository'
             description = 'This repository has not yet been cloned from its remote repository.'
             buttonLabel = 'Clone now'
+            if (this.props.repo.mirrorInfo.lastError) {
+                info = (
+                    <div className="alert alert-danger action-container__alert">
+                        The last attempt to clone this repository failed: {this.props.repo.mirrorInfo.lastError}
+                    </div>
+                )
+            }
         }
 
         return (
@@ -23,6 +23,8 @@ export function fetchRepository(name: string): Observable<GQL.IRepository> {
                         cloneProgress
                         cloned
                         updatedAt
+                        shallow
+                        lastError
                         updateSchedule {
                             due
                             index
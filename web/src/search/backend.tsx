@@ -251,6 +251,8 @@ const savedSearchFragment = gql`
         userID
         orgID
         slackWebhookURL
+        notifyWebhook
+        webhookURL
     }
 `
 
@@ -303,7 +305,10 @@ export function createSavedSearch(
     notify: boolean,
     notifySlack: boolean,
     userId: GQL.ID | null,
-    orgId: GQL.ID | null
+    orgId: GQL.ID | null,
+    notifyWebhook: boolean,
+    webhookURL: string | null,
+    webhookSecret: string | null
 ): Observable<void> {
     return mutateGraphQL(
         gql`
@@ -314,6 +319,9 @@ export function createSavedSearch(
                 $notifySlack: Boolean!
                 $userID: ID
                 $orgID: ID
+                $notifyWebhook: Boolean
+                $webhookURL: String
+                $webhookSecret: String
             ) {
                 createSavedSearch(
                     description: $description
@@ -322,6 +330,9 @@ export function createSavedSearch(
                     notifySlack: $notifySlack
                     userID: $userID
                     orgID: $orgID
+                    notifyWebhook: $notifyWebhook
+                    webhookURL: $webhookURL
+                    webhookSecret: $webhookSecret
                 ) {
                     ...SavedSearchFields
                 }
@@ -335,6 +346,9 @@ export function createSavedSearch(
             notifySlack,
             userID: userId,
             orgID: orgId,
+            notifyWebhook,
+            webhookURL,
+            webhookSecret,
         }
     ).pipe(
         map(dataOrThrowErrors),
@@ -349,7 +363,10 @@ export function updateSavedSearch(
     notify: boolean,
     notifySlack: boolean,
     userId: GQL.ID | null,
-    orgId: GQL.ID | null
+    orgId: GQL.ID | null,
+    notifyWebhook: boolean,
+    webhookURL: string | null,
+    webhookSecret: string | null
 ): Observable<void> {
     return mutateGraphQL(
         gql`
@@ -361,6 +378,9 @@ export function updateSavedSearch(
                 $notifySlack: Boolean!
                 $userID: ID
                 $orgID: ID
+                $notifyWebhook: Boolean
+                $webhookURL: String
+                $webhookSecret: String
             ) {
                 updateSavedSearch(
                     id: $id
@@ -370,6 +390,9 @@ export function updateSavedSearch(
                     notifySlack: $notifySlack
                     userID: $userID
                     orgID: $orgID
+                    notifyWebhook: $notifyWebhook
+                    webhookURL: $webhookURL
+                    webhookSecret: $webhookSecret
                 ) {
                     ...SavedSearchFields
                 }
@@ -384,6 +407,9 @@ export function updateSavedSearch(
             notifySlack,
             userID: userId,
             orgID: orgId,
+            notifyWebhook,
+            webhookURL,
+            webhookSecret,
         }
     ).pipe(
         map(dataOrThrowErrors),
@@ -46,7 +46,10 @@ export class SavedSearchCreateForm extends React.Component<Props, State> {
                                 fields.notify,
                                 fields.notifySlack,
                                 fields.userID,
-                                fields.orgID
+                                fields.orgID,
+                                fields.notifyWebhook,
+                                fields.webhookURL || null,
+                                fields.webhookSecret
                             ).pipe(
                                 map(() => true),
                                 catchError(error => [error])
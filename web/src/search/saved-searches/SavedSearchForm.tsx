@@ -14,6 +14,10 @@ export interface SavedQueryFields {
     userID: GQL.ID | null
     orgID: GQL.ID | null
     slackWebhookURL: string | null
+    notifyWebhook: boolean
+    webhookURL: string | null
+    /** The new webhook secret, or null to keep the existing secret. */
+    webhookSecret: string | null
 }
 
 interface Props extends RouteComponentProps<{}> {
@@ -45,6 +49,8 @@ export class SavedSearchForm extends React.Component<Props, State> {
             userID = null,
             orgID = null,
             slackWebhookURL = '',
+            notifyWebhook = false,
+            webhookURL = '',
         } = props.defaultValues || {}
 
         this.state = {
@@ -56,6 +62,9 @@ export class SavedSearchForm extends React.Component<Props, State> {
                 userID,
                 orgID,
                 slackWebhookURL,
+                notifyWebhook,
+                webhookURL,
+                webhookSecret: null,
             },
         }
     }
@@ -79,12 +88,22 @@ export class SavedSearchForm extends React.Component<Props, State> {
 
     private handleSubmit = (event: React.FormEvent<HTMLFormElement>) => {
         event.preventDefault()
-        this.props.onSubmit(this.state.values)
+        // An empty secret keeps the existing secret.
+        this.props.onSubmit({ ...this.state.values, webhookSecret: this.state.values.webhookSecret || null })
     }
 
     public render(): JSX.Element | null {
         const {
-            values: { query, description, notify, notifySlack, slackWebhookURL },
+            values: {
+                query,
+                description,
+                notify,
+                notifySlack,
+                slackWebhookURL,
+                notifyWebhook,
+                webhookURL,
+                webhookSecret,
+            },
         } = this.state
 
         return (
@@ -133,6 +152,43 @@ export class SavedSearchForm extends React.Component<Props, State> {
                             </label>
                         </div>
                     </div>
+                    <div className="saved-search-form__input">
+                        <label className="saved-search-form__label">Webhook notifications:</label>
+                        <div>
+                            <label>
+                                <input
+                                    type="checkbox"
+                                    name="Notify webhook"
+                                    className="saved-search-form__checkbox"
+                                    defaultChecked={notifyWebhook}
+                                    onChange={this.createInputChangeHandler('notifyWebhook')}
+                                />{' '}
+                                <span>POST new results to a webhook URL</span>
+                            </label>
+                        </div>
+                        {notifyWebhook && (
+                            <>
+                                <input
+                                    type="url"
+                                    name="Webhook URL"
+                                    className="form-control mb-1"
+                                    placeholder="https://example.com/webhook"
+                                    required={true}
+                                    value={webhookURL || ''}
+                                    onChange={this.createInputChangeHandler('webhookURL')}
+                                />
+                                <input
+                                    type="password"
+                                    name="Webhook secret"
+                                    className="form-control"
+                                    placeholder="Secret used to sign payloads (leave empty to keep the existing secret)"
+                                    autoComplete="new-password"
+                                    value={webhookSecret || ''}
+                                    onChange={this.createInputChangeHandler('webhookSecret')}
+                                />
+                            </>
+                        )}
+                    </div>
                     {notifySlack && slackWebhookURL && (
                         <div className="saved-search-form__input">
                             <label className="saved-search-form__label">Slack notifications:</label>
@@ -63,7 +63,10 @@ export class SavedSearchUpdateForm extends React.Component<Props, State> {
                                 input.notify,
                                 input.notifySlack,
                                 input.userID,
-                                input.orgID
+                                input.orgID,
+                                input.notifyWebhook,
+                                input.webhookURL,
+                                input.webhookSecret
                             ).pipe(
                                 mapTo(null),
                                 mergeMap(() =>
@@ -107,6 +110,8 @@ export class SavedSearchUpdateForm extends React.Component<Props, State> {
                             notify: savedSearch.notify,
                             notifySlack: savedSearch.notifySlack,
                             slackWebhookURL: savedSearch.slackWebhookURL,
+                            notifyWebhook: savedSearch.notifyWebhook,
+                            webhookURL: savedSearch.webhookURL,
                             userID: savedSearch.userID,
                             orgID: savedSearch.orgID,
                         }}